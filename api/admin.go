@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/frantjc/go-js"
+	"github.com/gin-gonic/gin"
+	"github.com/logsquaredn/rototiller/pb"
+)
+
+// DefaultWorkerDeadAfter is three of the worker's default heartbeat intervals.
+const DefaultWorkerDeadAfter = 30 * time.Second
+
+// adminHandler only lets the admin namespaces through.
+func (a *Handler) adminHandler(ctx *gin.Context) {
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		a.err(ctx, err)
+		ctx.Abort()
+		return
+	}
+
+	if namespace == "" || !js.Includes(a.AdminNamespaces, namespace) {
+		a.err(ctx, pb.NewErr(fmt.Errorf("requester is not an admin"), http.StatusForbidden))
+		ctx.Abort()
+		return
+	}
+
+	ctx.Next()
+}
+
+// listWorkers lists the workers, marking those
+// that have missed too many heartbeats as dead.
+func (a *Handler) listWorkers(offset, limit int) ([]*pb.Worker, error) {
+	workers, err := a.Datastore.GetWorkers(offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, worker := range workers {
+		worker.Status = js.Ternary(
+			time.Since(worker.GetHeartbeatTime().AsTime()) > a.WorkerDeadAfter,
+			pb.WorkerStatusDead, pb.WorkerStatusAlive,
+		).String()
+	}
+
+	return workers, nil
+}
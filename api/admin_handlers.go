@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/logsquaredn/rototiller"
+)
+
+// @Security     ApiKeyAuth
+// @Summary      Get a list of workers
+// @Description  Get each registered worker, including its version, the task types that it handles, how many jobs it may run at once, the IDs of the jobs that it is running and when it last heartbeated
+// @Description  &emsp; - Workers that have not heartbeated recently are marked dead
+// @Description  &emsp; - Only admins may list workers
+// @Tags         Admin
+// @Produce      application/json
+// @Param        offset  query     int  false  "Offset of workers to return"
+// @Param        limit   query     int  false  "Limit of workers to return"
+// @Success      200     {object}  []rototiller.Worker
+// @Failure      401     {object}  rototiller.Error
+// @Failure      403     {object}  rototiller.Error
+// @Failure      500     {object}  rototiller.Error
+// @Router       /api/v1/admin/workers [get].
+func (a *Handler) listWorkersHandler(ctx *gin.Context) {
+	q := &listQuery{}
+	if err := ctx.BindQuery(q); err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	workers, err := a.listWorkers(q.Offset, q.Limit)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workers)
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -18,21 +19,31 @@ type Handler struct {
 	Datastore           *postgres.Datastore
 	EventStreamProducer *amqp.EventStreamProducer
 	Blobstore           *bucket.Blobstore
+	// AdminNamespaces may use the admin endpoints
+	AdminNamespaces []string
+	// WorkerDeadAfter is how long after its last
+	// heartbeat that a worker is considered dead
+	WorkerDeadAfter time.Duration
 	*http.ServeMux
 }
 
-func NewHandler(ctx context.Context, datastore *postgres.Datastore, eventStreamProducer *amqp.EventStreamProducer, blobstore *bucket.Blobstore) (*Handler, error) {
+func NewHandler(ctx context.Context, datastore *postgres.Datastore, eventStreamProducer *amqp.EventStreamProducer, blobstore *bucket.Blobstore, opts ...Opt) (*Handler, error) {
 	var (
 		logger = rototiller.LoggerFrom(ctx)
 		a      = &Handler{
 			Datastore:           datastore,
 			EventStreamProducer: eventStreamProducer,
 			Blobstore:           blobstore,
+			WorkerDeadAfter:     DefaultWorkerDeadAfter,
 			ServeMux:            http.NewServeMux(),
 		}
 		router = gin.New()
 	)
 
+	for _, opt := range opts {
+		opt(a)
+	}
+
 	router.Use(gin.Recovery())
 
 	router.GET("/healthz", a.healthzHandler)
@@ -67,24 +78,62 @@ func NewHandler(ctx context.Context, datastore *postgres.Datastore, eventStreamP
 			{
 				storages.POST("", a.createStorageHandler)
 				storages.GET("", a.listStorageHandler)
+				storages.POST("/import", a.createImportHandler)
 				storage := storages.Group("/:storage")
 				{
 					storage.GET("", a.getStorageHandler)
 					storage.GET("/content", a.getStorageContentHandler)
+					storage.GET("/content/url", a.getStorageContentURLHandler)
+					storage.PUT("/content", a.putStorageContentHandler)
+					storage.GET("/revisions", a.listStorageRevisionsHandler)
+					storage.GET("/revisions/:revision", a.getStorageRevisionHandler)
+					storage.GET("/revisions/:revision/content", a.getStorageRevisionContentHandler)
+				}
+			}
+			imports := v1.Group("/imports")
+			{
+				imports.GET("", a.listImportHandler)
+				imports.GET("/:import", a.getImportHandler)
+			}
+			uploads := v1.Group("/uploads")
+			{
+				uploads.POST("", a.createUploadHandler)
+				upload := uploads.Group("/:upload")
+				{
+					upload.GET("", a.getUploadHandler)
+					upload.DELETE("", a.deleteUploadHandler)
+					upload.PUT("/parts/:part", a.putUploadPartHandler)
+					upload.GET("/parts/:part/url", a.getUploadPartURLHandler)
+					upload.POST("/complete", a.completeUploadHandler)
+					upload.POST("/finalize", a.finalizeUploadHandler)
 				}
 			}
+			schedules := v1.Group("/schedules")
+			{
+				schedules.POST("", a.createScheduleHandler)
+				schedules.GET("", a.listScheduleHandler)
+				schedule := schedules.Group("/:schedule")
+				{
+					schedule.GET("", a.getScheduleHandler)
+					schedule.DELETE("", a.deleteScheduleHandler)
+					schedule.POST("/pause", a.pauseScheduleHandler)
+					schedule.POST("/resume", a.resumeScheduleHandler)
+					schedule.GET("/runs", a.listScheduleRunsHandler)
+				}
+			}
+			v1.GET("/usage", a.getUsageHandler)
+			admin := v1.Group("/admin", a.adminHandler)
+			{
+				admin.GET("/workers", a.listWorkersHandler)
+			}
 			jobs := v1.Group("/jobs")
 			{
 				jobs.GET("", a.listJobHandler)
-				jobs.POST("/buffer", a.createBufferJobHandler)
-				jobs.POST("/filter", a.createFilterJobHandler)
-				jobs.POST("/reproject", a.createReprojectJobHandler)
-				jobs.POST("/removebadgeometry", a.createRemoveBadGeometryJobHandler)
-				jobs.POST("/vectorlookup", a.createVectorLookupJobHandler)
-				jobs.POST("/rasterlookup", a.createRasterLookupJobHandler)
-				jobs.POST("/polygonvectorlookup", a.createPolygonVectorLookupJobHandler)
 				job := jobs.Group("/:job")
 				{
+					// gin requires sibling wildcards to share a name, so
+					// POST /api/v1/jobs/{task} is routed through /:job
+					job.POST("", a.createJobHandler)
 					job.GET("", a.getJobHandler)
 					job.GET("/tasks", a.getJobTasksHandler)
 					jobStorages := job.Group("storages")
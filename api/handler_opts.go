@@ -0,0 +1,23 @@
+package api
+
+import (
+	"time"
+)
+
+type Opt func(*Handler)
+
+// WithAdminNamespaces sets the namespaces
+// that may use the admin endpoints.
+func WithAdminNamespaces(namespaces ...string) Opt {
+	return func(a *Handler) {
+		a.AdminNamespaces = namespaces
+	}
+}
+
+// WithWorkerDeadAfter sets how long after its last
+// heartbeat that a worker is considered dead.
+func WithWorkerDeadAfter(deadAfter time.Duration) Opt {
+	return func(a *Handler) {
+		a.WorkerDeadAfter = deadAfter
+	}
+}
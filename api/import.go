@@ -0,0 +1,106 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/logsquaredn/rototiller/fetch"
+	"github.com/logsquaredn/rototiller/pb"
+	"golang.org/x/net/http/httpguts"
+)
+
+const (
+	// maxImportHeaders is the most headers that an import may be fetched with.
+	maxImportHeaders = 32
+	// maxImportNameLen is the longest name that is
+	// derived from the URL of an import not given one.
+	maxImportNameLen = 64
+)
+
+type importRequest struct {
+	URL     string            `json:"url"`
+	Name    string            `json:"name"`
+	Headers map[string]string `json:"headers"`
+}
+
+// createImportForNamespace records the import and leaves fetching it to a
+// worker. Whether the URL is allowed is only fully known once its host is
+// resolved by the worker, so only what can be checked up front is here.
+func (a *Handler) createImportForNamespace(ctx *gin.Context, namespace string) (*pb.Import, error) {
+	req := &importRequest{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		return nil, pb.NewErr(err, http.StatusBadRequest)
+	}
+
+	u, err := fetch.CheckURL(req.URL, fetch.Schemes)
+	if err != nil {
+		return nil, pb.NewErr(err, http.StatusBadRequest)
+	}
+
+	if len(req.Headers) > maxImportHeaders {
+		return nil, pb.NewErr(fmt.Errorf("at most %d headers are allowed", maxImportHeaders), http.StatusBadRequest)
+	}
+
+	for k, v := range req.Headers {
+		if !httpguts.ValidHeaderFieldName(k) || !httpguts.ValidHeaderFieldValue(v) {
+			return nil, pb.NewErr(fmt.Errorf("invalid header '%s'", k), http.StatusBadRequest)
+		}
+
+		if strings.EqualFold(k, "Host") {
+			return nil, pb.NewErr(fmt.Errorf("header 'Host' is not allowed"), http.StatusBadRequest)
+		}
+	}
+
+	name := req.Name
+	if name == "" {
+		if name = strings.Trim(path.Base(u.Path), "/."); len(name) > maxImportNameLen {
+			name = name[:maxImportNameLen]
+		}
+	}
+
+	imp, err := a.Datastore.CreateImport(&pb.Import{
+		Namespace: namespace,
+		Name:      name,
+		Url:       u.String(),
+		Headers:   req.Headers,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	priority, err := a.fairPriority(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.EventStreamProducer.EmitWithPriority(ctx, &pb.Event{
+		Type: pb.EventTypeImportCreated.String(),
+		Metadata: map[string]string{
+			"id":        imp.Id,
+			"namespace": imp.Namespace,
+		},
+	}, priority); err != nil {
+		return nil, err
+	}
+
+	return imp, nil
+}
+
+func (a *Handler) getImportForNamespace(id string, namespace string) (*pb.Import, error) {
+	imp, err := a.Datastore.GetImport(id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, pb.NewErr(fmt.Errorf("import '%s' not found", id), http.StatusNotFound)
+	case err != nil:
+		return nil, err
+	case imp.Namespace != namespace:
+		return nil, pb.NewErr(fmt.Errorf("requester does not own import '%s'", id), http.StatusForbidden)
+	}
+
+	return imp, nil
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/logsquaredn/rototiller"
+	"github.com/logsquaredn/rototiller/pb"
+)
+
+// @Security     ApiKeyAuth
+// @Summary      Import a storage
+// @Description  Stores a dataset fetched from an http(s) URL, e.g. a public dataset. The fetch happens asynchronously, so poll the returned import until its status is complete, then use its storage_id as input to jobs
+// @Description  &emsp; - Pass a JSON body of the form {"url": "https://...", "name": "...", "headers": {"Authorization": "..."}}, where name and headers are optional
+// @Description  &emsp; - The format of the dataset is found from the Content-Type it is served with, its filename or its content, and it is validated just as creating a storage does
+// @Description  &emsp; - Datasets that are too large, URLs with disallowed schemes and hosts that resolve to private addresses are refused
+// @Tags         Storage
+// @Accept       application/json
+// @Produce      application/json
+// @Success      202  {object}  rototiller.Import
+// @Failure      400  {object}  rototiller.Error
+// @Failure      401  {object}  rototiller.Error
+// @Failure      500  {object}  rototiller.Error
+// @Router       /api/v1/storages/import [post].
+func (a *Handler) createImportHandler(ctx *gin.Context) {
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+	imp, err := a.createImportForNamespace(ctx, namespace)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", path.Join(pb.EndpointImports, imp.GetId()))
+	ctx.JSON(http.StatusAccepted, imp)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Get a list of imports
+// @Description  Get a list of imports based on API Key
+// @Tags         Storage
+// @Produce      application/json
+// @Param        offset  query     int  false  "Offset of imports to return"
+// @Param        limit   query     int  false  "Limit of imports to return"
+// @Success      200     {object}  []rototiller.Import
+// @Failure      400     {object}  rototiller.Error
+// @Failure      401     {object}  rototiller.Error
+// @Failure      500     {object}  rototiller.Error
+// @Router       /api/v1/imports [get].
+func (a *Handler) listImportHandler(ctx *gin.Context) {
+	q := &listQuery{}
+	if err := ctx.BindQuery(q); err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+	imports, err := a.Datastore.GetImports(namespace, q.Offset, q.Limit)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		imports = []*pb.Import{}
+	case err != nil:
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, imports)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Get an import
+// @Description  Gets an import, including its status, any error that it failed with and, once it is complete, the ID of the storage that it created
+// @Tags         Storage
+// @Produce      application/json
+// @Param        id   path      string  true  "Import ID"
+// @Success      200  {object}  rototiller.Import
+// @Failure      401  {object}  rototiller.Error
+// @Failure      403  {object}  rototiller.Error
+// @Failure      404  {object}  rototiller.Error
+// @Failure      500  {object}  rototiller.Error
+// @Router       /api/v1/imports/{id} [get].
+func (a *Handler) getImportHandler(ctx *gin.Context) {
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+	imp, err := a.getImportForNamespace(ctx.Param("import"), namespace)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, imp)
+}
@@ -1,32 +1,84 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/frantjc/go-js"
 	"github.com/gin-gonic/gin"
 	"github.com/logsquaredn/rototiller/pb"
+	tasks "github.com/logsquaredn/rototiller/task"
 )
 
 const (
 	qInput    = "input"
 	qInputOf  = "input-of"
 	qOutputOf = "output-of"
+	qPriority = "priority"
 )
 
-func (a *Handler) createJobForNamespace(ctx *gin.Context, taskType pb.TaskType, namespace string) (*pb.Job, error) {
-	task, err := a.getTaskType(taskType)
+// parsePriority parses the priority of a job, which defaults to 0.
+// Jobs with higher priorities are run ahead of those with lower ones.
+func parsePriority(rawPriority string) (int32, error) {
+	if rawPriority == "" {
+		return 0, nil
+	}
+
+	priority, err := strconv.ParseInt(rawPriority, 10, 32)
+	if err != nil {
+		return 0, pb.NewErr(fmt.Errorf("query '%s' must be an integer, got '%s'", qPriority, rawPriority), http.StatusBadRequest)
+	}
+
+	return int32(priority), nil
+}
+
+// jobRequest is what a job is created from, whether
+// by a request to the API or by a schedule.
+type jobRequest struct {
+	// Query returns the value of each of the task's params as
+	// well as those of the input, input-of, output-of, priority
+	// and name queries, or "" if one was not given.
+	Query func(string) string
+	// ContentType and Body are the job's input if it is not given
+	// by any of the input, input-of and output-of queries.
+	ContentType string
+	Body        io.Reader
+}
+
+func (a *Handler) createJobForNamespace(ctx context.Context, rawTaskType string, req *jobRequest, namespace string) (*pb.Job, error) {
+	task, err := a.getTask(rawTaskType)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := tasks.BuildArgs(task, req.Query)
+	if err != nil {
+		return nil, pb.NewErr(err, http.StatusBadRequest)
+	}
+
+	if err = a.checkStorageArgs(task, args, namespace); err != nil {
+		return nil, err
+	}
+
+	priority, err := parsePriority(req.Query(qPriority))
 	if err != nil {
 		return nil, err
 	}
 
+	if err = a.checkJobQuota(namespace, priority); err != nil {
+		return nil, err
+	}
+
 	var (
-		input    = ctx.Query(qInput)
-		inputOf  = ctx.Query(qInputOf)
-		outputOf = ctx.Query(qOutputOf)
+		input    = req.Query(qInput)
+		inputOf  = req.Query(qInputOf)
+		outputOf = req.Query(qOutputOf)
 		inputIDs = js.Filter(
 			[]string{input, inputOf, outputOf},
 			func(s string, _ int, _ []string) bool {
@@ -39,7 +91,12 @@ func (a *Handler) createJobForNamespace(ctx *gin.Context, taskType pb.TaskType,
 	case len(inputIDs) > 1:
 		return nil, pb.NewErr(fmt.Errorf("cannot specify more than one of queries '%s', '%s' and '%s'", qInput, qInputOf, qOutputOf), http.StatusBadRequest)
 	case input != "":
-		storage, err = a.getStorageForNamespace(input, namespace)
+		id, revision, err := parseStorageRef(input)
+		if err != nil {
+			return nil, err
+		}
+
+		storage, err = a.getStorageRevisionForNamespace(id, revision, namespace)
 		if err != nil {
 			return nil, err
 		}
@@ -54,54 +111,119 @@ func (a *Handler) createJobForNamespace(ctx *gin.Context, taskType pb.TaskType,
 			return nil, err
 		}
 	default:
-		storage, err = a.putRequestVolumeForNamespace(ctx, ctx.GetHeader("Content-Type"), ctx.Query("name"), ctx.Request.Body, namespace)
+		contentTypes := acceptedContentTypes(task)
+		if req.Body == nil || !js.Some(contentTypes, func(input string, _ int, _ []string) bool {
+			return strings.Contains(req.ContentType, input)
+		}) {
+			return nil, pb.NewErr(fmt.Errorf("task '%s' requires Content-Type among '%s'", task.Type, strings.Join(contentTypes, "', '")), http.StatusBadRequest)
+		}
+
+		storage, err = a.putRequestContentForNamespace(ctx, req.ContentType, req.Query("name"), req.Body, namespace)
 		if err != nil {
 			return nil, err
 		}
-
-		defer ctx.Request.Body.Close()
 	}
 
-	switch pb.StorageStatus(storage.Status) {
-	case pb.StorageStatusFinal:
-		return nil, pb.NewErr(fmt.Errorf("cannot create job, storage id %s is final", storage.Id), http.StatusBadRequest)
-	case pb.StorageStatusUnusable:
-		return nil, pb.NewErr(fmt.Errorf("cannot create job, storage id %s is unsusable", storage.Id), http.StatusBadRequest)
+	if err = checkStorageUsable(storage); err != nil {
+		return nil, err
 	}
 
 	job, err := a.Datastore.CreateJob(&pb.Job{
 		Steps: []*pb.Step{
 			{
-				TaskType: task.Type,
-				Args:     buildJobArgs(ctx, task.Params),
+				TaskType:  task.Type,
+				Args:      args,
+				OverlayId: overlayID(task, args),
 			},
 		},
 		Namespace: namespace,
 		InputId:   storage.Id,
+		// recorded even if the latest revision was asked for,
+		// so that the job can be reproduced once there are more
+		InputRevision: storage.GetRevision(),
+		Priority:      priority,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	if err = a.EventStreamProducer.Emit(ctx, &pb.Event{
+	eventPriority, err := a.fairPriority(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.EventStreamProducer.EmitWithPriority(ctx, &pb.Event{
 		Type: pb.EventTypeJobCreated.String(),
 		Metadata: map[string]string{
-			"id": job.Id,
+			"id":        job.Id,
+			"namespace": job.Namespace,
+			"priority":  strconv.Itoa(int(job.Priority)),
+			"task_type": task.Type,
 		},
-	}); err != nil {
+	}, eventPriority, task.Type); err != nil {
 		return nil, err
 	}
 
 	return job, nil
 }
 
-func (a *Handler) createJob(ctx *gin.Context, taskType pb.TaskType) (*pb.Job, error) {
+// checkStorageArgs checks that the requester owns each storage
+// that the args refer to and that each can be used by a job.
+func (a *Handler) checkStorageArgs(task *pb.Task, args []string, namespace string) error {
+	for i, name := range task.GetParams() {
+		if i >= len(args) || args[i] == "" || tasks.SchemaOf(task, name).GetFormat() != tasks.ParamFormatStorage.String() {
+			continue
+		}
+
+		storage, err := a.getStorageForNamespace(args[i], namespace)
+		if err != nil {
+			return err
+		}
+
+		if err = checkStorageUsable(storage); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// overlayID returns the ID of the storage given by the
+// Task's overlay param, if it has one, or else "".
+func overlayID(task *pb.Task, args []string) string {
+	for i, name := range task.GetParams() {
+		if name == tasks.ParamOverlay && i < len(args) {
+			return args[i]
+		}
+	}
+
+	return ""
+}
+
+func checkStorageUsable(storage *pb.Storage) error {
+	switch pb.StorageStatus(storage.Status) {
+	case pb.StorageStatusFinal:
+		return pb.NewErr(fmt.Errorf("cannot create job, storage id %s is final", storage.Id), http.StatusBadRequest)
+	case pb.StorageStatusUnusable:
+		return pb.NewErr(fmt.Errorf("cannot create job, storage id %s is unsusable", storage.Id), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (a *Handler) createJob(ctx *gin.Context, rawTaskType string) (*pb.Job, error) {
 	namespace, err := a.getNamespaceFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return a.createJobForNamespace(ctx, taskType, namespace)
+	defer ctx.Request.Body.Close()
+
+	return a.createJobForNamespace(ctx, rawTaskType, &jobRequest{
+		Query:       ctx.Query,
+		ContentType: ctx.GetHeader("Content-Type"),
+		Body:        ctx.Request.Body,
+	}, namespace)
 }
 
 func (a *Handler) getJob(ctx *gin.Context, id string) (*pb.Job, error) {
@@ -132,12 +254,3 @@ func (a *Handler) checkJobOwnership(job *pb.Job, namespace string) (*pb.Job, err
 
 	return job, nil
 }
-
-func buildJobArgs(ctx *gin.Context, taskParams []string) []string {
-	jobArgs := make([]string, len(taskParams))
-	for i, param := range taskParams {
-		jobArgs[i] = ctx.Query(param)
-	}
-
-	return jobArgs
-}
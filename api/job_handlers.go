@@ -3,7 +3,6 @@ package api
 import (
 	"database/sql"
 	"errors"
-	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -101,7 +100,7 @@ func (a *Handler) getJobTasksHandler(ctx *gin.Context) {
 
 // @Security     ApiKeyAuth
 // @Summary      Get a job's input
-// @Description  Get the metadata of a job's input
+// @Description  Get the metadata of a job's input at the revision that the job used
 // @Tags         Storage
 // @Produce      application/json
 // @Param        id   path      string  true  "Job ID"
@@ -125,13 +124,16 @@ func (a *Handler) getJobInputHandler(ctx *gin.Context) {
 // @Summary      Get a job's input content
 // @Description  Gets the content of a job's input
 // @Tags         Content
-// @Produce      application/json, application/zip
-// @Param        Accept  header  string  false  "Request results as a Zip or JSON. Default Zip"
+// @Produce      application/json, application/zip, application/geopackage+sqlite3, application/vnd.google-earth.kml+xml, text/csv, application/flatgeobuf, application/x-ndjson
+// @Param        Accept  header  string  false  "Request results as a Zip, JSON, GeoPackage, KML, CSV, FlatGeobuf or newline-delimited GeoJSON, converted from GeoJSON if need be. Default Zip"
+// @Param        format  query   string  false  "Overrides Accept with one of geojson, shapefile, geopackage, kml, csv, flatgeobuf or ndjson, or their file extensions"
 // @Param        id      path    string  true   "Job ID"
 // @Success      200
+// @Failure      400  {object}  rototiller.Error
 // @Failure      401  {object}  rototiller.Error
 // @Failure      403  {object}  rototiller.Error
 // @Failure      404  {object}  rototiller.Error
+// @Failure      406  {object}  rototiller.Error
 // @Failure      500  {object}  rototiller.Error
 // @Router       /api/v1/jobs/{id}/storages/input/content [get].
 func (a *Handler) getJobInputContentHandler(ctx *gin.Context) {
@@ -141,21 +143,15 @@ func (a *Handler) getJobInputContentHandler(ctx *gin.Context) {
 		return
 	}
 
-	volume, err := a.Blobstore.GetObject(ctx, storage.GetId())
+	volume, err := a.Blobstore.GetObject(ctx, storage.GetObjectId())
 	if err != nil {
 		a.err(ctx, err)
 		return
 	}
 
-	r, contentType, err := a.getVolumeContent(ctx.GetHeader("Accept"), volume)
-	if err != nil {
+	if err = a.writeVolumeContent(ctx, storage, volume); err != nil {
 		a.err(ctx, err)
-		return
 	}
-	defer r.Close()
-
-	ctx.Writer.Header().Add("Content-Type", contentType)
-	_, _ = io.Copy(ctx.Writer, r)
 }
 
 // @Security     ApiKeyAuth
@@ -184,13 +180,16 @@ func (a *Handler) getJobOutputHandler(ctx *gin.Context) {
 // @Summary      Get a job's output content
 // @Description  Gets the content of a job's output
 // @Tags         Content
-// @Produce      application/json, application/zip
-// @Param        Accept  header  string  false  "Request results as a Zip or JSON. Default Zip"
+// @Produce      application/json, application/zip, application/geopackage+sqlite3, application/vnd.google-earth.kml+xml, text/csv, application/flatgeobuf, application/x-ndjson
+// @Param        Accept  header  string  false  "Request results as a Zip, JSON, GeoPackage, KML, CSV, FlatGeobuf or newline-delimited GeoJSON, converted from GeoJSON if need be. Default Zip"
+// @Param        format  query   string  false  "Overrides Accept with one of geojson, shapefile, geopackage, kml, csv, flatgeobuf or ndjson, or their file extensions"
 // @Param        id      path    string  true   "Job ID"
 // @Success      200
+// @Failure      400  {object}  rototiller.Error
 // @Failure      401  {object}  rototiller.Error
 // @Failure      403  {object}  rototiller.Error
 // @Failure      404  {object}  rototiller.Error
+// @Failure      406  {object}  rototiller.Error
 // @Failure      500  {object}  rototiller.Error
 // @Router       /api/v1/jobs/{id}/storages/output/content [get].
 func (a *Handler) getJobOutputContentHandler(ctx *gin.Context) {
@@ -200,311 +199,51 @@ func (a *Handler) getJobOutputContentHandler(ctx *gin.Context) {
 		return
 	}
 
-	volume, err := a.Blobstore.GetObject(ctx, storage.GetId())
-	if err != nil {
-		a.err(ctx, err)
-		return
-	}
-
-	r, contentType, err := a.getVolumeContent(ctx.GetHeader("Accept"), volume)
-	if err != nil {
-		a.err(ctx, err)
-		return
-	}
-	defer r.Close()
-
-	ctx.Writer.Header().Add("Content-Type", contentType)
-	_, _ = io.Copy(ctx.Writer, r)
-}
-
-type bufferQuery struct {
-	Distance     int `form:"buffer-distance" binding:"required"`
-	SegmentCount int `form:"quadrant-segment-count" binding:"required"`
-}
-
-// @Security     ApiKeyAuth
-// @Summary      Create a buffer job
-// @Description  <b><u>Create a buffer job</u></b>
-// @Description  &emsp; - Buffers every geometry by the given distance
-// @Description
-// @Description  &emsp; - For extra info: https://gdal.org/api/vector_c_pb.html#_CPPv412OGR_G_Buffer12OGRGeometryHdi
-// @Description  &emsp; - Pass the geospatial data to be processed in the request body OR
-// @Description  &emsp; - Pass the ID of an existing dataset with an empty request body
-// @Description  &emsp; - This task accepts a ZIP containing a shapefile or GeoJSON input
-// @Description  &emsp; - This task will automatically generate both GeoJSON and ZIP (shapfile) output
-// @Tags         Job
-// @Accept       application/json, application/zip
-// @Produce      application/json
-// @Param        Content-Type  header    string  false  "Required if passing geospatial data in request body"
-// @Param        input                   query     string   false  "ID of existing dataset to use"
-// @Param        input-of                query     string   false  "ID of existing job whose input dataset to use"
-// @Param        output-of               query     string   false  "ID of existing job whose output dataset to use"
-// @Param        buffer-distance         query     integer  true   "Buffer distance"
-// @Param        quadrant-segment-count  query     integer  true   "Quadrant Segment count"
-// @Success      200                     {object}  rototiller.Job
-// @Failure      400                     {object}  rototiller.Error
-// @Failure      401                     {object}  rototiller.Error
-// @Failure      403                     {object}  rototiller.Error
-// @Failure      500                     {object}  rototiller.Error
-// @Router       /api/v1/jobs/buffer [post].
-func (a *Handler) createBufferJobHandler(ctx *gin.Context) {
-	if err := ctx.ShouldBindQuery(&bufferQuery{}); err != nil {
-		a.err(ctx, pb.NewErr(err, http.StatusBadRequest))
-		return
-	}
-
-	job, err := a.createJob(ctx, pb.TaskTypeBuffer)
-	if err != nil {
-		a.err(ctx, err)
-		return
-	}
-
-	ctx.JSON(http.StatusOK, job)
-}
-
-type filterQuery struct {
-	FilterColumn string `form:"filter-column" binding:"required"`
-	FilterValue  string `form:"filter-value" binding:"required"`
-}
-
-// @Security     ApiKeyAuth
-// @Summary      Create a filter job
-// @Description  <b><u>Create a filter job</u></b>
-// @Description  &emsp; - Drops features and their geometries that don't match the given filter
-// @Description  &emsp; - Pass the geospatial data to be processed in the request body OR
-// @Description  &emsp; - Pass the ID of an existing dataset with an empty request body
-// @Description  &emsp; - This task accepts a ZIP containing a shapefile or GeoJSON input
-// @Description  &emsp; - This task will automatically generate both GeoJSON and ZIP (shapfile) output
-// @Tags         Job
-// @Accept       application/json, application/zip
-// @Produce      application/json
-// @Param        Content-Type   header    string  false  "Required if passing geospatial data in request body"
-// @Param        input          query     string  false  "ID of existing dataset to use"
-// @Param        input-of       query     string  false  "ID of existing job whose input dataset to use"
-// @Param        output-of      query     string  false  "ID of existing job whose output dataset to use"
-// @Param        filter-column  query     string  true   "Column to filter on"
-// @Param        filter-value   query     string  true   "Value to filter on"
-// @Success      200            {object}  rototiller.Job
-// @Failure      400            {object}  rototiller.Error
-// @Failure      401            {object}  rototiller.Error
-// @Failure      403            {object}  rototiller.Error
-// @Failure      500            {object}  rototiller.Error
-// @Router       /api/v1/jobs/filter [post].
-func (a *Handler) createFilterJobHandler(ctx *gin.Context) {
-	if err := ctx.ShouldBindQuery(&filterQuery{}); err != nil {
-		a.err(ctx, pb.NewErr(err, http.StatusBadRequest))
-		return
-	}
-
-	job, err := a.createJob(ctx, pb.TaskTypeFilter)
+	volume, err := a.Blobstore.GetObject(ctx, storage.GetObjectId())
 	if err != nil {
 		a.err(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, job)
-}
-
-type reprojectQuery struct {
-	TargetProjection int `form:"target-projection" binding:"required"`
-}
-
-// @Security     ApiKeyAuth
-// @Summary      Create a reproject job
-// @Description  <b><u>Create a reproject job</u></b>
-// @Description  &emsp; - Reprojects all geometries to the given projection
-// @Description  &emsp; - Pass the geospatial data to be processed in the request body OR
-// @Description  &emsp; - Pass the ID of an existing dataset with an empty request body
-// @Description  &emsp; - This task accepts a ZIP containing a shapefile or GeoJSON input
-// @Description  &emsp; - This task will automatically generate both GeoJSON and ZIP (shapfile) output
-// @Tags         Job
-// @Accept       application/json, application/zip
-// @Produce      application/json
-// @Param        Content-Type       header    string   false  "Required if passing geospatial data in request body"
-// @Param        input              query     string   false  "ID of existing dataset to use"
-// @Param        input-of           query     string   false  "ID of existing job whose input dataset to use"
-// @Param        output-of          query     string   false  "ID of existing job whose output dataset to use"
-// @Param        target-projection  query     integer  true   "Target projection EPSG"
-// @Success      200                {object}  rototiller.Job
-// @Failure      400                {object}  rototiller.Error
-// @Failure      401                {object}  rototiller.Error
-// @Failure      403                {object}  rototiller.Error
-// @Failure      500                {object}  rototiller.Error
-// @Router       /api/v1/jobs/reproject [post].
-func (a *Handler) createReprojectJobHandler(ctx *gin.Context) {
-	if err := ctx.ShouldBindQuery(&reprojectQuery{}); err != nil {
-		a.err(ctx, pb.NewErr(err, http.StatusBadRequest))
-		return
-	}
-
-	job, err := a.createJob(ctx, pb.TaskTypeReproject)
-	if err != nil {
+	if err = a.writeVolumeContent(ctx, storage, volume); err != nil {
 		a.err(ctx, err)
-		return
 	}
-
-	ctx.JSON(http.StatusOK, job)
 }
 
 // @Security     ApiKeyAuth
-// @Summary      Create a remove bad geometry job
-// @Description  <b><u>Create a remove bad geometry job</u></b>
-// @Description  &emsp; - Drops geometries that are invalid
-// @Description
-// @Description  &emsp; - For extra info: https://gdal.org/api/vector_c_pb.html#_CPPv413OGR_G_IsValid12OGRGeometryH
-// @Description  &emsp; - API Key is required either as a query parameter or a header
+// @Summary      Create a job
+// @Description  <b><u>Create a job</u></b>
+// @Description  &emsp; - Runs the given task over the input dataset
+// @Description  &emsp; - See /api/v1/tasks for the available tasks, the params that they take and the inputs that they accept
+// @Description  &emsp; - Each param is passed as a query and validated against the task's schema. Every invalid param is listed in the error's details
+// @Description  &emsp; - Params with format 'storage' take the ID of another dataset, which the requester must own
+// @Description  &emsp; - Tasks that combine two datasets, e.g. intersect, take the second as the 'overlay' param, which is recorded on the job's step as its overlay_id
 // @Description  &emsp; - Pass the geospatial data to be processed in the request body OR
 // @Description  &emsp; - Pass the ID of an existing dataset with an empty request body
-// @Description  &emsp; - This task accepts a ZIP containing a shapefile or GeoJSON input
-// @Description  &emsp; - This task will automatically generate both GeoJSON and ZIP (shapfile) output
+// @Description  &emsp; - GeoPackage, KML, CSV (with a WKT column or longitude and latitude columns), FlatGeobuf and newline-delimited GeoJSON data is stored as-is alongside its conversion to GeoJSON, which tasks that accept GeoJSON run over
+// @Description  &emsp; - The data is validated against its Content-Type on upload, e.g. that a zip has each shapefile's .shp, .shx, .dbf and .prj, and every problem found is listed in the error's details. The detected format, layers and feature count are recorded on the stored dataset
+// @Description  &emsp; - Transformation tasks will automatically generate both GeoJSON and ZIP (shapfile) output
+// @Description  &emsp; - Lookup tasks will generate JSON output
 // @Tags         Job
-// @Accept       application/json, application/zip
+// @Accept       application/json, application/zip, application/geopackage+sqlite3, application/vnd.google-earth.kml+xml, text/csv, application/flatgeobuf, application/x-ndjson
 // @Produce      application/json
 // @Param        Content-Type  header    string  false  "Required if passing geospatial data in request body"
-// @Param        input         query     string  false  "ID of existing dataset to use"
-// @Param        input-of      query     string  false  "ID of existing job whose input dataset to use"
+// @Param        task          path      string  true   "Task type"
+// @Param        input         query     string  false  "ID of existing dataset to use, optionally pinned to one of its revisions as <id>@<revision>. Default its latest revision"
+// @Param        input-of      query     string  false  "ID of existing job whose input dataset, at the revision that it used, to use"
 // @Param        output-of     query     string  false  "ID of existing job whose output dataset to use"
+// @Param        priority      query     int     false  "Priority of the job, up to the namespace's max_priority. Jobs with higher priorities are run ahead of those with lower ones. Default 0"
 // @Success      200           {object}  rototiller.Job
 // @Failure      400           {object}  rototiller.Error
 // @Failure      401           {object}  rototiller.Error
 // @Failure      403           {object}  rototiller.Error
+// @Failure      404           {object}  rototiller.Error
+// @Failure      413           {object}  rototiller.Error
+// @Failure      429           {object}  rototiller.Error
 // @Failure      500           {object}  rototiller.Error
-// @Router       /api/v1/jobs/removebadgeometry [post].
-func (a *Handler) createRemoveBadGeometryJobHandler(ctx *gin.Context) {
-	job, err := a.createJob(ctx, pb.TaskTypeRemoveBadGeometry)
-	if err != nil {
-		a.err(ctx, err)
-		return
-	}
-
-	ctx.JSON(http.StatusOK, job)
-}
-
-type vectorLookupQuery struct {
-	Attributes string  `form:"attributes" binding:"required"`
-	Longitude  float64 `form:"longitude" binding:"required"`
-	Latitude   float64 `form:"latitude" binding:"required"`
-}
-
-// @Security     ApiKeyAuth
-// @Summary      Create a vector lookup job
-// @Description  <b><u>Create a vector lookup job</u></b>
-// @Description  &emsp; - Returns a list of attribute values of which the given point intersects
-// @Description  &emsp; - Pass the geospatial data to be processed in the request body OR
-// @Description  &emsp; - Pass the ID of an existing dataset with an empty request body
-// @Description  &emsp; - This task accepts a ZIP containing a shapefile or GeoJSON input
-// @Description  &emsp; - This task will generate JSON output
-// @Tags         Job
-// @Accept       application/json, application/zip
-// @Produce      application/json
-// @Param        Content-Type  header    string  false  "Required if passing geospatial data in request body"
-// @Param        input         query     string  false  "ID of existing dataset to use"
-// @Param        input-of      query     string  false  "ID of existing job whose input dataset to use"
-// @Param        output-of     query     string  false  "ID of existing job whose output dataset to use"
-// @Param        attributes    query     string  true   "Comma separated list of attributes"
-// @Param        longitude     query     number  true   "Longitude"
-// @Param        latitude      query     number  true   "Latitude"
-// @Success      200           {object}  rototiller.Job
-// @Failure      400           {object}  rototiller.Error
-// @Failure      401           {object}  rototiller.Error
-// @Failure      403           {object}  rototiller.Error
-// @Failure      500           {object}  rototiller.Error
-// @Router       /api/v1/jobs/vectorlookup [post].
-func (a *Handler) createVectorLookupJobHandler(ctx *gin.Context) {
-	if err := ctx.ShouldBindQuery(&vectorLookupQuery{}); err != nil {
-		a.err(ctx, pb.NewErr(err, http.StatusBadRequest))
-		return
-	}
-
-	job, err := a.createJob(ctx, pb.TaskTypeVectorLookup)
-	if err != nil {
-		a.err(ctx, err)
-		return
-	}
-
-	ctx.JSON(http.StatusOK, job)
-}
-
-type rasterLookupQuery struct {
-	Bands     string  `form:"bands" binding:"required"`
-	Longitude float64 `form:"longitude" binding:"required"`
-	Latitude  float64 `form:"latitude" binding:"required"`
-}
-
-// @Security     ApiKeyAuth
-// @Summary      Create a raster lookup job
-// @Description  <b><u>Create a raster lookup job</u></b>
-// @Description  &emsp; - Returns the value of each requested band of which the given point intersects
-// @Description  &emsp; - Pass the geospatial data to be processed in the request body OR
-// @Description  &emsp; - Pass the ID of an existing dataset with an empty request body
-// @Description  &emsp; - This task accepts a ZIP containing a single TIF file. Valid extensions are: tif, tiff, geotif, geotiff
-// @Description  &emsp; - This task will generate JSON output
-// @Tags         Job
-// @Accept       application/json, application/zip
-// @Produce      application/json
-// @Param        Content-Type            header    string   false  "Required if passing geospatial data in request body"
-// @Param        input         query     string  false  "ID of existing dataset to use"
-// @Param        input-of      query     string  false  "ID of existing job whose input dataset to use"
-// @Param        output-of     query     string  false  "ID of existing job whose output dataset to use"
-// @Param        bands         query     string  true   "Comma separated list of bands"
-// @Param        longitude     query     number  true   "Longitude"
-// @Param        latitude      query     number  true   "Latitude"
-// @Success      200           {object}  rototiller.Job
-// @Failure      400           {object}  rototiller.Error
-// @Failure      401           {object}  rototiller.Error
-// @Failure      403           {object}  rototiller.Error
-// @Failure      500           {object}  rototiller.Error
-// @Router       /api/v1/jobs/rasterlookup [post].
-func (a *Handler) createRasterLookupJobHandler(ctx *gin.Context) {
-	if err := ctx.ShouldBindQuery(&rasterLookupQuery{}); err != nil {
-		a.err(ctx, pb.NewErr(err, http.StatusBadRequest))
-		return
-	}
-
-	job, err := a.createJob(ctx, pb.TaskTypeRasterLookup)
-	if err != nil {
-		a.err(ctx, err)
-		return
-	}
-
-	ctx.JSON(http.StatusOK, job)
-}
-
-type polygonVectorLookupQuery struct {
-	Attributes string `form:"attributes" binding:"required"`
-	Polygon    string `form:"polygon" binding:"required"`
-}
-
-// @Security     ApiKeyAuth
-// @Summary      Create a polygon vector lookup job
-// @Description  <b><u>Create a polygon vector lookup job</u></b>
-// @Description  &emsp; - Returns a list of attribute values of which the given polygon intersects
-// @Description  &emsp; - Pass the geospatial data to be processed in the request body OR
-// @Description  &emsp; - Pass the ID of an existing dataset with an empty request body
-// @Description  &emsp; - This task accepts a ZIP containing a shapefile or GeoJSON input
-// @Description  &emsp; - This task will generate JSON output
-// @Tags         Job
-// @Accept       application/json, application/zip
-// @Produce      application/json
-// @Param        Content-Type  header    string  false  "Required if passing geospatial data in request body"
-// @Param        input         query     string  false  "ID of existing dataset to use"
-// @Param        input-of      query     string  false  "ID of existing job whose input dataset to use"
-// @Param        output-of     query     string  false  "ID of existing job whose output dataset to use"
-// @Param        attributes    query     string  true   "Comma separated list of attributes"
-// @Param        polygon       query     string  true   "Polygon in WKT format"
-// @Success      200           {object}  rototiller.Job
-// @Failure      400           {object}  rototiller.Error
-// @Failure      401           {object}  rototiller.Error
-// @Failure      403           {object}  rototiller.Error
-// @Failure      500           {object}  rototiller.Error
-// @Router       /api/v1/jobs/polygonvectorlookup [post].
-func (a *Handler) createPolygonVectorLookupJobHandler(ctx *gin.Context) {
-	if err := ctx.ShouldBindQuery(&polygonVectorLookupQuery{}); err != nil {
-		a.err(ctx, pb.NewErr(err, http.StatusBadRequest))
-		return
-	}
-
-	job, err := a.createJob(ctx, pb.TaskTypePolygonVectorLookup)
+// @Router       /api/v1/jobs/{task} [post].
+func (a *Handler) createJobHandler(ctx *gin.Context) {
+	job, err := a.createJob(ctx, ctx.Param("job"))
 	if err != nil {
 		a.err(ctx, err)
 		return
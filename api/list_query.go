@@ -1,6 +1,60 @@
 package api
 
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/logsquaredn/rototiller/encoding/format"
+	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/store/data/postgres"
+	"github.com/logsquaredn/rototiller/task"
+)
+
 type listQuery struct {
 	Offset int `form:"offset,default=0"`
 	Limit  int `form:"limit,default=10"`
 }
+
+type storageListQuery struct {
+	listQuery
+	Format       string `form:"format"`
+	GeometryType string `form:"geometry-type"`
+	CRS          string `form:"crs"`
+	Column       string `form:"column"`
+	MinFeatures  *int64 `form:"min-features"`
+	MaxFeatures  *int64 `form:"max-features"`
+	BBox         string `form:"bbox"`
+}
+
+// filter validates the query's filters and returns them
+// in the form that the datastore takes.
+func (q *storageListQuery) filter() (*postgres.StorageFilter, error) {
+	filter := &postgres.StorageFilter{
+		GeometryType: q.GeometryType,
+		CRS:          strings.ToUpper(q.CRS),
+		Column:       q.Column,
+		MinFeatures:  q.MinFeatures,
+		MaxFeatures:  q.MaxFeatures,
+	}
+
+	if q.Format != "" {
+		f := format.FromName(q.Format)
+		if f == nil {
+			return nil, pb.NewErr(fmt.Errorf("unknown format '%s', expected one of '%s'", q.Format, strings.Join(format.Names(), "', '")), http.StatusBadRequest)
+		}
+
+		filter.Format = f.Name
+	}
+
+	if q.BBox != "" {
+		bound, err := task.ParseBBox(q.BBox)
+		if err != nil {
+			return nil, pb.NewErr(fmt.Errorf("bbox %w", err), http.StatusBadRequest)
+		}
+
+		filter.BBox = []float64{bound.Min.X(), bound.Min.Y(), bound.Max.X(), bound.Max.Y()}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/stream/event/amqp"
+)
+
+func (a *Handler) getUsageForNamespace(namespace string) (*pb.Usage, error) {
+	quota, err := a.Datastore.GetQuota(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := a.Datastore.GetUsage(namespace)
+	if err != nil {
+		return nil, err
+	}
+	usage.Quota = quota
+
+	return usage, nil
+}
+
+func (a *Handler) getUsage(ctx *gin.Context) (*pb.Usage, error) {
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.getUsageForNamespace(namespace)
+}
+
+// checkJobQuota checks that the namespace may create another job of the given
+// priority before anything else is done to create it, e.g. uploading its input.
+// Creating the job checks the same again as it does so.
+func (a *Handler) checkJobQuota(namespace string, priority int32) error {
+	return a.Datastore.CheckJobQuota(namespace, priority)
+}
+
+// fairPriority is the priority to emit an event of the namespace with, so that
+// the events of a namespace with many jobs and imports yet to finish are
+// delivered behind those of the rest instead of holding them up.
+func (a *Handler) fairPriority(namespace string) (uint8, error) {
+	pending, err := a.Datastore.GetPending(namespace)
+	if err != nil {
+		return 0, err
+	}
+
+	return amqp.FairPriority(pending), nil
+}
+
+// limitUpload limits the reader to the most that the namespace may upload at once,
+// or to what it may yet store if that is less, failing once either is exceeded.
+func (a *Handler) limitUpload(namespace string, r io.Reader) (io.Reader, error) {
+	limit, err := a.Datastore.GetUploadLimit(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit.Bytes <= 0 {
+		return r, nil
+	}
+
+	return &quotaReader{Reader: r, n: limit.Bytes, err: limit.Err}, nil
+}
+
+// checkUploadSize checks that the namespace may upload content of the
+// given size at once, for content whose size is known before it is read.
+func (a *Handler) checkUploadSize(namespace string, size int64) error {
+	limit, err := a.Datastore.GetUploadLimit(namespace)
+	if err != nil {
+		return err
+	}
+
+	if limit.Bytes > 0 && size > limit.Bytes {
+		return limit.Err
+	}
+
+	return nil
+}
+
+// quotaReader reads up to n bytes from Reader,
+// failing with err if there are more than that.
+type quotaReader struct {
+	io.Reader
+	n   int64
+	err error
+}
+
+func (q *quotaReader) Read(p []byte) (int, error) {
+	if q.n < 0 {
+		return 0, q.err
+	}
+
+	// read at most one byte more than the
+	// limit to find out if there is more
+	if int64(len(p)) > q.n+1 {
+		p = p[:q.n+1]
+	}
+
+	n, err := q.Reader.Read(p)
+	if q.n -= int64(n); q.n < 0 {
+		return n, q.err
+	}
+
+	return n, err
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/logsquaredn/rototiller"
+)
+
+// @Security     ApiKeyAuth
+// @Summary      Get usage
+// @Description  Get how much the namespace has stored, how many of its jobs have yet to finish and how many jobs it has created in the last day, along with its quota of each
+// @Description  &emsp; - A limit that is not set is no limit
+// @Description  &emsp; - Exceeding the stored bytes or upload bytes limit fails with 413, and exceeding a job limit fails with 429
+// @Tags         Usage
+// @Produce      application/json
+// @Success      200  {object}  rototiller.Usage
+// @Failure      401  {object}  rototiller.Error
+// @Failure      500  {object}  rototiller.Error
+// @Router       /api/v1/usage [get].
+func (a *Handler) getUsageHandler(ctx *gin.Context) {
+	usage, err := a.getUsage(ctx)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, usage)
+}
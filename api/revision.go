@@ -0,0 +1,115 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/store"
+)
+
+// revisionSeparator separates a storage's ID from one
+// of its revisions when referring to it, e.g. as input.
+const revisionSeparator = "@"
+
+// parseStorageRef parses a reference to a storage of the form <id>[@<revision>],
+// returning a revision of 0 if none was given, i.e. the latest one.
+func parseStorageRef(ref string) (string, int32, error) {
+	id, rawRevision, ok := strings.Cut(ref, revisionSeparator)
+	if !ok {
+		return id, 0, nil
+	}
+
+	revision, err := parseRevision(rawRevision)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return id, revision, nil
+}
+
+func parseRevision(rawRevision string) (int32, error) {
+	revision, err := strconv.ParseInt(rawRevision, 10, 32)
+	if err != nil || revision < 1 {
+		return 0, pb.NewErr(fmt.Errorf("revision must be a positive integer, got '%s'", rawRevision), http.StatusBadRequest)
+	}
+
+	return int32(revision), nil
+}
+
+// getStorageRevisionForNamespace gets the revision of the storage,
+// or its latest revision if revision is 0.
+func (a *Handler) getStorageRevisionForNamespace(id string, revision int32, namespace string) (*pb.Storage, error) {
+	if revision == 0 {
+		return a.getStorageForNamespace(id, namespace)
+	}
+
+	storage, err := a.Datastore.GetStorageRevision(id, revision)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if _, err = a.getStorageForNamespace(id, namespace); err != nil {
+			return nil, err
+		}
+
+		return nil, pb.NewErr(fmt.Errorf("storage '%s' has no revision %d", id, revision), http.StatusNotFound)
+	case err != nil:
+		return nil, err
+	}
+
+	return a.checkStorageOwnership(storage, namespace)
+}
+
+func (a *Handler) getStorageRevision(ctx *gin.Context, id, rawRevision string) (*pb.Storage, error) {
+	revision, err := parseRevision(rawRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.getStorageRevisionForNamespace(id, revision, namespace)
+}
+
+// putStorageContent stores the request's content as the next revision of
+// the storage, validated and deduplicated just as creating a storage is.
+func (a *Handler) putStorageContent(ctx *gin.Context, id, contentType string, r io.Reader) (*pb.Storage, error) {
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := a.getStorageForNamespace(id, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if r, err = a.limitUpload(namespace, r); err != nil {
+		return nil, err
+	}
+
+	content, info, err := a.getRequestContent(ctx, contentType, r)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err = store.CreateStorageRevision(ctx, a.Datastore, a.Blobstore, info.Describe(&pb.Storage{
+		Id:        storage.GetId(),
+		Namespace: storage.GetNamespace(),
+	}), content)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+		return nil, pb.NewErr(fmt.Errorf("storage '%s' was revised concurrently, try again", id), http.StatusConflict)
+	}
+
+	return storage, err
+}
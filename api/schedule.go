@@ -0,0 +1,293 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/logsquaredn/rototiller"
+	"github.com/logsquaredn/rototiller/pb"
+	tasks "github.com/logsquaredn/rototiller/task"
+	"github.com/robfig/cron/v3"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// DefaultScheduleInterval is how often due schedules are run by default.
+	DefaultScheduleInterval = 30 * time.Second
+	// maxCatchUpRuns is the most missed runs that a schedule
+	// catches up on at once, the latest of them being kept.
+	maxCatchUpRuns = 100
+)
+
+type scheduleRequest struct {
+	Name            string            `json:"name"`
+	Cron            string            `json:"cron"`
+	TaskType        string            `json:"task_type"`
+	Args            map[string]string `json:"args"`
+	Input           string            `json:"input"`
+	MissedRunPolicy string            `json:"missed_run_policy"`
+}
+
+// parseCron parses the cron expression, which is in UTC
+// unless it is prefixed with another, e.g. CRON_TZ=America/Chicago.
+func parseCron(rawCron string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(rawCron)
+	if err != nil {
+		return nil, pb.NewErr(fmt.Errorf("invalid cron expression '%s': %w", rawCron, err), http.StatusBadRequest)
+	}
+
+	// the parser falls back to the local time zone, which would
+	// make schedules' times depend on the API's environment
+	if spec, ok := schedule.(*cron.SpecSchedule); ok && spec.Location == time.Local {
+		spec.Location = time.UTC
+	}
+
+	return schedule, nil
+}
+
+// createScheduleForNamespace validates the schedule's job spec as creating a job
+// with it would, so that a bad one is refused now instead of failing every run.
+func (a *Handler) createScheduleForNamespace(ctx *gin.Context, namespace string) (*pb.Schedule, error) {
+	req := &scheduleRequest{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		return nil, pb.NewErr(err, http.StatusBadRequest)
+	}
+
+	schedule, err := parseCron(req.Cron)
+	if err != nil {
+		return nil, err
+	}
+
+	missedRunPolicy := pb.MissedRunPolicySkip
+	if req.MissedRunPolicy != "" {
+		if missedRunPolicy, err = pb.ParseMissedRunPolicy(req.MissedRunPolicy); err != nil {
+			return nil, pb.NewErr(err, http.StatusBadRequest)
+		}
+	}
+
+	task, err := a.getTask(req.TaskType)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, q := range []string{qInput, qInputOf, qOutputOf} {
+		if _, ok := req.Args[q]; ok {
+			return nil, pb.NewErr(fmt.Errorf("arg '%s' is not allowed, use input instead", q), http.StatusBadRequest)
+		}
+	}
+
+	args, err := tasks.BuildArgs(task, func(name string) string {
+		return req.Args[name]
+	})
+	if err != nil {
+		return nil, pb.NewErr(err, http.StatusBadRequest)
+	}
+
+	if err = a.checkStorageArgs(task, args, namespace); err != nil {
+		return nil, err
+	}
+
+	if _, err = parsePriority(req.Args[qPriority]); err != nil {
+		return nil, err
+	}
+
+	if req.Input == "" {
+		return nil, pb.NewErr(fmt.Errorf("input is required"), http.StatusBadRequest)
+	}
+
+	id, revision, err := parseStorageRef(req.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := a.getStorageRevisionForNamespace(id, revision, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkStorageUsable(storage); err != nil {
+		return nil, err
+	}
+
+	return a.Datastore.CreateSchedule(&pb.Schedule{
+		Namespace:       namespace,
+		Name:            req.Name,
+		Cron:            req.Cron,
+		TaskType:        task.GetType(),
+		Args:            req.Args,
+		Input:           req.Input,
+		MissedRunPolicy: missedRunPolicy.String(),
+		NextRunTime:     timestamppb.New(schedule.Next(time.Now())),
+	})
+}
+
+func (a *Handler) checkScheduleOwnership(schedule *pb.Schedule, namespace string) (*pb.Schedule, error) {
+	if schedule.Namespace != namespace {
+		return nil, pb.NewErr(fmt.Errorf("requester does not own schedule '%s'", schedule.Id), http.StatusForbidden)
+	}
+
+	return schedule, nil
+}
+
+func (a *Handler) getScheduleForNamespace(id string, namespace string) (*pb.Schedule, error) {
+	schedule, err := a.Datastore.GetSchedule(id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, pb.NewErr(fmt.Errorf("schedule '%s' not found", id), http.StatusNotFound)
+	case err != nil:
+		return nil, err
+	}
+
+	return a.checkScheduleOwnership(schedule, namespace)
+}
+
+func (a *Handler) getSchedule(ctx *gin.Context, id string) (*pb.Schedule, error) {
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.getScheduleForNamespace(id, namespace)
+}
+
+// setScheduleStatus pauses or resumes the schedule. A resumed schedule next
+// runs when it is next due from now, so the runs that it would have made
+// while paused are not caught up on.
+func (a *Handler) setScheduleStatus(ctx *gin.Context, id string, status pb.ScheduleStatus) (*pb.Schedule, error) {
+	schedule, err := a.getSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule.GetStatus() == status.String() {
+		return schedule, nil
+	}
+
+	nextRunTime := schedule.GetNextRunTime().AsTime()
+	if status == pb.ScheduleStatusActive {
+		cronSchedule, err := parseCron(schedule.GetCron())
+		if err != nil {
+			return nil, err
+		}
+
+		nextRunTime = cronSchedule.Next(time.Now())
+	}
+
+	return a.Datastore.UpdateScheduleStatus(schedule.GetId(), status, nextRunTime)
+}
+
+// RunSchedules creates the jobs of the schedules as they come due, checking
+// every interval, until ctx is done. Schedules are claimed before they are
+// run, so that running this alongside other instances of the API is safe.
+func (a *Handler) RunSchedules(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.runDueSchedules(ctx); err != nil {
+				rototiller.LoggerFrom(ctx).Error(err, "failed to run schedules")
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (a *Handler) runDueSchedules(ctx context.Context) error {
+	var (
+		logr = rototiller.LoggerFrom(ctx)
+		now  = time.Now()
+	)
+
+	schedules, err := a.Datastore.GetDueSchedules(now)
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		cronSchedule, err := parseCron(schedule.GetCron())
+		if err != nil {
+			logr.Error(err, "invalid cron expression", "id", schedule.GetId())
+			continue
+		}
+
+		runTimes := dueRunTimes(cronSchedule, schedule, now)
+		_, claimed, err := a.Datastore.ClaimSchedule(schedule, cronSchedule.Next(now), runTimes[len(runTimes)-1])
+		if err != nil {
+			logr.Error(err, "failed to claim schedule", "id", schedule.GetId())
+			continue
+		} else if !claimed {
+			continue
+		}
+
+		for _, runTime := range runTimes {
+			run := a.runSchedule(ctx, schedule, runTime)
+			if run.GetError() != "" {
+				logr.Info("scheduled job failed to be created", "id", schedule.GetId(), "error", run.GetError())
+			}
+
+			if _, err = a.Datastore.CreateScheduleRun(run); err != nil {
+				logr.Error(err, "failed to record schedule run", "id", schedule.GetId())
+			}
+		}
+	}
+
+	return nil
+}
+
+// dueRunTimes returns the times that the schedule was due to run at up to now,
+// which are many if it missed some, e.g. because the API was down. Schedules
+// that skip missed runs only run for the latest of them.
+func dueRunTimes(cronSchedule cron.Schedule, schedule *pb.Schedule, now time.Time) []time.Time {
+	runTimes := []time.Time{schedule.GetNextRunTime().AsTime()}
+	if schedule.GetMissedRunPolicy() == pb.MissedRunPolicyCatchUp.String() {
+		for runTime := cronSchedule.Next(runTimes[0]); !runTime.After(now); runTime = cronSchedule.Next(runTime) {
+			if runTimes = append(runTimes, runTime); len(runTimes) > maxCatchUpRuns {
+				runTimes = runTimes[1:]
+			}
+		}
+
+		return runTimes
+	}
+
+	runTime := runTimes[0]
+	for next := cronSchedule.Next(runTime); !next.After(now); next = cronSchedule.Next(next) {
+		runTime = next
+	}
+
+	return []time.Time{runTime}
+}
+
+// runSchedule creates the schedule's job just as a request to create it by
+// hand would, so that it is validated and subject to the namespace's quota
+// just the same, recording its ID or why it failed.
+func (a *Handler) runSchedule(ctx context.Context, schedule *pb.Schedule, runTime time.Time) *pb.ScheduleRun {
+	run := &pb.ScheduleRun{
+		ScheduleId:    schedule.GetId(),
+		ScheduledTime: timestamppb.New(runTime),
+	}
+
+	job, err := a.createJobForNamespace(ctx, schedule.GetTaskType(), &jobRequest{
+		Query: func(name string) string {
+			if name == qInput {
+				return schedule.GetInput()
+			}
+
+			return schedule.GetArgs()[name]
+		},
+	}, schedule.GetNamespace())
+	if err != nil {
+		run.Error = pb.NewErr(err).Message
+		return run
+	}
+	run.JobId = job.GetId()
+
+	return run
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/logsquaredn/rototiller"
+	"github.com/logsquaredn/rototiller/pb"
+)
+
+// @Security     ApiKeyAuth
+// @Summary      Create a schedule
+// @Description  Creates a job on a cron schedule, e.g. to re-run a job nightly against a storage whose content is revised
+// @Description  &emsp; - Pass a JSON body of the form {"name": "...", "cron": "0 0 * * *", "task_type": "...", "args": {"...": "..."}, "input": "<id>[@<revision>]", "missed_run_policy": "skip"}, where name and missed_run_policy are optional
+// @Description  &emsp; - The cron expression has five fields, minute, hour, day of month, month and day of week, in UTC unless prefixed with e.g. CRON_TZ=America/Chicago, or is a descriptor such as @daily or @every 1h
+// @Description  &emsp; - args are the queries that creating a job of the task type takes, e.g. priority, and are validated now as they would be then
+// @Description  &emsp; - input refers to an existing dataset, optionally pinned to one of its revisions. Default its latest revision at the time of each run
+// @Description  &emsp; - runs that are missed, e.g. because of downtime, are either skipped for the latest of them, "skip", or each run, "catchup". Default skip
+// @Tags         Schedule
+// @Accept       application/json
+// @Produce      application/json
+// @Success      201  {object}  rototiller.Schedule
+// @Failure      400  {object}  rototiller.Error
+// @Failure      401  {object}  rototiller.Error
+// @Failure      403  {object}  rototiller.Error
+// @Failure      404  {object}  rototiller.Error
+// @Failure      500  {object}  rototiller.Error
+// @Router       /api/v1/schedules [post].
+func (a *Handler) createScheduleHandler(ctx *gin.Context) {
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+	schedule, err := a.createScheduleForNamespace(ctx, namespace)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", path.Join(pb.EndpointSchedules, schedule.GetId()))
+	ctx.JSON(http.StatusCreated, schedule)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Get a list of schedules
+// @Description  Get a list of schedules based on API Key
+// @Tags         Schedule
+// @Produce      application/json
+// @Param        offset  query     int  false  "Offset of schedules to return"
+// @Param        limit   query     int  false  "Limit of schedules to return"
+// @Success      200     {object}  []rototiller.Schedule
+// @Failure      400     {object}  rototiller.Error
+// @Failure      401     {object}  rototiller.Error
+// @Failure      500     {object}  rototiller.Error
+// @Router       /api/v1/schedules [get].
+func (a *Handler) listScheduleHandler(ctx *gin.Context) {
+	q := &listQuery{}
+	if err := ctx.BindQuery(q); err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+	schedules, err := a.Datastore.GetSchedules(namespace, q.Offset, q.Limit)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		schedules = []*pb.Schedule{}
+	case err != nil:
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, schedules)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Get a schedule
+// @Description  Get a schedule, including when it next runs
+// @Tags         Schedule
+// @Produce      application/json
+// @Param        id   path      string  true  "Schedule ID"
+// @Success      200  {object}  rototiller.Schedule
+// @Failure      401  {object}  rototiller.Error
+// @Failure      403  {object}  rototiller.Error
+// @Failure      404  {object}  rototiller.Error
+// @Failure      500  {object}  rototiller.Error
+// @Router       /api/v1/schedules/{id} [get].
+func (a *Handler) getScheduleHandler(ctx *gin.Context) {
+	schedule, err := a.getSchedule(ctx, ctx.Param("schedule"))
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, schedule)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Delete a schedule
+// @Description  Deletes a schedule and its run history. The jobs that it created are kept
+// @Tags         Schedule
+// @Param        id   path  string  true  "Schedule ID"
+// @Success      204
+// @Failure      401  {object}  rototiller.Error
+// @Failure      403  {object}  rototiller.Error
+// @Failure      404  {object}  rototiller.Error
+// @Failure      500  {object}  rototiller.Error
+// @Router       /api/v1/schedules/{id} [delete].
+func (a *Handler) deleteScheduleHandler(ctx *gin.Context) {
+	schedule, err := a.getSchedule(ctx, ctx.Param("schedule"))
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	if err = a.Datastore.DeleteSchedule(schedule.GetId()); err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Pause a schedule
+// @Description  Stops a schedule from creating jobs until it is resumed
+// @Tags         Schedule
+// @Produce      application/json
+// @Param        id   path      string  true  "Schedule ID"
+// @Success      200  {object}  rototiller.Schedule
+// @Failure      401  {object}  rototiller.Error
+// @Failure      403  {object}  rototiller.Error
+// @Failure      404  {object}  rototiller.Error
+// @Failure      500  {object}  rototiller.Error
+// @Router       /api/v1/schedules/{id}/pause [post].
+func (a *Handler) pauseScheduleHandler(ctx *gin.Context) {
+	schedule, err := a.setScheduleStatus(ctx, ctx.Param("schedule"), pb.ScheduleStatusPaused)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, schedule)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Resume a schedule
+// @Description  Resumes a paused schedule from its next run after now. The runs that it missed while paused are not made
+// @Tags         Schedule
+// @Produce      application/json
+// @Param        id   path      string  true  "Schedule ID"
+// @Success      200  {object}  rototiller.Schedule
+// @Failure      401  {object}  rototiller.Error
+// @Failure      403  {object}  rototiller.Error
+// @Failure      404  {object}  rototiller.Error
+// @Failure      500  {object}  rototiller.Error
+// @Router       /api/v1/schedules/{id}/resume [post].
+func (a *Handler) resumeScheduleHandler(ctx *gin.Context) {
+	schedule, err := a.setScheduleStatus(ctx, ctx.Param("schedule"), pb.ScheduleStatusActive)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, schedule)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Get a schedule's run history
+// @Description  Get each time that a schedule ran, latest first, with the ID of the job that it created or why it could not
+// @Tags         Schedule
+// @Produce      application/json
+// @Param        id      path      string  true   "Schedule ID"
+// @Param        offset  query     int     false  "Offset of runs to return"
+// @Param        limit   query     int     false  "Limit of runs to return"
+// @Success      200     {object}  []rototiller.ScheduleRun
+// @Failure      400     {object}  rototiller.Error
+// @Failure      401     {object}  rototiller.Error
+// @Failure      403     {object}  rototiller.Error
+// @Failure      404     {object}  rototiller.Error
+// @Failure      500     {object}  rototiller.Error
+// @Router       /api/v1/schedules/{id}/runs [get].
+func (a *Handler) listScheduleRunsHandler(ctx *gin.Context) {
+	q := &listQuery{}
+	if err := ctx.BindQuery(q); err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	schedule, err := a.getSchedule(ctx, ctx.Param("schedule"))
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	runs, err := a.Datastore.GetScheduleRuns(schedule.GetId(), q.Offset, q.Limit)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, runs)
+}
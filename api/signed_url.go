@@ -0,0 +1,161 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/logsquaredn/rototiller/encoding/format"
+	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/store/blob/bucket"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultSignedURLExpiry = 15 * time.Minute
+	// maxSignedURLExpiry is the longest that S3, the
+	// most restrictive of the buckets, allows.
+	maxSignedURLExpiry = 7 * 24 * time.Hour
+)
+
+func signedURLExpiry(raw string) (time.Duration, error) {
+	if raw == "" {
+		return defaultSignedURLExpiry, nil
+	}
+
+	expiry, err := time.ParseDuration(raw)
+	if err != nil || expiry <= 0 || expiry > maxSignedURLExpiry {
+		return 0, pb.NewErr(fmt.Errorf("expiry must be a positive duration no longer than %s", maxSignedURLExpiry), http.StatusBadRequest)
+	}
+
+	return expiry, nil
+}
+
+// proxiedURL returns the path of the endpoint
+// that proxies a transfer through the API.
+func proxiedURL(method, proxied string) *pb.SignedURL {
+	return &pb.SignedURL{
+		Url:     proxied,
+		Method:  method,
+		Proxied: true,
+	}
+}
+
+// newSignedURL returns the signed URL or, if the bucket could not sign it,
+// the path of the endpoint that proxies the same transfer through the API.
+func newSignedURL(signed string, err error, method, proxied string, expiry time.Duration) (*pb.SignedURL, error) {
+	switch {
+	case errors.Is(err, bucket.ErrSigningUnsupported):
+		return proxiedURL(method, proxied), nil
+	case err != nil:
+		return nil, err
+	}
+
+	return &pb.SignedURL{
+		Url:        signed,
+		Method:     method,
+		ExpireTime: timestamppb.New(time.Now().Add(expiry)),
+	}, nil
+}
+
+func (a *Handler) getUploadPartURL(ctx *gin.Context, id, rawNumber string) (*pb.SignedURL, error) {
+	number, err := parsePartNumber(rawNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	expiry, err := signedURLExpiry(ctx.Query("expiry"))
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := strconv.ParseInt(ctx.Query("size"), 10, 64)
+	if err != nil || size < 1 {
+		return nil, pb.NewErr(fmt.Errorf("query 'size' must be the part's size in bytes, got '%s'", ctx.Query("size")), http.StatusBadRequest)
+	}
+
+	upload, err := a.getPendingUpload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.Size > 0 && size > upload.Size {
+		return nil, pb.NewErr(fmt.Errorf("part of %d bytes is larger than upload '%s' of %d bytes", size, upload.Id, upload.Size), http.StatusBadRequest)
+	}
+
+	// the part is PUT directly to the bucket, so the size signed
+	// into its URL is all that keeps it within the upload quota
+	if err = a.checkUploadSize(upload.Namespace, size); err != nil {
+		return nil, err
+	}
+
+	signed, err := a.Blobstore.SignPartURL(ctx, upload.Id, number, size, expiry)
+	return newSignedURL(
+		signed, err, http.MethodPut,
+		path.Join(pb.EndpointUploads, upload.Id, "parts", strconv.Itoa(number)),
+		expiry,
+	)
+}
+
+// getStorageContentURL signs a URL to the storage's file of the requested
+// format. Content that would have to be converted to that format cannot be
+// got directly from the bucket, so it is proxied through the API instead.
+func (a *Handler) getStorageContentURL(ctx *gin.Context, id string) (*pb.SignedURL, error) {
+	requested, err := requestedFormat(ctx.Query("format"), "")
+	if err != nil {
+		return nil, err
+	}
+
+	expiry, err := signedURLExpiry(ctx.Query("expiry"))
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := a.getStorageForNamespace(id, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := a.Blobstore.ListObjectFiles(ctx, storage.GetObjectId())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		return nil, pb.NewErr(fmt.Errorf("could not find content"), http.StatusNotFound)
+	}
+
+	// same default as getting the content: a zip if there is one or else whatever there is
+	name := names[0]
+	for _, n := range names {
+		ext := strings.ToLower(filepath.Ext(n))
+		if (requested == nil && ext == format.Shapefile.Ext) || (requested != nil && ext == requested.Ext) {
+			name = n
+			break
+		}
+	}
+
+	proxied := &url.URL{Path: path.Join(pb.EndpointStorages, storage.GetId(), "content")}
+	if requested != nil {
+		proxied.RawQuery = url.Values{"format": []string{requested.Name}}.Encode()
+
+		if !strings.EqualFold(filepath.Ext(name), requested.Ext) {
+			return proxiedURL(http.MethodGet, proxied.String()), nil
+		}
+	}
+
+	signed, err := a.Blobstore.SignObjectFileURL(ctx, storage.GetObjectId(), name, expiry)
+	return newSignedURL(signed, err, http.MethodGet, proxied.String(), expiry)
+}
@@ -1,13 +1,16 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/logsquaredn/rototiller/encoding/format"
 	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/store"
 )
 
 func (a *Handler) checkStorageOwnership(storage *pb.Storage, namespace string) (*pb.Storage, error) {
@@ -30,16 +33,13 @@ func (a *Handler) getStorageForNamespace(id string, namespace string) (*pb.Stora
 	return a.checkStorageOwnership(storage, namespace)
 }
 
-func (a *Handler) createStorageForNamespace(name string, namespace string) (*pb.Storage, error) {
-	storage, err := a.Datastore.CreateStorage(&pb.Storage{
+// createStorageForNamespace stores the content, linking to the namespace's
+// existing copy of the same content, if any, rather than storing it again.
+func (a *Handler) createStorageForNamespace(ctx context.Context, name string, namespace string, info *format.Info, content store.Content) (*pb.Storage, error) {
+	return store.CreateStorage(ctx, a.Datastore, a.Blobstore, info.Describe(&pb.Storage{
 		Namespace: namespace,
 		Name:      name,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	return storage, nil
+	}), content)
 }
 
 func (a *Handler) getJobOutputStorage(ctx *gin.Context, id string) (*pb.Storage, error) {
@@ -50,7 +50,7 @@ func (a *Handler) getJobOutputStorage(ctx *gin.Context, id string) (*pb.Storage,
 	return a.getJobOutputStorageForNamespace(ctx, id, namespace)
 }
 
-func (a *Handler) getJobOutputStorageForNamespace(ctx *gin.Context, id string, namespace string) (*pb.Storage, error) {
+func (a *Handler) getJobOutputStorageForNamespace(ctx context.Context, id string, namespace string) (*pb.Storage, error) {
 	storage, err := a.Datastore.GetJobOutputStorage(id)
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
@@ -70,7 +70,7 @@ func (a *Handler) getJobInputStorage(ctx *gin.Context, id string) (*pb.Storage,
 	return a.getJobInputStorageForNamespace(ctx, id, namespace)
 }
 
-func (a *Handler) getJobInputStorageForNamespace(ctx *gin.Context, id string, namespace string) (*pb.Storage, error) {
+func (a *Handler) getJobInputStorageForNamespace(ctx context.Context, id string, namespace string) (*pb.Storage, error) {
 	storage, err := a.Datastore.GetJobInputStorage(id)
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
@@ -3,7 +3,6 @@ package api
 import (
 	"database/sql"
 	"errors"
-	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -13,28 +12,42 @@ import (
 
 // @Security     ApiKeyAuth
 // @Summary      Get a list of storage
-// @Description  Get a list of stored datasets based on API Key
+// @Description  Get a list of stored datasets based on API Key, optionally filtered by their metadata
 // @Tags         Storage
 // @Produce      application/json
-// @Param        offset  query     int  false  "Offset of storages to return"
-// @Param        limit   query     int  false  "Limit of storages to return"
-// @Success      200     {object}  []rototiller.Storage
-// @Failure      401     {object}  rototiller.Error
-// @Failure      500     {object}  rototiller.Error
+// @Param        offset         query     int     false  "Offset of storages to return"
+// @Param        limit          query     int     false  "Limit of storages to return"
+// @Param        format         query     string  false  "Only storages of this format, e.g. geopackage"
+// @Param        geometry-type  query     string  false  "Only storages whose geometries are of this GeoJSON type, e.g. Polygon, or Mixed"
+// @Param        crs            query     string  false  "Only storages in this CRS, e.g. EPSG:4326"
+// @Param        column         query     string  false  "Only storages whose features have this column"
+// @Param        min-features   query     int     false  "Only storages with at least this many features"
+// @Param        max-features   query     int     false  "Only storages with at most this many features"
+// @Param        bbox           query     string  false  "Only storages whose bbox intersects this one of the form minx,miny,maxx,maxy"
+// @Success      200            {object}  []rototiller.Storage
+// @Failure      400            {object}  rototiller.Error
+// @Failure      401            {object}  rototiller.Error
+// @Failure      500            {object}  rototiller.Error
 // @Router       /api/v1/storages [get].
 func (a *Handler) listStorageHandler(ctx *gin.Context) {
-	q := &listQuery{}
+	q := &storageListQuery{}
 	if err := ctx.BindQuery(q); err != nil {
 		a.err(ctx, err)
 		return
 	}
 
+	filter, err := q.filter()
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
 	namespace, err := a.getNamespaceFromContext(ctx)
 	if err != nil {
 		a.err(ctx, err)
 		return
 	}
-	storage, err := a.Datastore.GetOwnerStorage(namespace, q.Offset, q.Limit)
+	storage, err := a.Datastore.GetOwnerStorage(namespace, q.Offset, q.Limit, filter)
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
 		storage = []*pb.Storage{}
@@ -50,7 +63,7 @@ func (a *Handler) listStorageHandler(ctx *gin.Context) {
 
 // @Security     ApiKeyAuth
 // @Summary      Get a storage
-// @Description  Get the metadata of a stored dataset
+// @Description  Get the metadata of a stored dataset: its format, size, SHA-256 checksum, CRS, bbox, geometry type, feature count, layers and columns
 // @Tags         Storage
 // @Produce      application/json
 // @Param        id   path      string  true  "Storage ID"
@@ -79,14 +92,16 @@ func (a *Handler) getStorageHandler(ctx *gin.Context) {
 // @Summary      Get a storage's content
 // @Description  Gets the content of a stored dataset
 // @Tags         Content
-// @Produce      application/json, application/zip
-// @Param        Accept  header  string  false  "Request results as a Zip or JSON. Default Zip"
+// @Produce      application/json, application/zip, application/geopackage+sqlite3, application/vnd.google-earth.kml+xml, text/csv, application/flatgeobuf, application/x-ndjson
+// @Param        Accept  header  string  false  "Request results as a Zip, JSON, GeoPackage, KML, CSV, FlatGeobuf or newline-delimited GeoJSON, converted from GeoJSON if need be. Default Zip"
+// @Param        format  query   string  false  "Overrides Accept with one of geojson, shapefile, geopackage, kml, csv, flatgeobuf or ndjson, or their file extensions"
 // @Param        id      path    string  true   "Storage ID"
 // @Success      200
 // @Failure      400  {object}  rototiller.Error
 // @Failure      401  {object}  rototiller.Error
 // @Failure      403  {object}  rototiller.Error
 // @Failure      404  {object}  rototiller.Error
+// @Failure      406  {object}  rototiller.Error
 // @Failure      500  {object}  rototiller.Error
 // @Router       /api/v1/storages/{id}/content [get].
 func (a *Handler) getStorageContentHandler(ctx *gin.Context) {
@@ -101,59 +116,201 @@ func (a *Handler) getStorageContentHandler(ctx *gin.Context) {
 		return
 	}
 
-	volume, err := a.Blobstore.GetObject(ctx, storage.GetId())
+	volume, err := a.Blobstore.GetObject(ctx, storage.GetObjectId())
 	if err != nil {
 		a.err(ctx, err)
 		return
 	}
 
-	r, contentType, err := a.getVolumeContent(ctx.GetHeader("Accept"), volume)
+	if err = a.writeVolumeContent(ctx, storage, volume); err != nil {
+		a.err(ctx, err)
+	}
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Get a URL to a storage's content
+// @Description  Gets a short-lived signed URL that the content of a stored dataset can be downloaded from directly, without its bytes passing through the API
+// @Description  &emsp; - If the bucket does not support signed URLs, or the content must be converted to the requested format, the path of the endpoint to get the content through the API is returned instead and "proxied" is true
+// @Tags         Content
+// @Produce      application/json
+// @Param        format  query     string  false  "One of geojson, shapefile, geopackage, kml, csv, flatgeobuf or ndjson, or their file extensions. Default Zip"
+// @Param        expiry  query     string  false  "How long the URL is valid for, e.g. 1h. Default 15m, max 168h"
+// @Param        id      path      string  true   "Storage ID"
+// @Success      200     {object}  rototiller.SignedURL
+// @Failure      400     {object}  rototiller.Error
+// @Failure      401     {object}  rototiller.Error
+// @Failure      403     {object}  rototiller.Error
+// @Failure      404     {object}  rototiller.Error
+// @Failure      500     {object}  rototiller.Error
+// @Router       /api/v1/storages/{id}/content/url [get].
+func (a *Handler) getStorageContentURLHandler(ctx *gin.Context) {
+	signedURL, err := a.getStorageContentURL(ctx, ctx.Param("storage"))
 	if err != nil {
 		a.err(ctx, err)
 		return
 	}
-	defer r.Close()
 
-	ctx.Writer.Header().Add("Content-Type", contentType)
-	_, _ = io.Copy(ctx.Writer, r)
+	ctx.JSON(http.StatusOK, signedURL)
 }
 
 // @Security     ApiKeyAuth
 // @Summary      Create a storage
 // @Description  Stores a dataset. The ID of this stored dataset can be used as input to jobs
 // @Description  &emsp; - Pass the geospatial data to be stored in the request body
+// @Description  &emsp; - GeoPackage, KML, CSV (with a WKT column or longitude and latitude columns), FlatGeobuf and newline-delimited GeoJSON data is stored as-is alongside its conversion to GeoJSON
+// @Description  &emsp; - The data is validated against its Content-Type on upload, e.g. that a zip has each shapefile's .shp, .shx, .dbf and .prj, and every problem found is listed in the error's details. The detected format, layers and feature count are recorded on the stored dataset
+// @Description  &emsp; - Content identical to that of a dataset already stored in the same namespace, by SHA-256 checksum and format, is linked to rather than stored again
 // @Tags         Storage
-// @Accept       application/json, application/zip
+// @Accept       application/json, application/zip, application/geopackage+sqlite3, application/vnd.google-earth.kml+xml, text/csv, application/flatgeobuf, application/x-ndjson
 // @Produce      application/json
 // @Param        name  query     string  false  "Storage name"
 // @Success      200   {object}  rototiller.Storage
 // @Failure      400   {object}  rototiller.Error
 // @Failure      401   {object}  rototiller.Error
+// @Failure      413   {object}  rototiller.Error
 // @Failure      500   {object}  rototiller.Error
 // @Router       /api/v1/storages [post].
 func (a *Handler) createStorageHandler(ctx *gin.Context) {
 	defer ctx.Request.Body.Close()
-	volume, err := a.getRequestVolume(ctx.Request.Header.Get("Content-Type"), ctx.Request.Body)
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+	storage, err := a.putRequestContentForNamespace(ctx, ctx.Request.Header.Get("Content-Type"), ctx.Query("name"), ctx.Request.Body, namespace)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, storage)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Revise a storage's content
+// @Description  Stores a dataset as the next revision of a storage, which becomes its latest. Earlier revisions are kept as they were, so jobs can still use them as input with input=<id>@<revision>
+// @Description  &emsp; - The data is validated and deduplicated just as creating a storage does
+// @Tags         Storage
+// @Accept       application/json, application/zip, application/geopackage+sqlite3, application/vnd.google-earth.kml+xml, text/csv, application/flatgeobuf, application/x-ndjson
+// @Produce      application/json
+// @Param        id   path      string  true  "Storage ID"
+// @Success      200  {object}  rototiller.Storage
+// @Failure      400  {object}  rototiller.Error
+// @Failure      401  {object}  rototiller.Error
+// @Failure      403  {object}  rototiller.Error
+// @Failure      404  {object}  rototiller.Error
+// @Failure      409  {object}  rototiller.Error
+// @Failure      413  {object}  rototiller.Error
+// @Failure      500  {object}  rototiller.Error
+// @Router       /api/v1/storages/{id}/content [put].
+func (a *Handler) putStorageContentHandler(ctx *gin.Context) {
+	defer ctx.Request.Body.Close()
+	storage, err := a.putStorageContent(ctx, ctx.Param("storage"), ctx.GetHeader("Content-Type"), ctx.Request.Body)
 	if err != nil {
 		a.err(ctx, err)
 		return
 	}
 
+	ctx.JSON(http.StatusOK, storage)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Get a list of a storage's revisions
+// @Description  Get the metadata of each revision of a stored dataset, oldest first
+// @Tags         Storage
+// @Produce      application/json
+// @Param        id      path      string  true   "Storage ID"
+// @Param        offset  query     int     false  "Offset of revisions to return"
+// @Param        limit   query     int     false  "Limit of revisions to return"
+// @Success      200     {object}  []rototiller.Storage
+// @Failure      400     {object}  rototiller.Error
+// @Failure      401     {object}  rototiller.Error
+// @Failure      403     {object}  rototiller.Error
+// @Failure      404     {object}  rototiller.Error
+// @Failure      500     {object}  rototiller.Error
+// @Router       /api/v1/storages/{id}/revisions [get].
+func (a *Handler) listStorageRevisionsHandler(ctx *gin.Context) {
+	q := &listQuery{}
+	if err := ctx.BindQuery(q); err != nil {
+		a.err(ctx, err)
+		return
+	}
+
 	namespace, err := a.getNamespaceFromContext(ctx)
 	if err != nil {
 		a.err(ctx, err)
 		return
 	}
-	storage, err := a.createStorageForNamespace(ctx.Query("name"), namespace)
+	storage, err := a.getStorageForNamespace(ctx.Param("storage"), namespace)
 	if err != nil {
 		a.err(ctx, err)
 		return
 	}
 
-	if err = a.Blobstore.PutObject(ctx, storage.GetId(), volume); err != nil {
+	revisions, err := a.Datastore.GetStorageRevisions(storage.GetId(), q.Offset, q.Limit)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, revisions)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Get a storage's revision
+// @Description  Get the metadata of one revision of a stored dataset
+// @Tags         Storage
+// @Produce      application/json
+// @Param        id        path      string  true  "Storage ID"
+// @Param        revision  path      int     true  "Revision"
+// @Success      200       {object}  rototiller.Storage
+// @Failure      400       {object}  rototiller.Error
+// @Failure      401       {object}  rototiller.Error
+// @Failure      403       {object}  rototiller.Error
+// @Failure      404       {object}  rototiller.Error
+// @Failure      500       {object}  rototiller.Error
+// @Router       /api/v1/storages/{id}/revisions/{revision} [get].
+func (a *Handler) getStorageRevisionHandler(ctx *gin.Context) {
+	storage, err := a.getStorageRevision(ctx, ctx.Param("storage"), ctx.Param("revision"))
+	if err != nil {
 		a.err(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, storage)
 }
+
+// @Security     ApiKeyAuth
+// @Summary      Get a storage's revision's content
+// @Description  Gets the content of one revision of a stored dataset
+// @Tags         Content
+// @Produce      application/json, application/zip, application/geopackage+sqlite3, application/vnd.google-earth.kml+xml, text/csv, application/flatgeobuf, application/x-ndjson
+// @Param        Accept    header  string  false  "Request results as a Zip, JSON, GeoPackage, KML, CSV, FlatGeobuf or newline-delimited GeoJSON, converted from GeoJSON if need be. Default Zip"
+// @Param        format    query   string  false  "Overrides Accept with one of geojson, shapefile, geopackage, kml, csv, flatgeobuf or ndjson, or their file extensions"
+// @Param        id        path    string  true   "Storage ID"
+// @Param        revision  path    int     true   "Revision"
+// @Success      200
+// @Failure      400  {object}  rototiller.Error
+// @Failure      401  {object}  rototiller.Error
+// @Failure      403  {object}  rototiller.Error
+// @Failure      404  {object}  rototiller.Error
+// @Failure      406  {object}  rototiller.Error
+// @Failure      500  {object}  rototiller.Error
+// @Router       /api/v1/storages/{id}/revisions/{revision}/content [get].
+func (a *Handler) getStorageRevisionContentHandler(ctx *gin.Context) {
+	storage, err := a.getStorageRevision(ctx, ctx.Param("storage"), ctx.Param("revision"))
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	volume, err := a.Blobstore.GetObject(ctx, storage.GetObjectId())
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	if err = a.writeVolumeContent(ctx, storage, volume); err != nil {
+		a.err(ctx, err)
+	}
+}
@@ -3,25 +3,22 @@ package api
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/logsquaredn/rototiller/pb"
 )
 
 func (a *Handler) getTask(rawTaskType string) (*pb.Task, error) {
-	taskType, err := pb.ParseTaskType(rawTaskType)
-	if err != nil {
-		return nil, pb.NewErr(err, http.StatusBadRequest)
-	}
-
-	return a.getTaskType(taskType)
+	return a.getTaskType(pb.TaskType(strings.ToLower(rawTaskType)))
 }
 
 func (a *Handler) getTaskType(taskType pb.TaskType) (*pb.Task, error) {
 	task, err := a.Datastore.GetTask(taskType)
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
-		return nil, pb.NewErr(err, http.StatusNotFound)
+		return nil, pb.NewErr(fmt.Errorf("task '%s' not found", taskType), http.StatusNotFound)
 	case err != nil:
 		return nil, err
 	}
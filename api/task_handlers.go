@@ -19,9 +19,7 @@ import (
 // @Failure   500  {object}  rototiller.Error
 // @Router    /api/v1/tasks [get].
 func (a *Handler) listTasksHandler(ctx *gin.Context) {
-	tasks, err := a.Datastore.GetTasks(
-		pb.AllTaskTypes...,
-	)
+	tasks, err := a.Datastore.ListTasks()
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
 		tasks = []*pb.Task{}
@@ -35,17 +33,18 @@ func (a *Handler) listTasksHandler(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, tasks)
 }
 
-// @Security  ApiKeyAuth
-// @Summary   Get a task type
-// @Tags      Task
-// @Produce   application/json
-// @Param     type  path      string  true  "Task type"
-// @Success   200   {object}  rototiller.Task
-// @Failure   400   {object}  rototiller.Error
-// @Failure   401   {object}  rototiller.Error
-// @Failure   404   {object}  rototiller.Error
-// @Failure   500   {object}  rototiller.Error
-// @Router    /api/v1/tasks/{type} [get].
+// @Security     ApiKeyAuth
+// @Summary      Get a task type
+// @Description  Get a task type, including the schema of each of its params
+// @Description  that is used to validate the queries of jobs created with it
+// @Tags         Task
+// @Produce      application/json
+// @Param        task  path      string  true  "Task type"
+// @Success      200   {object}  rototiller.Task
+// @Failure      401   {object}  rototiller.Error
+// @Failure      404   {object}  rototiller.Error
+// @Failure      500   {object}  rototiller.Error
+// @Router       /api/v1/tasks/{task} [get].
 func (a *Handler) getTaskHandler(ctx *gin.Context) {
 	task, err := a.getTask(ctx.Param("task"))
 	if err != nil {
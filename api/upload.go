@@ -0,0 +1,250 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/frantjc/go-js"
+	"github.com/gin-gonic/gin"
+	"github.com/logsquaredn/rototiller/encoding/format"
+	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/store"
+	"github.com/logsquaredn/rototiller/store/blob/bucket"
+)
+
+// maxParts is the most parts that an upload may have.
+const maxParts = 10000
+
+var sha256Pattern = regexp.MustCompile("^[0-9a-f]{64}$")
+
+type uploadQuery struct {
+	Name     string `form:"name"`
+	Size     int64  `form:"size"`
+	Checksum string `form:"sha256"`
+}
+
+func (a *Handler) createUploadForNamespace(ctx *gin.Context, namespace string) (*pb.Upload, error) {
+	q := &uploadQuery{}
+	if err := ctx.BindQuery(q); err != nil {
+		return nil, pb.NewErr(err, http.StatusBadRequest)
+	}
+
+	contentType := ctx.GetHeader("Content-Type")
+	f, err := format.FromContentType(contentType)
+	if err != nil {
+		return nil, pb.NewErr(err, http.StatusBadRequest)
+	}
+
+	if q.Size < 0 {
+		return nil, pb.NewErr(fmt.Errorf("size must not be negative"), http.StatusBadRequest)
+	}
+
+	checksum := strings.ToLower(q.Checksum)
+	if checksum != "" && !sha256Pattern.MatchString(checksum) {
+		return nil, pb.NewErr(fmt.Errorf("sha256 must be a hex-encoded SHA-256 checksum"), http.StatusBadRequest)
+	}
+
+	return a.Datastore.CreateUpload(&pb.Upload{
+		Namespace:   namespace,
+		Name:        q.Name,
+		ContentType: f.ContentType,
+		Size:        q.Size,
+		Checksum:    checksum,
+	})
+}
+
+func (a *Handler) getUploadForNamespace(ctx *gin.Context, id string, namespace string) (*pb.Upload, error) {
+	upload, err := a.Datastore.GetUpload(id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, pb.NewErr(fmt.Errorf("upload '%s' not found", id), http.StatusNotFound)
+	case err != nil:
+		return nil, err
+	case upload.Namespace != namespace:
+		return nil, pb.NewErr(fmt.Errorf("requester does not own upload '%s'", id), http.StatusForbidden)
+	}
+
+	if upload.Status == pb.UploadStatusPending.String() {
+		parts, err := a.Blobstore.ListParts(ctx, upload.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		upload.Parts = make([]*pb.Part, len(parts))
+		for i, p := range parts {
+			upload.Parts[i] = &pb.Part{Number: int32(p.Number), Size: p.Size}
+		}
+	}
+
+	return upload, nil
+}
+
+func (a *Handler) getUpload(ctx *gin.Context, id string) (*pb.Upload, error) {
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.getUploadForNamespace(ctx, id, namespace)
+}
+
+func parsePartNumber(rawNumber string) (int, error) {
+	number, err := strconv.Atoi(rawNumber)
+	if err != nil || number < 1 || number > maxParts {
+		return 0, pb.NewErr(fmt.Errorf("part number must be an integer from 1 to %d", maxParts), http.StatusBadRequest)
+	}
+
+	return number, nil
+}
+
+// getPendingUpload gets the upload, making sure
+// that parts can still be uploaded to it.
+func (a *Handler) getPendingUpload(ctx *gin.Context, id string) (*pb.Upload, error) {
+	upload, err := a.getUpload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.Status != pb.UploadStatusPending.String() {
+		return nil, pb.NewErr(fmt.Errorf("upload '%s' is %s", id, upload.Status), http.StatusConflict)
+	}
+
+	return upload, nil
+}
+
+func (a *Handler) putUploadPart(ctx *gin.Context, id, rawNumber string, r io.Reader) (*pb.Part, error) {
+	number, err := parsePartNumber(rawNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := a.getPendingUpload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	part, err := a.Blobstore.PutPart(ctx, upload.Id, number, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.Part{Number: int32(part.Number), Size: part.Size, Checksum: part.Checksum}, nil
+}
+
+// completeUpload assembles the upload's parts, verifies them against the
+// upload's checksum and only then stores them like any other dataset.
+// The parts are assembled within the bucket where it can, and are
+// otherwise streamed through the API, so that they are never held in
+// memory and only ever read back, rather than written again, to validate them.
+// Completing an upload that is already complete returns its storage, so
+// that a client whose request to complete it was interrupted can retry.
+// Parts PUT directly to the bucket were never hashed by us, so whether
+// a checksum is required to complete the upload is up to the caller.
+func (a *Handler) completeUpload(ctx *gin.Context, id string, requireChecksum bool) (*pb.Storage, error) {
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := a.getUploadForNamespace(ctx, id, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.Status == pb.UploadStatusComplete.String() {
+		return a.getStorageForNamespace(upload.StorageId, namespace)
+	}
+
+	checksum := strings.ToLower(js.Ternary(ctx.Query("sha256") != "", ctx.Query("sha256"), upload.Checksum))
+	if (requireChecksum || checksum != "") && !sha256Pattern.MatchString(checksum) {
+		return nil, pb.NewErr(fmt.Errorf("sha256 must be given as a hex-encoded SHA-256 checksum when the upload is created or completed"), http.StatusBadRequest)
+	}
+
+	f, err := format.FromContentType(upload.ContentType)
+	if err != nil {
+		return nil, pb.NewErr(err, http.StatusBadRequest)
+	}
+
+	parts, err := a.Blobstore.ListParts(ctx, upload.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := checkParts(upload, parts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.checkUploadSize(namespace, size); err != nil {
+		return nil, err
+	}
+
+	info, content, err := store.IngestParts(ctx, a.Blobstore, f, upload.Id, parts, inputPrefix)
+	if err != nil {
+		return nil, validationErr(err)
+	}
+
+	if checksum != "" && info.Checksum != checksum {
+		_ = store.Discard(ctx, a.Blobstore, content)
+		return nil, pb.NewErr(fmt.Errorf("sha256 of the assembled parts is '%s', not '%s'", info.Checksum, checksum), http.StatusBadRequest)
+	}
+
+	storage, err := a.createStorageForNamespace(ctx, upload.Name, namespace, info, content)
+	if err != nil {
+		return nil, err
+	}
+
+	upload.Status = pb.UploadStatusComplete.String()
+	upload.StorageId = storage.GetId()
+	if _, err = a.Datastore.UpdateUpload(upload); err != nil {
+		return nil, err
+	}
+
+	if err = a.Blobstore.DeleteParts(ctx, upload.Id); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// checkParts checks that the parts are numbered from 1 without gaps and, if
+// the upload's size was given, that they add up to it, returning their size.
+func checkParts(upload *pb.Upload, parts []*bucket.Part) (int64, error) {
+	if len(parts) == 0 {
+		return 0, pb.NewErr(fmt.Errorf("upload '%s' has no parts", upload.Id), http.StatusBadRequest)
+	}
+
+	var size int64
+	for i, p := range parts {
+		if p.Number != i+1 {
+			return 0, pb.NewErr(fmt.Errorf("upload '%s' is missing part %d", upload.Id, i+1), http.StatusBadRequest)
+		}
+
+		size += p.Size
+	}
+
+	if upload.Size > 0 && size != upload.Size {
+		return 0, pb.NewErr(fmt.Errorf("parts of upload '%s' add up to %d bytes, not %d", upload.Id, size, upload.Size), http.StatusBadRequest)
+	}
+
+	return size, nil
+}
+
+func (a *Handler) deleteUpload(ctx *gin.Context, id string) error {
+	upload, err := a.getUpload(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = a.Blobstore.DeleteParts(ctx, upload.Id); err != nil {
+		return err
+	}
+
+	return a.Datastore.DeleteUpload(upload.Id)
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/logsquaredn/rototiller"
+)
+
+// @Security     ApiKeyAuth
+// @Summary      Create an upload
+// @Description  Starts a resumable upload of a large dataset. Its parts are uploaded separately, in any order and as many times as need be, then the upload is completed to store them as one dataset
+// @Description  &emsp; - Pass the Content-Type of the dataset as a whole, e.g. application/zip
+// @Description  &emsp; - The SHA-256 checksum of the dataset as a whole must be given either here or when completing the upload
+// @Tags         Upload
+// @Produce      application/json
+// @Param        name    query     string  false  "Storage name"
+// @Param        size    query     int     false  "Size of the dataset in bytes"
+// @Param        sha256  query     string  false  "Hex-encoded SHA-256 checksum of the dataset"
+// @Success      201     {object}  rototiller.Upload
+// @Failure      400     {object}  rototiller.Error
+// @Failure      401     {object}  rototiller.Error
+// @Failure      500     {object}  rototiller.Error
+// @Router       /api/v1/uploads [post].
+func (a *Handler) createUploadHandler(ctx *gin.Context) {
+	namespace, err := a.getNamespaceFromContext(ctx)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+	upload, err := a.createUploadForNamespace(ctx, namespace)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, upload)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Get an upload
+// @Description  Gets an upload, including the number and size of each of its parts that have been uploaded so far, so that an interrupted upload can be resumed
+// @Tags         Upload
+// @Produce      application/json
+// @Param        id   path      string  true  "Upload ID"
+// @Success      200  {object}  rototiller.Upload
+// @Failure      401  {object}  rototiller.Error
+// @Failure      403  {object}  rototiller.Error
+// @Failure      404  {object}  rototiller.Error
+// @Failure      500  {object}  rototiller.Error
+// @Router       /api/v1/uploads/{id} [get].
+func (a *Handler) getUploadHandler(ctx *gin.Context) {
+	upload, err := a.getUpload(ctx, ctx.Param("upload"))
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, upload)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Upload a part
+// @Description  Uploads one part of an upload, replacing it if it was already uploaded. Parts are numbered from 1 and are assembled in order of their number
+// @Tags         Upload
+// @Accept       application/octet-stream
+// @Produce      application/json
+// @Param        id    path      string  true  "Upload ID"
+// @Param        part  path      int     true  "Part number, from 1 to 10000"
+// @Success      200   {object}  rototiller.Part
+// @Failure      400   {object}  rototiller.Error
+// @Failure      401   {object}  rototiller.Error
+// @Failure      403   {object}  rototiller.Error
+// @Failure      404   {object}  rototiller.Error
+// @Failure      409   {object}  rototiller.Error
+// @Failure      500   {object}  rototiller.Error
+// @Router       /api/v1/uploads/{id}/parts/{part} [put].
+func (a *Handler) putUploadPartHandler(ctx *gin.Context) {
+	defer ctx.Request.Body.Close()
+	part, err := a.putUploadPart(ctx, ctx.Param("upload"), ctx.Param("part"), ctx.Request.Body)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, part)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Complete an upload
+// @Description  Assembles an upload's parts and verifies them against its SHA-256 checksum, then validates and stores them as a dataset just as creating a storage does. Completing an upload that is already complete gets its storage
+// @Tags         Upload
+// @Produce      application/json
+// @Param        id      path      string  true   "Upload ID"
+// @Param        sha256  query     string  false  "Hex-encoded SHA-256 checksum of the dataset, if it was not given when creating the upload"
+// @Success      200     {object}  rototiller.Storage
+// @Failure      400     {object}  rototiller.Error
+// @Failure      401     {object}  rototiller.Error
+// @Failure      403     {object}  rototiller.Error
+// @Failure      404     {object}  rototiller.Error
+// @Failure      413     {object}  rototiller.Error
+// @Failure      500     {object}  rototiller.Error
+// @Router       /api/v1/uploads/{id}/complete [post].
+func (a *Handler) completeUploadHandler(ctx *gin.Context) {
+	storage, err := a.completeUpload(ctx, ctx.Param("upload"), true)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, storage)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Delete an upload
+// @Description  Aborts an upload, deleting any of its parts. The storage of a complete upload is not deleted
+// @Tags         Upload
+// @Param        id   path  string  true  "Upload ID"
+// @Success      204
+// @Failure      401  {object}  rototiller.Error
+// @Failure      403  {object}  rototiller.Error
+// @Failure      404  {object}  rototiller.Error
+// @Failure      500  {object}  rototiller.Error
+// @Router       /api/v1/uploads/{id} [delete].
+func (a *Handler) deleteUploadHandler(ctx *gin.Context) {
+	if err := a.deleteUpload(ctx, ctx.Param("upload")); err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Get a URL to upload a part to
+// @Description  Gets a short-lived signed URL that one part of an upload can be PUT to directly, without its bytes passing through the API. Finalize the upload once its parts are uploaded
+// @Description  &emsp; - If the bucket does not support signed URLs, the path of the endpoint to upload the part through the API is returned instead and "proxied" is true
+// @Description  &emsp; - A dataset that is small enough to upload in one request is uploaded as part 1
+// @Description  &emsp; - The URL only accepts a part of exactly the given size
+// @Tags         Upload
+// @Produce      application/json
+// @Param        id      path      string  true   "Upload ID"
+// @Param        part    path      int     true   "Part number, from 1 to 10000"
+// @Param        size    query     int     true   "Size of the part in bytes"
+// @Param        expiry  query     string  false  "How long the URL is valid for, e.g. 1h. Default 15m, max 168h"
+// @Success      200     {object}  rototiller.SignedURL
+// @Failure      400     {object}  rototiller.Error
+// @Failure      401     {object}  rototiller.Error
+// @Failure      403     {object}  rototiller.Error
+// @Failure      404     {object}  rototiller.Error
+// @Failure      409     {object}  rototiller.Error
+// @Failure      413     {object}  rototiller.Error
+// @Failure      500     {object}  rototiller.Error
+// @Router       /api/v1/uploads/{id}/parts/{part}/url [get].
+func (a *Handler) getUploadPartURLHandler(ctx *gin.Context) {
+	signedURL, err := a.getUploadPartURL(ctx, ctx.Param("upload"), ctx.Param("part"))
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, signedURL)
+}
+
+// @Security     ApiKeyAuth
+// @Summary      Finalize an upload
+// @Description  Registers the parts uploaded to an upload's signed URLs as a storage, validating them just as creating a storage does. Where the bucket can, the parts are assembled within it and only read back to validate them. Unlike completing an upload, a SHA-256 checksum is only verified if one was given
+// @Tags         Upload
+// @Produce      application/json
+// @Param        id      path      string  true   "Upload ID"
+// @Param        sha256  query     string  false  "Hex-encoded SHA-256 checksum of the dataset, if it was not given when creating the upload"
+// @Success      200     {object}  rototiller.Storage
+// @Failure      400     {object}  rototiller.Error
+// @Failure      401     {object}  rototiller.Error
+// @Failure      403     {object}  rototiller.Error
+// @Failure      404     {object}  rototiller.Error
+// @Failure      413     {object}  rototiller.Error
+// @Failure      500     {object}  rototiller.Error
+// @Router       /api/v1/uploads/{id}/finalize [post].
+func (a *Handler) finalizeUploadHandler(ctx *gin.Context) {
+	storage, err := a.completeUpload(ctx, ctx.Param("upload"), false)
+	if err != nil {
+		a.err(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, storage)
+}
@@ -1,92 +1,235 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/frantjc/go-js"
 	"github.com/gin-gonic/gin"
+	"github.com/logsquaredn/rototiller/encoding/format"
 	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/store"
 	"github.com/logsquaredn/rototiller/volume"
 )
 
 const (
-	zipExt  = ".zip"
-	jsonExt = ".json"
+	// inputPrefix is the name of uploaded files, without their extension.
+	inputPrefix = "input"
 )
 
-func (a *Handler) putRequestVolumeForNamespace(ctx *gin.Context, contentType, name string, r io.Reader, namespace string) (*pb.Storage, error) {
-	volume, err := a.getRequestVolume(contentType, r)
+func (a *Handler) putRequestContentForNamespace(ctx context.Context, contentType, name string, r io.Reader, namespace string) (*pb.Storage, error) {
+	r, err := a.limitUpload(namespace, r)
 	if err != nil {
 		return nil, err
 	}
 
-	storage, err := a.createStorageForNamespace(name, namespace)
+	content, info, err := a.getRequestContent(ctx, contentType, r)
 	if err != nil {
 		return nil, err
 	}
 
-	if err = a.Blobstore.PutObject(ctx, storage.GetId(), volume); err != nil {
-		return nil, err
+	return a.createStorageForNamespace(ctx, name, namespace, info, content)
+}
+
+// getRequestContent validates the request's content against its Content-Type,
+// so that a mislabeled or corrupt upload is rejected with every problem
+// found with it instead of failing later inside of a task. Content that
+// tasks can read as-is is validated as it is written to the blobstore,
+// so that large uploads need not be held in memory.
+func (a *Handler) getRequestContent(ctx context.Context, contentType string, r io.Reader) (store.Content, *format.Info, error) {
+	f, err := format.FromContentType(contentType)
+	if err != nil {
+		return nil, nil, pb.NewErr(err, http.StatusBadRequest)
 	}
 
-	return storage, nil
+	info, content, err := store.Ingest(ctx, a.Blobstore, f, inputPrefix, r)
+	if err != nil {
+		return nil, nil, validationErr(err)
+	}
+
+	return content, info, nil
 }
 
-func (a *Handler) getRequestVolume(contentType string, r io.Reader) (volume.Volume, error) {
-	var (
-		applicationJSON = strings.Contains(contentType, "application/json")
-		applicationZip  = strings.Contains(contentType, "application/zip")
-		inputName       = js.Ternary(applicationZip, "input.zip", "input.json")
-	)
-	switch {
-	case applicationJSON && applicationZip:
-		// both possible Content-Types are specified
-		return nil, pb.NewErr(fmt.Errorf("only one Content-Type among 'application/json' and 'application/zip' may be specified"), http.StatusBadRequest)
-	case !(applicationJSON || applicationZip):
-		// neither possible Content-Types are specified
-		return nil, pb.NewErr(fmt.Errorf("must specify one Content-Type among 'application/json' and 'application/zip'"), http.StatusBadRequest)
+// validationErr makes a *format.ValidationError a bad request,
+// since the error that ingesting content fails with may
+// instead be e.g. a problem writing it to the blobstore.
+func validationErr(err error) error {
+	if vErr := (*format.ValidationError)(nil); errors.As(err, &vErr) {
+		return pb.NewErr(vErr, http.StatusBadRequest)
 	}
 
-	return volume.New(volume.NewFile(inputName, r, 0)), nil
+	return err
 }
 
-func (a *Handler) getVolumeContent(accept string, vol volume.Volume) (io.ReadCloser, string, error) {
+// acceptedContentTypes returns the Content-Types that the task accepts,
+// including those of the formats that are normalized to GeoJSON if it
+// accepts GeoJSON.
+func acceptedContentTypes(task *pb.Task) []string {
+	contentTypes := append([]string{}, task.GetInputs()...)
+	if js.Includes(contentTypes, format.GeoJSON.ContentType) {
+		for _, f := range format.Formats {
+			if !f.Native() && !js.Includes(contentTypes, f.ContentType) {
+				contentTypes = append(contentTypes, f.ContentType)
+			}
+		}
+	}
+
+	return contentTypes
+}
+
+// writeVolumeContent writes the content of the storage's volume in the
+// format requested by the "format" query or, failing that, the Accept header,
+// converting it from GeoJSON if it was not stored in that format. If no
+// supported format was requested, a zip is preferred, as it always has been.
+func (a *Handler) writeVolumeContent(ctx *gin.Context, storage *pb.Storage, vol volume.Volume) error {
+	requested, err := requestedFormat(ctx.Query("format"), ctx.GetHeader("Accept"))
+	if err != nil {
+		return err
+	}
+
 	var (
-		applicationJSON = strings.Contains(accept, "application/json")
-		applicationZip  = strings.Contains(accept, "application/zip")
-		contentType     string
-		r               io.ReadCloser
+		files = map[string]volume.File{}
+		first volume.File
 	)
-	// if the request was for both content types,
-	// we don't know what to do
-	if applicationJSON && applicationZip {
-		return nil, "", pb.NewErr(fmt.Errorf("only one Accept among 'application/json' and 'application/zip' may be specified"), http.StatusBadRequest)
-	}
-
-	err := vol.Walk(func(_ string, f volume.File, e error) error {
-		switch {
-		// pass errors through
-		case e != nil:
-		// 1. if the request was for application/zip and we found a zip, give it
-		// 2. if the request was for application/json and we found some json, give it
-		// 3. if the request was for no specific Content-Type, give whatever we found. May be overridden later in the Walk by a zip
-		// 4. if the request was for no specific Content-Type and we found a zip, give it
-		case applicationZip && filepath.Ext(f.GetName()) == zipExt, applicationJSON && filepath.Ext(f.GetName()) == jsonExt, !applicationJSON && !applicationZip && r == nil, !applicationJSON && !applicationZip && filepath.Ext(f.GetName()) == zipExt:
-			r = f
-			contentType = js.Ternary(filepath.Ext(f.GetName()) == zipExt, "application/zip", "application/json")
+	if err = vol.Walk(func(_ string, f volume.File, e error) error {
+		if e != nil {
+			return e
 		}
-		return e
-	})
-	switch {
-	case err != nil:
-		return nil, "", err
-	case r == nil:
-		return nil, "", pb.NewErr(fmt.Errorf("could not find: '%s' content", contentType), http.StatusNotFound)
+
+		if first == nil {
+			first = f
+		}
+
+		files[strings.ToLower(filepath.Ext(f.GetName()))] = f
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if first == nil {
+		return pb.NewErr(fmt.Errorf("could not find content"), http.StatusNotFound)
+	}
+
+	filename := storage.GetName()
+	if filename == "" {
+		filename = storage.GetId()
+	} else if format.FromFilename(filename) != nil {
+		filename = strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+
+	f := requested
+	if f == nil {
+		// no preference, so give a zip if we found one or else whatever we found
+		f = format.Shapefile
+		if _, ok := files[f.Ext]; !ok {
+			if f = format.FromFilename(first.GetName()); f == nil {
+				f = format.GeoJSON
+			}
+			files[f.Ext] = first
+		}
+	}
+
+	var r io.Reader
+	switch file, ok := files[f.Ext]; {
+	case ok:
+		r = file
+		defer file.Close()
+	default:
+		// convert from GeoJSON, which every dataset of features has
+		// either as-is or normalized, or, failing that, from a zip
+		src, ok := files[format.GeoJSON.Ext]
+		from := format.GeoJSON
+		if !ok {
+			if src, ok = files[format.Shapefile.Ext]; !ok {
+				return pb.NewErr(fmt.Errorf("content cannot be converted to %s", f), http.StatusNotAcceptable)
+			}
+			from = format.Shapefile
+		}
+		defer src.Close()
+
+		b, err := io.ReadAll(src)
+		if err != nil {
+			return err
+		}
+
+		fc, err := from.Unmarshal(b)
+		if err != nil {
+			return pb.NewErr(fmt.Errorf("content cannot be converted to %s: %w", f, err), http.StatusNotAcceptable)
+		}
+
+		if b, err = f.Marshal(fc, filename); err != nil {
+			return pb.NewErr(fmt.Errorf("content cannot be converted to %s: %w", f, err), http.StatusNotAcceptable)
+		}
+
+		r = bytes.NewReader(b)
+	}
+
+	ctx.Writer.Header().Add("Content-Type", f.ContentType)
+	ctx.Writer.Header().Add("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename + f.Ext}))
+	_, _ = io.Copy(ctx.Writer, r)
+
+	return nil
+}
+
+// requestedFormat returns the format named by the "format" query or else the
+// most preferred supported one in the Accept header, or nil if neither
+// requests one. Media types that are not supported are skipped, so that
+// e.g. a browser's Accept header gets the default.
+func requestedFormat(query, accept string) (*format.Format, error) {
+	if query != "" {
+		if f := format.FromName(query); f != nil {
+			return f, nil
+		}
+
+		return nil, pb.NewErr(fmt.Errorf("unknown format '%s', expected one of '%s'", query, strings.Join(format.Names(), "', '")), http.StatusBadRequest)
 	}
 
-	return r, contentType, nil
+	type preference struct {
+		format *format.Format
+		q      float64
+	}
+
+	preferences := []preference{}
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		for _, f := range format.Formats {
+			if f.ContentType == mediaType {
+				preferences = append(preferences, preference{f, q})
+			}
+		}
+	}
+
+	if len(preferences) == 0 {
+		return nil, nil
+	}
+
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].q > preferences[j].q
+	})
+
+	return preferences[0].format, nil
 }
@@ -0,0 +1,379 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/logsquaredn/rototiller/encoding/format"
+	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb"
+)
+
+// lotA is the first feature of each of the encodings' parcels fixtures.
+var lotA = orb.MultiPolygon{
+	{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+		{{2, 2}, {2, 4}, {4, 4}, {4, 2}, {2, 2}},
+	},
+	{
+		{{20, 0}, {25, 0}, {25, 5}, {20, 5}, {20, 0}},
+	},
+}
+
+// fixture reads the parcels fixture of the encoding of the given Format.
+func fixture(t *testing.T, pkg string, f *format.Format) []byte {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("..", "encoding", pkg, "testdata", "parcels"+f.Ext))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func TestGetRequestContent(t *testing.T) {
+	tests := []struct {
+		pkg          string
+		contentType  string
+		format       *format.Format
+		featureCount int
+		crs          string
+		layers       []string
+	}{
+		{
+			pkg:          "gpkg",
+			contentType:  format.GeoPackage.ContentType,
+			format:       format.GeoPackage,
+			featureCount: 5,
+			crs:          format.WGS84,
+			layers:       []string{"parcels", "roads"},
+		},
+		{
+			pkg:          "kml",
+			contentType:  format.KML.ContentType,
+			format:       format.KML,
+			featureCount: 4,
+			crs:          format.WGS84,
+		},
+		{
+			pkg:          "csv",
+			contentType:  format.CSV.ContentType + "; charset=utf-8",
+			format:       format.CSV,
+			featureCount: 3,
+		},
+		{
+			pkg:          "flatgeobuf",
+			contentType:  format.FlatGeobuf.ContentType,
+			format:       format.FlatGeobuf,
+			featureCount: 3,
+			crs:          format.WGS84,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format.Name, func(t *testing.T) {
+			// content that is normalized to GeoJSON is held in
+			// memory, so the blobstore is never written to
+			_, info, err := (&Handler{}).getRequestContent(context.Background(), tt.contentType, bytes.NewReader(fixture(t, tt.pkg, tt.format)))
+			if err != nil {
+				t.Fatalf("getRequestContent() = %v", err)
+			}
+
+			if info.Format != tt.format || info.FeatureCount != tt.featureCount || info.CRS != tt.crs || !reflect.DeepEqual(info.Layers, tt.layers) {
+				t.Errorf("getRequestContent() = %s with %d features in %q, layers %v, want %s with %d features in %q, layers %v", info.Format, info.FeatureCount, info.CRS, info.Layers, tt.format, tt.featureCount, tt.crs, tt.layers)
+			}
+
+			// the features that tasks run over
+			if fc := info.FeatureCollection; len(fc.Features) == 0 || !orb.Equal(fc.Features[0].Geometry, lotA) {
+				t.Errorf("getRequestContent() normalized to %v, want lot a first", fc.Features)
+			}
+		})
+	}
+}
+
+func TestGetRequestContentRejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		content     []byte
+	}{
+		{name: "no Content-Type", content: fixture(t, "kml", format.KML)},
+		{name: "mislabeled", contentType: format.CSV.ContentType, content: fixture(t, "kml", format.KML)},
+		{name: "mislabeled binary", contentType: format.FlatGeobuf.ContentType, content: fixture(t, "gpkg", format.GeoPackage)},
+		{name: "corrupt", contentType: format.FlatGeobuf.ContentType, content: fixture(t, "flatgeobuf", format.FlatGeobuf)[:64]},
+		{name: "empty", contentType: format.KML.ContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := (&Handler{}).getRequestContent(context.Background(), tt.contentType, bytes.NewReader(tt.content))
+
+			var pbErr *pb.Error
+			if !errors.As(err, &pbErr) || pbErr.HTTPStatusCode != http.StatusBadRequest {
+				t.Errorf("getRequestContent() = %v, want HTTP %d", err, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestAcceptedContentTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []string
+		want   []string
+	}{
+		{
+			name:   "GeoJSON",
+			inputs: []string{format.GeoJSON.ContentType},
+			want: []string{
+				format.GeoJSON.ContentType,
+				format.GeoPackage.ContentType,
+				format.KML.ContentType,
+				format.CSV.ContentType,
+				format.FlatGeobuf.ContentType,
+				format.GeoJSONSeq.ContentType,
+			},
+		},
+		{
+			// only tasks that read GeoJSON can read what is normalized to it
+			name:   "only shapefiles",
+			inputs: []string{format.Shapefile.ContentType},
+			want:   []string{format.Shapefile.ContentType},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := acceptedContentTypes(&pb.Task{Inputs: tt.inputs}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("acceptedContentTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestedFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		accept  string
+		want    *format.Format
+		wantErr bool
+	}{
+		{name: "nothing"},
+		{name: "query", query: "kml", want: format.KML},
+		{name: "query by extension", query: "gpkg", want: format.GeoPackage},
+		{name: "query over Accept", query: "csv", accept: format.KML.ContentType, want: format.CSV},
+		{name: "unknown query", query: "gpx", wantErr: true},
+		{name: "Accept", accept: format.FlatGeobuf.ContentType, want: format.FlatGeobuf},
+		{name: "Accept by quality", accept: "text/csv;q=0.5, application/vnd.google-earth.kml+xml", want: format.KML},
+		{name: "Accept with q=0", accept: "application/geopackage+sqlite3;q=0, text/csv;q=0.1", want: format.CSV},
+		{name: "Accept of a browser", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := requestedFormat(tt.query, tt.accept)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("requestedFormat() = %v, %v, want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// uploaded returns a volume of the GeoPackage fixture as it is stored once
+// uploaded: as-is alongside its normalization to GeoJSON.
+func uploaded(t *testing.T) (volume.Volume, []byte) {
+	t.Helper()
+
+	b := fixture(t, "gpkg", format.GeoPackage)
+	info, err := format.Validate(format.GeoPackage, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vol, err := info.Volume("input", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := volume.Directory(t.TempDir())
+	if err = vol.Download(string(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, b
+}
+
+// download writes the content of vol with the given
+// format query and Accept header, if any.
+func download(t *testing.T, vol volume.Volume, query, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var (
+		w      = httptest.NewRecorder()
+		ctx, _ = gin.CreateTestContext(w)
+	)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?format="+query, nil)
+	if accept != "" {
+		ctx.Request.Header.Set("Accept", accept)
+	}
+
+	if err := (&Handler{}).writeVolumeContent(ctx, &pb.Storage{Id: "id", Name: "parcels.gpkg"}, vol); err != nil {
+		t.Fatalf("writeVolumeContent() = %v", err)
+	}
+
+	return w
+}
+
+func TestWriteVolumeContent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, f := range format.Formats {
+		t.Run(f.Name, func(t *testing.T) {
+			vol, gpkg := uploaded(t)
+			w := download(t, vol, f.Name, "")
+
+			if got := w.Header().Get("Content-Type"); got != f.ContentType {
+				t.Errorf("got Content-Type %s, want %s", got, f.ContentType)
+			}
+
+			// named after the storage, without its extension
+			if _, params, _ := mime.ParseMediaType(w.Header().Get("Content-Disposition")); params["filename"] != "parcels"+f.Ext {
+				t.Errorf("got filename %s, want %s", params["filename"], "parcels"+f.Ext)
+			}
+
+			if f == format.GeoPackage && !bytes.Equal(w.Body.Bytes(), gpkg) {
+				t.Error("got a GeoPackage other than the one that was uploaded")
+			}
+
+			fc, err := f.Unmarshal(w.Body.Bytes())
+			if err != nil {
+				t.Fatalf("Unmarshal() = %v", err)
+			}
+
+			if len(fc.Features) == 0 || !orb.Equal(fc.Features[0].Geometry, lotA) {
+				t.Fatalf("got %v, want lot a first", fc.Features)
+			}
+
+			if name := fc.Features[0].Properties["NAME"]; name != "lot a" {
+				t.Errorf("got NAME %v, want lot a", name)
+			}
+		})
+	}
+}
+
+func TestWriteVolumeContentNegotiation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		accept string
+		want   *format.Format
+	}{
+		// what was uploaded, since there is no zip
+		{name: "no preference", want: format.GeoPackage},
+		{name: "Accept", accept: "text/csv", want: format.CSV},
+		{name: "Accept of a browser", accept: "text/html,*/*;q=0.8", want: format.GeoPackage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vol, _ := uploaded(t)
+			if got := download(t, vol, "", tt.accept).Header().Get("Content-Type"); got != tt.want.ContentType {
+				t.Errorf("got Content-Type %s, want %s", got, tt.want.ContentType)
+			}
+		})
+	}
+}
+
+func TestWriteVolumeContentFromShapefile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fc, err := format.GeoPackage.Unmarshal(fixture(t, "gpkg", format.GeoPackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zip, err := format.Shapefile.Marshal(fc, "parcels")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// e.g. the output of a job whose task outputs
+	// shapefiles, so there is no GeoJSON to convert
+	dir := t.TempDir()
+	if err = os.WriteFile(filepath.Join(dir, "output.zip"), zip, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := download(t, volume.Directory(dir), format.KML.Name, "")
+	got, err := format.KML.Unmarshal(w.Body.Bytes())
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	if len(got.Features) == 0 || !orb.Equal(got.Features[0].Geometry, lotA) {
+		t.Errorf("got %v, want lot a first", got.Features)
+	}
+}
+
+func TestWriteVolumeContentErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name  string
+		vol   func(*testing.T) volume.Volume
+		query string
+		want  int
+	}{
+		{
+			name:  "unknown format",
+			vol:   func(t *testing.T) volume.Volume { vol, _ := uploaded(t); return vol },
+			query: "gpx",
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "no content",
+			vol:  func(t *testing.T) volume.Volume { return volume.Directory(t.TempDir()) },
+			want: http.StatusNotFound,
+		},
+		{
+			name: "nothing to convert from",
+			vol: func(t *testing.T) volume.Volume {
+				dir := t.TempDir()
+				if err := os.WriteFile(filepath.Join(dir, "input.gpkg"), fixture(t, "gpkg", format.GeoPackage), 0o644); err != nil {
+					t.Fatal(err)
+				}
+
+				return volume.Directory(dir)
+			},
+			query: format.CSV.Name,
+			want:  http.StatusNotAcceptable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/?format="+tt.query, nil)
+
+			err := (&Handler{}).writeVolumeContent(ctx, &pb.Storage{Id: "id"}, tt.vol(t))
+
+			var pbErr *pb.Error
+			if !errors.As(err, &pbErr) || pbErr.HTTPStatusCode != tt.want {
+				t.Errorf("writeVolumeContent() = %v, want HTTP %d", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package client
+
+import "github.com/logsquaredn/rototiller/pb"
+
+// GetWorkers gets the registered workers. Only admins may.
+func (c *Client) GetWorkers() ([]*pb.Worker, error) {
+	workers := []*pb.Worker{}
+	return workers, c.get(c.endpoint(pb.EndpointAdminWorkers), &workers)
+}
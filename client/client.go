@@ -35,6 +35,7 @@ func New(addr, apiKey string, opts ...ClientOpt) (*Client, error) {
 		httpClient:   http.DefaultClient,
 		pollInterval: time.Second / 2,
 		bufferSize:   8 * 1024,
+		partSize:     16 * 1024 * 1024,
 	}
 	c.httpClient.Transport = http.DefaultTransport
 	for _, opt := range opts {
@@ -76,6 +77,41 @@ func (c *Client) post(url *url.URL, r io.Reader, contentType string, i interface
 	return json.NewDecoder(res.Body).Decode(i)
 }
 
+func (c *Client) put(url *url.URL, r io.Reader, contentType string, i interface{}) error {
+	req, err := http.NewRequest(http.MethodPut, url.String(), r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if err = c.err(res); err != nil {
+		return err
+	}
+
+	return json.NewDecoder(res.Body).Decode(i)
+}
+
+func (c *Client) delete(url *url.URL) error {
+	req, err := http.NewRequest(http.MethodDelete, url.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return c.err(res)
+}
+
 func (c *Client) err(res *http.Response) error {
 	if res.StatusCode < 299 && res.StatusCode >= 200 {
 		return nil
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
 	"time"
@@ -8,6 +9,7 @@ import (
 
 type Client struct {
 	bufferSize   int
+	partSize     int64
 	url          *url.URL
 	httpClient   *http.Client
 	pollInterval time.Duration
@@ -35,3 +37,16 @@ func WithBufferSize(bufferSize int) ClientOpt {
 		return nil
 	}
 }
+
+// WithPartSize sets the size of the parts that datasets are
+// uploaded in. Datasets no larger than it are uploaded whole.
+func WithPartSize(partSize int64) ClientOpt {
+	return func(c *Client) error {
+		if partSize <= 0 {
+			return fmt.Errorf("part size must be positive")
+		}
+
+		c.partSize = partSize
+		return nil
+	}
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/logsquaredn/rototiller/pb"
+)
+
+func (c *Client) GetImport(id string) (*pb.Import, error) {
+	imp := &pb.Import{}
+	return imp, c.get(c.endpoint(pb.EndpointImports, id), imp)
+}
+
+// ImportStorage starts storing the dataset at the URL, fetched with the given
+// headers. Poll the returned import with GetImport until it is complete.
+func (c *Client) ImportStorage(rawURL, name string, headers map[string]string) (*pb.Import, error) {
+	b, err := json.Marshal(map[string]any{
+		"url":     rawURL,
+		"name":    name,
+		"headers": headers,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &pb.Import{}
+	return imp, c.post(c.endpoint(pb.EndpointStoragesImport), bytes.NewReader(b), "application/json", imp)
+}
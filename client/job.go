@@ -2,6 +2,7 @@ package client
 
 import (
 	"path"
+	"strings"
 	"time"
 
 	"github.com/logsquaredn/rototiller/pb"
@@ -30,16 +31,26 @@ func (c *Client) GetJob(id string) (*pb.Job, error) {
 }
 
 func (c *Client) CreateJob(rawTaskType string, r Request) (*pb.Job, error) {
+	if c.shouldUpload(r) {
+		rs, _, _ := seekable(r)
+		storage, err := c.UploadStorage(NewStorageWithName(rs, r.ContentType(), r.Query()["name"]))
+		if err != nil {
+			return nil, err
+		}
+
+		query := map[string]string{}
+		for k, v := range r.Query() {
+			query[k] = v
+		}
+		r = NewJobWithInput(storage.GetId(), query)
+	}
+
 	var (
-		url           = c.url
-		job           = &pb.Job{}
-		taskType, err = pb.ParseTaskType(rawTaskType)
+		url = c.url
+		job = &pb.Job{}
 	)
-	if err != nil {
-		return nil, err
-	}
 
-	url.Path = path.Join(pb.EndpointJobs, taskType.String())
+	url.Path = path.Join(pb.EndpointJobs, strings.ToLower(rawTaskType))
 	values := url.Query()
 	for k, v := range r.Query() {
 		if k != "" && v != "" {
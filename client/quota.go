@@ -0,0 +1,9 @@
+package client
+
+import "github.com/logsquaredn/rototiller/pb"
+
+// GetUsage gets how much the namespace has used of its quota.
+func (c *Client) GetUsage() (*pb.Usage, error) {
+	usage := &pb.Usage{}
+	return usage, c.get(c.endpoint(pb.EndpointUsage), usage)
+}
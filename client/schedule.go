@@ -0,0 +1,57 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/logsquaredn/rototiller/pb"
+)
+
+// CreateSchedule creates a job on the cron schedule with the given task type,
+// args and input, which is a storage ID optionally pinned as <id>@<revision>.
+func (c *Client) CreateSchedule(name, cron, rawTaskType string, args map[string]string, input string, missedRunPolicy pb.MissedRunPolicy) (*pb.Schedule, error) {
+	b, err := json.Marshal(map[string]any{
+		"name":              name,
+		"cron":              cron,
+		"task_type":         rawTaskType,
+		"args":              args,
+		"input":             input,
+		"missed_run_policy": missedRunPolicy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &pb.Schedule{}
+	return schedule, c.post(c.endpoint(pb.EndpointSchedules), bytes.NewReader(b), "application/json", schedule)
+}
+
+func (c *Client) GetSchedules() ([]*pb.Schedule, error) {
+	schedules := []*pb.Schedule{}
+	return schedules, c.get(c.endpoint(pb.EndpointSchedules), &schedules)
+}
+
+func (c *Client) GetSchedule(id string) (*pb.Schedule, error) {
+	schedule := &pb.Schedule{}
+	return schedule, c.get(c.endpoint(pb.EndpointSchedules, id), schedule)
+}
+
+func (c *Client) DeleteSchedule(id string) error {
+	return c.delete(c.endpoint(pb.EndpointSchedules, id))
+}
+
+func (c *Client) PauseSchedule(id string) (*pb.Schedule, error) {
+	schedule := &pb.Schedule{}
+	return schedule, c.post(c.endpoint(pb.EndpointSchedules, id, "pause"), nil, "", schedule)
+}
+
+func (c *Client) ResumeSchedule(id string) (*pb.Schedule, error) {
+	schedule := &pb.Schedule{}
+	return schedule, c.post(c.endpoint(pb.EndpointSchedules, id, "resume"), nil, "", schedule)
+}
+
+// GetScheduleRuns gets the schedule's runs, latest first.
+func (c *Client) GetScheduleRuns(id string) ([]*pb.ScheduleRun, error) {
+	runs := []*pb.ScheduleRun{}
+	return runs, c.get(c.endpoint(pb.EndpointSchedules, id, "runs"), &runs)
+}
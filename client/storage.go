@@ -52,6 +52,10 @@ func (c *Client) GetJobOutput(id string) (*pb.Storage, error) {
 }
 
 func (c *Client) CreateStorage(ctx context.Context, r Request) (*pb.Storage, error) {
+	if c.shouldUpload(r) {
+		return c.UploadStorage(r)
+	}
+
 	var (
 		url     = c.url
 		storage = &pb.Storage{}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"path"
+	"strings"
 
 	"github.com/logsquaredn/rototiller/pb"
 )
@@ -19,15 +20,11 @@ func (c *Client) GetTasks() ([]*pb.Task, error) {
 
 func (c *Client) GetTask(rawTaskType string) (*pb.Task, error) {
 	var (
-		url           = c.url
-		task          = &pb.Task{}
-		taskType, err = pb.ParseTaskType(rawTaskType)
+		url  = c.url
+		task = &pb.Task{}
 	)
-	if err != nil {
-		return nil, err
-	}
 
-	url.Path = path.Join(pb.EndpointTasks, taskType.String())
+	url.Path = path.Join(pb.EndpointTasks, strings.ToLower(rawTaskType))
 
 	return task, c.get(url, task)
 }
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/logsquaredn/rototiller/pb"
+)
+
+const (
+	// maxParts is the most parts that an upload may have.
+	maxParts = 10000
+	// partAttempts is how many times a part is
+	// attempted to be uploaded before giving up.
+	partAttempts = 3
+)
+
+// seekable returns the content of the request and its size if it can be
+// uploaded in parts, which requires that it can be read more than once.
+func seekable(r Request) (io.ReadSeeker, int64, bool) {
+	req, ok := r.(*request)
+	if !ok {
+		return nil, 0, false
+	}
+
+	rs, ok := req.Reader.(io.ReadSeeker)
+	if !ok {
+		return nil, 0, false
+	}
+
+	cur, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	end, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	if _, err = rs.Seek(cur, io.SeekStart); err != nil {
+		return nil, 0, false
+	}
+
+	return rs, end - cur, true
+}
+
+// shouldUpload reports whether the request's content is large
+// enough that it should be uploaded in parts, and can be.
+func (c *Client) shouldUpload(r Request) bool {
+	_, size, ok := seekable(r)
+	return ok && size > c.partSize
+}
+
+func (c *Client) endpoint(elem ...string) *url.URL {
+	u := *c.url
+	u.Path = path.Join(elem...)
+	u.RawQuery = ""
+	return &u
+}
+
+func (c *Client) GetUpload(id string) (*pb.Upload, error) {
+	upload := &pb.Upload{}
+	return upload, c.get(c.endpoint(pb.EndpointUploads, id), upload)
+}
+
+func (c *Client) DeleteUpload(id string) error {
+	return c.delete(c.endpoint(pb.EndpointUploads, id))
+}
+
+// UploadStorage stores the request's content as a dataset by uploading it in
+// parts, each of which is retried on failure. It is used by CreateStorage and
+// CreateJob for large datasets, but can also be called directly.
+func (c *Client) UploadStorage(r Request) (*pb.Storage, error) {
+	rs, size, ok := seekable(r)
+	if !ok {
+		return nil, fmt.Errorf("content must be seekable to be uploaded in parts")
+	}
+
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, rs); err != nil {
+		return nil, err
+	}
+
+	if _, err = rs.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	u := c.endpoint(pb.EndpointUploads)
+	values := u.Query()
+	for k, v := range r.Query() {
+		if k != "" && v != "" {
+			values.Add(k, v)
+		}
+	}
+	values.Set("size", strconv.FormatInt(size, 10))
+	values.Set("sha256", hex.EncodeToString(hash.Sum(nil)))
+	u.RawQuery = values.Encode()
+
+	upload := &pb.Upload{}
+	if err = c.post(u, nil, r.ContentType(), upload); err != nil {
+		return nil, err
+	}
+
+	return c.ResumeUpload(upload.GetId(), r)
+}
+
+// ResumeUpload uploads whichever parts of the request's content the given
+// upload does not already have, then completes it. The content must be
+// the same as that which the upload was created for.
+func (c *Client) ResumeUpload(id string, r Request) (*pb.Storage, error) {
+	rs, size, ok := seekable(r)
+	if !ok {
+		return nil, fmt.Errorf("content must be seekable to be uploaded in parts")
+	}
+
+	upload, err := c.GetUpload(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.GetStatus() == pb.UploadStatusPending.String() {
+		start, err := rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+
+		uploaded := map[int64]int64{}
+		for _, part := range upload.GetParts() {
+			uploaded[int64(part.GetNumber())] = part.GetSize()
+		}
+
+		partSize := c.partSize
+		if size > partSize*maxParts {
+			partSize = (size + maxParts - 1) / maxParts
+		}
+
+		for number, offset := int64(1), int64(0); offset < size || number == 1; number, offset = number+1, offset+partSize {
+			n := size - offset
+			if n > partSize {
+				n = partSize
+			}
+
+			if s, ok := uploaded[number]; ok && s == n {
+				continue
+			}
+
+			if err = c.putPart(id, number, rs, start+offset, n); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	storage := &pb.Storage{}
+	return storage, c.post(c.endpoint(pb.EndpointUploads, id, "complete"), nil, "", storage)
+}
+
+func (c *Client) putPart(id string, number int64, rs io.ReadSeeker, offset, n int64) (err error) {
+	u := c.endpoint(pb.EndpointUploads, id, "parts", strconv.FormatInt(number, 10))
+	for attempt := 0; attempt < partAttempts; attempt++ {
+		if _, err = rs.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		if err = c.put(u, io.LimitReader(rs, n), "application/octet-stream", &pb.Part{}); err == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("failed to upload part %d after %d attempts: %w", number, partAttempts, err)
+}
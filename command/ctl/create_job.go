@@ -6,9 +6,9 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"path/filepath"
 
 	"github.com/logsquaredn/rototiller/client"
+	"github.com/logsquaredn/rototiller/encoding/format"
 	"github.com/spf13/cobra"
 )
 
@@ -37,11 +37,9 @@ func NewCreateJob() *cobra.Command {
 					switch file {
 					case "", stdin:
 					default:
-						ext := filepath.Ext(file)
-						if ext == "geojson" {
-							ext = "json"
+						if f := format.FromFilename(file); f != nil {
+							contentType = f.ContentType
 						}
-						contentType = "application/" + ext
 					}
 				}
 
@@ -97,7 +95,7 @@ func NewCreateJob() *cobra.Command {
 	cmd.Flags().StringVar(&addr, "addr", "", "rototiller address")
 	cmd.Flags().StringVar(&apiKey, "api-key", "", "rototiller API key")
 	cmd.Flags().StringVarP(&file, "file", "f", "", "path to input file")
-	_ = cmd.MarkFlagFilename("file", "json", "yaml", "yml", "zip")
+	_ = cmd.MarkFlagFilename("file", "json", "geojson", "zip", "gpkg", "kml", "csv", "fgb")
 	cmd.Flags().StringVar(&input, "input", "", "storage ID to use")
 	cmd.Flags().StringVar(&inputOf, "input-of", "", "job ID to use the input of")
 	cmd.Flags().StringVar(&outputOf, "output-of", "", "job ID to use the output of")
@@ -6,9 +6,9 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"path/filepath"
 
 	"github.com/logsquaredn/rototiller/client"
+	"github.com/logsquaredn/rototiller/encoding/format"
 	"github.com/spf13/cobra"
 )
 
@@ -36,11 +36,9 @@ func NewRunJob() *cobra.Command {
 					switch file {
 					case "", stdin:
 					default:
-						ext := filepath.Ext(file)
-						if ext == "geojson" {
-							ext = "json"
+						if f := format.FromFilename(file); f != nil {
+							contentType = f.ContentType
 						}
-						contentType = "application/" + ext
 					}
 				}
 
@@ -76,10 +74,8 @@ func NewRunJob() *cobra.Command {
 						r = bytes.NewReader(b)
 					}
 
-					switch contentType {
-					case "application/json", "application/zip":
-					default:
-						contentType = "application/json"
+					if _, err := format.FromContentType(contentType); err != nil {
+						contentType = format.GeoJSON.ContentType
 					}
 
 					req = client.NewJobFromInput(r, contentType, query)
@@ -101,7 +97,7 @@ func NewRunJob() *cobra.Command {
 	cmd.Flags().StringVar(&addr, "addr", "", "rototiller address")
 	cmd.Flags().StringVar(&apiKey, "api-key", "", "rototiller API key")
 	cmd.Flags().StringVarP(&file, "file", "f", "", "path to input file")
-	_ = cmd.MarkFlagFilename("file", "json", "yaml", "yml", "zip")
+	_ = cmd.MarkFlagFilename("file", "json", "geojson", "zip", "gpkg", "kml", "csv", "fgb")
 	cmd.Flags().StringVar(&input, "input", "", "storage ID to use")
 	cmd.Flags().StringVar(&inputOf, "input-of", "", "job ID to use the input of")
 	cmd.Flags().StringVar(&outputOf, "output-of", "", "job ID to use the output of")
@@ -1,15 +1,19 @@
 package command
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/logsquaredn/rototiller"
 	"github.com/logsquaredn/rototiller/api"
 	"github.com/logsquaredn/rototiller/store/blob/bucket"
 	"github.com/logsquaredn/rototiller/store/data/postgres"
 	"github.com/logsquaredn/rototiller/stream/event/amqp"
+	"github.com/logsquaredn/rototiller/task"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
@@ -17,9 +21,11 @@ import (
 
 func NewAPI() *cobra.Command {
 	var (
-		port                               int64
-		postgresAddr, bucketAddr, amqpAddr string
-		cmd                                = &cobra.Command{
+		port                                        int64
+		adminNamespaces                             []string
+		workerDeadAfter, scheduleInterval           time.Duration
+		postgresAddr, bucketAddr, amqpAddr, taskDir string
+		cmd                                         = &cobra.Command{
 			Use:     "api",
 			Aliases: []string{"a"},
 			RunE: func(cmd *cobra.Command, args []string) error {
@@ -42,6 +48,10 @@ func NewAPI() *cobra.Command {
 					return err
 				}
 
+				if err = syncTasks(ctx, datastore, taskDir); err != nil {
+					return err
+				}
+
 				eventStream, err := amqp.New(ctx, amqpAddr)
 				if err != nil {
 					return err
@@ -57,11 +67,23 @@ func NewAPI() *cobra.Command {
 					return err
 				}
 
-				srv, err := api.NewHandler(ctx, datastore, eventStreamProducer, blobstore)
+				srv, err := api.NewHandler(
+					ctx, datastore, eventStreamProducer, blobstore,
+					api.WithAdminNamespaces(adminNamespaces...),
+					api.WithWorkerDeadAfter(workerDeadAfter),
+				)
 				if err != nil {
 					return err
 				}
 
+				if scheduleInterval > 0 {
+					go func() {
+						if err := srv.RunSchedules(ctx, scheduleInterval); err != nil && !errors.Is(err, context.Canceled) {
+							logr.Error(err, "schedules stopped running")
+						}
+					}()
+				}
+
 				addr := fmt.Sprintf(":%d", port)
 				l, err := net.Listen("tcp", addr)
 				if err != nil {
@@ -77,7 +99,11 @@ func NewAPI() *cobra.Command {
 	cmd.Flags().StringVar(&amqpAddr, "amqp-addr", "", "AMQP address")
 	cmd.Flags().StringVar(&bucketAddr, "bucket-addr", "", "bucket address")
 	cmd.Flags().StringVar(&postgresAddr, "postgres-addr", "", "Postgres address")
+	cmd.Flags().StringVar(&taskDir, "task-dir", task.DefaultDir, "directory of task manifests")
 	cmd.Flags().Int64VarP(&port, "port", "p", 8080, "listen port")
+	cmd.Flags().StringSliceVar(&adminNamespaces, "admin-namespaces", nil, "namespaces that may use the admin endpoints")
+	cmd.Flags().DurationVar(&scheduleInterval, "schedule-interval", api.DefaultScheduleInterval, "how often to create the jobs of schedules that are due, or 0 to leave it to other instances")
+	cmd.Flags().DurationVar(&workerDeadAfter, "worker-dead-after", api.DefaultWorkerDeadAfter, "how long after its last heartbeat that a worker is considered dead")
 
 	return cmd
 }
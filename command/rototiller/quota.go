@@ -0,0 +1,113 @@
+package command
+
+import (
+	"github.com/logsquaredn/rototiller"
+	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/store/data/postgres"
+	"github.com/spf13/cobra"
+)
+
+// quotaFlags binds a flag to each of the quota's limits,
+// leaving those whose flags are not set unset.
+func quotaFlags(cmd *cobra.Command, quota *pb.Quota) {
+	var (
+		storageBytes, uploadBytes int64
+		concurrentJobs, dailyJobs int32
+		maxPriority               int32
+		flags                     = cmd.Flags()
+	)
+
+	flags.Int64Var(&storageBytes, "storage-bytes", 0, "most bytes that a namespace may store, or 0 for no limit")
+	flags.Int64Var(&uploadBytes, "upload-bytes", 0, "most bytes that a namespace may upload at once, or 0 for no limit")
+	flags.Int32Var(&concurrentJobs, "concurrent-jobs", 0, "most jobs that a namespace may have yet to finish, or 0 for no limit")
+	flags.Int32Var(&dailyJobs, "daily-jobs", 0, "most jobs that a namespace may create in a day, or 0 for no limit")
+	flags.Int32Var(&maxPriority, "max-priority", 0, "highest priority that a namespace may give its jobs")
+
+	preRunE := cmd.PreRunE
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if flags.Changed("storage-bytes") {
+			quota.StorageBytes = &storageBytes
+		}
+
+		if flags.Changed("upload-bytes") {
+			quota.UploadBytes = &uploadBytes
+		}
+
+		if flags.Changed("concurrent-jobs") {
+			quota.ConcurrentJobs = &concurrentJobs
+		}
+
+		if flags.Changed("daily-jobs") {
+			quota.DailyJobs = &dailyJobs
+		}
+
+		if flags.Changed("max-priority") {
+			quota.MaxPriority = &maxPriority
+		}
+
+		if preRunE != nil {
+			return preRunE(cmd, args)
+		}
+
+		return nil
+	}
+}
+
+func NewQuota() *cobra.Command {
+	var (
+		postgresAddr string
+		reset        bool
+		asDefault    bool
+		quota        = &pb.Quota{}
+		cmd          = &cobra.Command{
+			Use:     "quota {NAMESPACE|--default}",
+			Aliases: []string{"q"},
+			Args: func(cmd *cobra.Command, args []string) error {
+				if asDefault {
+					return cobra.NoArgs(cmd, args)
+				}
+
+				return cobra.ExactArgs(1)(cmd, args)
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				var (
+					ctx = cmd.Context()
+					_   = rototiller.LoggerFrom(ctx)
+				)
+
+				datastore, err := postgres.New(ctx, postgresAddr)
+				if err != nil {
+					return err
+				}
+
+				if asDefault {
+					if reset {
+						if err = datastore.DeleteDefaultQuota(); err != nil {
+							return err
+						}
+					}
+
+					_, err = datastore.UpsertDefaultQuota(quota)
+					return err
+				}
+
+				if reset {
+					if err = datastore.DeleteQuota(args[0]); err != nil {
+						return err
+					}
+				}
+
+				quota.Namespace = args[0]
+				_, err = datastore.UpsertQuota(quota)
+				return err
+			},
+		}
+	)
+
+	cmd.Flags().StringVar(&postgresAddr, "postgres-addr", "", "Postgres address")
+	cmd.Flags().BoolVar(&reset, "reset", false, "unset each limit that is not given, so that it falls back to the default")
+	cmd.Flags().BoolVar(&asDefault, "default", false, "set the default quota, whose limits apply to each namespace that does not set its own, rather than a namespace's")
+	quotaFlags(cmd, quota)
+
+	return cmd
+}
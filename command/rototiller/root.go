@@ -21,7 +21,7 @@ func New() *cobra.Command {
 
 	cmd.PersistentFlags().CountVarP(&verbosity, "verbose", "V", "verbose")
 	cmd.SetVersionTemplate("{{ .Name }}{{ .Version }} " + runtime.Version() + "\n")
-	cmd.AddCommand(NewAPI(), NewWorker(), NewMigrate(), NewSecretary())
+	cmd.AddCommand(NewAPI(), NewWorker(), NewMigrate(), NewSecretary(), NewSandbox(), NewRunTask(), NewQuota())
 
 	return cmd
 }
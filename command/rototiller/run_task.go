@@ -0,0 +1,65 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/logsquaredn/rototiller/worker"
+	"github.com/spf13/cobra"
+	"mellium.im/sysexit"
+)
+
+// NewRunTask returns the hidden command that the worker re-executes
+// itself as inside of a sandbox to run a Go task there.
+func NewRunTask() *cobra.Command {
+	var (
+		input, output       string
+		rawArgs, rawVolumes []string
+		cmd                 = &cobra.Command{
+			Use:    worker.RunTaskCommand,
+			Hidden: true,
+			Args:   cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				t, ok := task.Lookup(args[0])
+				if !ok {
+					cmd.PrintErrln(fmt.Sprintf("task '%s' does not run in-process", args[0]))
+					os.Exit(int(sysexit.ErrSoftware))
+				}
+
+				var names, values []string
+				for _, rawArg := range rawArgs {
+					name, value, _ := strings.Cut(rawArg, "=")
+					names = append(names, name)
+					values = append(values, value)
+				}
+
+				taskArgs := task.NewArgs(names, values)
+				for _, rawVolume := range rawVolumes {
+					name, dir, ok := strings.Cut(rawVolume, "=")
+					if !ok {
+						cmd.PrintErrln(fmt.Sprintf("invalid volume '%s'", rawVolume))
+						os.Exit(int(sysexit.ErrSoftware))
+					}
+
+					taskArgs = taskArgs.WithVolume(name, volume.Directory(dir))
+				}
+
+				// exits the way that an executable task would, so
+				// that the worker maps its exit code to a status alike
+				os.Exit(worker.RunTask(cmd.Context(), t, volume.Directory(input), volume.Directory(output), taskArgs, cmd.ErrOrStderr()))
+
+				return nil
+			},
+		}
+	)
+
+	cmd.Flags().StringVar(&input, "input", "", "directory of the task's input")
+	cmd.Flags().StringVar(&output, "output", "", "directory to write the task's output to")
+	cmd.Flags().StringArrayVar(&rawArgs, "arg", nil, "name=value of a param of the task")
+	cmd.Flags().StringArrayVar(&rawVolumes, "volume", nil, "name=directory of the content of the storage that a param refers to")
+
+	return cmd
+}
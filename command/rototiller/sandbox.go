@@ -0,0 +1,52 @@
+package command
+
+import (
+	"os"
+
+	"github.com/logsquaredn/rototiller/sandbox"
+	"github.com/spf13/cobra"
+	"mellium.im/sysexit"
+)
+
+// NewSandbox returns the hidden command that the worker
+// re-executes itself as to set up a task's sandbox from
+// the inside before exec'ing the task.
+func NewSandbox() *cobra.Command {
+	var (
+		root      string
+		rawMounts []string
+		uid, gid  int
+		cmd       = &cobra.Command{
+			Use:    sandbox.InitCommand,
+			Hidden: true,
+			Args:   cobra.MinimumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				mounts := make([]*sandbox.Mount, len(rawMounts))
+				for i, rawMount := range rawMounts {
+					mount, err := sandbox.ParseMount(rawMount)
+					if err != nil {
+						return err
+					}
+
+					mounts[i] = mount
+				}
+
+				// on success, Init never returns because the
+				// current process is replaced by the task
+				if err := sandbox.Init(root, mounts, uid, gid, args[0], args[1:]...); err != nil {
+					cmd.PrintErrln("sandbox:", err)
+					os.Exit(int(sysexit.ErrOS))
+				}
+
+				return nil
+			},
+		}
+	)
+
+	cmd.Flags().StringVar(&root, "root", "", "empty directory to build the sandbox's root filesystem on")
+	cmd.Flags().StringArrayVar(&rawMounts, "mount", nil, "source:target[:ro] to mount into the sandbox")
+	cmd.Flags().IntVar(&uid, "uid", -1, "uid to run as inside of the sandbox")
+	cmd.Flags().IntVar(&gid, "gid", -1, "gid to run as inside of the sandbox")
+
+	return cmd
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/logsquaredn/rototiller"
+	"github.com/logsquaredn/rototiller/store"
 	"github.com/logsquaredn/rototiller/store/blob/bucket"
 	"github.com/logsquaredn/rototiller/store/data/postgres"
 	"github.com/logsquaredn/rototiller/volume"
@@ -20,6 +21,8 @@ func NewSecretary() *cobra.Command {
 	var (
 		defaultDuration                             = time.Hour * 24
 		workJobsBefore, workStorageBefore           time.Duration
+		workUploadsBefore, workImportsBefore        time.Duration
+		workWorkersBefore                           time.Duration
 		postgresAddr, bucketAddr, archiveBucketAddr string
 		cmd                                         = &cobra.Command{
 			Use:     "secretary",
@@ -66,15 +69,18 @@ func NewSecretary() *cobra.Command {
 					chargeRate, err := strconv.ParseInt(c.Metadata["charge_rate"], 10, 64)
 					if err != nil {
 						logr.Error(err, "parsing customer charge rate", "id", j.GetNamespace())
-						return err
+						continue
 					}
-					c.Balance += chargeRate
-					customers[j.Namespace] = c
 
+					// one job that cannot be worked must not stop the rest, nor
+					// the storages, uploads and so on after them, from being worked.
+					// It is left to be worked again, and only charged for once it is
 					if err = datastore.DeleteJob(j.GetId()); err != nil {
 						logr.Error(err, "deleting data for customer", "id", j.GetNamespace())
-						return err
+						continue
 					}
+					c.Balance += chargeRate
+					customers[j.Namespace] = c
 
 					// TODO write all steps, not just first one
 					archive.WriteString(strings.Join([]string{j.GetId(), j.GetInputId(), j.GetOutputId(), j.Steps[0].TaskType, j.GetStatus(), j.GetError(), j.GetStartTime().String(), j.GetEndTime().String(), strings.Join(j.Steps[0].GetArgs(), "|"), j.GetNamespace(), c.Name}, ",") + "\n")
@@ -99,19 +105,58 @@ func NewSecretary() *cobra.Command {
 
 				logr.Info("processing storages")
 				for _, s := range storages {
+					// content that other storages still link to is kept
 					logr.Info("deleting storage", "id", s.GetId())
-					if err = blobstore.DeleteObject(ctx, s.GetId()); err != nil {
+					if err = store.DeleteStorage(ctx, datastore, blobstore, s); err != nil {
 						logr.Error(err, "deleting storage", "id", s.GetId())
-						return err
 					}
+				}
 
-					logr.Info("deleting storage data: %s", s.GetId())
-					err = datastore.DeleteStorage(s.GetId())
-					if err != nil {
-						logr.Error(err, "deleting storage data: %s", s.GetId())
+				logr.Info("getting uploads")
+				uploads, err := datastore.GetUploadsBefore(workUploadsBefore)
+				if err != nil {
+					logr.Error(err, "getting uploads")
+					return err
+				}
+
+				logr.Info("processing uploads")
+				for _, u := range uploads {
+					// the parts of complete uploads were deleted when they
+					// were completed, but abandoned ones' never will be
+					if u.GetStatus() == rototiller.UploadStatusPending.String() {
+						logr.Info("deleting upload parts", "id", u.GetId())
+						if err = blobstore.DeleteParts(ctx, u.GetId()); err != nil {
+							// the upload is kept so that its parts are deleted next time
+							logr.Error(err, "deleting upload parts", "id", u.GetId())
+							continue
+						}
+					}
+
+					if err = datastore.DeleteUpload(u.GetId()); err != nil {
+						logr.Error(err, "deleting upload data", "id", u.GetId())
+					}
+				}
+
+				logr.Info("getting imports")
+				imports, err := datastore.GetImportsBefore(workImportsBefore)
+				if err != nil {
+					logr.Error(err, "getting imports")
+					return err
+				}
+
+				logr.Info("processing imports")
+				for _, i := range imports {
+					// the storages that imports created are worked like any other
+					if err = datastore.DeleteImport(i.GetId()); err != nil {
+						logr.Error(err, "deleting import data", "id", i.GetId())
 					}
 				}
 
+				logr.Info("deleting dead workers")
+				if err = datastore.DeleteWorkersBefore(workWorkersBefore); err != nil {
+					logr.Error(err, "deleting dead workers")
+				}
+
 				if len(archive.String()) > 0 {
 					// cleverly use the same bucket code with different env vars
 					// for the archive bucket as well as the regular bucket
@@ -156,6 +201,9 @@ func NewSecretary() *cobra.Command {
 	cmd.Flags().StringVar(&stripe.Key, "stripe-api-key", "", "Stripe API key")
 	cmd.Flags().DurationVar(&workJobsBefore, "work-jobs-before", defaultDuration, "work jobs before")
 	cmd.Flags().DurationVar(&workStorageBefore, "work-storage-before", defaultDuration, "work storage before")
+	cmd.Flags().DurationVar(&workUploadsBefore, "work-uploads-before", defaultDuration, "work uploads before")
+	cmd.Flags().DurationVar(&workImportsBefore, "work-imports-before", defaultDuration, "work imports before")
+	cmd.Flags().DurationVar(&workWorkersBefore, "work-workers-before", defaultDuration, "work workers that last heartbeated before")
 
 	return cmd
 }
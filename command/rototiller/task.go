@@ -0,0 +1,30 @@
+package command
+
+import (
+	"context"
+
+	"github.com/logsquaredn/rototiller"
+	"github.com/logsquaredn/rototiller/store/data/postgres"
+	"github.com/logsquaredn/rototiller/task"
+)
+
+// syncTasks upserts a row into the datastore's task table
+// for each of the builtin task manifests and those in taskDir.
+func syncTasks(ctx context.Context, datastore *postgres.Datastore, taskDir string) error {
+	logr := rototiller.LoggerFrom(ctx)
+
+	manifests, err := task.LoadManifests(taskDir)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range manifests {
+		if _, err = datastore.UpsertTask(m.Task()); err != nil {
+			return err
+		}
+
+		logr.Info("synced task", "type", m.Name, "executable", m.Executable)
+	}
+
+	return nil
+}
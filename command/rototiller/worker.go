@@ -1,22 +1,44 @@
 package command
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/frantjc/go-js"
 	"github.com/logsquaredn/rototiller"
+	"github.com/logsquaredn/rototiller/fetch"
 	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/sandbox"
 	"github.com/logsquaredn/rototiller/store/blob/bucket"
 	"github.com/logsquaredn/rototiller/store/data/postgres"
 	"github.com/logsquaredn/rototiller/stream/event/amqp"
+	"github.com/logsquaredn/rototiller/task"
+	_ "github.com/logsquaredn/rototiller/task/builtin"
 	"github.com/logsquaredn/rototiller/worker"
 	"github.com/spf13/cobra"
 )
 
 func NewWorker() *cobra.Command {
 	var (
-		workingDir, postgresAddr, bucketAddr, amqpAddr string
-		cmd                                            = &cobra.Command{
+		workingDir, postgresAddr, bucketAddr, amqpAddr, taskDir string
+		useSandbox                                              bool
+		sandboxUID, sandboxGID                                  int
+		sandboxEnv                                              []string
+		importMaxSize                                           int64
+		importSchemes                                           []string
+		importAllowPrivate                                      bool
+		namespaceConcurrency, concurrency, prefetch             int
+		taskConcurrency                                         map[string]int
+		taskTypes, excludeTaskTypes                             []string
+		imports                                                 bool
+		heartbeatInterval                                       time.Duration
+		cmd                                                     = &cobra.Command{
 			Use:     "worker",
 			Aliases: []string{"w"},
 			RunE: func(cmd *cobra.Command, args []string) error {
@@ -39,12 +61,76 @@ func NewWorker() *cobra.Command {
 					return err
 				}
 
+				if err = syncTasks(ctx, datastore, taskDir); err != nil {
+					return err
+				}
+
 				eventStream, err := amqp.New(ctx, amqpAddr)
 				if err != nil {
 					return err
 				}
 
-				eventStreamConsumer, err := eventStream.NewConsumer(ctx, "worker", pb.EventTypeJobCreated)
+				if len(taskTypes) > 0 && len(excludeTaskTypes) > 0 {
+					return fmt.Errorf("--task-types and --exclude-task-types cannot both be set")
+				}
+
+				taskConcurrencyTypes := make([]string, 0, len(taskConcurrency))
+				for taskType := range taskConcurrency {
+					taskConcurrencyTypes = append(taskConcurrencyTypes, taskType)
+				}
+
+				for _, types := range [][]string{taskTypes, excludeTaskTypes, taskConcurrencyTypes} {
+					if err = checkTaskTypes(datastore, types); err != nil {
+						return err
+					}
+				}
+
+				// workers that handle the same task types share a queue, so each job
+				// is delivered to one of them, but each pool of workers gets its own
+				var (
+					queueID          = "worker"
+					eventTypes       = []pb.EventType{pb.EventTypeJobCreated.Key("#")}
+					handledTaskTypes []string
+				)
+				switch {
+				case len(taskTypes) > 0:
+					taskTypes = js.Unique(taskTypes)
+					sort.Strings(taskTypes)
+
+					queueID = strings.Join(append([]string{queueID}, taskTypes...), ".")
+					eventTypes = js.Map(taskTypes, func(taskType string, _ int, _ []string) pb.EventType {
+						return pb.EventTypeJobCreated.Key(taskType)
+					})
+					handledTaskTypes = taskTypes
+				case len(excludeTaskTypes) > 0:
+					excludeTaskTypes = js.Unique(excludeTaskTypes)
+					sort.Strings(excludeTaskTypes)
+
+					tasks, err := datastore.ListTasks()
+					if err != nil {
+						return err
+					}
+
+					// bound to each of the rest of the task types rather than to every
+					// one, so that the jobs of the excluded ones, which pools dedicated
+					// to them handle, are not also delivered to this pool
+					for _, t := range tasks {
+						if !js.Includes(excludeTaskTypes, t.GetType()) {
+							handledTaskTypes = append(handledTaskTypes, t.GetType())
+						}
+					}
+
+					queueID = strings.Join(append([]string{queueID + "-except"}, excludeTaskTypes...), ".")
+					eventTypes = js.Map(handledTaskTypes, func(taskType string, _ int, _ []string) pb.EventType {
+						return pb.EventTypeJobCreated.Key(taskType)
+					})
+				}
+
+				if len(taskTypes) == 0 || imports {
+					eventTypes = append(eventTypes, pb.EventTypeImportCreated)
+				}
+
+				eventStreamConsumer, err := eventStream.NewConsumer(ctx, queueID, eventTypes...)
 				if err != nil {
 					return err
 				}
@@ -54,44 +140,100 @@ func NewWorker() *cobra.Command {
 					return err
 				}
 
-				wrkr, err := worker.New(ctx, workingDir, datastore, blobstore)
+				opts := []worker.Opt{
+					worker.WithFetcher(fetch.New(
+						fetch.WithMaxSize(importMaxSize),
+						fetch.WithSchemes(importSchemes...),
+						fetch.WithAllowPrivate(importAllowPrivate),
+					)),
+				}
+				if useSandbox {
+					opts = append(opts, worker.WithSandbox(
+						sandbox.New(
+							sandbox.WithUser(sandboxUID, sandboxGID),
+							sandbox.WithEnv(sandboxEnv...),
+						),
+					))
+				}
+
+				wrkr, err := worker.New(ctx, workingDir, datastore, blobstore, opts...)
 				if err != nil {
 					return err
 				}
 
-				gorolimitVar := os.Getenv("GORO_LIMIT")
-				gorolimit := 16
-				if gorolimitVar != "" {
-					gorolimit, err = strconv.Atoi(gorolimitVar)
-					if err != nil {
-						gorolimit = 16
-					}
+				hostname, err := os.Hostname()
+				if err != nil {
+					return err
 				}
-				sem := make(chan struct{}, gorolimit)
+
+				registration, err := wrkr.Register(ctx, &pb.Worker{
+					Hostname:  hostname,
+					Version:   rototiller.GetSemver(),
+					TaskTypes: handledTaskTypes,
+					Capacity:  int32(concurrency),
+				}, heartbeatInterval)
+				if err != nil {
+					return err
+				}
+
+				// the queue delivers the events of namespaces with few jobs yet to finish
+				// ahead of those of namespaces with many, and the scheduler takes turns
+				// among the namespaces of the events that it holds, so it is given more
+				// than it can run at once, but no more than that, so that the rest are
+				// left on the queue to be delivered in that order, to any worker
+				if !cmd.Flags().Changed("prefetch") {
+					prefetch = 2 * concurrency
+				}
+
+				if err = eventStreamConsumer.Prefetch(prefetch); err != nil {
+					return err
+				}
+
+				sched := worker.NewScheduler(concurrency, namespaceConcurrency, taskConcurrency, prefetch)
 				eventC, errC := eventStreamConsumer.Listen(ctx)
 
-				logr.Info("listening for jobs")
-				for {
-					select {
-					case err := <-errC:
-						logr.Error(err, "event stream errored")
-						return err
-					case event := <-eventC:
-						sem <- struct{}{}
+				go func() {
+					for {
+						event, done, err := sched.Next(ctx)
+						if err != nil {
+							return
+						}
+
 						go func() {
+							defer done()
 							id := pb.JobEventMetadata(event.Metadata).GetId()
 
-							if err = wrkr.DoJob(ctx, id); err != nil {
-								logr.Error(err, "job failed", "id", id)
+							switch event.GetType() {
+							case pb.EventTypeImportCreated.String():
+								if err := wrkr.DoImport(ctx, id); err != nil {
+									logr.Error(err, "import failed", "id", id)
+								}
+							default:
+								if err := wrkr.DoJob(ctx, id); err != nil {
+									logr.Error(err, "job failed", "id", id)
+								}
 							}
 
-							if err = eventStreamConsumer.Ack(event); err != nil {
+							if err := eventStreamConsumer.Ack(event); err != nil {
 								logr.Error(err, "failed to ack", "event", event.GetId())
 							}
-
-							<-sem
 						}()
 					}
+				}()
+
+				logr.Info("listening for jobs", "id", registration.GetId(), "in-process tasks", task.Registered(), "task types", handledTaskTypes)
+				for {
+					select {
+					case err := <-errC:
+						logr.Error(err, "event stream errored")
+						return err
+					case event := <-eventC:
+						// events are acked once handled rather than when queued,
+						// so those still queued are redelivered if this exits
+						if err := sched.Push(ctx, event); err != nil {
+							return err
+						}
+					}
 				}
 			},
 		}
@@ -100,7 +242,48 @@ func NewWorker() *cobra.Command {
 	cmd.Flags().StringVar(&amqpAddr, "amqp-addr", "", "AMQP address")
 	cmd.Flags().StringVar(&bucketAddr, "bucket-addr", "", "bucket address")
 	cmd.Flags().StringVar(&postgresAddr, "postgres-addr", "", "Postgres address")
+	cmd.Flags().StringVar(&taskDir, "task-dir", task.DefaultDir, "directory of task manifests")
 	cmd.Flags().StringVar(&workingDir, "working-dir", "/var/lib/rototiller", "working directory")
+	cmd.Flags().BoolVar(&useSandbox, "sandbox", false, "run tasks in a sandbox with an isolated filesystem and no network")
+	cmd.Flags().IntVar(&sandboxUID, "sandbox-uid", sandbox.DefaultUID, "uid to run sandboxed tasks as")
+	cmd.Flags().IntVar(&sandboxGID, "sandbox-gid", sandbox.DefaultGID, "gid to run sandboxed tasks as")
+	cmd.Flags().Int64Var(&importMaxSize, "import-max-size", fetch.DefaultMaxSize, "most bytes to fetch for an import")
+	cmd.Flags().StringSliceVar(&importSchemes, "import-schemes", fetch.Schemes, "URL schemes that imports may use")
+	cmd.Flags().BoolVar(&importAllowPrivate, "import-allow-private", false, "allow imports from private, loopback and link-local addresses")
+	cmd.Flags().StringSliceVar(&taskTypes, "task-types", nil, "only handle jobs of these task types, so that pools of workers can be sized for them. Pools must not handle the same task types, as each pool gets every job of its task types, so see --exclude-task-types for a pool that handles the rest. Default all")
+	cmd.Flags().StringSliceVar(&excludeTaskTypes, "exclude-task-types", nil, "handle jobs of every task type but these, so that pools of workers set up with --task-types can handle them instead")
+	cmd.Flags().BoolVar(&imports, "imports", false, "also handle imports if --task-types is set, as they are otherwise only handled by workers without it")
+	cmd.Flags().IntVar(&concurrency, "concurrency", defaultConcurrency(), "most jobs and imports to run at once, or 0 for no limit. Defaults to $GORO_LIMIT if set")
+	cmd.Flags().IntVar(&prefetch, "prefetch", 0, "most jobs and imports to hold at once, queued or running, or 0 for no limit. Default twice --concurrency")
+	cmd.Flags().StringToIntVar(&taskConcurrency, "task-concurrency", nil, "most jobs of each task type to run at once, e.g. buffer=2,vectorlookup=32")
+	cmd.Flags().DurationVar(&heartbeatInterval, "heartbeat-interval", 10*time.Second, "how often to record that the worker is alive")
+	cmd.Flags().IntVar(&namespaceConcurrency, "namespace-concurrency", 0, "most jobs of one namespace to run at once, or 0 for no limit")
+	cmd.Flags().StringSliceVar(&sandboxEnv, "sandbox-env", nil, "additional environment variable names to pass into the sandbox")
 
 	return cmd
 }
+
+// defaultConcurrency is the value of GORO_LIMIT, which
+// set the worker's concurrency before --concurrency did,
+// or 16 if it is not set to an integer.
+func defaultConcurrency() int {
+	if concurrency, err := strconv.Atoi(os.Getenv("GORO_LIMIT")); err == nil {
+		return concurrency
+	}
+
+	return 16
+}
+
+// checkTaskTypes checks that each of the task types is known,
+// so that a typo in a flag fails loudly instead of being ignored.
+func checkTaskTypes(datastore *postgres.Datastore, taskTypes []string) error {
+	for _, taskType := range taskTypes {
+		if _, err := datastore.GetTask(pb.TaskType(taskType)); errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("unknown task type '%s'", taskType)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -19,3 +19,23 @@ const (
 const (
 	TaskKindLookup = pb.TaskKindLookup
 )
+
+const (
+	UploadStatusPending  = pb.UploadStatusPending
+	UploadStatusComplete = pb.UploadStatusComplete
+)
+
+const (
+	WorkerStatusAlive = pb.WorkerStatusAlive
+	WorkerStatusDead  = pb.WorkerStatusDead
+)
+
+const (
+	ScheduleStatusActive = pb.ScheduleStatusActive
+	ScheduleStatusPaused = pb.ScheduleStatusPaused
+)
+
+const (
+	MissedRunPolicySkip    = pb.MissedRunPolicySkip
+	MissedRunPolicyCatchUp = pb.MissedRunPolicyCatchUp
+)
@@ -0,0 +1,161 @@
+// Package csv encodes and decodes GeoJSON FeatureCollections as CSV files,
+// as served as text/csv, whose geometries are in a WKT column or, when
+// decoding, a pair of longitude and latitude columns.
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/paulmach/orb/geojson"
+)
+
+var (
+	// WKTColumns are the names of the columns, compared case-insensitively,
+	// that are read as WKT, in order of preference.
+	WKTColumns = []string{"wkt", "geometry", "geom", "the_geom", "shape"}
+	// LonColumns are the names of the columns that are read as longitudes.
+	LonColumns = []string{"lon", "lng", "long", "longitude", "x"}
+	// LatColumns are the names of the columns that are read as latitudes.
+	LatColumns = []string{"lat", "latitude", "y"}
+)
+
+// Unmarshal decodes the CSV file b, whose first row is its header, into a
+// feature per row. The geometry of each feature comes from its WKT column
+// or, if there is none, its longitude and latitude columns. The other
+// columns become its properties, which are numbers if they can be parsed
+// as such, strings otherwise and null if they are empty.
+func Unmarshal(b []byte) (*geojson.FeatureCollection, error) {
+	// tolerate the byte order mark that spreadsheets like to write
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+
+	r := csv.NewReader(bytes.NewReader(b))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("decode csv: %w", err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("csv has no header")
+	}
+
+	var (
+		header = records[0]
+		wktCol = find(header, WKTColumns)
+		lonCol = find(header, LonColumns)
+		latCol = find(header, LatColumns)
+	)
+	if wktCol < 0 && (lonCol < 0 || latCol < 0) {
+		return nil, fmt.Errorf("csv must have a WKT column among '%s' or longitude and latitude columns among '%s' and '%s'", strings.Join(WKTColumns, "', '"), strings.Join(LonColumns, "', '"), strings.Join(LatColumns, "', '"))
+	}
+
+	fc := geojson.NewFeatureCollection()
+	for i, record := range records[1:] {
+		if len(record) > len(header) {
+			return nil, fmt.Errorf("row %d has %d columns but the header has %d", i+1, len(record), len(header))
+		}
+
+		var (
+			f   = &geojson.Feature{Type: "Feature", Properties: geojson.Properties{}}
+			err error
+		)
+		if wktCol >= 0 {
+			f.Geometry, err = parseWKT(value(record, wktCol))
+		} else {
+			f.Geometry, err = parsePoint(value(record, lonCol), value(record, latCol))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+
+		for j, name := range header {
+			if j == wktCol || (wktCol < 0 && (j == lonCol || j == latCol)) {
+				continue
+			}
+
+			f.Properties[name] = parseValue(value(record, j))
+		}
+
+		fc.Append(f)
+	}
+
+	return fc, nil
+}
+
+// find returns the index of the first column of header that
+// has one of names, in order of preference, or -1 if none do.
+func find(header []string, names []string) int {
+	for _, name := range names {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// value returns the ith value of record, which may be short.
+func value(record []string, i int) string {
+	if i < len(record) {
+		return strings.TrimSpace(record[i])
+	}
+
+	return ""
+}
+
+func parseWKT(s string) (orb.Geometry, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	g, err := wkt.Unmarshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WKT: %w", err)
+	}
+
+	return g, nil
+}
+
+func parsePoint(lon, lat string) (orb.Geometry, error) {
+	if lon == "" && lat == "" {
+		return nil, nil
+	}
+
+	x, err := strconv.ParseFloat(lon, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude '%s'", lon)
+	}
+
+	y, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude '%s'", lat)
+	}
+
+	return orb.Point{x, y}, nil
+}
+
+func parseValue(s string) any {
+	if s == "" {
+		return nil
+	}
+
+	// identifiers with leading zeros, e.g. ZIP codes, lose them as numbers
+	if len(s) > 1 && s[0] == '0' && s[1] != '.' {
+		return s
+	}
+
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f
+	}
+
+	return s
+}
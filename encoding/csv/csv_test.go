@@ -0,0 +1,170 @@
+package csv_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/encoding/csv"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// parcels is what testdata/parcels.csv decodes to: a polygon with a hole and
+// a second shell, a row without a geometry and a polygon. Its ZIP column has
+// a leading zero, which is kept, and its WKT column is not a property.
+var parcels = []struct {
+	properties geojson.Properties
+	geometry   orb.Geometry
+}{
+	{
+		properties: geojson.Properties{"NAME": "lot a", "AREA": 121.0, "ZIP": "01234"},
+		geometry: orb.MultiPolygon{
+			{
+				{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+				{{2, 2}, {2, 4}, {4, 4}, {4, 2}, {2, 2}},
+			},
+			{
+				{{20, 0}, {25, 0}, {25, 5}, {20, 5}, {20, 0}},
+			},
+		},
+	},
+	{
+		properties: geojson.Properties{"NAME": "vacant", "AREA": nil, "ZIP": nil},
+	},
+	{
+		properties: geojson.Properties{"NAME": "lot c", "AREA": 1.0, "ZIP": 0.5},
+		geometry:   orb.Polygon{{{30, 0}, {31, 0}, {31, 1}, {30, 1}, {30, 0}}},
+	},
+}
+
+func TestUnmarshal(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "parcels.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fc, err := csv.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	if len(fc.Features) != len(parcels) {
+		t.Fatalf("got %d features, want %d", len(fc.Features), len(parcels))
+	}
+
+	for i, want := range parcels {
+		assertFeature(t, fc.Features[i], want.properties, want.geometry)
+	}
+}
+
+func TestUnmarshalLonLat(t *testing.T) {
+	fc, err := csv.Unmarshal([]byte("name,Latitude,Longitude\nAustin,30.27,-97.74\nnowhere,,\n"))
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	if len(fc.Features) != 2 {
+		t.Fatalf("got %d features, want 2", len(fc.Features))
+	}
+
+	assertFeature(t, fc.Features[0], geojson.Properties{"name": "Austin"}, orb.Point{-97.74, 30.27})
+	assertFeature(t, fc.Features[1], geojson.Properties{"name": "nowhere"}, nil)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want string
+	}{
+		{name: "empty", csv: "", want: "csv has no header"},
+		{name: "no geometry columns", csv: "name,lat\nAustin,30.27\n", want: "csv must have a WKT column"},
+		{name: "invalid WKT", csv: "name,wkt\nAustin,POINT(-97.74)\n", want: "row 1: invalid WKT"},
+		{name: "invalid longitude", csv: "name,lon,lat\nAustin,west,30.27\n", want: "row 1: invalid longitude 'west'"},
+		{name: "too many columns", csv: "name,lon,lat\nAustin,-97.74,30.27,TX\n", want: "row 1 has 4 columns but the header has 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := csv.Unmarshal([]byte(tt.csv)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Unmarshal() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "parcels.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fc, err := csv.Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b, err = csv.Marshal(fc); err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+
+	got, err := csv.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	if len(got.Features) != len(parcels) {
+		t.Fatalf("got %d features, want %d", len(got.Features), len(parcels))
+	}
+
+	for i, want := range parcels {
+		assertFeature(t, got.Features[i], want.properties, want.geometry)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	fc := geojson.NewFeatureCollection()
+	f := geojson.NewFeature(orb.Point{1, 2})
+	f.Properties = geojson.Properties{
+		"wkt":  "taken",
+		"pop":  1.5,
+		"ok":   true,
+		"tags": []any{"a", 1.0},
+	}
+	fc.Append(f)
+	fc.Append(&geojson.Feature{Type: "Feature", Properties: geojson.Properties{"pop": nil}})
+
+	b, err := csv.Marshal(fc)
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+
+	// the geometry goes in the first of the WKT columns
+	// that is not a property and then properties by name
+	want := `geometry,ok,pop,tags,wkt
+POINT(1 2),true,1.5,"[""a"",1]",taken
+,,,,
+`
+	if string(b) != want {
+		t.Errorf("Marshal() = %q, want %q", b, want)
+	}
+}
+
+func assertFeature(t *testing.T, f *geojson.Feature, properties geojson.Properties, geometry orb.Geometry) {
+	t.Helper()
+
+	if !reflect.DeepEqual(f.Properties, properties) {
+		t.Errorf("got properties %v, want %v", f.Properties, properties)
+	}
+
+	if geometry == nil {
+		if f.Geometry != nil {
+			t.Errorf("got geometry %v, want null", f.Geometry)
+		}
+	} else if f.Geometry == nil || !orb.Equal(f.Geometry, geometry) {
+		t.Errorf("got geometry %v, want %v", f.Geometry, geometry)
+	}
+}
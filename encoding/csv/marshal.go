@@ -0,0 +1,95 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Marshal encodes fc as a CSV file with a row per feature, whose
+// geometry is in the first column as WKT and whose properties are in
+// the rest, sorted by name. The geometry column is named by the first
+// of WKTColumns that is not the name of a property.
+func Marshal(fc *geojson.FeatureCollection) ([]byte, error) {
+	set := map[string]bool{}
+	for _, f := range fc.Features {
+		for k := range f.Properties {
+			set[k] = true
+		}
+	}
+
+	columns := make([]string, 0, len(set))
+	for k := range set {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+
+	geomCol := ""
+	for _, name := range WKTColumns {
+		if !set[name] {
+			geomCol = name
+			break
+		}
+	}
+
+	if geomCol == "" {
+		return nil, fmt.Errorf("properties use every name for the geometry column")
+	}
+
+	var (
+		buf = new(bytes.Buffer)
+		w   = csv.NewWriter(buf)
+	)
+	if err := w.Write(append([]string{geomCol}, columns...)); err != nil {
+		return nil, err
+	}
+
+	for _, f := range fc.Features {
+		record := make([]string, 0, len(columns)+1)
+		if f.Geometry != nil {
+			record = append(record, wkt.MarshalString(f.Geometry))
+		} else {
+			record = append(record, "")
+		}
+
+		for _, c := range columns {
+			record = append(record, formatValue(f.Properties[c]))
+		}
+
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+// formatValue formats a property's value as text,
+// with arrays and objects as JSON.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
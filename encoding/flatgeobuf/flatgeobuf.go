@@ -0,0 +1,108 @@
+// Package flatgeobuf encodes and decodes GeoJSON FeatureCollections as
+// FlatGeobuf files, as served as application/flatgeobuf.
+//
+// Only 2D geometries are read and written. Z, M and T values are dropped,
+// as is the spatial index, since every feature is read anyway, and so
+// none is written.
+package flatgeobuf
+
+import (
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// magic is the first 8 bytes of a FlatGeobuf file, major version 3.
+var magic = []byte{'f', 'g', 'b', 3, 'f', 'g', 'b', 0}
+
+// GeometryType is the type of a geometry, see
+// https://github.com/flatgeobuf/flatgeobuf/blob/master/src/fbs/header.fbs.
+type GeometryType uint8
+
+const (
+	Unknown            GeometryType = 0
+	Point              GeometryType = 1
+	LineString         GeometryType = 2
+	Polygon            GeometryType = 3
+	MultiPoint         GeometryType = 4
+	MultiLineString    GeometryType = 5
+	MultiPolygon       GeometryType = 6
+	GeometryCollection GeometryType = 7
+)
+
+// ColumnType is the type of the values of a column.
+type ColumnType uint8
+
+const (
+	Byte     ColumnType = 0
+	UByte    ColumnType = 1
+	Bool     ColumnType = 2
+	Short    ColumnType = 3
+	UShort   ColumnType = 4
+	Int      ColumnType = 5
+	UInt     ColumnType = 6
+	Long     ColumnType = 7
+	ULong    ColumnType = 8
+	Float    ColumnType = 9
+	Double   ColumnType = 10
+	String   ColumnType = 11
+	JSON     ColumnType = 12
+	DateTime ColumnType = 13
+	Binary   ColumnType = 14
+)
+
+// slot returns the vtable offset of the ith field of a table.
+func slot(i int) flatbuffers.VOffsetT {
+	return flatbuffers.VOffsetT(4 + 2*i)
+}
+
+// the fields of the tables of the schema, by index
+const (
+	headerName          = 0
+	headerGeometryType  = 2
+	headerColumns       = 7
+	headerFeaturesCount = 8
+	headerIndexNodeSize = 9
+	headerCRS           = 10
+	headerFields        = 14
+
+	crsOrg    = 0
+	crsCode   = 1
+	crsFields = 6
+
+	columnName   = 0
+	columnType   = 1
+	columnFields = 11
+
+	featureGeometry   = 0
+	featureProperties = 1
+	featureColumns    = 2
+	featureFields     = 3
+
+	geometryEnds   = 0
+	geometryXY     = 1
+	geometryType   = 6
+	geometryParts  = 7
+	geometryFields = 8
+)
+
+// nodeSize is the size of a node of the packed Hilbert R-tree
+// index, i.e. its bounding box and the offset of its feature.
+const nodeSize = 4*8 + 8
+
+// indexSize returns the size of the index of a file with
+// the given number of features and node size.
+func indexSize(featuresCount uint64, indexNodeSize uint16) int {
+	if indexNodeSize < 2 || featuresCount == 0 {
+		return 0
+	}
+
+	var (
+		n     = featuresCount
+		nodes = n
+	)
+	for n != 1 {
+		n = (n + uint64(indexNodeSize) - 1) / uint64(indexNodeSize)
+		nodes += n
+	}
+
+	return int(nodes) * nodeSize
+}
@@ -0,0 +1,175 @@
+package flatgeobuf_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/encoding/flatgeobuf"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// parcels is what testdata/parcels.fgb decodes to: a polygon with a hole and
+// a second shell, a feature without a geometry and a polygon. The header's
+// geometry type is unknown, so each geometry has its own, and it is followed
+// by a spatial index, which is skipped. Columns that a feature has no value
+// for are not among its properties.
+var parcels = []struct {
+	properties geojson.Properties
+	geometry   orb.Geometry
+}{
+	{
+		properties: geojson.Properties{"NAME": "lot a", "AREA": 121.0, "LOTS": 2.0, "VACANT": false},
+		geometry: orb.MultiPolygon{
+			{
+				{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+				{{2, 2}, {2, 4}, {4, 4}, {4, 2}, {2, 2}},
+			},
+			{
+				{{20, 0}, {25, 0}, {25, 5}, {20, 5}, {20, 0}},
+			},
+		},
+	},
+	{
+		properties: geojson.Properties{"NAME": "vacant", "LOTS": 0.0, "VACANT": true},
+	},
+	{
+		properties: geojson.Properties{"NAME": "lot c", "AREA": 1.0, "LOTS": 1.0, "VACANT": false},
+		geometry:   orb.Polygon{{{30, 0}, {31, 0}, {31, 1}, {30, 1}, {30, 0}}},
+	},
+}
+
+func TestUnmarshal(t *testing.T) {
+	fc, err := flatgeobuf.Unmarshal(read(t))
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	assertParcels(t, fc)
+}
+
+func TestCRS(t *testing.T) {
+	if crs, err := flatgeobuf.CRS(read(t)); err != nil || crs != "EPSG:4326" {
+		t.Errorf("CRS() = %q, %v, want EPSG:4326", crs, err)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	b := read(t)
+
+	tests := []struct {
+		name string
+		b    []byte
+		want string
+	}{
+		{name: "not flatgeobuf", b: []byte("fgb\x02fgb\x00\x00\x00\x00\x00"), want: "not a flatgeobuf version 3 file"},
+		{name: "truncated header", b: b[:64], want: "header: truncated"},
+		{name: "truncated feature", b: b[:len(b)-8], want: "feature 2: truncated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := flatgeobuf.Unmarshal(tt.b); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Unmarshal() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	fc, err := flatgeobuf.Unmarshal(read(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := flatgeobuf.Marshal(fc, "parcels")
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+
+	if crs, err := flatgeobuf.CRS(b); err != nil || crs != "EPSG:4326" {
+		t.Errorf("CRS() = %q, %v, want EPSG:4326", crs, err)
+	}
+
+	got, err := flatgeobuf.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	assertParcels(t, got)
+}
+
+func TestMarshalColumnTypes(t *testing.T) {
+	fc := geojson.NewFeatureCollection()
+	for _, props := range []geojson.Properties{
+		{"mixed": "a", "tags": []any{"a", 1.0}, "nickname": nil},
+		{"mixed": 3.0, "tags": map[string]any{"k": "v"}, "nickname": nil},
+	} {
+		f := geojson.NewFeature(orb.LineString{{0, 0}, {1, 1}})
+		f.Properties = props
+		fc.Append(f)
+	}
+
+	b, err := flatgeobuf.Marshal(fc, "output")
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+
+	got, err := flatgeobuf.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	// columns of more than one type, arrays and objects are JSON,
+	// which keeps their values, and nulls are left out
+	want := []geojson.Properties{
+		{"mixed": "a", "tags": []any{"a", 1.0}},
+		{"mixed": 3.0, "tags": map[string]any{"k": "v"}},
+	}
+	for i, f := range got.Features {
+		if !reflect.DeepEqual(f.Properties, want[i]) {
+			t.Errorf("feature %d: got properties %v, want %v", i, f.Properties, want[i])
+		}
+
+		if !orb.Equal(f.Geometry, fc.Features[i].Geometry) {
+			t.Errorf("feature %d: got geometry %v, want %v", i, f.Geometry, fc.Features[i].Geometry)
+		}
+	}
+}
+
+func assertParcels(t *testing.T, fc *geojson.FeatureCollection) {
+	t.Helper()
+
+	if len(fc.Features) != len(parcels) {
+		t.Fatalf("got %d features, want %d", len(fc.Features), len(parcels))
+	}
+
+	for i, want := range parcels {
+		f := fc.Features[i]
+		if !reflect.DeepEqual(f.Properties, want.properties) {
+			t.Errorf("feature %d: got properties %v, want %v", i, f.Properties, want.properties)
+		}
+
+		if want.geometry == nil {
+			if f.Geometry != nil {
+				t.Errorf("feature %d: got geometry %v, want null", i, f.Geometry)
+			}
+		} else if f.Geometry == nil || !orb.Equal(f.Geometry, want.geometry) {
+			t.Errorf("feature %d: got geometry %v, want %v", i, f.Geometry, want.geometry)
+		}
+	}
+}
+
+func read(t *testing.T) []byte {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", "parcels.fgb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
@@ -0,0 +1,338 @@
+package flatgeobuf
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// epsg4326 is WGS 84, which GeoJSON coordinates are always in.
+const epsg4326 = 4326
+
+// Marshal encodes fc as a FlatGeobuf file without a spatial index whose
+// layer is named name. Columns are Double, Bool or String if each of their
+// values are numbers, booleans or strings respectively, and JSON otherwise.
+func Marshal(fc *geojson.FeatureCollection, name string) ([]byte, error) {
+	var (
+		columns  = columnsOf(fc)
+		geomType = geometryTypeOf(fc)
+		out      = append([]byte{}, magic...)
+		b        = flatbuffers.NewBuilder(1024)
+	)
+
+	out = append(out, buildHeader(b, fc, name, geomType, columns)...)
+
+	index := make(map[string]int, len(columns))
+	for i, c := range columns {
+		index[c.name] = i
+	}
+
+	for i, f := range fc.Features {
+		b.Reset()
+
+		feature, err := buildFeature(b, f, geomType, columns, index)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+
+		out = append(out, feature...)
+	}
+
+	return out, nil
+}
+
+func buildHeader(b *flatbuffers.Builder, fc *geojson.FeatureCollection, name string, geomType GeometryType, columns []*column) []byte {
+	offsets := make([]flatbuffers.UOffsetT, len(columns))
+	for i, c := range columns {
+		n := b.CreateString(c.name)
+		b.StartObject(columnFields)
+		b.PrependUOffsetTSlot(columnName, n, 0)
+		b.PrependUint8Slot(columnType, uint8(c.typ), uint8(Byte))
+		offsets[i] = b.EndObject()
+	}
+
+	b.StartVector(4, len(offsets), 4)
+	for i := len(offsets) - 1; i >= 0; i-- {
+		b.PrependUOffsetT(offsets[i])
+	}
+	cols := b.EndVector(len(offsets))
+
+	org := b.CreateString("EPSG")
+	b.StartObject(crsFields)
+	b.PrependUOffsetTSlot(crsOrg, org, 0)
+	b.PrependInt32Slot(crsCode, epsg4326, 0)
+	crs := b.EndObject()
+
+	n := b.CreateString(name)
+	b.StartObject(headerFields)
+	b.PrependUOffsetTSlot(headerName, n, 0)
+	b.PrependUint8Slot(headerGeometryType, uint8(geomType), uint8(Unknown))
+	b.PrependUOffsetTSlot(headerColumns, cols, 0)
+	b.PrependUint64Slot(headerFeaturesCount, uint64(len(fc.Features)), 0)
+	// the default of 16 would mean that an index follows
+	b.PrependUint16Slot(headerIndexNodeSize, 0, 16)
+	b.PrependUOffsetTSlot(headerCRS, crs, 0)
+	b.FinishSizePrefixed(b.EndObject())
+
+	return b.FinishedBytes()
+}
+
+func buildFeature(b *flatbuffers.Builder, f *geojson.Feature, geomType GeometryType, columns []*column, index map[string]int) ([]byte, error) {
+	props, err := encodeProperties(f.Properties, columns, index)
+	if err != nil {
+		return nil, err
+	}
+	properties := b.CreateByteVector(props)
+
+	var geometry flatbuffers.UOffsetT
+	if f.Geometry != nil {
+		if geometry, err = buildGeometry(b, f.Geometry, geomType == Unknown); err != nil {
+			return nil, err
+		}
+	}
+
+	b.StartObject(featureFields)
+	if geometry != 0 {
+		b.PrependUOffsetTSlot(featureGeometry, geometry, 0)
+	}
+	b.PrependUOffsetTSlot(featureProperties, properties, 0)
+	b.FinishSizePrefixed(b.EndObject())
+
+	return b.FinishedBytes(), nil
+}
+
+// encodeProperties encodes each non-null property as the
+// index of its column followed by its value.
+func encodeProperties(properties geojson.Properties, columns []*column, index map[string]int) ([]byte, error) {
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b := []byte{}
+	for _, k := range keys {
+		v := properties[k]
+		if v == nil {
+			continue
+		}
+
+		i := index[k]
+		b = binary.LittleEndian.AppendUint16(b, uint16(i))
+
+		switch columns[i].typ {
+		case Double:
+			b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v.(float64)))
+		case Bool:
+			b = append(b, 0)
+			if v.(bool) {
+				b[len(b)-1] = 1
+			}
+		case String:
+			s := v.(string)
+			b = binary.LittleEndian.AppendUint32(b, uint32(len(s)))
+			b = append(b, s...)
+		default:
+			j, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("property %s: %w", k, err)
+			}
+
+			b = binary.LittleEndian.AppendUint32(b, uint32(len(j)))
+			b = append(b, j...)
+		}
+	}
+
+	return b, nil
+}
+
+// buildGeometry builds g, including its type if the header does not have one.
+func buildGeometry(b *flatbuffers.Builder, g orb.Geometry, withType bool) (flatbuffers.UOffsetT, error) {
+	var (
+		parts  []orb.Geometry
+		points []orb.Point
+		ends   []uint32
+	)
+	switch g := g.(type) {
+	case orb.Point:
+		points = []orb.Point{g}
+	case orb.MultiPoint:
+		points = g
+	case orb.LineString:
+		points = g
+	case orb.MultiLineString:
+		for _, ls := range g {
+			points = append(points, ls...)
+			ends = append(ends, uint32(len(points)))
+		}
+	case orb.Ring:
+		points = g
+	case orb.Polygon:
+		for _, r := range g {
+			points = append(points, r...)
+			ends = append(ends, uint32(len(points)))
+		}
+	case orb.MultiPolygon:
+		for _, p := range g {
+			parts = append(parts, p)
+		}
+	case orb.Collection:
+		parts = g
+	case orb.Bound:
+		return buildGeometry(b, g.ToPolygon(), withType)
+	default:
+		return 0, fmt.Errorf("unsupported geometry type %s", g.GeoJSONType())
+	}
+
+	typ := typeOf(g)
+
+	var partsVector flatbuffers.UOffsetT
+	if parts != nil {
+		offsets := make([]flatbuffers.UOffsetT, len(parts))
+		for i, part := range parts {
+			var err error
+			// the parts of a MultiPolygon are always Polygons
+			if offsets[i], err = buildGeometry(b, part, typ == GeometryCollection); err != nil {
+				return 0, err
+			}
+		}
+
+		b.StartVector(4, len(offsets), 4)
+		for i := len(offsets) - 1; i >= 0; i-- {
+			b.PrependUOffsetT(offsets[i])
+		}
+		partsVector = b.EndVector(len(offsets))
+	}
+
+	var xy flatbuffers.UOffsetT
+	if points != nil {
+		b.StartVector(8, 2*len(points), 8)
+		for i := len(points) - 1; i >= 0; i-- {
+			b.PrependFloat64(points[i].Y())
+			b.PrependFloat64(points[i].X())
+		}
+		xy = b.EndVector(2 * len(points))
+	}
+
+	var endsVector flatbuffers.UOffsetT
+	// a single part's end is implied
+	if len(ends) > 1 {
+		b.StartVector(4, len(ends), 4)
+		for i := len(ends) - 1; i >= 0; i-- {
+			b.PrependUint32(ends[i])
+		}
+		endsVector = b.EndVector(len(ends))
+	}
+
+	b.StartObject(geometryFields)
+	if endsVector != 0 {
+		b.PrependUOffsetTSlot(geometryEnds, endsVector, 0)
+	}
+	if xy != 0 {
+		b.PrependUOffsetTSlot(geometryXY, xy, 0)
+	}
+	if partsVector != 0 {
+		b.PrependUOffsetTSlot(geometryParts, partsVector, 0)
+	}
+	if withType {
+		b.PrependUint8Slot(geometryType, uint8(typ), uint8(Unknown))
+	}
+
+	return b.EndObject(), nil
+}
+
+func typeOf(g orb.Geometry) GeometryType {
+	switch g.(type) {
+	case orb.Point:
+		return Point
+	case orb.MultiPoint:
+		return MultiPoint
+	case orb.LineString:
+		return LineString
+	case orb.MultiLineString:
+		return MultiLineString
+	case orb.Ring, orb.Polygon, orb.Bound:
+		return Polygon
+	case orb.MultiPolygon:
+		return MultiPolygon
+	case orb.Collection:
+		return GeometryCollection
+	}
+
+	return Unknown
+}
+
+// geometryTypeOf returns the type that the geometries
+// of fc have in common, or Unknown if they have none.
+func geometryTypeOf(fc *geojson.FeatureCollection) GeometryType {
+	typ := Unknown
+	for _, f := range fc.Features {
+		if f.Geometry == nil {
+			continue
+		}
+
+		switch t := typeOf(f.Geometry); typ {
+		case Unknown:
+			typ = t
+		case t:
+		default:
+			return Unknown
+		}
+	}
+
+	return typ
+}
+
+// columnsOf infers a column for each property of the features of fc, sorted by name.
+func columnsOf(fc *geojson.FeatureCollection) []*column {
+	types := map[string]ColumnType{}
+	for _, f := range fc.Features {
+		for k, v := range f.Properties {
+			var typ ColumnType
+			switch v.(type) {
+			case nil:
+				if _, ok := types[k]; !ok {
+					// until a value says otherwise
+					types[k] = Binary
+				}
+				continue
+			case float64:
+				typ = Double
+			case bool:
+				typ = Bool
+			case string:
+				typ = String
+			default:
+				typ = JSON
+			}
+
+			if t, ok := types[k]; !ok || t == Binary {
+				types[k] = typ
+			} else if t != typ {
+				types[k] = JSON
+			}
+		}
+	}
+
+	columns := make([]*column, 0, len(types))
+	for k, t := range types {
+		if t == Binary {
+			// only ever null
+			t = String
+		}
+
+		columns = append(columns, &column{name: k, typ: t})
+	}
+
+	sort.Slice(columns, func(i, j int) bool {
+		return columns[i].name < columns[j].name
+	})
+
+	return columns
+}
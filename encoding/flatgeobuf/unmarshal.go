@@ -0,0 +1,381 @@
+package flatgeobuf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+type column struct {
+	name string
+	typ  ColumnType
+}
+
+// Unmarshal decodes the FlatGeobuf file b.
+func Unmarshal(b []byte) (fc *geojson.FeatureCollection, err error) {
+	// flatbuffers trusts its input, so a corrupt
+	// file panics rather than returning an error
+	defer func() {
+		if r := recover(); r != nil {
+			fc, err = nil, fmt.Errorf("flatgeobuf is corrupt: %v", r)
+		}
+	}()
+
+	if len(b) < len(magic)+4 || !bytes.Equal(b[:3], magic[:3]) || b[3] != magic[3] {
+		return nil, fmt.Errorf("not a flatgeobuf version %d file", magic[3])
+	}
+
+	off := len(magic)
+	header, off, err := table(b, off)
+	if err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+
+	var (
+		geomType = GeometryType(header.GetUint8Slot(slot(headerGeometryType), uint8(Unknown)))
+		columns  = readColumns(header, headerColumns)
+		count    = header.GetUint64Slot(slot(headerFeaturesCount), 0)
+	)
+	off += indexSize(count, header.GetUint16Slot(slot(headerIndexNodeSize), 16))
+
+	fc = geojson.NewFeatureCollection()
+	for off < len(b) {
+		var feature *flatbuffers.Table
+		if feature, off, err = table(b, off); err != nil {
+			return nil, fmt.Errorf("feature %d: %w", len(fc.Features), err)
+		}
+
+		f, err := readFeature(feature, geomType, columns)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", len(fc.Features), err)
+		}
+
+		fc.Append(f)
+	}
+
+	return fc, nil
+}
+
+// table reads the size-prefixed table at off in b,
+// returning it and the offset of what follows it.
+func table(b []byte, off int) (*flatbuffers.Table, int, error) {
+	if off+4 > len(b) {
+		return nil, 0, fmt.Errorf("truncated")
+	}
+
+	size := int(binary.LittleEndian.Uint32(b[off:]))
+	off += 4
+	if size < 4 || off+size > len(b) {
+		return nil, 0, fmt.Errorf("truncated")
+	}
+
+	buf := b[off : off+size]
+	return &flatbuffers.Table{Bytes: buf, Pos: flatbuffers.GetUOffsetT(buf)}, off + size, nil
+}
+
+// vector returns the position and length of the vector field at index i
+// of t, or 0 and 0 if t does not have it.
+func vector(t *flatbuffers.Table, i int) (flatbuffers.UOffsetT, int) {
+	o := flatbuffers.UOffsetT(t.Offset(slot(i)))
+	if o == 0 {
+		return 0, 0
+	}
+
+	return t.Vector(o), t.VectorLen(o)
+}
+
+// subtables returns the tables of the vector of tables field at index i of t.
+func subtables(t *flatbuffers.Table, i int) []*flatbuffers.Table {
+	pos, n := vector(t, i)
+	ts := make([]*flatbuffers.Table, n)
+	for j := range ts {
+		elem := pos + flatbuffers.UOffsetT(j)*4
+		ts[j] = &flatbuffers.Table{Bytes: t.Bytes, Pos: t.Indirect(elem)}
+	}
+
+	return ts
+}
+
+func stringField(t *flatbuffers.Table, i int) string {
+	o := flatbuffers.UOffsetT(t.Offset(slot(i)))
+	if o == 0 {
+		return ""
+	}
+
+	return t.String(t.Pos + o)
+}
+
+func readColumns(t *flatbuffers.Table, i int) []*column {
+	tables := subtables(t, i)
+	columns := make([]*column, len(tables))
+	for j, c := range tables {
+		columns[j] = &column{
+			name: stringField(c, columnName),
+			typ:  ColumnType(c.GetUint8Slot(slot(columnType), uint8(Byte))),
+		}
+	}
+
+	return columns
+}
+
+func readFeature(t *flatbuffers.Table, geomType GeometryType, columns []*column) (*geojson.Feature, error) {
+	f := &geojson.Feature{Type: "Feature", Properties: geojson.Properties{}}
+
+	// features may override the columns of the header
+	if cs := readColumns(t, featureColumns); len(cs) > 0 {
+		columns = cs
+	}
+
+	if o := flatbuffers.UOffsetT(t.Offset(slot(featureGeometry))); o != 0 {
+		g := &flatbuffers.Table{Bytes: t.Bytes, Pos: t.Indirect(t.Pos + o)}
+		geometry, err := readGeometry(g, geomType)
+		if err != nil {
+			return nil, err
+		}
+
+		f.Geometry = geometry
+	}
+
+	pos, n := vector(t, featureProperties)
+	props := t.Bytes[pos : int(pos)+n]
+	for len(props) > 0 {
+		if len(props) < 2 {
+			return nil, fmt.Errorf("properties are truncated")
+		}
+
+		i := int(binary.LittleEndian.Uint16(props))
+		if i >= len(columns) {
+			return nil, fmt.Errorf("property of column %d, but there are %d columns", i, len(columns))
+		}
+
+		v, rest, err := readValue(columns[i].typ, props[2:])
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %w", columns[i].name, err)
+		}
+
+		f.Properties[columns[i].name] = v
+		props = rest
+	}
+
+	return f, nil
+}
+
+// valueSizes are the sizes of the fixed-size ColumnTypes.
+var valueSizes = map[ColumnType]int{
+	Byte: 1, UByte: 1, Bool: 1,
+	Short: 2, UShort: 2,
+	Int: 4, UInt: 4, Float: 4,
+	Long: 8, ULong: 8, Double: 8,
+}
+
+// readValue reads a value of the given type from the front of b,
+// returning it and what follows it.
+func readValue(typ ColumnType, b []byte) (any, []byte, error) {
+	if size, ok := valueSizes[typ]; ok {
+		if len(b) < size {
+			return nil, nil, fmt.Errorf("truncated")
+		}
+
+		var v any
+		switch typ {
+		case Byte:
+			v = float64(int8(b[0]))
+		case UByte:
+			v = float64(b[0])
+		case Bool:
+			v = b[0] != 0
+		case Short:
+			v = float64(int16(binary.LittleEndian.Uint16(b)))
+		case UShort:
+			v = float64(binary.LittleEndian.Uint16(b))
+		case Int:
+			v = float64(int32(binary.LittleEndian.Uint32(b)))
+		case UInt:
+			v = float64(binary.LittleEndian.Uint32(b))
+		case Float:
+			v = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		case Long:
+			v = float64(int64(binary.LittleEndian.Uint64(b)))
+		case ULong:
+			v = float64(binary.LittleEndian.Uint64(b))
+		case Double:
+			v = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}
+
+		return v, b[size:], nil
+	}
+
+	if len(b) < 4 {
+		return nil, nil, fmt.Errorf("truncated")
+	}
+
+	size := int(binary.LittleEndian.Uint32(b))
+	if len(b) < 4+size {
+		return nil, nil, fmt.Errorf("truncated")
+	}
+
+	var (
+		content = b[4 : 4+size]
+		rest    = b[4+size:]
+	)
+	switch typ {
+	case String, DateTime:
+		return string(content), rest, nil
+	case JSON:
+		var v any
+		if err := json.Unmarshal(content, &v); err != nil {
+			return string(content), rest, nil
+		}
+
+		return v, rest, nil
+	case Binary:
+		// binary values have no GeoJSON equivalent
+		return nil, rest, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown column type %d", typ)
+}
+
+func readGeometry(t *flatbuffers.Table, typ GeometryType) (orb.Geometry, error) {
+	if typ == Unknown {
+		typ = GeometryType(t.GetUint8Slot(slot(geometryType), uint8(Unknown)))
+	}
+
+	switch typ {
+	case MultiPolygon, GeometryCollection:
+		c := orb.Collection{}
+		mp := orb.MultiPolygon{}
+		for _, part := range subtables(t, geometryParts) {
+			partType := Polygon
+			if typ == GeometryCollection {
+				partType = Unknown
+			}
+
+			g, err := readGeometry(part, partType)
+			if err != nil {
+				return nil, err
+			}
+
+			if g == nil {
+				continue
+			}
+
+			c = append(c, g)
+			if p, ok := g.(orb.Polygon); ok {
+				mp = append(mp, p)
+			}
+		}
+
+		if typ == MultiPolygon {
+			return mp, nil
+		}
+
+		return c, nil
+	}
+
+	var (
+		pos, n = vector(t, geometryXY)
+		points = make([]orb.Point, n/2)
+	)
+	for i := range points {
+		points[i] = orb.Point{
+			t.GetFloat64(pos + flatbuffers.UOffsetT(i*16)),
+			t.GetFloat64(pos + flatbuffers.UOffsetT(i*16+8)),
+		}
+	}
+
+	var (
+		endsPos, numEnds = vector(t, geometryEnds)
+		parts            = [][]orb.Point{}
+		start            = 0
+	)
+	for i := 0; i < numEnds; i++ {
+		end := int(t.GetUint32(endsPos + flatbuffers.UOffsetT(i*4)))
+		if end < start || end > len(points) {
+			return nil, fmt.Errorf("invalid ends")
+		}
+
+		parts = append(parts, points[start:end])
+		start = end
+	}
+
+	if numEnds == 0 {
+		parts = append(parts, points)
+	}
+
+	switch typ {
+	case Point:
+		if len(points) == 0 {
+			return nil, nil
+		}
+
+		return points[0], nil
+	case MultiPoint:
+		return orb.MultiPoint(points), nil
+	case LineString:
+		return orb.LineString(points), nil
+	case MultiLineString:
+		mls := make(orb.MultiLineString, len(parts))
+		for i, part := range parts {
+			mls[i] = part
+		}
+
+		return mls, nil
+	case Polygon:
+		p := make(orb.Polygon, len(parts))
+		for i, part := range parts {
+			p[i] = part
+		}
+
+		return p, nil
+	}
+
+	return nil, fmt.Errorf("unsupported geometry type %d", typ)
+}
+
+// CRS returns the coordinate reference system in the header of the
+// FlatGeobuf file b as organization:code, e.g. EPSG:4326, or "" if it
+// does not have one.
+func CRS(b []byte) (crs string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			crs, err = "", fmt.Errorf("flatgeobuf is corrupt: %v", r)
+		}
+	}()
+
+	if len(b) < len(magic)+4 || !bytes.Equal(b[:3], magic[:3]) {
+		return "", fmt.Errorf("not a flatgeobuf file")
+	}
+
+	header, _, err := table(b, len(magic))
+	if err != nil {
+		return "", fmt.Errorf("header: %w", err)
+	}
+
+	o := flatbuffers.UOffsetT(header.Offset(slot(headerCRS)))
+	if o == 0 {
+		return "", nil
+	}
+
+	var (
+		t    = &flatbuffers.Table{Bytes: header.Bytes, Pos: header.Indirect(header.Pos + o)}
+		org  = stringField(t, crsOrg)
+		code = t.GetInt32Slot(slot(crsCode), 0)
+	)
+	if code <= 0 {
+		return "", nil
+	}
+
+	if org == "" {
+		org = "EPSG"
+	}
+
+	return fmt.Sprintf("%s:%d", strings.ToUpper(org), code), nil
+}
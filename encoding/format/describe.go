@@ -0,0 +1,183 @@
+package format
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/logsquaredn/rototiller/pb"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// WGS84 is the CRS of all GeoJSON, per RFC 7946, and KML.
+const WGS84 = "EPSG:4326"
+
+// Column is a property of features along with the type of its values:
+// string, number, boolean, array or object, mixed if they have more than
+// one or null if they are all null.
+type Column struct {
+	Name string
+	Type string
+}
+
+// GeometryTypeMixed is the geometry type of content
+// whose features' geometries have more than one type.
+const GeometryTypeMixed = "Mixed"
+
+// description accumulates what is learned about content's features.
+type description struct {
+	bound        *orb.Bound
+	geometryType string
+	columns      map[string]string
+}
+
+func (d *description) add(fc *geojson.FeatureCollection) {
+	for _, f := range fc.Features {
+		d.addFeature(f)
+	}
+}
+
+func (d *description) addFeature(f *geojson.Feature) {
+	if d.columns == nil {
+		d.columns = map[string]string{}
+	}
+
+	if f.Geometry != nil {
+		if b := f.Geometry.Bound(); d.bound == nil {
+			d.bound = &b
+		} else {
+			*d.bound = d.bound.Union(b)
+		}
+
+		switch t := f.Geometry.GeoJSONType(); d.geometryType {
+		case "":
+			d.geometryType = t
+		case t:
+		default:
+			d.geometryType = GeometryTypeMixed
+		}
+	}
+
+	for k, v := range f.Properties {
+		t := typeOf(v)
+		switch d.columns[k] {
+		case "", "null":
+			d.columns[k] = t
+		case t, "mixed":
+		default:
+			if t != "null" {
+				d.columns[k] = "mixed"
+			}
+		}
+	}
+}
+
+// describe fills in what d learned on info.
+func (d *description) describe(info *Info) {
+	info.Bound = d.bound
+	info.GeometryType = d.geometryType
+	info.Columns = make([]*Column, 0, len(d.columns))
+	for k, t := range d.columns {
+		info.Columns = append(info.Columns, &Column{Name: k, Type: t})
+	}
+
+	sort.Slice(info.Columns, func(i, j int) bool {
+		return info.Columns[i].Name < info.Columns[j].Name
+	})
+}
+
+func typeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64, int, int64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	}
+
+	return "object"
+}
+
+var (
+	// wktAuthority matches the authority of the outermost
+	// object of WKT, which comes last, e.g. AUTHORITY["EPSG","4326"]]
+	// or, in WKT2, ID["EPSG",4326]].
+	wktAuthority = regexp.MustCompile(`(?:AUTHORITY|ID)\[\s*"([^"]+)"\s*,\s*"?(\d+)"?\s*\]\s*\]\s*$`)
+	// wktName matches the name of the outermost object of WKT.
+	wktName = regexp.MustCompile(`^\s*\w+\[\s*"([^"]*)"`)
+	// urnCRS matches OGC URNs and short names of CRSes,
+	// e.g. urn:ogc:def:crs:EPSG::3857 or EPSG:3857.
+	urnCRS = regexp.MustCompile(`(?i)(EPSG|OGC):(?:[\d.]*:)?(\w+)$`)
+)
+
+// crsFromWKT returns the authority and code of the CRS described by
+// wkt, e.g. EPSG:4326, or, failing that, its name. Esri's WKT, as
+// is often found in .prj files, has no authority, but WGS 84 is
+// recognized by name.
+func crsFromWKT(wkt string) string {
+	if wkt == "" {
+		return ""
+	}
+
+	if m := wktAuthority.FindStringSubmatch(wkt); m != nil {
+		return strings.ToUpper(m[1]) + ":" + m[2]
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(wkt), "GEOGCS") && (strings.Contains(wkt, `"GCS_WGS_1984"`) || strings.Contains(wkt, `"WGS 84"`)) {
+		return WGS84
+	}
+
+	if m := wktName.FindStringSubmatch(wkt); m != nil {
+		return m[1]
+	}
+
+	return ""
+}
+
+// crsOfGeoJSON returns the CRS named by crs, the legacy crs member of
+// a FeatureCollection, if it has one, or else WGS84, per RFC 7946.
+func crsOfGeoJSON(crs any) string {
+	member, ok := crs.(map[string]any)
+	if !ok {
+		return WGS84
+	}
+
+	properties, _ := member["properties"].(map[string]any)
+	name, _ := properties["name"].(string)
+	if m := urnCRS.FindStringSubmatch(name); m != nil {
+		if strings.EqualFold(m[1], "OGC") && strings.EqualFold(m[2], "CRS84") {
+			return WGS84
+		}
+
+		return strings.ToUpper(m[1]) + ":" + m[2]
+	}
+
+	return WGS84
+}
+
+// Describe sets the metadata of the storage that holds the content from i.
+func (i *Info) Describe(s *pb.Storage) *pb.Storage {
+	s.Format = i.Format.Name
+	s.Size = i.Size
+	s.Checksum = i.Checksum
+	s.Crs = i.CRS
+	s.Layers = i.Layers
+	s.FeatureCount = int64(i.FeatureCount)
+	s.GeometryType = i.GeometryType
+	s.Columns = make([]*pb.Column, len(i.Columns))
+	for j, c := range i.Columns {
+		s.Columns[j] = &pb.Column{Name: c.Name, Type: c.Type}
+	}
+
+	if i.Bound != nil {
+		s.Bbox = []float64{i.Bound.Min.X(), i.Bound.Min.Y(), i.Bound.Max.X(), i.Bound.Max.Y()}
+	}
+
+	return s
+}
@@ -0,0 +1,239 @@
+// Package format describes the geospatial formats that datasets can be
+// uploaded and downloaded in and how to convert each of them to and from GeoJSON.
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/logsquaredn/rototiller/encoding/csv"
+	"github.com/logsquaredn/rototiller/encoding/flatgeobuf"
+	"github.com/logsquaredn/rototiller/encoding/gpkg"
+	"github.com/logsquaredn/rototiller/encoding/kml"
+	"github.com/logsquaredn/rototiller/encoding/shapefile"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Format is a geospatial format.
+type Format struct {
+	// Name is the short name of the Format, e.g. kml.
+	Name string
+	// ContentType is the media type of the Format.
+	ContentType string
+	// Ext is the extension of files of the Format.
+	Ext string
+	// Unmarshal decodes content of the Format.
+	Unmarshal func([]byte) (*geojson.FeatureCollection, error)
+	// Marshal encodes a FeatureCollection as the Format. Formats
+	// that have layers name the FeatureCollection's layer name.
+	Marshal func(fc *geojson.FeatureCollection, name string) ([]byte, error)
+}
+
+func (f *Format) String() string {
+	return f.Name
+}
+
+// Native reports whether tasks read the Format directly. Datasets in
+// other Formats are stored alongside their normalization to GeoJSON.
+func (f *Format) Native() bool {
+	return f == GeoJSON || f == Shapefile
+}
+
+var (
+	GeoJSON = &Format{
+		Name:        "geojson",
+		ContentType: "application/json",
+		Ext:         ".json",
+		Unmarshal:   UnmarshalGeoJSON,
+		Marshal:     MarshalGeoJSON,
+	}
+	Shapefile = &Format{
+		Name:        "shapefile",
+		ContentType: "application/zip",
+		Ext:         ".zip",
+		Unmarshal:   shapefile.Unmarshal,
+		Marshal:     shapefile.Marshal,
+	}
+	GeoPackage = &Format{
+		Name:        "geopackage",
+		ContentType: "application/geopackage+sqlite3",
+		Ext:         ".gpkg",
+		Unmarshal:   gpkg.Unmarshal,
+		Marshal:     gpkg.Marshal,
+	}
+	KML = &Format{
+		Name:        "kml",
+		ContentType: "application/vnd.google-earth.kml+xml",
+		Ext:         ".kml",
+		Unmarshal:   kml.Unmarshal,
+		Marshal:     kml.Marshal,
+	}
+	CSV = &Format{
+		Name:        "csv",
+		ContentType: "text/csv",
+		Ext:         ".csv",
+		Unmarshal:   csv.Unmarshal,
+		Marshal: func(fc *geojson.FeatureCollection, _ string) ([]byte, error) {
+			return csv.Marshal(fc)
+		},
+	}
+	FlatGeobuf = &Format{
+		Name:        "flatgeobuf",
+		ContentType: "application/flatgeobuf",
+		Ext:         ".fgb",
+		Unmarshal:   flatgeobuf.Unmarshal,
+		Marshal:     flatgeobuf.Marshal,
+	}
+	GeoJSONSeq = &Format{
+		Name:        "ndjson",
+		ContentType: "application/x-ndjson",
+		Ext:         ".ndjson",
+		Unmarshal:   UnmarshalGeoJSONSeq,
+		Marshal:     MarshalGeoJSONSeq,
+	}
+)
+
+// Formats are all of the supported Formats.
+var Formats = []*Format{GeoJSON, Shapefile, GeoPackage, KML, CSV, FlatGeobuf, GeoJSONSeq}
+
+// ContentTypes returns the content types of all of the supported Formats.
+func ContentTypes() []string {
+	contentTypes := make([]string, len(Formats))
+	for i, f := range Formats {
+		contentTypes[i] = f.ContentType
+	}
+
+	return contentTypes
+}
+
+// Names returns the names of all of the supported Formats.
+func Names() []string {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = f.Name
+	}
+
+	return names
+}
+
+// FromContentType returns the Format whose content type is in contentType,
+// which may have parameters, e.g. text/csv; charset=utf-8. It is an error
+// for contentType to have none or more than one.
+func FromContentType(contentType string) (*Format, error) {
+	var found *Format
+	for _, f := range Formats {
+		if strings.Contains(contentType, f.ContentType) {
+			if found != nil {
+				return nil, fmt.Errorf("only one Content-Type among '%s' may be specified", strings.Join(ContentTypes(), "', '"))
+			}
+
+			found = f
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("must specify one Content-Type among '%s'", strings.Join(ContentTypes(), "', '"))
+	}
+
+	return found, nil
+}
+
+// FromFilename returns the Format of the file with the given name by its
+// extension, or nil if it has none. .geojson is GeoJSON, too.
+func FromFilename(name string) *Format {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".geojson" {
+		return GeoJSON
+	}
+
+	for _, f := range Formats {
+		if f.Ext == ext {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// FromName returns the Format with the given name or
+// extension, with or without its leading dot, or nil.
+func FromName(name string) *Format {
+	name = strings.ToLower(name)
+	for _, f := range Formats {
+		if f.Name == name || f.Ext == name || f.Ext == "."+name {
+			return f
+		}
+	}
+
+	return FromFilename("." + strings.TrimPrefix(name, "."))
+}
+
+// UnmarshalGeoJSON decodes a GeoJSON FeatureCollection
+// or a Feature, which is wrapped in a FeatureCollection.
+func UnmarshalGeoJSON(b []byte) (*geojson.FeatureCollection, error) {
+	if fc, err := geojson.UnmarshalFeatureCollection(b); err == nil {
+		return fc, nil
+	}
+
+	f, err := geojson.UnmarshalFeature(b)
+	if err != nil {
+		return nil, fmt.Errorf("not a GeoJSON FeatureCollection or Feature")
+	}
+
+	fc := geojson.NewFeatureCollection()
+	fc.Append(f)
+
+	return fc, nil
+}
+
+// MarshalGeoJSON encodes a GeoJSON FeatureCollection.
+func MarshalGeoJSON(fc *geojson.FeatureCollection, _ string) ([]byte, error) {
+	return fc.MarshalJSON()
+}
+
+// UnmarshalGeoJSONSeq decodes newline-delimited GeoJSON Features, each of
+// which may be preceded by an RS character per RFC 8142, into a FeatureCollection.
+func UnmarshalGeoJSONSeq(b []byte) (*geojson.FeatureCollection, error) {
+	var (
+		fc      = geojson.NewFeatureCollection()
+		scanner = bufio.NewScanner(bytes.NewReader(b))
+		line    = 0
+	)
+	scanner.Buffer(nil, len(b)+1)
+	for scanner.Scan() {
+		line++
+
+		text := bytes.TrimSpace(bytes.TrimLeft(scanner.Bytes(), "\x1e"))
+		if len(text) == 0 {
+			continue
+		}
+
+		f, err := geojson.UnmarshalFeature(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: not a GeoJSON Feature", line)
+		}
+
+		fc.Append(f)
+	}
+
+	return fc, scanner.Err()
+}
+
+// MarshalGeoJSONSeq encodes the Features of fc as newline-delimited GeoJSON.
+func MarshalGeoJSONSeq(fc *geojson.FeatureCollection, _ string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	for _, f := range fc.Features {
+		b, err := f.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,133 @@
+package format_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/encoding/format"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+func TestGeoJSONSeqRoundTrip(t *testing.T) {
+	fc := geojson.NewFeatureCollection()
+	a := geojson.NewFeature(orb.Point{1, 2})
+	a.Properties = geojson.Properties{"name": "a", "tags": []any{"x", 1.0}}
+	fc.Append(a)
+	fc.Append(&geojson.Feature{Type: "Feature", Properties: geojson.Properties{"name": nil}})
+
+	b, err := format.MarshalGeoJSONSeq(fc, "")
+	if err != nil {
+		t.Fatalf("MarshalGeoJSONSeq() = %v", err)
+	}
+
+	if lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n"); len(lines) != len(fc.Features) {
+		t.Fatalf("MarshalGeoJSONSeq() = %d lines, want %d", len(lines), len(fc.Features))
+	}
+
+	got, err := format.UnmarshalGeoJSONSeq(b)
+	if err != nil {
+		t.Fatalf("UnmarshalGeoJSONSeq() = %v", err)
+	}
+
+	if len(got.Features) != len(fc.Features) {
+		t.Fatalf("got %d features, want %d", len(got.Features), len(fc.Features))
+	}
+
+	for i, f := range got.Features {
+		want := fc.Features[i]
+		if (f.Geometry == nil) != (want.Geometry == nil) || (f.Geometry != nil && !orb.Equal(f.Geometry, want.Geometry)) {
+			t.Errorf("feature %d: got geometry %v, want %v", i, f.Geometry, want.Geometry)
+		}
+
+		if !reflect.DeepEqual(f.Properties, want.Properties) {
+			t.Errorf("feature %d: got properties %v, want %v", i, f.Properties, want.Properties)
+		}
+	}
+}
+
+func TestUnmarshalGeoJSONSeq(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       string
+		want    int
+		wantErr string
+	}{
+		{
+			name: "RFC 8142 record separators and blank lines",
+			b:    "\x1e{\"type\":\"Feature\",\"geometry\":null,\"properties\":{}}\n\n\x1e{\"type\":\"Feature\",\"geometry\":null,\"properties\":{}}\r\n",
+			want: 2,
+		},
+		{
+			name:    "not a Feature",
+			b:       "{\"type\":\"Feature\",\"geometry\":null,\"properties\":{}}\n{\"type\":\"FeatureCollection\"\n",
+			wantErr: "line 2: not a GeoJSON Feature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc, err := format.UnmarshalGeoJSONSeq([]byte(tt.b))
+			switch {
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("UnmarshalGeoJSONSeq() = %v, want error containing %q", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("UnmarshalGeoJSONSeq() = %v", err)
+			case len(fc.Features) != tt.want:
+				t.Errorf("got %d features, want %d", len(fc.Features), tt.want)
+			}
+		})
+	}
+}
+
+func TestFromName(t *testing.T) {
+	tests := []struct {
+		name string
+		want *format.Format
+	}{
+		{name: "kml", want: format.KML},
+		{name: "GeoPackage", want: format.GeoPackage},
+		{name: "gpkg", want: format.GeoPackage},
+		{name: ".fgb", want: format.FlatGeobuf},
+		{name: "ndjson", want: format.GeoJSONSeq},
+		{name: "geojson", want: format.GeoJSON},
+		{name: "json", want: format.GeoJSON},
+		{name: "zip", want: format.Shapefile},
+		{name: "gpx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := format.FromName(tt.name); got != tt.want {
+				t.Errorf("FromName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        *format.Format
+		wantErr     bool
+	}{
+		{contentType: "text/csv; charset=utf-8", want: format.CSV},
+		{contentType: "application/flatgeobuf", want: format.FlatGeobuf},
+		{contentType: "application/x-ndjson", want: format.GeoJSONSeq},
+		{contentType: "", wantErr: true},
+		{contentType: "application/xml", wantErr: true},
+		{contentType: "application/zip, text/csv", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			got, err := format.FromContentType(tt.contentType)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("FromContentType() = %v, %v, want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
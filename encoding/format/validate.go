@@ -0,0 +1,593 @@
+package format
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/logsquaredn/rototiller/encoding/flatgeobuf"
+	"github.com/logsquaredn/rototiller/encoding/gpkg"
+	"github.com/logsquaredn/rototiller/encoding/shapefile"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+var (
+	zipMagic    = []byte("PK\x03\x04")
+	sqliteMagic = []byte("SQLite format 3\x00")
+	fgbMagic    = []byte("fgb")
+)
+
+// shapefileExts are the members that each shapefile in a zip must have.
+var shapefileExts = []string{".shp", ".shx", ".dbf", ".prj"}
+
+// SniffLen is how much of the start of content Sniff looks at,
+// so that content can be sniffed before the rest of it is read.
+const SniffLen = 4096
+
+// Sniff detects the Format of b by its content, or returns
+// nil if it cannot, as is the case for CSV, which is only text.
+func Sniff(b []byte) *Format {
+	if len(b) > SniffLen {
+		b = b[:SniffLen]
+	}
+
+	switch {
+	case bytes.HasPrefix(b, zipMagic):
+		return Shapefile
+	case bytes.HasPrefix(b, sqliteMagic):
+		return GeoPackage
+	case bytes.HasPrefix(b, fgbMagic):
+		return FlatGeobuf
+	}
+
+	text := bytes.TrimLeft(bytes.TrimPrefix(b, []byte("\xef\xbb\xbf")), " \t\r\n")
+	switch {
+	case bytes.HasPrefix(text, []byte("\x1e")):
+		return GeoJSONSeq
+	case bytes.HasPrefix(text, []byte("{")):
+		// each line of newline-delimited GeoJSON is an object by itself,
+		// whereas the first line of a pretty-printed object is not
+		if line, rest, ok := bytes.Cut(text, []byte("\n")); ok && len(bytes.TrimSpace(rest)) > 0 && json.Valid(line) {
+			return GeoJSONSeq
+		}
+
+		return GeoJSON
+	case bytes.HasPrefix(text, []byte("<")):
+		head := text
+		if len(head) > 1024 {
+			head = head[:1024]
+		}
+		if bytes.Contains(head, []byte("<kml")) {
+			return KML
+		}
+	}
+
+	return nil
+}
+
+// Info is what validating content learns about it.
+type Info struct {
+	Format *Format
+	// Size is the number of bytes of the content.
+	Size int64
+	// Checksum is the hex-encoded SHA-256 of the content.
+	Checksum string
+	// CRS is the coordinate reference system of the content, e.g.
+	// EPSG:4326, or "" if it is not known.
+	CRS string
+	// Layers are the names of the content's layers, for
+	// Formats that have them, e.g. GeoPackage.
+	Layers []string
+	// FeatureCount is the number of features across all of the layers.
+	FeatureCount int
+	// Bound is the bounding box of the features
+	// across all of the layers, or nil if none have geometry.
+	Bound *orb.Bound
+	// GeometryType is the GeoJSON type of the geometries of the features
+	// across all of the layers, GeometryTypeMixed if they have more than
+	// one or "" if none have geometry.
+	GeometryType string
+	// Columns are the properties of the features
+	// across all of the layers, sorted by name.
+	Columns []*Column
+	// FeatureCollection is the content's first layer, which is the one
+	// that tasks run over, for Formats that are normalized to GeoJSON.
+	// Native Formats are stored as-is, so their features are only
+	// decoded one at a time to validate them and it is nil.
+	FeatureCollection *geojson.FeatureCollection
+}
+
+// ValidationError lists every problem found with content.
+type ValidationError struct {
+	Format   *Format
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Format, strings.Join(e.Problems, "; "))
+}
+
+// Details returns each problem.
+func (e *ValidationError) Details() []string {
+	return e.Problems
+}
+
+// ValidateHead checks that content that starts with head, of which Sniff
+// looks at no more than SniffLen bytes, is not empty and looks like the
+// given Format, e.g. as claimed by its Content-Type, so that mislabeled
+// content can be rejected before the rest of it is read.
+func ValidateHead(f *Format, head []byte) error {
+	if len(head) == 0 {
+		return &ValidationError{Format: f, Problems: []string{"content is empty"}}
+	}
+
+	// newline-delimited GeoJSON of a single Feature looks like GeoJSON
+	if sniffed := Sniff(head); sniffed != nil && sniffed != f && !(f == GeoJSONSeq && sniffed == GeoJSON) {
+		return &ValidationError{Format: f, Problems: []string{fmt.Sprintf("content looks like %s, not %s", sniffed, f)}}
+	}
+
+	return nil
+}
+
+// Validate checks that b is content of the given Format, e.g. as claimed
+// by its Content-Type, and decodes it. If it is not, a *ValidationError
+// listing every problem found is returned.
+func Validate(f *Format, b []byte) (*Info, error) {
+	if err := ValidateHead(f, b); err != nil {
+		return nil, err
+	}
+
+	var (
+		info *Info
+		err  error
+	)
+	switch f {
+	case GeoJSON:
+		info, err = ValidateGeoJSON(bytes.NewReader(b))
+	case Shapefile:
+		info, err = ValidateShapefile(bytes.NewReader(b), int64(len(b)))
+	case GeoPackage:
+		info, err = validateGeoPackage(b)
+	default:
+		info, err = validateFeatureCollection(f, b)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.Sum256(b)
+	info.Size = int64(len(b))
+	info.Checksum = hex.EncodeToString(checksum[:])
+
+	return info, nil
+}
+
+// validateFeatureCollection checks that b, which is in a Format
+// without layers that is normalized to GeoJSON, can be decoded.
+func validateFeatureCollection(f *Format, b []byte) (*Info, error) {
+	invalid := func(problems ...string) error {
+		return &ValidationError{Format: f, Problems: problems}
+	}
+
+	fc, err := f.Unmarshal(b)
+	if err != nil {
+		return nil, invalid(err.Error())
+	}
+
+	info := &Info{Format: f, FeatureCount: len(fc.Features), FeatureCollection: fc}
+	switch f {
+	case GeoJSONSeq, KML:
+		info.CRS = WGS84
+	case FlatGeobuf:
+		if info.CRS, err = flatgeobuf.CRS(b); err != nil {
+			return nil, invalid(err.Error())
+		}
+	}
+
+	d := &description{}
+	d.add(fc)
+	d.describe(info)
+
+	return info, nil
+}
+
+// ValidateShapefile checks that every shapefile in the zip of the given size
+// that is read from r has all of its members and can be decoded. Shapefiles
+// are decoded a record at a time, so the zip need not be held in memory, but
+// since a zip's directory is at its end, it cannot be validated as it is
+// read from start to end like GeoJSON can. Its Size and Checksum are left
+// unset for the caller to find out, e.g. as it is written.
+func ValidateShapefile(r io.ReaderAt, size int64) (*Info, error) {
+	a, err := shapefile.NewArchive(r, size)
+	if err != nil {
+		return nil, &ValidationError{Format: Shapefile, Problems: []string{fmt.Sprintf("not a valid zip archive: %s", err)}}
+	}
+
+	members := map[string]bool{}
+	for _, f := range a.File {
+		members[strings.ToLower(f.Name)] = true
+	}
+
+	layers := a.Layers()
+	if len(layers) == 0 {
+		return nil, &ValidationError{Format: Shapefile, Problems: []string{shapefile.ErrNoShapefile.Error()}}
+	}
+
+	var (
+		info     = &Info{Format: Shapefile, Layers: layers}
+		problems = []string{}
+		d        = &description{}
+		first    = true
+	)
+	for _, layer := range layers {
+		missing := []string{}
+		for _, ext := range shapefileExts {
+			if !members[strings.ToLower(layer+ext)] {
+				missing = append(missing, path.Base(layer)+ext)
+			}
+		}
+
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("shapefile '%s' is missing '%s'", layer, strings.Join(missing, "', '")))
+			continue
+		}
+
+		count, err := describeLayer(a, layer, d)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("shapefile '%s': %s", layer, err))
+			continue
+		}
+
+		if first {
+			first = false
+
+			prj, err := a.Projection(layer)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("shapefile '%s': %s", layer, err))
+				continue
+			}
+			info.CRS = crsFromWKT(prj)
+		}
+		info.FeatureCount += count
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Format: Shapefile, Problems: problems}
+	}
+	d.describe(info)
+
+	return info, nil
+}
+
+// describeLayer decodes each of the features of the named
+// shapefile into d, returning how many there are.
+func describeLayer(a *shapefile.Archive, layer string, d *description) (int, error) {
+	r, err := a.Open(layer)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	for count := 0; ; count++ {
+		f, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		} else if err != nil {
+			return 0, err
+		}
+
+		d.addFeature(f)
+	}
+}
+
+// validateGeoPackage checks that the GeoPackage b
+// has feature tables and that each can be decoded.
+func validateGeoPackage(b []byte) (*Info, error) {
+	layers, err := gpkg.Layers(b)
+	if err != nil {
+		return nil, &ValidationError{Format: GeoPackage, Problems: []string{err.Error()}}
+	}
+
+	if len(layers) == 0 {
+		return nil, &ValidationError{Format: GeoPackage, Problems: []string{gpkg.ErrNoLayers.Error()}}
+	}
+
+	var (
+		info     = &Info{Format: GeoPackage, Layers: layers}
+		problems = []string{}
+		d        = &description{}
+	)
+	for _, layer := range layers {
+		fc, err := gpkg.UnmarshalLayer(b, layer)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("layer '%s': %s", layer, err))
+			continue
+		}
+
+		if info.FeatureCollection == nil {
+			info.FeatureCollection = fc
+			if info.CRS, err = gpkg.SRS(b, layer); err != nil {
+				problems = append(problems, fmt.Sprintf("layer '%s': %s", layer, err))
+				continue
+			}
+		}
+		info.FeatureCount += len(fc.Features)
+		d.add(fc)
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Format: GeoPackage, Problems: problems}
+	}
+	d.describe(info)
+
+	return info, nil
+}
+
+// ValidateGeoJSON checks that what is read from r is a GeoJSON
+// FeatureCollection or Feature, pointing out where it is not, since
+// GeoJSON's decoder does not. Its features are decoded one at a time
+// as they are read, so it need not be held in memory. Its Size and
+// Checksum are left unset for the caller to find out as it is read.
+func ValidateGeoJSON(r io.Reader) (*Info, error) {
+	var (
+		pr   = &positionReader{Reader: r, lastNewline: -1}
+		info = &Info{Format: GeoJSON}
+		d    = &description{}
+	)
+	if err := decodeGeoJSON(json.NewDecoder(pr), pr, info, d); err != nil {
+		if pr.err != nil {
+			// not a problem with the content, but with reading it
+			return nil, pr.err
+		}
+
+		var syntaxErr *json.SyntaxError
+		switch {
+		case errors.As(err, &syntaxErr):
+			line, col := pr.position(syntaxErr.Offset)
+			err = fmt.Errorf("invalid JSON at line %d, column %d: %s", line, col, syntaxErr)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			err = fmt.Errorf("invalid JSON: unexpected end of JSON input")
+		}
+
+		return nil, &ValidationError{Format: GeoJSON, Problems: []string{err.Error()}}
+	}
+	d.describe(info)
+
+	return info, nil
+}
+
+// featureMembers are the members of a Feature that are kept
+// to decode it once its type is known. Any others are skipped.
+var featureMembers = map[string]bool{
+	"type": true, "id": true, "bbox": true, "geometry": true, "properties": true, "crs": true,
+}
+
+// decodeGeoJSON decodes the object read by dec into info and d, one
+// member at a time, and each feature of a FeatureCollection's
+// "features" one at a time, since the object's type may come after them.
+func decodeGeoJSON(dec *json.Decoder, pr *positionReader, info *Info, d *description) error {
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return fmt.Errorf("content must be a JSON object")
+	}
+
+	var (
+		members     = map[string]json.RawMessage{}
+		hasFeatures bool
+	)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch key, _ := tok.(string); {
+		case key == "features":
+			hasFeatures = true
+			if err = decodeFeatures(dec, pr, info, d); err != nil {
+				return err
+			}
+		case featureMembers[key]:
+			var raw json.RawMessage
+			if err = dec.Decode(&raw); err != nil {
+				return err
+			}
+			members[key] = raw
+		default:
+			if err = skipValue(dec); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	if _, err := dec.Token(); err == nil {
+		return fmt.Errorf("content must be only one JSON object")
+	} else if !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	var t string
+	if raw, ok := members["type"]; ok {
+		var typeErr *json.UnmarshalTypeError
+		if err := json.Unmarshal(raw, &t); errors.As(err, &typeErr) {
+			return fmt.Errorf("'type' must not be a JSON %s", typeErr.Value)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	switch t {
+	case "FeatureCollection":
+		var crs any
+		if raw, ok := members["crs"]; ok {
+			if err := json.Unmarshal(raw, &crs); err != nil {
+				return err
+			}
+		}
+		info.CRS = crsOfGeoJSON(crs)
+	case "Feature":
+		if hasFeatures {
+			return fmt.Errorf("a Feature must not have 'features'")
+		}
+
+		b, err := json.Marshal(members)
+		if err != nil {
+			return err
+		}
+
+		f, err := geojson.UnmarshalFeature(b)
+		if err != nil {
+			return fmt.Errorf("not a GeoJSON FeatureCollection or Feature")
+		}
+
+		info.CRS = WGS84
+		info.FeatureCount++
+		d.addFeature(f)
+	case "":
+		return fmt.Errorf("'type' must be 'FeatureCollection' or 'Feature'")
+	default:
+		return fmt.Errorf("'type' must be 'FeatureCollection' or 'Feature', not '%s'", t)
+	}
+
+	return nil
+}
+
+// decodeFeatures decodes the features of the "features" array
+// read by dec into info and d, holding one of them at a time.
+func decodeFeatures(dec *json.Decoder, pr *positionReader, info *Info, d *description) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('['):
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("'features' must not be a JSON %s", kindOf(tok))
+	}
+
+	for i := 0; dec.More(); i++ {
+		// a syntax error can only be after here
+		pr.forget(dec.InputOffset())
+
+		var raw json.RawMessage
+		if err = dec.Decode(&raw); err != nil {
+			return err
+		}
+
+		f, err := geojson.UnmarshalFeature(raw)
+		if err != nil {
+			return fmt.Errorf("feature %d: %w", i, err)
+		}
+
+		info.FeatureCount++
+		d.addFeature(f)
+	}
+
+	_, err = dec.Token()
+	return err
+}
+
+// kindOf returns the kind of the JSON value that starts with tok.
+func kindOf(tok json.Token) string {
+	switch tok.(type) {
+	case json.Delim:
+		if tok == json.Delim('[') {
+			return "array"
+		}
+
+		return "object"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	}
+
+	return "null"
+}
+
+// skipValue skips the next value read by dec without holding all of it.
+func skipValue(dec *json.Decoder) error {
+	for depth := 0; ; {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// positionReader reads from Reader, keeping track of where the lines of
+// what it read start, but only after the offset that it was last told
+// to forget before, so that the line and column of a syntax error can
+// be found without holding all of what was read.
+type positionReader struct {
+	io.Reader
+	// err is the error that reading failed with, if any
+	err  error
+	read int64
+	// lines is how many newlines were forgotten, the
+	// last of which was at lastNewline, or -1 if none
+	lines       int
+	lastNewline int64
+	newlines    []int64
+}
+
+func (r *positionReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	for i, c := range p[:n] {
+		if c == '\n' {
+			r.newlines = append(r.newlines, r.read+int64(i))
+		}
+	}
+	r.read += int64(n)
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.err = err
+	}
+
+	return n, err
+}
+
+// forget forgets where the lines before offset start.
+func (r *positionReader) forget(offset int64) {
+	i := 0
+	for ; i < len(r.newlines) && r.newlines[i] < offset; i++ {
+		r.lastNewline = r.newlines[i]
+	}
+
+	r.lines += i
+	r.newlines = r.newlines[i:]
+}
+
+// position returns the line and column of the offset, both from 1.
+func (r *positionReader) position(offset int64) (int, int) {
+	r.forget(offset)
+	return r.lines + 1, int(offset - r.lastNewline)
+}
@@ -0,0 +1,27 @@
+package format
+
+import (
+	"bytes"
+
+	"github.com/logsquaredn/rototiller/volume"
+)
+
+// Volume returns a volume of the validated content, named name plus the
+// extension of its Format. Formats that tasks cannot read are stored
+// as-is alongside their normalization to GeoJSON.
+func (i *Info) Volume(name string, b []byte) (volume.Volume, error) {
+	file := volume.NewFile(name+i.Format.Ext, bytes.NewReader(b), len(b))
+	if i.Format.Native() {
+		return volume.New(file), nil
+	}
+
+	normalized, err := i.FeatureCollection.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return volume.New(
+		file,
+		volume.NewFile(name+GeoJSON.Ext, bytes.NewReader(normalized), len(normalized)),
+	), nil
+}
@@ -0,0 +1,121 @@
+// Package gpkg encodes and decodes GeoJSON FeatureCollections as the
+// feature tables of GeoPackages, as served as application/geopackage+sqlite3.
+//
+// Only 2D geometries are read and written. Z and M values are dropped.
+package gpkg
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	// register the pure Go sqlite driver
+	_ "modernc.org/sqlite"
+)
+
+const driverName = "sqlite"
+
+// open opens the GeoPackage b, which sqlite can only read from a file, so
+// b is written to a temporary one that the returned func removes.
+func open(b []byte) (*sql.DB, func(), error) {
+	f, err := os.CreateTemp("", "*.gpkg")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		os.Remove(f.Name())
+	}
+
+	if _, err = f.Write(b); err != nil {
+		f.Close()
+		cleanup()
+		return nil, nil, err
+	}
+
+	if err = f.Close(); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	db, err := sql.Open(driverName, "file:"+f.Name()+"?mode=ro")
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	return db, func() {
+		db.Close()
+		cleanup()
+	}, nil
+}
+
+// quote quotes name as an SQL identifier.
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Layers returns the sorted names of the feature tables of the GeoPackage b.
+func Layers(b []byte) ([]string, error) {
+	db, closeDB, err := open(b)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	return layers(db)
+}
+
+func layers(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT table_name FROM gpkg_contents WHERE data_type = 'features' ORDER BY table_name")
+	if err != nil {
+		return nil, fmt.Errorf("not a geopackage: %w", err)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+// SRS returns the spatial reference system of the named feature table's
+// geometry column as organization:code, e.g. EPSG:4326, or "" if it is
+// one of the undefined ones.
+func SRS(b []byte, layer string) (string, error) {
+	db, closeDB, err := open(b)
+	if err != nil {
+		return "", err
+	}
+	defer closeDB()
+
+	var (
+		org  string
+		code int64
+	)
+	if err = db.QueryRow(
+		"SELECT s.organization, s.organization_coordsys_id FROM gpkg_geometry_columns g INNER JOIN gpkg_spatial_ref_sys s ON g.srs_id = s.srs_id WHERE g.table_name = ?",
+		layer,
+	).Scan(&org, &code); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	if strings.EqualFold(org, "NONE") || code <= 0 {
+		return "", nil
+	}
+
+	return fmt.Sprintf("%s:%d", strings.ToUpper(org), code), nil
+}
@@ -0,0 +1,215 @@
+package gpkg_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/encoding/gpkg"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// parcels is what the parcels table of testdata/parcels.gpkg decodes to: a
+// polygon with a hole and a second shell, a null geometry, a polygon with Z
+// values and an empty geometry. Its fid is the ID of each feature and its
+// BOOLEAN column, whose values are integers, is of booleans.
+var parcels = []struct {
+	properties geojson.Properties
+	geometry   orb.Geometry
+}{
+	{
+		properties: geojson.Properties{"NAME": "lot a", "AREA": 121.0, "LOTS": 2.0, "VACANT": false},
+		geometry: orb.MultiPolygon{
+			{
+				{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+				{{2, 2}, {2, 4}, {4, 4}, {4, 2}, {2, 2}},
+			},
+			{
+				{{20, 0}, {25, 0}, {25, 5}, {20, 5}, {20, 0}},
+			},
+		},
+	},
+	{
+		properties: geojson.Properties{"NAME": "vacant", "AREA": nil, "LOTS": 0.0, "VACANT": true},
+	},
+	{
+		properties: geojson.Properties{"NAME": "lot c", "AREA": 1.0, "LOTS": 1.0, "VACANT": false},
+		geometry:   orb.Polygon{{{30, 0}, {31, 0}, {31, 1}, {30, 1}, {30, 0}}},
+	},
+	{
+		properties: geojson.Properties{"NAME": "lot d", "AREA": nil, "LOTS": nil, "VACANT": nil},
+	},
+}
+
+func TestUnmarshal(t *testing.T) {
+	fc, err := gpkg.Unmarshal(read(t))
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	assertParcels(t, fc)
+}
+
+func TestLayers(t *testing.T) {
+	b := read(t)
+
+	layers, err := gpkg.Layers(b)
+	if err != nil {
+		t.Fatalf("Layers() = %v", err)
+	}
+
+	if want := []string{"parcels", "roads"}; !reflect.DeepEqual(layers, want) {
+		t.Fatalf("Layers() = %v, want %v", layers, want)
+	}
+
+	fc, err := gpkg.UnmarshalLayer(b, "roads")
+	if err != nil {
+		t.Fatalf("UnmarshalLayer() = %v", err)
+	}
+
+	if len(fc.Features) != 1 || !orb.Equal(fc.Features[0].Geometry, orb.LineString{{0, 0}, {31, 0}}) {
+		t.Errorf("UnmarshalLayer() = %v, want main st", fc.Features)
+	}
+}
+
+func TestSRS(t *testing.T) {
+	b := read(t)
+
+	tests := []struct {
+		layer string
+		want  string
+	}{
+		{layer: "parcels", want: "EPSG:4326"},
+		// the undefined geographic SRS
+		{layer: "roads", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layer, func(t *testing.T) {
+			if got, err := gpkg.SRS(b, tt.layer); err != nil || got != tt.want {
+				t.Errorf("SRS() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	if _, err := gpkg.Unmarshal([]byte("not a geopackage")); err == nil {
+		t.Error("Unmarshal() = nil, want error")
+	}
+
+	// only has a tile table
+	b, err := os.ReadFile(filepath.Join("testdata", "empty.gpkg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = gpkg.Unmarshal(b); !errors.Is(err, gpkg.ErrNoLayers) {
+		t.Errorf("Unmarshal() = %v, want %v", err, gpkg.ErrNoLayers)
+	}
+
+	if _, err = gpkg.UnmarshalLayer(read(t), "lakes"); err == nil {
+		t.Error("UnmarshalLayer() = nil, want error for a table that does not exist")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	fc, err := gpkg.Unmarshal(read(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := gpkg.Marshal(fc, "output")
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+
+	if layers, err := gpkg.Layers(b); err != nil || !reflect.DeepEqual(layers, []string{"output"}) {
+		t.Fatalf("Layers() = %v, %v, want [output]", layers, err)
+	}
+
+	if srs, err := gpkg.SRS(b, "output"); err != nil || srs != "EPSG:4326" {
+		t.Errorf("SRS() = %q, %v, want EPSG:4326", srs, err)
+	}
+
+	got, err := gpkg.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	assertParcels(t, got)
+}
+
+func TestMarshalColumnTypes(t *testing.T) {
+	fc := geojson.NewFeatureCollection()
+	for _, props := range []geojson.Properties{
+		{"mixed": "a", "number": 1.0, "tags": []any{"a"}},
+		{"mixed": 3.0, "number": 1.5, "tags": nil},
+	} {
+		f := geojson.NewFeature(orb.Point{1, 2})
+		f.Properties = props
+		fc.Append(f)
+	}
+
+	b, err := gpkg.Marshal(fc, "output")
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+
+	got, err := gpkg.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	// columns of more than one type, arrays and objects are text
+	want := []geojson.Properties{
+		{"mixed": "a", "number": 1.0, "tags": `["a"]`},
+		{"mixed": "3", "number": 1.5, "tags": nil},
+	}
+	for i, f := range got.Features {
+		if !reflect.DeepEqual(f.Properties, want[i]) {
+			t.Errorf("feature %d: got properties %v, want %v", i, f.Properties, want[i])
+		}
+	}
+}
+
+func assertParcels(t *testing.T, fc *geojson.FeatureCollection) {
+	t.Helper()
+
+	if len(fc.Features) != len(parcels) {
+		t.Fatalf("got %d features, want %d", len(fc.Features), len(parcels))
+	}
+
+	for i, want := range parcels {
+		f := fc.Features[i]
+		if f.ID != float64(i+1) {
+			t.Errorf("feature %d: got ID %v, want %d", i, f.ID, i+1)
+		}
+
+		if !reflect.DeepEqual(f.Properties, want.properties) {
+			t.Errorf("feature %d: got properties %v, want %v", i, f.Properties, want.properties)
+		}
+
+		if want.geometry == nil {
+			if f.Geometry != nil {
+				t.Errorf("feature %d: got geometry %v, want null", i, f.Geometry)
+			}
+		} else if f.Geometry == nil || !orb.Equal(f.Geometry, want.geometry) {
+			t.Errorf("feature %d: got geometry %v, want %v", i, f.Geometry, want.geometry)
+		}
+	}
+}
+
+func read(t *testing.T) []byte {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", "parcels.gpkg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
@@ -0,0 +1,316 @@
+package gpkg
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkb"
+	"github.com/paulmach/orb/geojson"
+)
+
+const (
+	// applicationID is "GPKG" as a big-endian integer.
+	applicationID = 0x47504B47
+	// userVersion is GeoPackage version 1.3.0.
+	userVersion = 10300
+	// srsID is WGS 84, which GeoJSON coordinates are always in.
+	srsID = 4326
+
+	fidColumn  = "fid"
+	geomColumn = "geom"
+)
+
+// schema creates the tables that every GeoPackage must have.
+var schema = []string{
+	`CREATE TABLE gpkg_spatial_ref_sys (
+		srs_name TEXT NOT NULL,
+		srs_id INTEGER PRIMARY KEY,
+		organization TEXT NOT NULL,
+		organization_coordsys_id INTEGER NOT NULL,
+		definition TEXT NOT NULL,
+		description TEXT
+	)`,
+	`INSERT INTO gpkg_spatial_ref_sys VALUES
+		('Undefined cartesian SRS', -1, 'NONE', -1, 'undefined', 'undefined cartesian coordinate reference system'),
+		('Undefined geographic SRS', 0, 'NONE', 0, 'undefined', 'undefined geographic coordinate reference system'),
+		('WGS 84 geodetic', 4326, 'EPSG', 4326, 'GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563,AUTHORITY["EPSG","7030"]],AUTHORITY["EPSG","6326"]],PRIMEM["Greenwich",0,AUTHORITY["EPSG","8901"]],UNIT["degree",0.0174532925199433,AUTHORITY["EPSG","9122"]],AUTHORITY["EPSG","4326"]]', 'longitude/latitude coordinates in decimal degrees on the WGS 84 spheroid')`,
+	`CREATE TABLE gpkg_contents (
+		table_name TEXT NOT NULL PRIMARY KEY,
+		data_type TEXT NOT NULL,
+		identifier TEXT UNIQUE,
+		description TEXT DEFAULT '',
+		last_change DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ','now')),
+		min_x DOUBLE,
+		min_y DOUBLE,
+		max_x DOUBLE,
+		max_y DOUBLE,
+		srs_id INTEGER,
+		CONSTRAINT fk_gc_r_srs_id FOREIGN KEY (srs_id) REFERENCES gpkg_spatial_ref_sys(srs_id)
+	)`,
+	`CREATE TABLE gpkg_geometry_columns (
+		table_name TEXT NOT NULL,
+		column_name TEXT NOT NULL,
+		geometry_type_name TEXT NOT NULL,
+		srs_id INTEGER NOT NULL,
+		z TINYINT NOT NULL,
+		m TINYINT NOT NULL,
+		CONSTRAINT pk_geom_cols PRIMARY KEY (table_name, column_name),
+		CONSTRAINT fk_gc_tn FOREIGN KEY (table_name) REFERENCES gpkg_contents(table_name),
+		CONSTRAINT fk_gc_srs FOREIGN KEY (srs_id) REFERENCES gpkg_spatial_ref_sys (srs_id)
+	)`,
+}
+
+// Marshal encodes fc as a GeoPackage with a single feature table named
+// name. The type of each column is inferred from the property's values:
+// INTEGER if they are all whole numbers, REAL if they are all numbers,
+// BOOLEAN if they are all booleans and TEXT otherwise, with arrays and
+// objects as JSON.
+func Marshal(fc *geojson.FeatureCollection, name string) ([]byte, error) {
+	f, err := os.CreateTemp("", "*.gpkg")
+	if err != nil {
+		return nil, err
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	if err = write(path, fc, name); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path)
+}
+
+func write(path string, fc *geojson.FeatureCollection, name string) error {
+	db, err := sql.Open(driverName, path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	for _, stmt := range append([]string{
+		fmt.Sprintf("PRAGMA application_id = %d", applicationID),
+		fmt.Sprintf("PRAGMA user_version = %d", userVersion),
+	}, schema...) {
+		if _, err = tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	columns := columnsOf(fc)
+	defs := []string{quote(fidColumn) + " INTEGER PRIMARY KEY AUTOINCREMENT", quote(geomColumn) + " " + geometryTypeName(fc)}
+	for _, c := range columns {
+		defs = append(defs, quote(c.name)+" "+c.typ)
+	}
+
+	if _, err = tx.Exec(fmt.Sprintf("CREATE TABLE %s (%s)", quote(name), strings.Join(defs, ", "))); err != nil {
+		return err
+	}
+
+	bound := fc.BBox.Bound()
+	if len(fc.BBox) == 0 {
+		var b *orb.Bound
+		for _, f := range fc.Features {
+			if f.Geometry == nil {
+				continue
+			}
+
+			if fb := f.Geometry.Bound(); b == nil {
+				b = &fb
+			} else {
+				*b = b.Union(fb)
+			}
+		}
+
+		if b != nil {
+			bound = *b
+		}
+	}
+
+	if _, err = tx.Exec(
+		"INSERT INTO gpkg_contents (table_name, data_type, identifier, min_x, min_y, max_x, max_y, srs_id) VALUES (?, 'features', ?, ?, ?, ?, ?, ?)",
+		name, name, bound.Min.X(), bound.Min.Y(), bound.Max.X(), bound.Max.Y(), srsID,
+	); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(
+		"INSERT INTO gpkg_geometry_columns VALUES (?, ?, ?, ?, 0, 0)",
+		name, geomColumn, geometryTypeName(fc), srsID,
+	); err != nil {
+		return err
+	}
+
+	placeholders := strings.Repeat(", ?", len(columns))
+	names := []string{quote(geomColumn)}
+	for _, c := range columns {
+		names = append(names, quote(c.name))
+	}
+
+	insert, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES (?%s)", quote(name), strings.Join(names, ", "), placeholders))
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	for i, f := range fc.Features {
+		blob, err := encodeGeometry(f.Geometry)
+		if err != nil {
+			return fmt.Errorf("feature %d: %w", i, err)
+		}
+
+		values := []any{blob}
+		for _, c := range columns {
+			values = append(values, c.value(f.Properties[c.name]))
+		}
+
+		if _, err = insert.Exec(values...); err != nil {
+			return fmt.Errorf("feature %d: %w", i, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+type column struct {
+	name, typ string
+}
+
+// value converts a property's value into one that sqlite can hold.
+func (c *column) value(v any) any {
+	switch v := v.(type) {
+	case nil, string, float64, bool:
+		if c.typ == "TEXT" && v != nil {
+			return fmt.Sprint(v)
+		}
+
+		return v
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
+
+// columnsOf infers a column for each property of the features of fc, sorted by name.
+func columnsOf(fc *geojson.FeatureCollection) []*column {
+	kinds := map[string]map[string]bool{}
+	for _, f := range fc.Features {
+		for k, v := range f.Properties {
+			if k == fidColumn || k == geomColumn {
+				// taken
+				continue
+			}
+
+			if kinds[k] == nil {
+				kinds[k] = map[string]bool{}
+			}
+
+			switch v := v.(type) {
+			case nil:
+			case float64:
+				if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+					kinds[k]["INTEGER"] = true
+				} else {
+					kinds[k]["REAL"] = true
+				}
+			case bool:
+				kinds[k]["BOOLEAN"] = true
+			default:
+				kinds[k]["TEXT"] = true
+			}
+		}
+	}
+
+	columns := make([]*column, 0, len(kinds))
+	for k, ks := range kinds {
+		c := &column{name: k, typ: "TEXT"}
+		switch {
+		case len(ks) == 1 && !ks["TEXT"]:
+			for typ := range ks {
+				c.typ = typ
+			}
+		case len(ks) == 2 && ks["INTEGER"] && ks["REAL"]:
+			c.typ = "REAL"
+		}
+
+		columns = append(columns, c)
+	}
+
+	sort.Slice(columns, func(i, j int) bool {
+		return columns[i].name < columns[j].name
+	})
+
+	return columns
+}
+
+// geometryTypeName returns the type that the geometries
+// of fc have in common, or GEOMETRY if they have none.
+func geometryTypeName(fc *geojson.FeatureCollection) string {
+	name := ""
+	for _, f := range fc.Features {
+		if f.Geometry == nil {
+			continue
+		}
+
+		// e.g. MultiPolygon => MULTIPOLYGON
+		t := strings.ToUpper(f.Geometry.GeoJSONType())
+
+		switch name {
+		case "":
+			name = t
+		case t:
+		default:
+			return "GEOMETRY"
+		}
+	}
+
+	if name == "" {
+		return "GEOMETRY"
+	}
+
+	return name
+}
+
+// encodeGeometry encodes g as a GeoPackage geometry blob: a little-endian
+// header with its envelope followed by g as WKB. A nil g is NULL.
+func encodeGeometry(g orb.Geometry) ([]byte, error) {
+	if g == nil {
+		return nil, nil
+	}
+
+	b, err := wkb.Marshal(g, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		bound  = g.Bound()
+		header = make([]byte, 8+32)
+	)
+	header[0], header[1] = 'G', 'P'
+	// little-endian with an xy envelope
+	header[3] = 0x01 | 0x01<<1
+	binary.LittleEndian.PutUint32(header[4:], srsID)
+	for i, f := range []float64{bound.Min.X(), bound.Max.X(), bound.Min.Y(), bound.Max.Y()} {
+		binary.LittleEndian.PutUint64(header[8+i*8:], math.Float64bits(f))
+	}
+
+	return append(header, b...), nil
+}
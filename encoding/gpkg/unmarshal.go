@@ -0,0 +1,205 @@
+package gpkg
+
+import (
+	"database/sql"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/peterstace/simplefeatures/geom"
+)
+
+// ErrNoLayers is returned by Unmarshal when
+// the GeoPackage does not have a feature table.
+var ErrNoLayers = fmt.Errorf("geopackage has no feature tables")
+
+// Unmarshal decodes the first feature table of the GeoPackage b.
+func Unmarshal(b []byte) (*geojson.FeatureCollection, error) {
+	db, closeDB, err := open(b)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	names, err := layers(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		return nil, ErrNoLayers
+	}
+
+	return unmarshalLayer(db, names[0])
+}
+
+// UnmarshalLayer decodes the named feature table of the GeoPackage b.
+// Its primary key becomes the ID of each feature and its
+// other columns, other than its geometry, properties.
+func UnmarshalLayer(b []byte, layer string) (*geojson.FeatureCollection, error) {
+	db, closeDB, err := open(b)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	return unmarshalLayer(db, layer)
+}
+
+func unmarshalLayer(db *sql.DB, layer string) (*geojson.FeatureCollection, error) {
+	var geomCol string
+	if err := db.QueryRow("SELECT column_name FROM gpkg_geometry_columns WHERE table_name = ?", layer).Scan(&geomCol); err != nil {
+		return nil, fmt.Errorf("find geometry column of %s: %w", layer, err)
+	}
+
+	pkCol, err := primaryKey(db, layer)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT * FROM " + quote(layer))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	fc := geojson.NewFeatureCollection()
+	for rows.Next() {
+		var (
+			values = make([]any, len(columns))
+			ptrs   = make([]any, len(columns))
+		)
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+
+		if err = rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		f := &geojson.Feature{Type: "Feature", Properties: geojson.Properties{}}
+		for i, column := range columns {
+			switch column {
+			case geomCol:
+				if values[i] == nil {
+					continue
+				}
+
+				b, ok := values[i].([]byte)
+				if !ok {
+					return nil, fmt.Errorf("%s feature %d: geometry is not a blob", layer, len(fc.Features))
+				}
+
+				if f.Geometry, err = decodeGeometry(b); err != nil {
+					return nil, fmt.Errorf("%s feature %d: %w", layer, len(fc.Features), err)
+				}
+			case pkCol:
+				f.ID = value(values[i], "")
+			default:
+				f.Properties[column] = value(values[i], types[i].DatabaseTypeName())
+			}
+		}
+
+		fc.Append(f)
+	}
+
+	return fc, rows.Err()
+}
+
+// primaryKey returns the name of the primary key column of the table.
+func primaryKey(db *sql.DB, table string) (string, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?) WHERE pk = 1", table)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var pk string
+	for rows.Next() {
+		if err = rows.Scan(&pk); err != nil {
+			return "", err
+		}
+	}
+
+	return pk, rows.Err()
+}
+
+// value converts a value from sqlite of a column of the given declared type
+// into one that GeoJSON can hold. sqlite has no booleans, so GeoPackages
+// store them as integers in BOOLEAN columns.
+func value(v any, typ string) any {
+	switch v := v.(type) {
+	case int64:
+		if typ == "BOOLEAN" {
+			return v != 0
+		}
+
+		return float64(v)
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+
+		// binary values have no GeoJSON equivalent
+		return nil
+	}
+
+	return v
+}
+
+// envelopeSizes are the sizes of the envelopes of geometry blobs,
+// indexed by the envelope contents indicator of their flags.
+var envelopeSizes = []int{0, 32, 48, 48, 64}
+
+// decodeGeometry decodes a GeoPackage geometry blob, which is a header
+// followed by the geometry as WKB.
+func decodeGeometry(b []byte) (orb.Geometry, error) {
+	if len(b) < 8 || b[0] != 'G' || b[1] != 'P' {
+		return nil, fmt.Errorf("not a geopackage geometry")
+	}
+
+	flags := b[3]
+	if flags&0x20 != 0 {
+		return nil, fmt.Errorf("extended geopackage geometries are not supported")
+	}
+
+	if flags&0x10 != 0 {
+		// empty
+		return nil, nil
+	}
+
+	envelope := int(flags>>1) & 0x07
+	if envelope >= len(envelopeSizes) {
+		return nil, fmt.Errorf("invalid envelope contents indicator %d", envelope)
+	}
+
+	offset := 8 + envelopeSizes[envelope]
+	if len(b) < offset {
+		return nil, fmt.Errorf("geometry is truncated")
+	}
+
+	// read through simplefeatures, which understands Z and M
+	// values, to drop them before handing the geometry to orb
+	g, err := geom.UnmarshalWKB(b[offset:], geom.NoValidate{})
+	if err != nil {
+		return nil, err
+	}
+
+	if g.IsEmpty() {
+		return nil, nil
+	}
+
+	return wkb.Unmarshal(g.Force2D().AsBinary())
+}
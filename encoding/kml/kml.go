@@ -0,0 +1,313 @@
+// Package kml encodes and decodes GeoJSON FeatureCollections as
+// KML documents, as served as application/vnd.google-earth.kml+xml.
+//
+// Only Placemarks are read, along with their name, description and
+// ExtendedData, which become properties. Styles, overlays and altitudes
+// are dropped.
+package kml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// node is any KML element. Since Placemarks can be nested arbitrarily deep
+// in Documents and Folders, and geometries in MultiGeometries, the document
+// is decoded generically and then walked.
+type node struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Content  string     `xml:",chardata"`
+	Children []*node    `xml:",any"`
+}
+
+func (n *node) child(name string) *node {
+	for _, c := range n.Children {
+		if c.XMLName.Local == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func (n *node) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+func (n *node) text() string {
+	return strings.TrimSpace(n.Content)
+}
+
+// Unmarshal decodes the Placemarks of the KML document b, in document order.
+func Unmarshal(b []byte) (*geojson.FeatureCollection, error) {
+	root := &node{}
+	d := xml.NewDecoder(bytes.NewReader(b))
+	// KML is UTF-8 by definition, so ignore whatever encoding the
+	// declaration claims rather than failing on it
+	d.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) {
+		return r, nil
+	}
+	if err := d.Decode(root); err != nil {
+		return nil, fmt.Errorf("decode kml: %w", err)
+	}
+
+	if root.XMLName.Local != "kml" {
+		return nil, fmt.Errorf("root element is '%s', not 'kml'", root.XMLName.Local)
+	}
+
+	fc := geojson.NewFeatureCollection()
+	var walk func(*node) error
+	walk = func(n *node) error {
+		if n.XMLName.Local == "Placemark" {
+			f, err := placemark(n)
+			if err != nil {
+				return fmt.Errorf("placemark %d: %w", len(fc.Features), err)
+			}
+
+			fc.Append(f)
+			return nil
+		}
+
+		for _, c := range n.Children {
+			if err := walk(c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+
+	return fc, nil
+}
+
+func placemark(n *node) (*geojson.Feature, error) {
+	f := &geojson.Feature{Type: "Feature", Properties: geojson.Properties{}}
+	if id := n.attr("id"); id != "" {
+		f.ID = id
+	}
+
+	for _, c := range n.Children {
+		switch c.XMLName.Local {
+		case "name", "description":
+			f.Properties[c.XMLName.Local] = c.text()
+		case "ExtendedData":
+			extendedData(c, f.Properties)
+		default:
+			g, ok, err := geometry(c)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				f.Geometry = g
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// extendedData reads both untyped Data and the SimpleData of SchemaData into props.
+func extendedData(n *node, props geojson.Properties) {
+	for _, c := range n.Children {
+		switch c.XMLName.Local {
+		case "Data":
+			if v := c.child("value"); v != nil {
+				props[c.attr("name")] = v.text()
+			}
+		case "SchemaData":
+			for _, sd := range c.Children {
+				if sd.XMLName.Local == "SimpleData" {
+					props[sd.attr("name")] = sd.text()
+				}
+			}
+		}
+	}
+}
+
+// geometry decodes n if it is a geometry, reporting whether it was.
+func geometry(n *node) (orb.Geometry, bool, error) {
+	switch n.XMLName.Local {
+	case "Point":
+		ps, err := coordinates(n)
+		if err != nil {
+			return nil, true, err
+		}
+
+		if len(ps) != 1 {
+			return nil, true, fmt.Errorf("Point has %d coordinates", len(ps))
+		}
+
+		return ps[0], true, nil
+	case "LineString":
+		ps, err := coordinates(n)
+		if err != nil {
+			return nil, true, err
+		}
+
+		return orb.LineString(ps), true, nil
+	case "LinearRing":
+		ps, err := coordinates(n)
+		if err != nil {
+			return nil, true, err
+		}
+
+		return orb.Polygon{ring(ps, orb.CCW)}, true, nil
+	case "Polygon":
+		p := orb.Polygon{}
+		for _, boundary := range []string{"outerBoundaryIs", "innerBoundaryIs"} {
+			for _, c := range n.Children {
+				if c.XMLName.Local != boundary {
+					continue
+				}
+
+				lr := c.child("LinearRing")
+				if lr == nil {
+					return nil, true, fmt.Errorf("%s has no LinearRing", boundary)
+				}
+
+				ps, err := coordinates(lr)
+				if err != nil {
+					return nil, true, err
+				}
+
+				if boundary == "outerBoundaryIs" {
+					p = append(orb.Polygon{ring(ps, orb.CCW)}, p...)
+				} else {
+					p = append(p, ring(ps, orb.CW))
+				}
+			}
+		}
+
+		if len(p) == 0 {
+			return nil, true, fmt.Errorf("Polygon has no outerBoundaryIs")
+		}
+
+		return p, true, nil
+	case "MultiGeometry":
+		c := orb.Collection{}
+		for _, m := range n.Children {
+			g, ok, err := geometry(m)
+			if err != nil {
+				return nil, true, err
+			}
+
+			if ok && g != nil {
+				c = append(c, g)
+			}
+		}
+
+		return homogenize(c), true, nil
+	}
+
+	return nil, false, nil
+}
+
+// homogenize turns a Collection whose members are all of one
+// type into the corresponding multi-geometry.
+func homogenize(c orb.Collection) orb.Geometry {
+	if len(c) == 0 {
+		return nil
+	}
+
+	switch c[0].(type) {
+	case orb.Point:
+		mp := orb.MultiPoint{}
+		for _, g := range c {
+			p, ok := g.(orb.Point)
+			if !ok {
+				return c
+			}
+			mp = append(mp, p)
+		}
+
+		return mp
+	case orb.LineString:
+		mls := orb.MultiLineString{}
+		for _, g := range c {
+			ls, ok := g.(orb.LineString)
+			if !ok {
+				return c
+			}
+			mls = append(mls, ls)
+		}
+
+		return mls
+	case orb.Polygon:
+		mp := orb.MultiPolygon{}
+		for _, g := range c {
+			p, ok := g.(orb.Polygon)
+			if !ok {
+				return c
+			}
+			mp = append(mp, p)
+		}
+
+		return mp
+	}
+
+	return c
+}
+
+// coordinates parses the coordinates of n, which are tuples of
+// lon,lat[,alt] separated by whitespace. Altitudes are dropped.
+func coordinates(n *node) ([]orb.Point, error) {
+	c := n.child("coordinates")
+	if c == nil {
+		return nil, fmt.Errorf("%s has no coordinates", n.XMLName.Local)
+	}
+
+	ps := []orb.Point{}
+	for _, tuple := range strings.Fields(c.Content) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid coordinate '%s'", tuple)
+		}
+
+		var p orb.Point
+		for i := range p {
+			f, err := strconv.ParseFloat(parts[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid coordinate '%s'", tuple)
+			}
+			p[i] = f
+		}
+
+		ps = append(ps, p)
+	}
+
+	return ps, nil
+}
+
+// ring returns a closed ring of ps wound in the given orientation,
+// since KML does not prescribe the winding of rings.
+func ring(ps []orb.Point, o orb.Orientation) orb.Ring {
+	r := orb.Ring(ps)
+	if len(r) > 0 && !r.Closed() {
+		r = append(r, r[0])
+	}
+
+	if r.Orientation() != o {
+		r.Reverse()
+	}
+
+	return r
+}
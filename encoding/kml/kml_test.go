@@ -0,0 +1,182 @@
+package kml_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/encoding/kml"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// parcels is what testdata/parcels.kml decodes to, in document order
+// through its Folders: a polygon with a hole and a second shell, whose
+// rings are wound the wrong way and unclosed in the document, a Placemark
+// without a geometry, a polygon and a mix of a point and a line.
+var parcels = []struct {
+	id         any
+	properties geojson.Properties
+	geometry   orb.Geometry
+}{
+	{
+		id:         "a",
+		properties: geojson.Properties{"name": "lot a", "description": "two parcels, one with a pond", "AREA": "121"},
+		geometry: orb.MultiPolygon{
+			{
+				{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+				{{2, 2}, {2, 4}, {4, 4}, {4, 2}, {2, 2}},
+			},
+			{
+				{{20, 0}, {25, 0}, {25, 5}, {20, 5}, {20, 0}},
+			},
+		},
+	},
+	{
+		properties: geojson.Properties{"name": "vacant"},
+	},
+	{
+		id:         "c",
+		properties: geojson.Properties{"name": "lot c", "AREA": "1"},
+		geometry:   orb.Polygon{{{30, 0}, {31, 0}, {31, 1}, {30, 1}, {30, 0}}},
+	},
+	{
+		properties: geojson.Properties{"name": "gate"},
+		geometry:   orb.Collection{orb.Point{10, 5}, orb.LineString{{10, 5}, {20, 2.5}}},
+	},
+}
+
+func TestUnmarshal(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "parcels.kml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fc, err := kml.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	assertParcels(t, fc)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		kml  string
+		want string
+	}{
+		{name: "not XML", kml: "{}", want: "decode kml"},
+		{name: "not KML", kml: "<gpx></gpx>", want: "root element is 'gpx', not 'kml'"},
+		{
+			name: "Point without coordinates",
+			kml:  "<kml><Placemark><Point></Point></Placemark></kml>",
+			want: "placemark 0: Point has no coordinates",
+		},
+		{
+			name: "invalid coordinate",
+			kml:  "<kml><Placemark/><Placemark><LineString><coordinates>0,0 1</coordinates></LineString></Placemark></kml>",
+			want: "placemark 1: invalid coordinate '1'",
+		},
+		{
+			name: "Polygon without an outer boundary",
+			kml:  "<kml><Placemark><Polygon></Polygon></Placemark></kml>",
+			want: "placemark 0: Polygon has no outerBoundaryIs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := kml.Unmarshal([]byte(tt.kml)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Unmarshal() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "parcels.kml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fc, err := kml.Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b, err = kml.Marshal(fc, "parcels"); err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+
+	got, err := kml.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	assertParcels(t, got)
+}
+
+func TestMarshalProperties(t *testing.T) {
+	fc := geojson.NewFeatureCollection()
+	f := geojson.NewFeature(orb.Point{1, 2})
+	f.ID = 7.0
+	f.Properties = geojson.Properties{
+		"name":        "a & b",
+		"description": nil,
+		"pop":         10.0,
+		"ok":          true,
+		"tags":        []any{"a"},
+		"nickname":    nil,
+	}
+	fc.Append(f)
+
+	b, err := kml.Marshal(fc, "parcels")
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+
+	got, err := kml.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	// KML only has text, and no null
+	want := geojson.Properties{"name": "a & b", "pop": "10", "ok": "true", "tags": `["a"]`}
+	if len(got.Features) != 1 {
+		t.Fatalf("got %d features, want 1", len(got.Features))
+	}
+
+	if f := got.Features[0]; f.ID != "7" || !reflect.DeepEqual(f.Properties, want) {
+		t.Errorf("got ID %v and properties %v, want 7 and %v", f.ID, f.Properties, want)
+	}
+}
+
+func assertParcels(t *testing.T, fc *geojson.FeatureCollection) {
+	t.Helper()
+
+	if len(fc.Features) != len(parcels) {
+		t.Fatalf("got %d features, want %d", len(fc.Features), len(parcels))
+	}
+
+	for i, want := range parcels {
+		f := fc.Features[i]
+		if f.ID != want.id {
+			t.Errorf("feature %d: got ID %v, want %v", i, f.ID, want.id)
+		}
+
+		if !reflect.DeepEqual(f.Properties, want.properties) {
+			t.Errorf("feature %d: got properties %v, want %v", i, f.Properties, want.properties)
+		}
+
+		if want.geometry == nil {
+			if f.Geometry != nil {
+				t.Errorf("feature %d: got geometry %v, want null", i, f.Geometry)
+			}
+		} else if f.Geometry == nil || !orb.Equal(f.Geometry, want.geometry) {
+			t.Errorf("feature %d: got geometry %v, want %v", i, f.Geometry, want.geometry)
+		}
+	}
+}
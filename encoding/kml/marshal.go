@@ -0,0 +1,187 @@
+package kml
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Marshal encodes fc as a KML document named name with a Placemark per
+// feature. The name and description properties become the Placemark's
+// name and description and the rest its ExtendedData. Null properties
+// are left out.
+func Marshal(fc *geojson.FeatureCollection, name string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<kml xmlns="http://www.opengis.net/kml/2.2"><Document>`)
+	writeElement(buf, "name", name)
+
+	for i, f := range fc.Features {
+		buf.WriteString("<Placemark")
+		if f.ID != nil {
+			buf.WriteString(` id="`)
+			escape(buf, fmt.Sprint(f.ID))
+			buf.WriteString(`"`)
+		}
+		buf.WriteString(">")
+
+		keys := make([]string, 0, len(f.Properties))
+		for k := range f.Properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range []string{"name", "description"} {
+			if v, ok := f.Properties[k]; ok && v != nil {
+				writeElement(buf, k, formatValue(v))
+			}
+		}
+
+		extended := false
+		for _, k := range keys {
+			// KML has no null, and an empty value would be read as ""
+			if k == "name" || k == "description" || f.Properties[k] == nil {
+				continue
+			}
+
+			if !extended {
+				buf.WriteString("<ExtendedData>")
+				extended = true
+			}
+
+			buf.WriteString(`<Data name="`)
+			escape(buf, k)
+			buf.WriteString(`">`)
+			writeElement(buf, "value", formatValue(f.Properties[k]))
+			buf.WriteString("</Data>")
+		}
+
+		if extended {
+			buf.WriteString("</ExtendedData>")
+		}
+
+		if f.Geometry != nil {
+			if err := writeGeometry(buf, f.Geometry); err != nil {
+				return nil, fmt.Errorf("feature %d: %w", i, err)
+			}
+		}
+
+		buf.WriteString("</Placemark>")
+	}
+
+	buf.WriteString("</Document></kml>\n")
+
+	return buf.Bytes(), nil
+}
+
+func escape(buf *bytes.Buffer, s string) {
+	_ = xml.EscapeText(buf, []byte(s))
+}
+
+func writeElement(buf *bytes.Buffer, name, text string) {
+	buf.WriteString("<" + name + ">")
+	escape(buf, text)
+	buf.WriteString("</" + name + ">")
+}
+
+// formatValue formats a property's value as text,
+// with arrays and objects as JSON.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
+
+func writeGeometry(buf *bytes.Buffer, g orb.Geometry) error {
+	switch g := g.(type) {
+	case orb.Point:
+		buf.WriteString("<Point>")
+		writeCoordinates(buf, []orb.Point{g})
+		buf.WriteString("</Point>")
+	case orb.LineString:
+		buf.WriteString("<LineString>")
+		writeCoordinates(buf, g)
+		buf.WriteString("</LineString>")
+	case orb.Ring:
+		return writeGeometry(buf, orb.Polygon{g})
+	case orb.Bound:
+		return writeGeometry(buf, g.ToPolygon())
+	case orb.Polygon:
+		buf.WriteString("<Polygon>")
+		for i, r := range g {
+			boundary := "innerBoundaryIs"
+			if i == 0 {
+				boundary = "outerBoundaryIs"
+			}
+
+			buf.WriteString("<" + boundary + "><LinearRing>")
+			writeCoordinates(buf, r)
+			buf.WriteString("</LinearRing></" + boundary + ">")
+		}
+		buf.WriteString("</Polygon>")
+	case orb.MultiPoint:
+		buf.WriteString("<MultiGeometry>")
+		for _, p := range g {
+			_ = writeGeometry(buf, p)
+		}
+		buf.WriteString("</MultiGeometry>")
+	case orb.MultiLineString:
+		buf.WriteString("<MultiGeometry>")
+		for _, ls := range g {
+			_ = writeGeometry(buf, ls)
+		}
+		buf.WriteString("</MultiGeometry>")
+	case orb.MultiPolygon:
+		buf.WriteString("<MultiGeometry>")
+		for _, p := range g {
+			_ = writeGeometry(buf, p)
+		}
+		buf.WriteString("</MultiGeometry>")
+	case orb.Collection:
+		buf.WriteString("<MultiGeometry>")
+		for _, m := range g {
+			if err := writeGeometry(buf, m); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("</MultiGeometry>")
+	default:
+		return fmt.Errorf("unsupported geometry type %T", g)
+	}
+
+	return nil
+}
+
+func writeCoordinates(buf *bytes.Buffer, ps []orb.Point) {
+	buf.WriteString("<coordinates>")
+	for i, p := range ps {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+
+		buf.WriteString(strconv.FormatFloat(p[0], 'f', -1, 64))
+		buf.WriteByte(',')
+		buf.WriteString(strconv.FormatFloat(p[1], 'f', -1, 64))
+	}
+	buf.WriteString("</coordinates>")
+}
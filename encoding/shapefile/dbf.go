@@ -0,0 +1,355 @@
+package shapefile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/paulmach/orb/geojson"
+)
+
+const (
+	dbfVersion       = 0x03
+	dbfHeaderSize    = 32
+	dbfFieldSize     = 32
+	dbfHeaderEnd     = 0x0D
+	dbfEOF           = 0x1A
+	dbfMaxNameLen    = 10
+	dbfMaxCharLen    = 254
+	dbfMaxIntLen     = 18
+	dbfRealLen       = 24
+	dbfRealPrecision = 15
+)
+
+// field is a column of a dBase table, which holds the attributes of a shapefile.
+type field struct {
+	// name is the name of the field in the table, which may be truncated
+	// from property, the name of the property that its values come from
+	name, property string
+	kind           byte
+	length         int
+	decimals       int
+}
+
+// encodeDBF encodes the properties of features as a dBase III table. The type
+// of each field is inferred from the property's values: numbers become 'N',
+// booleans 'L' and everything else 'C', with arrays and objects as JSON.
+func encodeDBF(features []*geojson.Feature) ([]byte, error) {
+	fields := dbfFields(features)
+
+	recordLen := 1
+	for _, f := range fields {
+		recordLen += f.length
+	}
+
+	var (
+		now       = time.Now()
+		headerLen = dbfHeaderSize + dbfFieldSize*len(fields) + 1
+		buf       = new(bytes.Buffer)
+		header    = make([]byte, dbfHeaderSize)
+	)
+	header[0] = dbfVersion
+	header[1], header[2], header[3] = byte(now.Year()-1900), byte(now.Month()), byte(now.Day())
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(features)))
+	binary.LittleEndian.PutUint16(header[8:], uint16(headerLen))
+	binary.LittleEndian.PutUint16(header[10:], uint16(recordLen))
+	buf.Write(header)
+
+	for _, f := range fields {
+		desc := make([]byte, dbfFieldSize)
+		copy(desc, f.name)
+		desc[11] = f.kind
+		desc[16] = byte(f.length)
+		desc[17] = byte(f.decimals)
+		buf.Write(desc)
+	}
+	buf.WriteByte(dbfHeaderEnd)
+
+	for i, feat := range features {
+		buf.WriteByte(' ')
+		for _, f := range fields {
+			var value string
+			if f.property == "" {
+				// the placeholder FID field
+				value = strconv.Itoa(i)
+			} else {
+				value = formatValue(f, feat.Properties[f.property])
+			}
+
+			if len(value) > f.length {
+				return nil, fmt.Errorf("value of property '%s' is too long for a dBase field", f.property)
+			}
+
+			if f.kind == 'C' {
+				buf.WriteString(value + strings.Repeat(" ", f.length-len(value)))
+			} else {
+				buf.WriteString(strings.Repeat(" ", f.length-len(value)) + value)
+			}
+		}
+	}
+	buf.WriteByte(dbfEOF)
+
+	return buf.Bytes(), nil
+}
+
+// dbfFields infers a field for each property of features, sorted by name.
+func dbfFields(features []*geojson.Feature) []*field {
+	properties := []string{}
+	values := map[string][]any{}
+	for _, f := range features {
+		for k, v := range f.Properties {
+			if k == "" {
+				// cannot be named in a dBase table
+				continue
+			}
+
+			if _, ok := values[k]; !ok {
+				properties = append(properties, k)
+			}
+			values[k] = append(values[k], v)
+		}
+	}
+	sort.Strings(properties)
+
+	var (
+		fields = make([]*field, 0, len(properties))
+		names  = map[string]bool{}
+	)
+	for _, property := range properties {
+		f := &field{name: uniqueName(property, names), property: property, length: 1}
+		names[strings.ToUpper(f.name)] = true
+
+		var hasNumber, hasBool, hasOther, isInt = false, false, false, true
+		for _, v := range values[property] {
+			switch v := v.(type) {
+			case nil:
+			case float64:
+				hasNumber = true
+				if v != math.Trunc(v) || math.Abs(v) >= 1e15 {
+					isInt = false
+				}
+			case bool:
+				hasBool = true
+			default:
+				hasOther = true
+			}
+		}
+
+		switch {
+		case hasNumber && !hasBool && !hasOther && !isInt:
+			f.kind, f.length, f.decimals = 'N', dbfRealLen, dbfRealPrecision
+		case hasNumber && !hasBool && !hasOther:
+			f.kind = 'N'
+			for _, v := range values[property] {
+				if l := len(formatValue(f, v)); l > f.length {
+					f.length = l
+				}
+			}
+
+			if f.length > dbfMaxIntLen {
+				f.length, f.decimals = dbfRealLen, dbfRealPrecision
+			}
+		case hasBool && !hasNumber && !hasOther:
+			f.kind = 'L'
+		default:
+			f.kind = 'C'
+			for _, v := range values[property] {
+				if l := len(formatValue(f, v)); l > f.length {
+					f.length = l
+				}
+			}
+		}
+
+		fields = append(fields, f)
+	}
+
+	if len(fields) == 0 {
+		// a dBase table must have at least one field
+		fields = append(fields, &field{name: "FID", kind: 'N', length: 11})
+	}
+
+	return fields
+}
+
+// uniqueName truncates property to fit in a field name,
+// making it unique among names if need be.
+func uniqueName(property string, names map[string]bool) string {
+	name := truncate(property, dbfMaxNameLen)
+	for i := 1; names[strings.ToUpper(name)]; i++ {
+		suffix := "_" + strconv.Itoa(i)
+		name = truncate(property, dbfMaxNameLen-len(suffix)) + suffix
+	}
+
+	return name
+}
+
+// truncate shortens s to at most n bytes without splitting a rune.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
+
+func formatValue(f *field, v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		if f.kind == 'L' {
+			return strings.ToUpper(strconv.FormatBool(v)[:1])
+		}
+
+		return strconv.FormatBool(v)
+	case float64:
+		if f.kind == 'N' {
+			s := strconv.FormatFloat(v, 'f', f.decimals, 64)
+			if f.decimals > 0 && len(s) > f.length {
+				// too big for fixed point, so fall back to scientific notation
+				s = strconv.FormatFloat(v, 'e', f.length-8, 64)
+			}
+
+			return s
+		}
+
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return truncate(v, dbfMaxCharLen)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return truncate(fmt.Sprint(v), dbfMaxCharLen)
+	}
+
+	return truncate(string(b), dbfMaxCharLen)
+}
+
+// dbfReader decodes the records of a dBase table one at a time.
+type dbfReader struct {
+	io.ReadCloser
+	fields                []*field
+	numRecords, recordLen int
+	// read is how many records have been read
+	read int
+}
+
+func newDBFReader(rc io.ReadCloser) (*dbfReader, error) {
+	header := make([]byte, dbfHeaderSize)
+	if _, err := io.ReadFull(rc, header); err != nil {
+		return nil, fmt.Errorf("dbf is too short")
+	}
+
+	var (
+		headerLen = int(binary.LittleEndian.Uint16(header[8:]))
+		r         = &dbfReader{
+			ReadCloser: rc,
+			numRecords: int(binary.LittleEndian.Uint32(header[4:])),
+			recordLen:  int(binary.LittleEndian.Uint16(header[10:])),
+		}
+	)
+	if headerLen < dbfHeaderSize {
+		return nil, fmt.Errorf("dbf header is truncated")
+	}
+
+	descs := make([]byte, headerLen-dbfHeaderSize)
+	if _, err := io.ReadFull(rc, descs); err != nil {
+		return nil, fmt.Errorf("dbf header is truncated")
+	}
+
+	for off := 0; off+dbfFieldSize <= len(descs) && descs[off] != dbfHeaderEnd; off += dbfFieldSize {
+		desc := descs[off : off+dbfFieldSize]
+		name := string(bytes.TrimRight(desc[:11], "\x00 "))
+		r.fields = append(r.fields, &field{name: name, property: name, kind: desc[11], length: int(desc[16]), decimals: int(desc[17])})
+	}
+
+	return r, nil
+}
+
+// next decodes the next record into a row of properties,
+// returning nil if it was deleted so that rows line up with shapes.
+func (r *dbfReader) next() (map[string]any, error) {
+	record := make([]byte, r.recordLen)
+	if _, err := io.ReadFull(r, record); err != nil || r.recordLen == 0 {
+		return nil, fmt.Errorf("dbf record %d is truncated", r.read)
+	}
+	r.read++
+
+	if record[0] == '*' {
+		return nil, nil
+	}
+
+	row, pos := map[string]any{}, 1
+	for _, f := range r.fields {
+		if pos+f.length > len(record) {
+			return nil, fmt.Errorf("dbf record %d is truncated", r.read-1)
+		}
+
+		row[f.property] = parseValue(f, record[pos:pos+f.length])
+		pos += f.length
+	}
+
+	return row, nil
+}
+
+func parseValue(f *field, b []byte) any {
+	s := decodeString(b)
+	switch f.kind {
+	case 'N', 'F':
+		s = strings.TrimSpace(s)
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil
+		}
+
+		return n
+	case 'L':
+		switch strings.TrimSpace(s) {
+		case "T", "t", "Y", "y":
+			return true
+		case "F", "f", "N", "n":
+			return false
+		}
+
+		return nil
+	case 'C':
+		s = strings.TrimRight(s, " \x00")
+	default:
+		s = strings.TrimSpace(s)
+	}
+
+	if s == "" {
+		// dBase has no null, so blank is the closest thing
+		return nil
+	}
+
+	return s
+}
+
+// decodeString decodes b as UTF-8 if it is valid and as Latin-1,
+// the most common encoding of older dBase tables, otherwise.
+func decodeString(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+
+	return string(runes)
+}
@@ -0,0 +1,403 @@
+package shapefile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/frantjc/go-js"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// family groups the GeoJSON geometry types that share a shapefile.
+type family int
+
+const (
+	familyNone family = iota
+	familyPoint
+	familyLine
+	familyPolygon
+)
+
+func (f family) String() string {
+	switch f {
+	case familyPoint:
+		return "point"
+	case familyLine:
+		return "line"
+	case familyPolygon:
+		return "polygon"
+	}
+
+	return "none"
+}
+
+func familyOf(g orb.Geometry) (family, error) {
+	switch g := g.(type) {
+	case nil:
+		return familyNone, nil
+	case orb.Point, orb.MultiPoint:
+		return familyPoint, nil
+	case orb.LineString, orb.MultiLineString:
+		return familyLine, nil
+	case orb.Ring, orb.Polygon, orb.MultiPolygon, orb.Bound:
+		return familyPolygon, nil
+	case orb.Collection:
+		f := familyNone
+		for _, m := range g {
+			mf, err := familyOf(m)
+			if err != nil {
+				return familyNone, err
+			}
+
+			switch {
+			case mf == familyNone:
+			case f == familyNone:
+				f = mf
+			case f != mf:
+				return familyNone, fmt.Errorf("a GeometryCollection of mixed geometry types cannot be written to a shapefile")
+			}
+		}
+
+		return f, nil
+	}
+
+	return familyNone, fmt.Errorf("unsupported geometry type %T", g)
+}
+
+// Marshal encodes fc as a zip archive holding a shapefile named name,
+// i.e. name.shp, name.shx, name.dbf, name.prj and name.cpg. Since every
+// shape in a shapefile must have the same type, features whose geometries
+// are points, lines and polygons are split into one shapefile each,
+// named e.g. name_point, name_line and name_polygon.
+func Marshal(fc *geojson.FeatureCollection, name string) ([]byte, error) {
+	var (
+		layers = map[family][]*geojson.Feature{}
+		order  = []family{}
+		nulls  = []*geojson.Feature{}
+	)
+	for i, f := range fc.Features {
+		fam, err := familyOf(f.Geometry)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+
+		if fam == familyNone {
+			nulls = append(nulls, f)
+			continue
+		}
+
+		if _, ok := layers[fam]; !ok {
+			order = append(order, fam)
+		}
+		layers[fam] = append(layers[fam], f)
+	}
+
+	// features without geometries can go in any shapefile
+	// as null shapes, so put them in the first one
+	if len(order) == 0 {
+		order = append(order, familyNone)
+	}
+	layers[order[0]] = append(layers[order[0]], nulls...)
+
+	var (
+		buf = new(bytes.Buffer)
+		zw  = zip.NewWriter(buf)
+	)
+	for _, fam := range order {
+		layerName := name
+		if len(order) > 1 {
+			layerName = name + "_" + fam.String()
+		}
+
+		if err := writeLayer(zw, layerName, fam, layers[fam]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeLayer(zw *zip.Writer, name string, fam family, features []*geojson.Feature) error {
+	shapeType := Null
+	switch fam {
+	case familyPoint:
+		shapeType = Point
+		for _, f := range features {
+			if _, ok := f.Geometry.(orb.Point); f.Geometry != nil && !ok {
+				shapeType = MultiPoint
+				break
+			}
+		}
+	case familyLine:
+		shapeType = PolyLine
+	case familyPolygon:
+		shapeType = Polygon
+	}
+
+	var (
+		shp   = new(bytes.Buffer)
+		shx   = new(bytes.Buffer)
+		bound *orb.Bound
+	)
+	for i, f := range features {
+		content, err := encodeShape(shapeType, f.Geometry)
+		if err != nil {
+			return fmt.Errorf("feature %d: %w", i, err)
+		}
+
+		if f.Geometry != nil {
+			b := f.Geometry.Bound()
+			if bound == nil {
+				bound = &b
+			} else {
+				*bound = bound.Union(b)
+			}
+		}
+
+		// offsets and lengths are in 16-bit words
+		_ = binary.Write(shx, binary.BigEndian, []int32{int32((headerSize + shp.Len()) / 2), int32(len(content) / 2)})
+		_ = binary.Write(shp, binary.BigEndian, []int32{int32(i + 1), int32(len(content) / 2)})
+		shp.Write(content)
+	}
+
+	if bound == nil {
+		bound = &orb.Bound{}
+	}
+
+	dbf, err := encodeDBF(features)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, file := range []struct {
+		ext     string
+		content []byte
+	}{
+		{".shp", append(header(shapeType, *bound, headerSize+shp.Len()), shp.Bytes()...)},
+		{".shx", append(header(shapeType, *bound, headerSize+shx.Len()), shx.Bytes()...)},
+		{".dbf", dbf},
+		{".prj", []byte(prj)},
+		{".cpg", []byte("UTF-8")},
+	} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name + file.ext, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+
+		if _, err = w.Write(file.content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func header(shapeType ShapeType, bound orb.Bound, size int) []byte {
+	b := make([]byte, headerSize)
+	binary.BigEndian.PutUint32(b[0:], fileCode)
+	binary.BigEndian.PutUint32(b[24:], uint32(size/2))
+	binary.LittleEndian.PutUint32(b[28:], version)
+	binary.LittleEndian.PutUint32(b[32:], uint32(shapeType))
+	putBound(b[36:], bound)
+
+	return b
+}
+
+func putBound(b []byte, bound orb.Bound) {
+	for i, f := range []float64{bound.Min.X(), bound.Min.Y(), bound.Max.X(), bound.Max.Y()} {
+		binary.LittleEndian.PutUint64(b[i*8:], math.Float64bits(f))
+	}
+}
+
+// encodeShape encodes the content of a record, i.e.
+// everything after the record header.
+func encodeShape(shapeType ShapeType, g orb.Geometry) ([]byte, error) {
+	if c, ok := g.(orb.Collection); ok {
+		g = flatten(c)
+	}
+
+	if g == nil {
+		return []byte{0, 0, 0, 0}, nil
+	}
+
+	buf := new(bytes.Buffer)
+	_ = binary.Write(buf, binary.LittleEndian, int32(shapeType))
+
+	switch shapeType {
+	case Point:
+		p, ok := g.(orb.Point)
+		if !ok {
+			return nil, fmt.Errorf("cannot write %s as a %s", g.GeoJSONType(), shapeType)
+		}
+
+		_ = binary.Write(buf, binary.LittleEndian, [2]float64(p))
+	case MultiPoint:
+		var mp orb.MultiPoint
+		switch g := g.(type) {
+		case orb.Point:
+			mp = orb.MultiPoint{g}
+		case orb.MultiPoint:
+			mp = g
+		default:
+			return nil, fmt.Errorf("cannot write %s as a %s", g.GeoJSONType(), shapeType)
+		}
+
+		buf.Write(boundBytes(mp.Bound()))
+		_ = binary.Write(buf, binary.LittleEndian, int32(len(mp)))
+		for _, p := range mp {
+			_ = binary.Write(buf, binary.LittleEndian, [2]float64(p))
+		}
+	case PolyLine, Polygon:
+		parts, err := partsOf(shapeType, g)
+		if err != nil {
+			return nil, err
+		}
+
+		var (
+			numPoints = 0
+			offsets   = make([]int32, len(parts))
+		)
+		for i, part := range parts {
+			offsets[i] = int32(numPoints)
+			numPoints += len(part)
+		}
+
+		buf.Write(boundBytes(g.Bound()))
+		_ = binary.Write(buf, binary.LittleEndian, []int32{int32(len(parts)), int32(numPoints)})
+		_ = binary.Write(buf, binary.LittleEndian, offsets)
+		for _, part := range parts {
+			for _, p := range part {
+				_ = binary.Write(buf, binary.LittleEndian, [2]float64(p))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("cannot write %s as a %s", g.GeoJSONType(), shapeType)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func boundBytes(bound orb.Bound) []byte {
+	b := make([]byte, 32)
+	putBound(b, bound)
+	return b
+}
+
+// flatten turns a Collection whose members are of a single family
+// into the corresponding multi-geometry.
+func flatten(c orb.Collection) orb.Geometry {
+	var (
+		mp  orb.MultiPoint
+		mls orb.MultiLineString
+		mpg orb.MultiPolygon
+	)
+	for _, g := range c {
+		switch g := g.(type) {
+		case orb.Point:
+			mp = append(mp, g)
+		case orb.MultiPoint:
+			mp = append(mp, g...)
+		case orb.LineString:
+			mls = append(mls, g)
+		case orb.MultiLineString:
+			mls = append(mls, g...)
+		case orb.Ring:
+			mpg = append(mpg, orb.Polygon{g})
+		case orb.Bound:
+			mpg = append(mpg, g.ToPolygon())
+		case orb.Polygon:
+			mpg = append(mpg, g)
+		case orb.MultiPolygon:
+			mpg = append(mpg, g...)
+		case orb.Collection:
+			switch f := flatten(g).(type) {
+			case orb.MultiPoint:
+				mp = append(mp, f...)
+			case orb.MultiLineString:
+				mls = append(mls, f...)
+			case orb.MultiPolygon:
+				mpg = append(mpg, f...)
+			}
+		}
+	}
+
+	switch {
+	case len(mp) > 0:
+		return mp
+	case len(mls) > 0:
+		return mls
+	case len(mpg) > 0:
+		return mpg
+	}
+
+	return nil
+}
+
+// partsOf returns the parts of a PolyLine or Polygon. The rings of a
+// Polygon are closed and wound clockwise for shells and counterclockwise
+// for holes, as shapefiles require.
+func partsOf(shapeType ShapeType, g orb.Geometry) ([][]orb.Point, error) {
+	switch shapeType {
+	case PolyLine:
+		switch g := g.(type) {
+		case orb.LineString:
+			return [][]orb.Point{g}, nil
+		case orb.MultiLineString:
+			parts := make([][]orb.Point, len(g))
+			for i, ls := range g {
+				parts[i] = ls
+			}
+
+			return parts, nil
+		}
+	case Polygon:
+		var mp orb.MultiPolygon
+		switch g := g.(type) {
+		case orb.Ring:
+			mp = orb.MultiPolygon{{g}}
+		case orb.Bound:
+			mp = orb.MultiPolygon{g.ToPolygon()}
+		case orb.Polygon:
+			mp = orb.MultiPolygon{g}
+		case orb.MultiPolygon:
+			mp = g
+		}
+
+		if mp != nil {
+			parts := [][]orb.Point{}
+			for _, p := range mp {
+				for i, r := range p {
+					parts = append(parts, wind(r, js.Ternary(i == 0, orb.CW, orb.CCW)))
+				}
+			}
+
+			return parts, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot write %s as a %s", g.GeoJSONType(), shapeType)
+}
+
+// wind returns a closed copy of r wound in the given orientation.
+func wind(r orb.Ring, o orb.Orientation) orb.Ring {
+	r = r.Clone()
+	if len(r) > 0 && !r.Closed() {
+		r = append(r, r[0])
+	}
+
+	if r.Orientation() != o {
+		r.Reverse()
+	}
+
+	return r
+}
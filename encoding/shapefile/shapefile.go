@@ -0,0 +1,71 @@
+// Package shapefile encodes and decodes GeoJSON FeatureCollections
+// as zipped ESRI Shapefiles, the format that the tasks accept and
+// produce as application/zip.
+//
+// Only 2D geometries are written. Z and M values are dropped when reading.
+package shapefile
+
+import (
+	"fmt"
+)
+
+// ShapeType is the type of the shapes in a shapefile.
+type ShapeType int32
+
+const (
+	Null        ShapeType = 0
+	Point       ShapeType = 1
+	PolyLine    ShapeType = 3
+	Polygon     ShapeType = 5
+	MultiPoint  ShapeType = 8
+	PointZ      ShapeType = 11
+	PolyLineZ   ShapeType = 13
+	PolygonZ    ShapeType = 15
+	MultiPointZ ShapeType = 18
+	PointM      ShapeType = 21
+	PolyLineM   ShapeType = 23
+	PolygonM    ShapeType = 25
+	MultiPointM ShapeType = 28
+)
+
+// base returns the 2D ShapeType that t extends.
+func (t ShapeType) base() ShapeType {
+	switch t {
+	case PointZ, PointM:
+		return Point
+	case PolyLineZ, PolyLineM:
+		return PolyLine
+	case PolygonZ, PolygonM:
+		return Polygon
+	case MultiPointZ, MultiPointM:
+		return MultiPoint
+	}
+
+	return t
+}
+
+func (t ShapeType) String() string {
+	switch t.base() {
+	case Null:
+		return "Null"
+	case Point:
+		return "Point"
+	case PolyLine:
+		return "PolyLine"
+	case Polygon:
+		return "Polygon"
+	case MultiPoint:
+		return "MultiPoint"
+	}
+
+	return fmt.Sprintf("ShapeType(%d)", int32(t))
+}
+
+const (
+	fileCode   = 9994
+	version    = 1000
+	headerSize = 100
+)
+
+// prj is the projection of GeoJSON, WGS 84, in the dialect that shapefiles use.
+const prj = `GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`
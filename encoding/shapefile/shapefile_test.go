@@ -0,0 +1,291 @@
+package shapefile_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/encoding/shapefile"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// parcels is what testdata/parcels decodes to: a polygon with a hole and a
+// second shell, which is a multipart shape, a null shape and a polygon.
+var parcels = []struct {
+	name     string
+	area     any
+	geometry orb.Geometry
+}{
+	{
+		name: "lot a",
+		area: 121.0,
+		geometry: orb.MultiPolygon{
+			{
+				{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+				{{2, 2}, {2, 4}, {4, 4}, {4, 2}, {2, 2}},
+			},
+			{
+				{{20, 0}, {25, 0}, {25, 5}, {20, 5}, {20, 0}},
+			},
+		},
+	},
+	{
+		name: "vacant",
+		area: nil,
+	},
+	{
+		name:     "lot c",
+		area:     1.0,
+		geometry: orb.Polygon{{{30, 0}, {31, 0}, {31, 1}, {30, 1}, {30, 0}}},
+	},
+}
+
+func TestUnmarshal(t *testing.T) {
+	fc, err := shapefile.Unmarshal(archive(t, "parcels", ".shp", ".shx", ".dbf", ".prj"))
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	if len(fc.Features) != len(parcels) {
+		t.Fatalf("got %d features, want %d", len(fc.Features), len(parcels))
+	}
+
+	for i, want := range parcels {
+		assertFeature(t, fc.Features[i], want.name, want.area, want.geometry)
+	}
+}
+
+func TestUnmarshalWithoutDBF(t *testing.T) {
+	fc, err := shapefile.Unmarshal(archive(t, "parcels", ".shp", ".shx"))
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	if len(fc.Features) != len(parcels) {
+		t.Fatalf("got %d features, want %d", len(fc.Features), len(parcels))
+	}
+
+	for i, f := range fc.Features {
+		if len(f.Properties) != 0 {
+			t.Errorf("feature %d: got properties %v, want none", i, f.Properties)
+		}
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+		want string
+	}{
+		{
+			name: "no shapefile",
+			b:    archive(t, "parcels", ".dbf", ".prj"),
+			want: shapefile.ErrNoShapefile.Error(),
+		},
+		{
+			name: "truncated shape",
+			b:    truncated(t),
+			want: "shape 3 is truncated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := shapefile.Unmarshal(tt.b); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Unmarshal() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjection(t *testing.T) {
+	b := archive(t, "parcels", ".shp", ".prj")
+	a, err := shapefile.NewArchive(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prj, err := a.Projection("parcels")
+	if err != nil {
+		t.Fatalf("Projection() = %v", err)
+	}
+
+	if !strings.HasPrefix(prj, `GEOGCS["GCS_WGS_1984"`) {
+		t.Errorf("got projection %q", prj)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	fc, err := shapefile.Unmarshal(archive(t, "parcels", ".shp", ".shx", ".dbf", ".prj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := shapefile.Marshal(fc, "output")
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+
+	layers, err := shapefile.Layers(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(layers) != 1 || layers[0] != "output" {
+		t.Fatalf("got layers %v, want [output]", layers)
+	}
+
+	got, err := shapefile.Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	if len(got.Features) != len(parcels) {
+		t.Fatalf("got %d features, want %d", len(got.Features), len(parcels))
+	}
+
+	// features without geometries are written after the others
+	byName := map[string]*geojson.Feature{}
+	for _, f := range got.Features {
+		byName[f.Properties.MustString("NAME", "")] = f
+	}
+
+	for _, want := range parcels {
+		f, ok := byName[want.name]
+		if !ok {
+			t.Errorf("feature %q is missing", want.name)
+			continue
+		}
+
+		assertFeature(t, f, want.name, want.area, want.geometry)
+	}
+}
+
+func TestMarshalSplitsFamilies(t *testing.T) {
+	fc := geojson.NewFeatureCollection()
+	fc.Append(geojson.NewFeature(orb.Point{1, 2}))
+	fc.Append(geojson.NewFeature(orb.LineString{{0, 0}, {1, 1}}))
+	fc.Append(geojson.NewFeature(orb.MultiPoint{{3, 4}, {5, 6}}))
+
+	b, err := shapefile.Marshal(fc, "output")
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+
+	tests := []struct {
+		layer string
+		want  []orb.Geometry
+	}{
+		{layer: "output_line", want: []orb.Geometry{orb.LineString{{0, 0}, {1, 1}}}},
+		// a layer of points that has any multipoints is of multipoints
+		{layer: "output_point", want: []orb.Geometry{orb.MultiPoint{{1, 2}}, orb.MultiPoint{{3, 4}, {5, 6}}}},
+	}
+
+	layers, err := shapefile.Layers(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(layers) != len(tests) {
+		t.Fatalf("got layers %v, want %d", layers, len(tests))
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layer, func(t *testing.T) {
+			got, err := shapefile.UnmarshalLayer(b, tt.layer)
+			if err != nil {
+				t.Fatalf("UnmarshalLayer() = %v", err)
+			}
+
+			if len(got.Features) != len(tt.want) {
+				t.Fatalf("got %d features, want %d", len(got.Features), len(tt.want))
+			}
+
+			for i, f := range got.Features {
+				if !orb.Equal(f.Geometry, tt.want[i]) {
+					t.Errorf("feature %d: got %v, want %v", i, f.Geometry, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func assertFeature(t *testing.T, f *geojson.Feature, name string, area any, geometry orb.Geometry) {
+	t.Helper()
+
+	if got := f.Properties["NAME"]; got != name {
+		t.Errorf("got NAME %v, want %q", got, name)
+	}
+
+	if got := f.Properties["AREA"]; got != area {
+		t.Errorf("%s: got AREA %v, want %v", name, got, area)
+	}
+
+	if geometry == nil {
+		if f.Geometry != nil {
+			t.Errorf("%s: got geometry %v, want null", name, f.Geometry)
+		}
+	} else if f.Geometry == nil || !orb.Equal(f.Geometry, geometry) {
+		t.Errorf("%s: got geometry %v, want %v", name, f.Geometry, geometry)
+	}
+}
+
+// archive zips the members of the named shapefile in testdata
+// with the given extensions.
+func archive(t *testing.T, layer string, exts ...string) []byte {
+	t.Helper()
+
+	members := map[string][]byte{}
+	for _, ext := range exts {
+		b, err := os.ReadFile(filepath.Join("testdata", layer+ext))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		members[layer+ext] = b
+	}
+
+	return zipOf(t, members)
+}
+
+// truncated zips testdata/parcels with its last shape cut short.
+func truncated(t *testing.T) []byte {
+	t.Helper()
+
+	shp, err := os.ReadFile(filepath.Join("testdata", "parcels.shp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return zipOf(t, map[string][]byte{"parcels.shp": shp[:len(shp)-16]})
+}
+
+func zipOf(t *testing.T, members map[string][]byte) []byte {
+	t.Helper()
+
+	var (
+		buf = new(bytes.Buffer)
+		zw  = zip.NewWriter(buf)
+	)
+	for name, b := range members {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = w.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
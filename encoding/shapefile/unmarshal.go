@@ -0,0 +1,461 @@
+package shapefile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/planar"
+)
+
+// ErrNoShapefile is returned by Unmarshal when
+// the zip archive does not contain a .shp file.
+var ErrNoShapefile = fmt.Errorf("zip must contain a .shp file")
+
+// Archive is a zip archive of shapefiles. Its shapefiles are decoded a
+// record at a time as they are read from it rather than all at once, so
+// that an archive that is too big to hold in memory can still be read.
+type Archive struct {
+	*zip.Reader
+}
+
+// NewArchive opens the zip archive of the given size that is read from r.
+func NewArchive(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Archive{zr}, nil
+}
+
+// Layers returns the sorted names of the shapefiles in the archive,
+// without their extensions.
+func (a *Archive) Layers() []string {
+	layers := []string{}
+	for _, f := range a.File {
+		if strings.EqualFold(path.Ext(f.Name), ".shp") && !strings.HasPrefix(f.Name, "__MACOSX/") {
+			layers = append(layers, strings.TrimSuffix(f.Name, path.Ext(f.Name)))
+		}
+	}
+	sort.Strings(layers)
+
+	return layers
+}
+
+// member returns the named shapefile's member with the given
+// extension, ignoring its case, or nil if it does not have one.
+func (a *Archive) member(layer, ext string) *zip.File {
+	for _, f := range a.File {
+		if strings.TrimSuffix(f.Name, path.Ext(f.Name)) == layer && strings.EqualFold(path.Ext(f.Name), ext) {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// Projection returns the WKT in the named shapefile's .prj,
+// or "" if it does not have one.
+func (a *Archive) Projection(layer string) (string, error) {
+	f := a.member(layer, ".prj")
+	if f == nil {
+		return "", nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	prj, err := io.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(prj)), nil
+}
+
+// Open opens the named shapefile so that its features can be read.
+func (a *Archive) Open(layer string) (*LayerReader, error) {
+	shp := a.member(layer, ".shp")
+	if shp == nil {
+		return nil, fmt.Errorf("zip has no shapefile %s", layer)
+	}
+
+	rc, err := shp.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, headerSize)
+	if _, err = io.ReadFull(rc, header); err != nil || binary.BigEndian.Uint32(header) != fileCode {
+		rc.Close()
+		return nil, fmt.Errorf("decode %s.shp: not a shapefile", layer)
+	}
+
+	r := &LayerReader{layer: layer, shp: rc, size: int64(shp.UncompressedSize64), off: headerSize}
+	if dbf := a.member(layer, ".dbf"); dbf != nil {
+		rc, err := dbf.Open()
+		if err != nil {
+			r.shp.Close()
+			return nil, err
+		}
+
+		if r.dbf, err = newDBFReader(rc); err != nil {
+			rc.Close()
+			r.shp.Close()
+			return nil, fmt.Errorf("decode %s.dbf: %w", layer, err)
+		}
+	}
+
+	return r, nil
+}
+
+// LayerReader reads the features of a shapefile, along with
+// the attributes in its .dbf, if any, one at a time.
+type LayerReader struct {
+	layer string
+	shp   io.ReadCloser
+	dbf   *dbfReader
+	// size is that of the .shp, and off how much of it has been read
+	size, off int64
+	// shapes is how many shapes have been read
+	shapes int
+}
+
+// Next returns the next feature, skipping those whose records were
+// deleted, or io.EOF once there are none left. Polygons are wound as
+// GeoJSON expects, i.e. counterclockwise shells and clockwise holes.
+func (r *LayerReader) Next() (*geojson.Feature, error) {
+	for {
+		g, err := r.nextShape()
+		if errors.Is(err, io.EOF) {
+			if r.dbf != nil && r.dbf.read < r.dbf.numRecords {
+				return nil, r.countMismatch()
+			}
+
+			return nil, io.EOF
+		} else if err != nil {
+			return nil, fmt.Errorf("decode %s.shp: %w", r.layer, err)
+		}
+
+		f := &geojson.Feature{Type: "Feature", Geometry: g, Properties: geojson.Properties{}}
+		if r.dbf != nil {
+			if r.dbf.read == r.dbf.numRecords {
+				return nil, r.countMismatch()
+			}
+
+			row, err := r.dbf.next()
+			if err != nil {
+				return nil, fmt.Errorf("decode %s.dbf: %w", r.layer, err)
+			}
+
+			if row == nil {
+				// deleted
+				continue
+			}
+
+			f.Properties = row
+		}
+
+		return f, nil
+	}
+}
+
+// nextShape decodes the next record of the .shp.
+func (r *LayerReader) nextShape() (orb.Geometry, error) {
+	// trailing bytes too few to be a record are ignored
+	if r.off+8 > r.size {
+		return nil, io.EOF
+	}
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r.shp, header); err != nil {
+		return nil, fmt.Errorf("shape %d is truncated", r.shapes+1)
+	}
+	r.off += 8
+
+	length := int64(binary.BigEndian.Uint32(header[4:])) * 2
+	if length < 4 || r.off+length > r.size {
+		return nil, fmt.Errorf("shape %d is truncated", r.shapes+1)
+	}
+
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r.shp, b); err != nil {
+		return nil, fmt.Errorf("shape %d is truncated", r.shapes+1)
+	}
+	r.off += length
+	r.shapes++
+
+	g, err := decodeShape(b)
+	if err != nil {
+		return nil, fmt.Errorf("shape %d: %w", r.shapes, err)
+	}
+
+	return g, nil
+}
+
+// countMismatch returns the error for a .shp and .dbf that do not
+// have as many shapes as records, counting the rest of the shapes.
+func (r *LayerReader) countMismatch() error {
+	header := make([]byte, 8)
+	for r.off+8 <= r.size {
+		if _, err := io.ReadFull(r.shp, header); err != nil {
+			break
+		}
+
+		length := int64(binary.BigEndian.Uint32(header[4:])) * 2
+		if _, err := io.CopyN(io.Discard, r.shp, length); err != nil {
+			break
+		}
+
+		r.off += 8 + length
+		r.shapes++
+	}
+
+	return fmt.Errorf("%s.shp has %d shapes but %s.dbf has %d records", r.layer, r.shapes, r.layer, r.dbf.numRecords)
+}
+
+// Close closes the shapefile's members.
+func (r *LayerReader) Close() error {
+	if r.dbf != nil {
+		_ = r.dbf.Close()
+	}
+
+	return r.shp.Close()
+}
+
+// Layers returns the sorted names of the shapefiles in the zip archive b,
+// without their extensions.
+func Layers(b []byte) ([]string, error) {
+	a, err := NewArchive(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Layers(), nil
+}
+
+// Unmarshal decodes the first shapefile in the zip archive b, along with the
+// attributes in its .dbf, if any. Polygons are wound as GeoJSON expects, i.e.
+// counterclockwise shells and clockwise holes.
+func Unmarshal(b []byte) (*geojson.FeatureCollection, error) {
+	layers, err := Layers(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(layers) == 0 {
+		return nil, ErrNoShapefile
+	}
+
+	return UnmarshalLayer(b, layers[0])
+}
+
+// UnmarshalLayer decodes the named shapefile in the zip archive b.
+func UnmarshalLayer(b []byte, layer string) (*geojson.FeatureCollection, error) {
+	a, err := NewArchive(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := a.Open(layer)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	fc := geojson.NewFeatureCollection()
+	for {
+		f, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return fc, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		fc.Append(f)
+	}
+}
+
+// decodeShape decodes the content of a record. Since Z and M values follow
+// the X and Y values of every type, they can be ignored by reading the
+// content of a Z or M type as its 2D base type.
+func decodeShape(b []byte) (orb.Geometry, error) {
+	r := &reader{b: b}
+	shapeType := ShapeType(r.int32())
+
+	switch shapeType.base() {
+	case Null:
+		return nil, r.err
+	case Point:
+		p := r.point()
+		return p, r.err
+	case MultiPoint:
+		r.skip(32)
+		mp := make(orb.MultiPoint, r.count(16))
+		for i := range mp {
+			mp[i] = r.point()
+		}
+
+		return mp, r.err
+	case PolyLine, Polygon:
+		r.skip(32)
+		var (
+			numParts  = r.count(4)
+			numPoints = r.count(16)
+			offsets   = make([]int, numParts)
+		)
+		for i := range offsets {
+			offsets[i] = int(r.int32())
+		}
+
+		points := make([]orb.Point, numPoints)
+		for i := range points {
+			points[i] = r.point()
+		}
+
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		parts := make([][]orb.Point, 0, numParts)
+		for i, start := range offsets {
+			end := numPoints
+			if i+1 < len(offsets) {
+				end = offsets[i+1]
+			}
+
+			if start < 0 || start > end || end > numPoints {
+				return nil, fmt.Errorf("invalid part offsets")
+			}
+
+			parts = append(parts, points[start:end])
+		}
+
+		if shapeType.base() == PolyLine {
+			if len(parts) == 1 {
+				return orb.LineString(parts[0]), nil
+			}
+
+			mls := make(orb.MultiLineString, len(parts))
+			for i, part := range parts {
+				mls[i] = part
+			}
+
+			return mls, nil
+		}
+
+		return polygons(parts), nil
+	}
+
+	return nil, fmt.Errorf("unsupported shape type %s", shapeType)
+}
+
+// polygons assembles the rings of a Polygon shape, where shells are clockwise
+// and holes counterclockwise, into a Polygon or MultiPolygon.
+func polygons(parts [][]orb.Point) orb.Geometry {
+	var (
+		shells = orb.MultiPolygon{}
+		holes  = []orb.Ring{}
+	)
+	for _, part := range parts {
+		r := orb.Ring(part)
+		if r.Orientation() == orb.CCW {
+			holes = append(holes, r)
+		} else {
+			shells = append(shells, orb.Polygon{wind(r, orb.CCW)})
+		}
+	}
+
+	for _, h := range holes {
+		found := false
+		for i, shell := range shells {
+			if len(h) > 0 && planar.RingContains(shell[0], h[0]) {
+				shells[i] = append(shell, wind(h, orb.CW))
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			// a hole outside of every shell is really a misdirected shell
+			shells = append(shells, orb.Polygon{wind(h, orb.CCW)})
+		}
+	}
+
+	if len(shells) == 1 {
+		return shells[0]
+	}
+
+	return shells
+}
+
+// reader reads little-endian values from a record,
+// remembering the first error that it encounters.
+type reader struct {
+	b   []byte
+	off int
+	err error
+}
+
+func (r *reader) skip(n int) {
+	if r.err == nil && r.off+n > len(r.b) {
+		r.err = fmt.Errorf("shape is truncated")
+	}
+	r.off += n
+}
+
+func (r *reader) int32() int32 {
+	if r.skip(4); r.err != nil {
+		return 0
+	}
+
+	return int32(binary.LittleEndian.Uint32(r.b[r.off-4:]))
+}
+
+func (r *reader) float64() float64 {
+	if r.skip(8); r.err != nil {
+		return 0
+	}
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(r.b[r.off-8:]))
+}
+
+func (r *reader) point() orb.Point {
+	return orb.Point{r.float64(), r.float64()}
+}
+
+// count reads a count of items of the given size,
+// checking that there is room left for them.
+func (r *reader) count(size int) int {
+	n := int(r.int32())
+	if r.err == nil && (n < 0 || n > (len(r.b)-r.off)/size) {
+		r.err = fmt.Errorf("shape is truncated")
+		return 0
+	}
+
+	return n
+}
+
+// Projection returns the WKT in the named shapefile's .prj
+// in the zip archive b, or "" if it does not have one.
+func Projection(b []byte, layer string) (string, error) {
+	a, err := NewArchive(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return "", err
+	}
+
+	return a.Projection(layer)
+}
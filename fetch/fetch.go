@@ -0,0 +1,254 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/frantjc/go-js"
+)
+
+const (
+	// DefaultMaxSize is the most bytes that are fetched by default.
+	DefaultMaxSize int64 = 1024 * 1024 * 1024
+	// maxRedirects is the most redirects that are followed,
+	// the same as the net/http default.
+	maxRedirects = 10
+)
+
+var (
+	// Schemes are the only URL schemes that can be fetched.
+	Schemes = []string{"https", "http"}
+	// blockedNetworks are the special-purpose ranges that net.IP has no
+	// method for, which are no more reachable from outside than private ones.
+	blockedNetworks = js.Map([]string{
+		"0.0.0.0/8",     // "this" network
+		"100.64.0.0/10", // carrier-grade NAT
+		"192.0.0.0/24",  // IETF protocol assignments
+		"198.18.0.0/15", // benchmarking
+		"240.0.0.0/4",   // reserved
+		"64:ff9b::/96",  // NAT64, which can embed any IPv4 address
+	}, func(cidr string, _ int, _ []string) *net.IPNet {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+
+		return network
+	})
+)
+
+var (
+	ErrScheme           = errors.New("scheme not allowed")
+	ErrTooLarge         = errors.New("content too large")
+	ErrForbiddenAddress = errors.New("address not allowed")
+)
+
+// Fetcher fetches content from URLs given by users, which
+// must not be able to reach addresses that only we can.
+type Fetcher struct {
+	// MaxSize is the most bytes that are fetched.
+	MaxSize int64
+	// Schemes are the URL schemes that can be
+	// fetched, which must be some of Schemes.
+	Schemes []string
+	// AllowPrivate allows private, loopback and other special-purpose
+	// addresses to be fetched from, e.g. for local development.
+	AllowPrivate bool
+	// Timeout is how long fetching can take
+	// altogether, or forever if it is 0.
+	Timeout time.Duration
+}
+
+type Opt func(*Fetcher)
+
+func WithMaxSize(maxSize int64) Opt {
+	return func(f *Fetcher) {
+		f.MaxSize = maxSize
+	}
+}
+
+func WithSchemes(schemes ...string) Opt {
+	return func(f *Fetcher) {
+		f.Schemes = schemes
+	}
+}
+
+func WithAllowPrivate(allowPrivate bool) Opt {
+	return func(f *Fetcher) {
+		f.AllowPrivate = allowPrivate
+	}
+}
+
+func WithTimeout(timeout time.Duration) Opt {
+	return func(f *Fetcher) {
+		f.Timeout = timeout
+	}
+}
+
+func New(opts ...Opt) *Fetcher {
+	f := &Fetcher{
+		MaxSize: DefaultMaxSize,
+		Schemes: Schemes,
+		Timeout: time.Hour,
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+// Response is fetched content.
+type Response struct {
+	// ContentType is the Content-Type that
+	// the content was served with, if any.
+	ContentType string
+	// Filename is the name of the content as given by
+	// its Content-Disposition or else its URL's path.
+	Filename string
+	Body     []byte
+}
+
+// CheckURL parses the URL, making sure that it is absolute,
+// has a host and has one of the given schemes.
+func CheckURL(rawURL string, schemes []string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if !js.Includes(schemes, strings.ToLower(u.Scheme)) || !js.Includes(Schemes, strings.ToLower(u.Scheme)) {
+		return nil, fmt.Errorf("%w: '%s', must be one of %s", ErrScheme, u.Scheme, strings.Join(schemes, ", "))
+	}
+
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("URL '%s' has no host", rawURL)
+	}
+
+	if u.User != nil {
+		return nil, fmt.Errorf("URL must not have userinfo, use the Authorization header instead")
+	}
+
+	return u, nil
+}
+
+// Forbidden reports whether the IP address is one that only we can reach.
+func Forbidden(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+
+	return js.Some(blockedNetworks, func(network *net.IPNet, _ int, _ []*net.IPNet) bool {
+		return network.Contains(ip)
+	})
+}
+
+// control checks the address that is about to be connected to, after it has
+// been resolved, so that a host cannot resolve to a public address when it is
+// checked and to a private one when it is connected to.
+func (f *Fetcher) control(_, address string, _ syscall.RawConn) error {
+	if f.AllowPrivate {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || Forbidden(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+
+	return nil
+}
+
+func (f *Fetcher) client() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: f.control,
+	}
+
+	return &http.Client{
+		Timeout: f.Timeout,
+		Transport: &http.Transport{
+			// no Proxy, as it would be connected to instead of the host
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: time.Minute,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+
+			_, err := CheckURL(req.URL.String(), f.Schemes)
+			return err
+		},
+	}
+}
+
+// Fetch GETs the URL with the given headers, following redirects.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string, header map[string]string) (*Response, error) {
+	u, err := CheckURL(rawURL, f.Schemes)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	res, err := f.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("fetching '%s' responded %s", u.Redacted(), res.Status)
+	}
+
+	if res.ContentLength > f.MaxSize {
+		return nil, fmt.Errorf("%w: %d bytes is more than the %d allowed", ErrTooLarge, res.ContentLength, f.MaxSize)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(res.Body, f.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(b)) > f.MaxSize {
+		return nil, fmt.Errorf("%w: more than the %d bytes allowed", ErrTooLarge, f.MaxSize)
+	}
+
+	filename := path.Base(res.Request.URL.Path)
+	if _, params, err := mime.ParseMediaType(res.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		filename = path.Base(params["filename"])
+	}
+
+	return &Response{
+		ContentType: res.Header.Get("Content-Type"),
+		Filename:    strings.Trim(filename, "/."),
+		Body:        b,
+	}, nil
+}
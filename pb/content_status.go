@@ -0,0 +1,29 @@
+package pb
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ContentStatus string
+
+const (
+	ContentStatusPending ContentStatus = "pending"
+	ContentStatusReady   ContentStatus = "ready"
+)
+
+func (k ContentStatus) String() string {
+	return string(k)
+}
+
+func ParseContentStatus(contentStatus string) (ContentStatus, error) {
+	for _, k := range []ContentStatus{
+		ContentStatusPending, ContentStatusReady,
+	} {
+		if strings.EqualFold(contentStatus, k.String()) {
+			return k, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown content status '%s'", contentStatus)
+}
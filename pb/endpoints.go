@@ -1,7 +1,13 @@
 package pb
 
 const (
-	EndpointJobs     = "/api/v1/jobs"
-	EndpointStorages = "/api/v1/storages"
-	EndpointTasks    = "/api/v1/tasks"
+	EndpointAdminWorkers   = "/api/v1/admin/workers"
+	EndpointImports        = "/api/v1/imports"
+	EndpointJobs           = "/api/v1/jobs"
+	EndpointSchedules      = "/api/v1/schedules"
+	EndpointStorages       = "/api/v1/storages"
+	EndpointStoragesImport = "/api/v1/storages/import"
+	EndpointTasks          = "/api/v1/tasks"
+	EndpointUploads        = "/api/v1/uploads"
+	EndpointUsage          = "/api/v1/usage"
 )
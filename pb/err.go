@@ -16,6 +16,9 @@ func NewErr(err error, codes ...int) *Error {
 		Message:        err.Error(),
 		HTTPStatusCode: http.StatusInternalServerError,
 	}
+	if d, ok := err.(interface{ Details() []string }); ok {
+		e.Details = d.Details()
+	}
 	switch len(codes) {
 	case 2:
 		e.HTTPStatusCode = codes[0]
@@ -27,8 +30,9 @@ func NewErr(err error, codes ...int) *Error {
 }
 
 type Error struct {
-	Message        string `json:"error,omitempty"`
-	HTTPStatusCode int    `json:"-"`
+	Message        string   `json:"error,omitempty"`
+	Details        []string `json:"details,omitempty"`
+	HTTPStatusCode int      `json:"-"`
 }
 
 func (e *Error) Error() string {
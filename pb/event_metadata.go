@@ -1,7 +1,26 @@
 package pb
 
+import "strconv"
+
 type JobEventMetadata map[string]string
 
 func (m JobEventMetadata) GetId() string {
 	return m["id"]
 }
+
+func (m JobEventMetadata) GetNamespace() string {
+	return m["namespace"]
+}
+
+// GetTaskType returns the task type of the event's
+// job, or "" if it has none, as imports do not.
+func (m JobEventMetadata) GetTaskType() string {
+	return m["task_type"]
+}
+
+// GetPriority returns the priority of the event's job,
+// or 0 if it has none, as imports do not.
+func (m JobEventMetadata) GetPriority() int32 {
+	priority, _ := strconv.ParseInt(m["priority"], 10, 32)
+	return int32(priority)
+}
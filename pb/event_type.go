@@ -6,6 +6,18 @@ func (e EventType) String() string {
 	return string(e)
 }
 
+// Key returns the routing key of the event type qualified by the given
+// words, e.g. EventTypeJobCreated.Key("buffer") is "job.created.buffer".
+// Words may be wildcards, e.g. EventTypeJobCreated.Key("#") matches any
+// job.created event, qualified or not.
+func (e EventType) Key(words ...string) EventType {
+	for _, word := range words {
+		e += EventType("." + word)
+	}
+
+	return e
+}
+
 const (
 	EventTypeJobCreated   EventType = "job.created"
 	EventTypeJobStarted   EventType = "job.started"
@@ -15,5 +27,8 @@ const (
 	EventTypeStorageCreated EventType = "storage.created"
 	EventTypeStorageAny     EventType = "storage.#"
 
+	EventTypeImportCreated EventType = "import.created"
+	EventTypeImportAny     EventType = "import.#"
+
 	EventTypeAny EventType = "#"
 )
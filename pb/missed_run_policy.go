@@ -0,0 +1,34 @@
+package pb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MissedRunPolicy is what a schedule does about the runs
+// that it missed, e.g. because the API was down.
+type MissedRunPolicy string
+
+const (
+	// MissedRunPolicySkip runs once for the latest
+	// missed run, skipping those before it.
+	MissedRunPolicySkip MissedRunPolicy = "skip"
+	// MissedRunPolicyCatchUp runs once for each missed run.
+	MissedRunPolicyCatchUp MissedRunPolicy = "catchup"
+)
+
+func (k MissedRunPolicy) String() string {
+	return string(k)
+}
+
+func ParseMissedRunPolicy(missedRunPolicy string) (MissedRunPolicy, error) {
+	for _, k := range []MissedRunPolicy{
+		MissedRunPolicySkip, MissedRunPolicyCatchUp,
+	} {
+		if strings.EqualFold(missedRunPolicy, k.String()) {
+			return k, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown missed run policy '%s'", missedRunPolicy)
+}
@@ -0,0 +1,29 @@
+package pb
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleStatusActive ScheduleStatus = "active"
+	ScheduleStatusPaused ScheduleStatus = "paused"
+)
+
+func (k ScheduleStatus) String() string {
+	return string(k)
+}
+
+func ParseScheduleStatus(scheduleStatus string) (ScheduleStatus, error) {
+	for _, k := range []ScheduleStatus{
+		ScheduleStatusActive, ScheduleStatusPaused,
+	} {
+		if strings.EqualFold(scheduleStatus, k.String()) {
+			return k, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown schedule status '%s'", scheduleStatus)
+}
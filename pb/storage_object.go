@@ -0,0 +1,11 @@
+package pb
+
+// GetObjectId returns the ID of the storage's object in the blobstore, which
+// is that of its content if it was deduplicated or else the storage's own.
+func (s *Storage) GetObjectId() string {
+	if s.GetContentId() != "" {
+		return s.GetContentId()
+	}
+
+	return s.GetId()
+}
@@ -8,27 +8,32 @@ import (
 )
 
 type RestJob struct {
-	Id        string    `json:"id,omitempty"`
-	Namespace string    `json:"-"`
-	InputId   string    `json:"input_id,omitempty"`
-	OutputId  string    `json:"output_id,omitempty"`
-	Status    string    `json:"status,omitempty"`
-	Error     string    `json:"error,omitempty"`
-	StartTime time.Time `json:"start_time,omitempty"`
-	EndTime   time.Time `json:"end_time,omitempty"`
-	Steps     []*Step   `json:"steps,omitempty"`
+	Id        string `json:"id,omitempty"`
+	Namespace string `json:"-"`
+	InputId   string `json:"input_id,omitempty"`
+	// InputRevision is the revision of the input that the job used
+	InputRevision int32     `json:"input_revision,omitempty"`
+	Priority      int32     `json:"priority,omitempty"`
+	OutputId      string    `json:"output_id,omitempty"`
+	Status        string    `json:"status,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	StartTime     time.Time `json:"start_time,omitempty"`
+	EndTime       time.Time `json:"end_time,omitempty"`
+	Steps         []*Step   `json:"steps,omitempty"`
 }
 
 func (j *Job) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&RestJob{
-		Id:        j.GetId(),
-		InputId:   j.GetInputId(),
-		OutputId:  j.GetOutputId(),
-		Status:    j.GetStatus(),
-		Error:     j.GetError(),
-		StartTime: j.GetStartTime().AsTime(),
-		EndTime:   j.GetEndTime().AsTime(),
-		Steps:     j.Steps,
+		Id:            j.GetId(),
+		InputId:       j.GetInputId(),
+		InputRevision: j.GetInputRevision(),
+		Priority:      j.GetPriority(),
+		OutputId:      j.GetOutputId(),
+		Status:        j.GetStatus(),
+		Error:         j.GetError(),
+		StartTime:     j.GetStartTime().AsTime(),
+		EndTime:       j.GetEndTime().AsTime(),
+		Steps:         j.Steps,
 	})
 }
 
@@ -41,6 +46,8 @@ func (j *Job) UnmarshalJSON(data []byte) error {
 	j.Id = rj.Id
 	j.Namespace = rj.Namespace
 	j.InputId = rj.InputId
+	j.InputRevision = rj.InputRevision
+	j.Priority = rj.Priority
 	j.OutputId = rj.OutputId
 	j.Status = rj.Status
 	j.StartTime = timestamppb.New(rj.StartTime)
@@ -50,34 +57,63 @@ func (j *Job) UnmarshalJSON(data []byte) error {
 }
 
 type RestStep struct {
-	TaskType string   `json:"task_type,omitempty"`
-	Args     []string `json:"args,omitempty"`
+	TaskType  string   `json:"task_type,omitempty"`
+	Args      []string `json:"args,omitempty"`
+	OverlayId string   `json:"overlay_id,omitempty"`
 }
 
 func (s *Step) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&RestStep{
-		TaskType: s.TaskType,
-		Args:     s.Args,
+		TaskType:  s.TaskType,
+		Args:      s.Args,
+		OverlayId: s.OverlayId,
 	})
 }
 
 type RestStorage struct {
-	Id         string    `json:"id,omitempty"`
-	Namespace  string    `json:"-"`
-	Name       string    `json:"name,omitempty"`
-	Status     string    `json:"status,omitempty"`
-	LastUsed   time.Time `json:"last_used,omitempty"`
-	CreateTime time.Time `json:"create_time,omitempty"`
+	Id           string    `json:"id,omitempty"`
+	Namespace    string    `json:"-"`
+	Name         string    `json:"name,omitempty"`
+	Revision     int32     `json:"revision,omitempty"`
+	Status       string    `json:"status,omitempty"`
+	Format       string    `json:"format,omitempty"`
+	Size         int64     `json:"size,omitempty"`
+	Checksum     string    `json:"checksum,omitempty"`
+	CRS          string    `json:"crs,omitempty"`
+	BBox         []float64 `json:"bbox,omitempty"`
+	GeometryType string    `json:"geometry_type,omitempty"`
+	Layers       []string  `json:"layers,omitempty"`
+	// FeatureCount is only known, and so
+	// only set, if Format is, too
+	FeatureCount *int64    `json:"feature_count,omitempty"`
+	Columns      []*Column `json:"columns,omitempty"`
+	LastUsed     time.Time `json:"last_used,omitempty"`
+	CreateTime   time.Time `json:"create_time,omitempty"`
 }
 
 func (s *Storage) MarshalJSON() ([]byte, error) {
-	return json.Marshal(&RestStorage{
-		Id:         s.GetId(),
-		Name:       s.GetName(),
-		Status:     s.GetStatus(),
-		LastUsed:   s.GetLastUsed().AsTime(),
-		CreateTime: s.GetCreateTime().AsTime(),
-	})
+	rs := &RestStorage{
+		Id:           s.GetId(),
+		Name:         s.GetName(),
+		Revision:     s.GetRevision(),
+		Status:       s.GetStatus(),
+		Format:       s.GetFormat(),
+		Size:         s.GetSize(),
+		Checksum:     s.GetChecksum(),
+		CRS:          s.GetCrs(),
+		BBox:         s.GetBbox(),
+		GeometryType: s.GetGeometryType(),
+		Layers:       s.GetLayers(),
+		Columns:      s.GetColumns(),
+		LastUsed:     s.GetLastUsed().AsTime(),
+		CreateTime:   s.GetCreateTime().AsTime(),
+	}
+	if s.GetFormat() != "" {
+		featureCount := s.GetFeatureCount()
+		rs.FeatureCount = &featureCount
+	}
+
+	return json.Marshal(rs)
 }
 
 func (s *Storage) UnmarshalJSON(data []byte) error {
@@ -89,9 +125,413 @@ func (s *Storage) UnmarshalJSON(data []byte) error {
 	s.Id = rs.Id
 	s.Namespace = rs.Namespace
 	s.Name = rs.Name
+	s.Revision = rs.Revision
 	s.Status = rs.Status
+	s.Format = rs.Format
+	s.Size = rs.Size
+	s.Checksum = rs.Checksum
+	s.Crs = rs.CRS
+	s.Bbox = rs.BBox
+	s.GeometryType = rs.GeometryType
+	s.Layers = rs.Layers
+	s.Columns = rs.Columns
+	if rs.FeatureCount != nil {
+		s.FeatureCount = *rs.FeatureCount
+	}
 	s.LastUsed = timestamppb.New(rs.LastUsed)
 	s.CreateTime = timestamppb.New(rs.CreateTime)
 
 	return nil
 }
+
+type RestTask struct {
+	Type        string   `json:"type,omitempty"`
+	Kind        string   `json:"kind,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Executable  string   `json:"-"`
+	Params      []string `json:"params,omitempty"`
+	Inputs      []string `json:"inputs,omitempty"`
+	Outputs     []string `json:"outputs,omitempty"`
+	Schema      []*Param `json:"schema,omitempty"`
+}
+
+func (t *Task) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&RestTask{
+		Type:        t.GetType(),
+		Kind:        t.GetKind(),
+		Description: t.GetDescription(),
+		Params:      t.GetParams(),
+		Inputs:      t.GetInputs(),
+		Outputs:     t.GetOutputs(),
+		Schema:      t.GetSchema(),
+	})
+}
+
+func (t *Task) UnmarshalJSON(data []byte) error {
+	rt := &RestTask{}
+	if err := json.Unmarshal(data, rt); err != nil {
+		return err
+	}
+
+	t.Type = rt.Type
+	t.Kind = rt.Kind
+	t.Description = rt.Description
+	t.Executable = rt.Executable
+	t.Params = rt.Params
+	t.Inputs = rt.Inputs
+	t.Outputs = rt.Outputs
+	t.Schema = rt.Schema
+
+	return nil
+}
+
+type RestUpload struct {
+	Id          string    `json:"id,omitempty"`
+	Namespace   string    `json:"-"`
+	Name        string    `json:"name,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	Size        int64     `json:"size,omitempty"`
+	Checksum    string    `json:"checksum,omitempty"`
+	StorageId   string    `json:"storage_id,omitempty"`
+	Parts       []*Part   `json:"parts,omitempty"`
+	CreateTime  time.Time `json:"create_time,omitempty"`
+}
+
+func (u *Upload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&RestUpload{
+		Id:          u.GetId(),
+		Name:        u.GetName(),
+		ContentType: u.GetContentType(),
+		Status:      u.GetStatus(),
+		Size:        u.GetSize(),
+		Checksum:    u.GetChecksum(),
+		StorageId:   u.GetStorageId(),
+		Parts:       u.GetParts(),
+		CreateTime:  u.GetCreateTime().AsTime(),
+	})
+}
+
+func (u *Upload) UnmarshalJSON(data []byte) error {
+	ru := &RestUpload{}
+	if err := json.Unmarshal(data, ru); err != nil {
+		return err
+	}
+
+	u.Id = ru.Id
+	u.Namespace = ru.Namespace
+	u.Name = ru.Name
+	u.ContentType = ru.ContentType
+	u.Status = ru.Status
+	u.Size = ru.Size
+	u.Checksum = ru.Checksum
+	u.StorageId = ru.StorageId
+	u.Parts = ru.Parts
+	u.CreateTime = timestamppb.New(ru.CreateTime)
+
+	return nil
+}
+
+type RestSignedURL struct {
+	URL    string `json:"url,omitempty"`
+	Method string `json:"method,omitempty"`
+	// ExpireTime is only set if the URL
+	// is signed, as proxied ones do not
+	ExpireTime *time.Time `json:"expire_time,omitempty"`
+	Proxied    bool       `json:"proxied,omitempty"`
+}
+
+func (u *SignedURL) MarshalJSON() ([]byte, error) {
+	ru := &RestSignedURL{
+		URL:     u.GetUrl(),
+		Method:  u.GetMethod(),
+		Proxied: u.GetProxied(),
+	}
+	if u.GetExpireTime() != nil {
+		expireTime := u.GetExpireTime().AsTime()
+		ru.ExpireTime = &expireTime
+	}
+
+	return json.Marshal(ru)
+}
+
+func (u *SignedURL) UnmarshalJSON(data []byte) error {
+	ru := &RestSignedURL{}
+	if err := json.Unmarshal(data, ru); err != nil {
+		return err
+	}
+
+	u.Url = ru.URL
+	u.Method = ru.Method
+	if ru.ExpireTime != nil {
+		u.ExpireTime = timestamppb.New(*ru.ExpireTime)
+	}
+	u.Proxied = ru.Proxied
+
+	return nil
+}
+
+type RestImport struct {
+	Id        string `json:"id,omitempty"`
+	Namespace string `json:"-"`
+	Name      string `json:"name,omitempty"`
+	URL       string `json:"url,omitempty"`
+	// Headers are never written, as they
+	// may have credentials in them
+	Headers    map[string]string `json:"-"`
+	Status     string            `json:"status,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	StorageId  string            `json:"storage_id,omitempty"`
+	CreateTime time.Time         `json:"create_time,omitempty"`
+	EndTime    time.Time         `json:"end_time,omitempty"`
+}
+
+func (i *Import) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&RestImport{
+		Id:         i.GetId(),
+		Name:       i.GetName(),
+		URL:        i.GetUrl(),
+		Status:     i.GetStatus(),
+		Error:      i.GetError(),
+		StorageId:  i.GetStorageId(),
+		CreateTime: i.GetCreateTime().AsTime(),
+		EndTime:    i.GetEndTime().AsTime(),
+	})
+}
+
+func (i *Import) UnmarshalJSON(data []byte) error {
+	ri := &RestImport{}
+	if err := json.Unmarshal(data, ri); err != nil {
+		return err
+	}
+
+	i.Id = ri.Id
+	i.Namespace = ri.Namespace
+	i.Name = ri.Name
+	i.Url = ri.URL
+	i.Headers = ri.Headers
+	i.Status = ri.Status
+	i.Error = ri.Error
+	i.StorageId = ri.StorageId
+	i.CreateTime = timestamppb.New(ri.CreateTime)
+	i.EndTime = timestamppb.New(ri.EndTime)
+
+	return nil
+}
+
+type RestQuota struct {
+	Namespace string `json:"-"`
+	// Limits of 0, i.e. no limit, are not written
+	StorageBytes   int64 `json:"storage_bytes,omitempty"`
+	UploadBytes    int64 `json:"upload_bytes,omitempty"`
+	ConcurrentJobs int32 `json:"concurrent_jobs,omitempty"`
+	DailyJobs      int32 `json:"daily_jobs,omitempty"`
+	// MaxPriority is written even if it is 0,
+	// as that is not no limit
+	MaxPriority int32 `json:"max_priority"`
+}
+
+func (q *Quota) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&RestQuota{
+		StorageBytes:   q.GetStorageBytes(),
+		UploadBytes:    q.GetUploadBytes(),
+		ConcurrentJobs: q.GetConcurrentJobs(),
+		DailyJobs:      q.GetDailyJobs(),
+		MaxPriority:    q.GetMaxPriority(),
+	})
+}
+
+func (q *Quota) UnmarshalJSON(data []byte) error {
+	rq := &RestQuota{}
+	if err := json.Unmarshal(data, rq); err != nil {
+		return err
+	}
+
+	q.Namespace = rq.Namespace
+	q.StorageBytes = &rq.StorageBytes
+	q.UploadBytes = &rq.UploadBytes
+	q.ConcurrentJobs = &rq.ConcurrentJobs
+	q.DailyJobs = &rq.DailyJobs
+	q.MaxPriority = &rq.MaxPriority
+
+	return nil
+}
+
+type RestUsage struct {
+	StorageBytes   int64  `json:"storage_bytes"`
+	ConcurrentJobs int32  `json:"concurrent_jobs"`
+	DailyJobs      int32  `json:"daily_jobs"`
+	Quota          *Quota `json:"quota,omitempty"`
+}
+
+func (u *Usage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&RestUsage{
+		StorageBytes:   u.GetStorageBytes(),
+		ConcurrentJobs: u.GetConcurrentJobs(),
+		DailyJobs:      u.GetDailyJobs(),
+		Quota:          u.GetQuota(),
+	})
+}
+
+func (u *Usage) UnmarshalJSON(data []byte) error {
+	ru := &RestUsage{}
+	if err := json.Unmarshal(data, ru); err != nil {
+		return err
+	}
+
+	u.StorageBytes = ru.StorageBytes
+	u.ConcurrentJobs = ru.ConcurrentJobs
+	u.DailyJobs = ru.DailyJobs
+	u.Quota = ru.Quota
+
+	return nil
+}
+
+type RestWorker struct {
+	Id       string `json:"id,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+	Version  string `json:"version,omitempty"`
+	// TaskTypes are empty if the worker
+	// handles jobs of every task type
+	TaskTypes []string `json:"task_types,omitempty"`
+	// Capacity is 0 if the worker
+	// runs any number of jobs at once
+	Capacity      int32     `json:"capacity,omitempty"`
+	JobIds        []string  `json:"job_ids"`
+	Status        string    `json:"status,omitempty"`
+	StartTime     time.Time `json:"start_time,omitempty"`
+	HeartbeatTime time.Time `json:"heartbeat_time,omitempty"`
+}
+
+func (w *Worker) MarshalJSON() ([]byte, error) {
+	rw := &RestWorker{
+		Id:            w.GetId(),
+		Hostname:      w.GetHostname(),
+		Version:       w.GetVersion(),
+		TaskTypes:     w.GetTaskTypes(),
+		Capacity:      w.GetCapacity(),
+		JobIds:        w.GetJobIds(),
+		Status:        w.GetStatus(),
+		StartTime:     w.GetStartTime().AsTime(),
+		HeartbeatTime: w.GetHeartbeatTime().AsTime(),
+	}
+	if rw.JobIds == nil {
+		rw.JobIds = []string{}
+	}
+
+	return json.Marshal(rw)
+}
+
+func (w *Worker) UnmarshalJSON(data []byte) error {
+	rw := &RestWorker{}
+	if err := json.Unmarshal(data, rw); err != nil {
+		return err
+	}
+
+	w.Id = rw.Id
+	w.Hostname = rw.Hostname
+	w.Version = rw.Version
+	w.TaskTypes = rw.TaskTypes
+	w.Capacity = rw.Capacity
+	w.JobIds = rw.JobIds
+	w.Status = rw.Status
+	w.StartTime = timestamppb.New(rw.StartTime)
+	w.HeartbeatTime = timestamppb.New(rw.HeartbeatTime)
+
+	return nil
+}
+
+type RestSchedule struct {
+	Id              string            `json:"id,omitempty"`
+	Namespace       string            `json:"-"`
+	Name            string            `json:"name,omitempty"`
+	Cron            string            `json:"cron,omitempty"`
+	TaskType        string            `json:"task_type,omitempty"`
+	Args            map[string]string `json:"args,omitempty"`
+	Input           string            `json:"input,omitempty"`
+	Status          string            `json:"status,omitempty"`
+	MissedRunPolicy string            `json:"missed_run_policy,omitempty"`
+	NextRunTime     time.Time         `json:"next_run_time,omitempty"`
+	// LastRunTime is only set if
+	// the schedule has run
+	LastRunTime *time.Time `json:"last_run_time,omitempty"`
+	CreateTime  time.Time  `json:"create_time,omitempty"`
+}
+
+func (s *Schedule) MarshalJSON() ([]byte, error) {
+	rs := &RestSchedule{
+		Id:              s.GetId(),
+		Name:            s.GetName(),
+		Cron:            s.GetCron(),
+		TaskType:        s.GetTaskType(),
+		Args:            s.GetArgs(),
+		Input:           s.GetInput(),
+		Status:          s.GetStatus(),
+		MissedRunPolicy: s.GetMissedRunPolicy(),
+		NextRunTime:     s.GetNextRunTime().AsTime(),
+		CreateTime:      s.GetCreateTime().AsTime(),
+	}
+	if s.GetLastRunTime() != nil {
+		lastRunTime := s.GetLastRunTime().AsTime()
+		rs.LastRunTime = &lastRunTime
+	}
+
+	return json.Marshal(rs)
+}
+
+func (s *Schedule) UnmarshalJSON(data []byte) error {
+	rs := &RestSchedule{}
+	if err := json.Unmarshal(data, rs); err != nil {
+		return err
+	}
+
+	s.Id = rs.Id
+	s.Namespace = rs.Namespace
+	s.Name = rs.Name
+	s.Cron = rs.Cron
+	s.TaskType = rs.TaskType
+	s.Args = rs.Args
+	s.Input = rs.Input
+	s.Status = rs.Status
+	s.MissedRunPolicy = rs.MissedRunPolicy
+	s.NextRunTime = timestamppb.New(rs.NextRunTime)
+	if rs.LastRunTime != nil {
+		s.LastRunTime = timestamppb.New(*rs.LastRunTime)
+	}
+	s.CreateTime = timestamppb.New(rs.CreateTime)
+
+	return nil
+}
+
+type RestScheduleRun struct {
+	ScheduleId    string    `json:"schedule_id,omitempty"`
+	ScheduledTime time.Time `json:"scheduled_time,omitempty"`
+	JobId         string    `json:"job_id,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	CreateTime    time.Time `json:"create_time,omitempty"`
+}
+
+func (r *ScheduleRun) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&RestScheduleRun{
+		ScheduleId:    r.GetScheduleId(),
+		ScheduledTime: r.GetScheduledTime().AsTime(),
+		JobId:         r.GetJobId(),
+		Error:         r.GetError(),
+		CreateTime:    r.GetCreateTime().AsTime(),
+	})
+}
+
+func (r *ScheduleRun) UnmarshalJSON(data []byte) error {
+	rr := &RestScheduleRun{}
+	if err := json.Unmarshal(data, rr); err != nil {
+		return err
+	}
+
+	r.ScheduleId = rr.ScheduleId
+	r.ScheduledTime = timestamppb.New(rr.ScheduledTime)
+	r.JobId = rr.JobId
+	r.Error = rr.Error
+	r.CreateTime = timestamppb.New(rr.CreateTime)
+
+	return nil
+}
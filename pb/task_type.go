@@ -15,12 +15,25 @@ const (
 	TaskTypeVectorLookup        TaskType = "vectorlookup"
 	TaskTypeRasterLookup        TaskType = "rasterlookup"
 	TaskTypePolygonVectorLookup TaskType = "polygonvectorlookup"
+	TaskTypeWhere               TaskType = "where"
+	TaskTypeSimplify            TaskType = "simplify"
+	TaskTypeClip                TaskType = "clip"
+	TaskTypeIntersect           TaskType = "intersect"
+	TaskTypeDifference          TaskType = "difference"
+	TaskTypeUnion               TaskType = "union"
+	TaskTypeSpatialJoin         TaskType = "spatialjoin"
+	TaskTypeDissolve            TaskType = "dissolve"
+	TaskTypeDerive              TaskType = "derive"
+	TaskTypeCalculate           TaskType = "calculate"
 )
 
 var AllTaskTypes = []TaskType{
 	TaskTypeBuffer, TaskTypeFilter, TaskTypeRemoveBadGeometry,
 	TaskTypeReproject, TaskTypeVectorLookup, TaskTypeRasterLookup,
-	TaskTypePolygonVectorLookup,
+	TaskTypePolygonVectorLookup, TaskTypeWhere, TaskTypeSimplify,
+	TaskTypeClip, TaskTypeIntersect, TaskTypeDifference,
+	TaskTypeUnion, TaskTypeSpatialJoin, TaskTypeDissolve,
+	TaskTypeDerive, TaskTypeCalculate,
 }
 
 func (t TaskType) String() string {
@@ -0,0 +1,29 @@
+package pb
+
+import (
+	"fmt"
+	"strings"
+)
+
+type UploadStatus string
+
+const (
+	UploadStatusPending  UploadStatus = "pending"
+	UploadStatusComplete UploadStatus = "complete"
+)
+
+func (k UploadStatus) String() string {
+	return string(k)
+}
+
+func ParseUploadStatus(uploadStatus string) (UploadStatus, error) {
+	for _, k := range []UploadStatus{
+		UploadStatusPending, UploadStatusComplete,
+	} {
+		if strings.EqualFold(uploadStatus, k.String()) {
+			return k, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown upload status '%s'", uploadStatus)
+}
@@ -0,0 +1,29 @@
+package pb
+
+import (
+	"fmt"
+	"strings"
+)
+
+type WorkerStatus string
+
+const (
+	WorkerStatusAlive WorkerStatus = "alive"
+	WorkerStatusDead  WorkerStatus = "dead"
+)
+
+func (k WorkerStatus) String() string {
+	return string(k)
+}
+
+func ParseWorkerStatus(workerStatus string) (WorkerStatus, error) {
+	for _, k := range []WorkerStatus{
+		WorkerStatusAlive, WorkerStatusDead,
+	} {
+		if strings.EqualFold(workerStatus, k.String()) {
+			return k, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown worker status '%s'", workerStatus)
+}
@@ -0,0 +1,144 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/frantjc/go-js"
+)
+
+// InitCommand is the subcommand of the current executable
+// that sets up the sandbox from the inside before exec'ing
+// the sandboxed process. It must be registered by the caller,
+// e.g. `rototiller sandbox`.
+var InitCommand = "sandbox"
+
+const (
+	// DefaultUID is the uid that sandboxed processes run as, i.e. "nobody".
+	// An unprivileged caller's own uid is mapped to it.
+	DefaultUID = 65534
+	// DefaultGID is the gid that sandboxed processes run as, i.e. "nogroup".
+	// An unprivileged caller's own gid is mapped to it.
+	DefaultGID = 65534
+)
+
+var (
+	// DefaultEnv is the allowlist of environment variables
+	// that are passed from the caller into the sandbox.
+	DefaultEnv = []string{
+		"PATH", "LANG", "LC_ALL", "TZ",
+		"GDAL_DATA", "GDAL_DRIVER_PATH", "PROJ_LIB", "PROJ_DATA",
+	}
+	// DefaultReadOnlyPaths are the paths from the caller's filesystem
+	// that are made visible read-only inside of the sandbox at the same location
+	// so that dynamically linked executables can run. Paths that do not exist are skipped.
+	DefaultReadOnlyPaths = []string{
+		"/bin", "/sbin", "/lib", "/lib32", "/lib64", "/usr",
+		"/etc/ld.so.cache", "/etc/ld.so.conf", "/etc/ld.so.conf.d",
+		"/etc/ld-musl-x86_64.path", "/etc/ld-musl-aarch64.path",
+	}
+	// devices are bind mounted into the sandbox's /dev.
+	devices = []string{
+		"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom",
+	}
+)
+
+// Mount describes a path from the caller's filesystem
+// that is made visible inside of the sandbox.
+type Mount struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// String formats the Mount as "source:target[:ro]".
+func (m *Mount) String() string {
+	return m.Source + ":" + m.Target + js.Ternary(m.ReadOnly, ":ro", "")
+}
+
+// ParseMount parses a Mount from the format "source:target[:ro]".
+func ParseMount(s string) (*Mount, error) {
+	parts := strings.Split(s, ":")
+	switch {
+	case len(parts) == 2:
+		return &Mount{Source: parts[0], Target: parts[1]}, nil
+	case len(parts) == 3 && parts[2] == "ro":
+		return &Mount{Source: parts[0], Target: parts[1], ReadOnly: true}, nil
+	case len(parts) == 3 && parts[2] == "rw":
+		return &Mount{Source: parts[0], Target: parts[1]}, nil
+	}
+
+	return nil, fmt.Errorf("invalid mount '%s'", s)
+}
+
+// Sandbox runs processes inside of Linux namespaces with an isolated
+// filesystem, no network access, an unprivileged uid and an allowlisted
+// environment.
+type Sandbox struct {
+	UID           int
+	GID           int
+	Env           []string
+	ReadOnlyPaths []string
+}
+
+type Opt func(*Sandbox)
+
+func WithUser(uid, gid int) Opt {
+	return func(s *Sandbox) {
+		s.UID = uid
+		s.GID = gid
+	}
+}
+
+// WithEnv adds environment variable names to the allowlist
+// of those passed from the caller into the sandbox.
+func WithEnv(env ...string) Opt {
+	return func(s *Sandbox) {
+		s.Env = append(s.Env, env...)
+	}
+}
+
+// WithReadOnlyPaths adds paths from the caller's filesystem
+// to those made visible read-only inside of the sandbox.
+func WithReadOnlyPaths(paths ...string) Opt {
+	return func(s *Sandbox) {
+		s.ReadOnlyPaths = append(s.ReadOnlyPaths, paths...)
+	}
+}
+
+func New(opts ...Opt) *Sandbox {
+	s := &Sandbox{
+		UID:           DefaultUID,
+		GID:           DefaultGID,
+		Env:           append([]string{}, DefaultEnv...),
+		ReadOnlyPaths: append([]string{}, DefaultReadOnlyPaths...),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// filterEnv returns the entries from env whose names are allowlisted.
+func (s *Sandbox) filterEnv(env []string) []string {
+	return js.Filter(env, func(e string, _ int, _ []string) bool {
+		name, _, _ := strings.Cut(e, "=")
+		return js.Includes(s.Env, name)
+	})
+}
+
+// readOnlyMounts returns Mounts for each of the Sandbox's
+// ReadOnlyPaths that exist.
+func (s *Sandbox) readOnlyMounts() []*Mount {
+	mounts := []*Mount{}
+	for _, path := range s.ReadOnlyPaths {
+		if _, err := os.Stat(path); err == nil {
+			mounts = append(mounts, &Mount{Source: path, Target: path, ReadOnly: true})
+		}
+	}
+
+	return mounts
+}
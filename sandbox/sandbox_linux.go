@@ -0,0 +1,281 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Command returns an *exec.Cmd that runs the named executable inside of a new
+// sandbox whose root filesystem is built on top of the empty directory root.
+// The executable only sees the Sandbox's ReadOnlyPaths, the given mounts and
+// a minimal /dev, /proc and /tmp; it has no network access and its environment
+// is made up of the caller's allowlisted environment plus env.
+func (s *Sandbox) Command(root string, mounts []*Mount, env []string, name string, arg ...string) (*exec.Cmd, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if path, err = filepath.Abs(path); err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	var (
+		privileged  = os.Geteuid() == 0
+		uid, gid    = -1, -1
+		allMounts   = append(s.readOnlyMounts(), mounts...)
+		cloneflags  = uintptr(unix.CLONE_NEWNS | unix.CLONE_NEWNET | unix.CLONE_NEWPID | unix.CLONE_NEWIPC | unix.CLONE_NEWUTS)
+		sysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+	)
+
+	if !isVisible(allMounts, path) {
+		// make the executable itself visible if it
+		// does not live in one of the mounted paths
+		allMounts = append(allMounts, &Mount{Source: path, Target: path, ReadOnly: true})
+	}
+
+	if privileged {
+		// the process will drop down to uid:gid after setting
+		// up the sandbox, so it needs to own the writable mounts
+		uid, gid = s.UID, s.GID
+		for _, m := range mounts {
+			if !m.ReadOnly {
+				if err = os.Chown(m.Source, uid, gid); err != nil {
+					return nil, err
+				}
+			}
+		}
+	} else {
+		// an unprivileged caller can only create the other namespaces from
+		// inside of a new user namespace, in which it can only map its own uid,
+		// so it is mapped to uid:gid rather than to root. It is only given the
+		// capability that it needs to set up the sandbox, which it gives up
+		// before exec'ing the executable. It remains the caller's unprivileged
+		// uid from the host's perspective
+		cloneflags |= unix.CLONE_NEWUSER
+		sysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: s.UID, HostID: os.Geteuid(), Size: 1}}
+		sysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: s.GID, HostID: os.Getegid(), Size: 1}}
+		sysProcAttr.GidMappingsEnableSetgroups = false
+		sysProcAttr.AmbientCaps = []uintptr{unix.CAP_SYS_ADMIN}
+	}
+	sysProcAttr.Cloneflags = cloneflags
+
+	args := []string{
+		InitCommand,
+		"--root", root,
+		"--uid", strconv.Itoa(uid),
+		"--gid", strconv.Itoa(gid),
+	}
+	for _, m := range allMounts {
+		args = append(args, "--mount", m.String())
+	}
+	args = append(append(args, "--", path), arg...)
+
+	cmd := exec.Command("/proc/self/exe", args...)
+	cmd.Env = append(s.filterEnv(os.Environ()), env...)
+	cmd.SysProcAttr = sysProcAttr
+
+	return cmd, nil
+}
+
+// isVisible reports whether path on the host is at the same path
+// inside of a sandbox with the given mounts.
+func isVisible(mounts []*Mount, path string) bool {
+	for _, m := range mounts {
+		if m.Source == m.Target && (path == m.Target || strings.HasPrefix(path, m.Target+"/")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Init is run as the first process inside of the sandbox's namespaces.
+// It builds the sandbox's root filesystem at root from mounts, pivots into it,
+// drops privileges to uid:gid (unless they are negative), as well as any
+// capabilities that it was given to do so, and then execs the executable
+// at path, replacing itself.
+func Init(root string, mounts []*Mount, uid, gid int, path string, arg ...string) error {
+	runtime.LockOSThread()
+
+	// make sure that nothing that happens in here propagates back to the host
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make / private: %w", err)
+	}
+
+	if err := unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755"); err != nil {
+		return fmt.Errorf("mount root: %w", err)
+	}
+
+	for _, m := range mounts {
+		if err := bind(root, m); err != nil {
+			return err
+		}
+	}
+
+	for _, device := range devices {
+		if _, err := os.Stat(device); err == nil {
+			if err := bind(root, &Mount{Source: device, Target: device}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for target, fstype := range map[string]string{"/proc": "proc", "/tmp": "tmpfs"} {
+		dir := filepath.Join(root, target)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+
+		if err := unix.Mount(fstype, dir, fstype, unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+			return fmt.Errorf("mount %s: %w", target, err)
+		}
+	}
+
+	if err := pivotRoot(root); err != nil {
+		return err
+	}
+
+	if err := unix.Sethostname([]byte("sandbox")); err != nil {
+		return fmt.Errorf("set hostname: %w", err)
+	}
+
+	if gid >= 0 {
+		if err := syscall.Setgroups([]int{}); err != nil {
+			return fmt.Errorf("set groups: %w", err)
+		}
+
+		if err := syscall.Setgid(gid); err != nil {
+			return fmt.Errorf("set gid: %w", err)
+		}
+	}
+
+	if uid >= 0 {
+		if err := syscall.Setuid(uid); err != nil {
+			return fmt.Errorf("set uid: %w", err)
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil {
+		return fmt.Errorf("clear ambient capabilities: %w", err)
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("set no new privileges: %w", err)
+	}
+
+	if err := os.Chdir("/tmp"); err != nil {
+		return err
+	}
+
+	return unix.Exec(path, append([]string{path}, arg...), os.Environ())
+}
+
+// bind bind mounts the Mount's source to its target inside of root,
+// remounting it read-only if need be.
+func bind(root string, m *Mount) error {
+	var (
+		target = filepath.Join(root, m.Target)
+		flags  = uintptr(unix.MS_BIND | unix.MS_REC)
+	)
+
+	fi, err := os.Stat(m.Source)
+	if err != nil {
+		return err
+	}
+
+	if fi.IsDir() {
+		if err = os.MkdirAll(target, 0o755); err != nil {
+			return err
+		}
+	} else {
+		if err = os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+
+		f, err := os.OpenFile(target, os.O_CREATE, 0o644)
+		if err != nil {
+			return err
+		}
+		f.Close()
+	}
+
+	if err = unix.Mount(m.Source, target, "", flags, ""); err != nil {
+		return fmt.Errorf("bind mount %s: %w", m.Source, err)
+	}
+
+	if m.ReadOnly {
+		// flags that are already set on the source, e.g. nosuid,
+		// are locked when inside of a user namespace and must be kept
+		statfs := &unix.Statfs_t{}
+		if err = unix.Statfs(target, statfs); err != nil {
+			return err
+		}
+
+		for st, ms := range map[int64]uintptr{
+			unix.ST_NOSUID:     unix.MS_NOSUID,
+			unix.ST_NODEV:      unix.MS_NODEV,
+			unix.ST_NOEXEC:     unix.MS_NOEXEC,
+			unix.ST_NOATIME:    unix.MS_NOATIME,
+			unix.ST_NODIRATIME: unix.MS_NODIRATIME,
+			unix.ST_RELATIME:   unix.MS_RELATIME,
+		} {
+			if int64(statfs.Flags)&st != 0 {
+				flags |= ms
+			}
+		}
+
+		if err = unix.Mount("", target, "", flags|unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID, ""); err != nil {
+			return fmt.Errorf("remount %s read-only: %w", m.Source, err)
+		}
+	}
+
+	return nil
+}
+
+// pivotRoot makes root the new root filesystem
+// and detaches the old one.
+func pivotRoot(root string) error {
+	old := filepath.Join(root, ".old")
+	if err := os.MkdirAll(old, 0o700); err != nil {
+		return err
+	}
+
+	if err := unix.PivotRoot(root, old); err != nil {
+		return fmt.Errorf("pivot root: %w", err)
+	}
+
+	if err := os.Chdir("/"); err != nil {
+		return err
+	}
+
+	if err := unix.Unmount("/.old", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("unmount old root: %w", err)
+	}
+
+	if err := os.Remove("/.old"); err != nil {
+		return err
+	}
+
+	// nothing should be written to the root filesystem itself,
+	// only to the writable mounts
+	if err := unix.Mount("", "/", "", unix.MS_BIND|unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("remount root read-only: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// Command is only supported on Linux.
+func (s *Sandbox) Command(root string, mounts []*Mount, env []string, name string, arg ...string) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("sandbox not supported on %s", runtime.GOOS)
+}
+
+// Init is only supported on Linux.
+func Init(root string, mounts []*Mount, uid, gid int, path string, arg ...string) error {
+	return fmt.Errorf("sandbox not supported on %s", runtime.GOOS)
+}
@@ -45,11 +45,14 @@ func New(ctx context.Context, addr string) (*Blobstore, error) {
 		return nil, err
 	}
 
-	return &Blobstore{bucket}, nil
+	return &Blobstore{Bucket: bucket, name: u.Host}, nil
 }
 
 type Blobstore struct {
 	*blob.Bucket
+	// name is the name of the bucket, which
+	// copies within it name their source by
+	name string
 }
 
 func (b *Blobstore) GetObject(ctx context.Context, id string) (volume.Volume, error) {
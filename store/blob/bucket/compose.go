@@ -0,0 +1,149 @@
+package bucket
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	s3v2 "github.com/aws/aws-sdk-go-v2/service/s3"
+	s3v2types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	awsv1 "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gocloud.dev/blob"
+)
+
+// minCopyPartSize is the smallest that S3 allows
+// each but the last part of a multipart upload to be.
+const minCopyPartSize = 5 << 20
+
+// ErrComposeUnsupported is returned when the bucket cannot assemble parts within
+// itself, in which case they must be read and written as one instead.
+var ErrComposeUnsupported = errors.New("bucket does not support composing parts")
+
+// ComposeParts assembles the given parts of the upload, in order, as the named
+// file of the object with the given ID by copying them within the bucket,
+// so that they need not be read and written again by us.
+func (b *Blobstore) ComposeParts(ctx context.Context, uploadID string, parts []*Part, id, name string) error {
+	key := path.Join(id, name)
+	if len(parts) == 1 {
+		return b.Copy(ctx, key, partKey(uploadID, parts[0].Number), &blob.CopyOptions{})
+	}
+
+	for _, p := range parts[:len(parts)-1] {
+		if p.Size < minCopyPartSize {
+			return ErrComposeUnsupported
+		}
+	}
+
+	var (
+		client   *s3.S3
+		clientV2 *s3v2.Client
+	)
+	switch {
+	case b.As(&client):
+		return b.composeS3(ctx, client, uploadID, parts, key)
+	case b.As(&clientV2):
+		return b.composeS3V2(ctx, clientV2, uploadID, parts, key)
+	}
+
+	return ErrComposeUnsupported
+}
+
+// copySource returns the source of a copy of the key within the bucket.
+func (b *Blobstore) copySource(key string) string {
+	return (&url.URL{Path: path.Join(b.name, key)}).EscapedPath()
+}
+
+func (b *Blobstore) composeS3(ctx context.Context, client *s3.S3, uploadID string, parts []*Part, key string) error {
+	mu, err := client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: awsv1.String(b.name),
+		Key:    awsv1.String(key),
+	})
+	if err != nil {
+		return err
+	}
+
+	abort := func(err error) error {
+		_, _ = client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   awsv1.String(b.name),
+			Key:      awsv1.String(key),
+			UploadId: mu.UploadId,
+		})
+		return err
+	}
+
+	completed := make([]*s3.CompletedPart, len(parts))
+	for i, p := range parts {
+		out, err := client.UploadPartCopyWithContext(ctx, &s3.UploadPartCopyInput{
+			Bucket:     awsv1.String(b.name),
+			Key:        awsv1.String(key),
+			UploadId:   mu.UploadId,
+			PartNumber: awsv1.Int64(int64(i + 1)),
+			CopySource: awsv1.String(b.copySource(partKey(uploadID, p.Number))),
+		})
+		if err != nil {
+			return abort(err)
+		}
+
+		completed[i] = &s3.CompletedPart{ETag: out.CopyPartResult.ETag, PartNumber: awsv1.Int64(int64(i + 1))}
+	}
+
+	if _, err = client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          awsv1.String(b.name),
+		Key:             awsv1.String(key),
+		UploadId:        mu.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	}); err != nil {
+		return abort(err)
+	}
+
+	return nil
+}
+
+func (b *Blobstore) composeS3V2(ctx context.Context, client *s3v2.Client, uploadID string, parts []*Part, key string) error {
+	mu, err := client.CreateMultipartUpload(ctx, &s3v2.CreateMultipartUploadInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return err
+	}
+
+	abort := func(err error) error {
+		_, _ = client.AbortMultipartUpload(ctx, &s3v2.AbortMultipartUploadInput{
+			Bucket:   aws.String(b.name),
+			Key:      aws.String(key),
+			UploadId: mu.UploadId,
+		})
+		return err
+	}
+
+	completed := make([]s3v2types.CompletedPart, len(parts))
+	for i, p := range parts {
+		out, err := client.UploadPartCopy(ctx, &s3v2.UploadPartCopyInput{
+			Bucket:     aws.String(b.name),
+			Key:        aws.String(key),
+			UploadId:   mu.UploadId,
+			PartNumber: int32(i + 1),
+			CopySource: aws.String(b.copySource(partKey(uploadID, p.Number))),
+		})
+		if err != nil {
+			return abort(err)
+		}
+
+		completed[i] = s3v2types.CompletedPart{ETag: out.CopyPartResult.ETag, PartNumber: int32(i + 1)}
+	}
+
+	if _, err = client.CompleteMultipartUpload(ctx, &s3v2.CompleteMultipartUploadInput{
+		Bucket:          aws.String(b.name),
+		Key:             aws.String(key),
+		UploadId:        mu.UploadId,
+		MultipartUpload: &s3v2types.CompletedMultipartUpload{Parts: completed},
+	}); err != nil {
+		return abort(err)
+	}
+
+	return nil
+}
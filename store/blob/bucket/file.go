@@ -0,0 +1,121 @@
+package bucket
+
+import (
+	"context"
+	"io"
+	"path"
+	"sync"
+
+	"gocloud.dev/blob"
+)
+
+// PutFile writes r as the named file of the object, returning how
+// many bytes it wrote. Unlike PutObject, if reading r fails, the write
+// is aborted rather than leaving what was read so far as the file.
+func (b *Blobstore) PutFile(ctx context.Context, id, name string, r io.Reader) (int64, error) {
+	// canceling the context that a blob.Writer was
+	// created with is the only way to abort its write
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w, err := b.NewWriter(ctx, path.Join(id, name), &blob.WriterOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := io.Copy(w, r)
+	if err != nil {
+		cancel()
+		_ = w.Close()
+		return 0, err
+	}
+
+	return size, w.Close()
+}
+
+// NewFileReaderAt returns an io.ReaderAt of the named file of the object,
+// which is of the given size, e.g. so that the directory at the end of a
+// zip can be read without reading the rest of it. The file is read a block
+// at a time and the most recently read blocks are kept, so that the many
+// small reads that e.g. a zip reader makes are not each a request.
+func (b *Blobstore) NewFileReaderAt(ctx context.Context, id, name string, size int64) io.ReaderAt {
+	return &fileReaderAt{ctx: ctx, b: b, key: path.Join(id, name), size: size, blocks: map[int64][]byte{}}
+}
+
+const (
+	// blockSize is how much of a file fileReaderAt reads at a time.
+	blockSize = 1 << 20
+	// maxBlocks is how many blocks fileReaderAt keeps, which is more
+	// than one so that reading two members of a zip in turn, e.g. a
+	// shapefile's .shp and .dbf, does not read each block repeatedly.
+	maxBlocks = 4
+)
+
+type fileReaderAt struct {
+	ctx  context.Context
+	b    *Blobstore
+	key  string
+	size int64
+
+	mu     sync.Mutex
+	blocks map[int64][]byte
+	// order is the blocks from least to most recently read
+	order []int64
+}
+
+func (r *fileReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for n < len(p) {
+		if off+int64(n) >= r.size {
+			return n, io.EOF
+		}
+
+		i := (off + int64(n)) / blockSize
+		block, err := r.block(i)
+		if err != nil {
+			return n, err
+		}
+
+		n += copy(p[n:], block[off+int64(n)-i*blockSize:])
+	}
+
+	return n, nil
+}
+
+// block returns the ith block of the file, reading it if it is not kept.
+func (r *fileReaderAt) block(i int64) ([]byte, error) {
+	for j, k := range r.order {
+		if k == i {
+			r.order = append(append(r.order[:j:j], r.order[j+1:]...), i)
+			return r.blocks[i], nil
+		}
+	}
+
+	length := int64(blockSize)
+	if rest := r.size - i*blockSize; rest < length {
+		length = rest
+	}
+
+	br, err := r.b.NewRangeReader(r.ctx, r.key, i*blockSize, length, &blob.ReaderOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer br.Close()
+
+	block := make([]byte, length)
+	if _, err = io.ReadFull(br, block); err != nil {
+		return nil, err
+	}
+
+	if len(r.order) == maxBlocks {
+		delete(r.blocks, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.blocks[i] = block
+	r.order = append(r.order, i)
+
+	return block, nil
+}
@@ -0,0 +1,150 @@
+package bucket
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gocloud.dev/blob"
+)
+
+// uploadsPrefix is where the parts of uploads are kept until they are
+// assembled. It cannot collide with storages, whose IDs are UUIDs.
+const uploadsPrefix = "uploads"
+
+// Part is a part of an upload.
+type Part struct {
+	Number   int
+	Size     int64
+	Checksum string
+}
+
+func partsPrefix(id string) string {
+	return path.Join(uploadsPrefix, id) + "/"
+}
+
+func partKey(id string, number int) string {
+	// zero-padded so that the parts list in order
+	return partsPrefix(id) + fmt.Sprintf("%05d", number)
+}
+
+// PutPart writes the numbered part of the upload, replacing
+// it if it was already written, e.g. by an interrupted attempt.
+func (b *Blobstore) PutPart(ctx context.Context, id string, number int, r io.Reader) (*Part, error) {
+	w, err := b.NewWriter(ctx, partKey(id, number), &blob.WriterOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+
+	var (
+		hash = sha256.New()
+		size int64
+	)
+	if size, err = io.Copy(io.MultiWriter(w, hash), r); err != nil {
+		return nil, err
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	return &Part{Number: number, Size: size, Checksum: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// ListParts returns the parts of the upload that have been written, in order.
+func (b *Blobstore) ListParts(ctx context.Context, id string) ([]*Part, error) {
+	var (
+		li    = b.List(&blob.ListOptions{Prefix: partsPrefix(id)})
+		parts = []*Part{}
+	)
+	for {
+		lo, err := li.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if lo.IsDir {
+			continue
+		}
+
+		number, err := strconv.Atoi(strings.TrimPrefix(lo.Key, partsPrefix(id)))
+		if err != nil {
+			continue
+		}
+
+		parts = append(parts, &Part{Number: number, Size: lo.Size})
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Number < parts[j].Number
+	})
+
+	return parts, nil
+}
+
+// GetParts returns the given parts of the upload read one after the other.
+func (b *Blobstore) GetParts(ctx context.Context, id string, parts []*Part) io.ReadCloser {
+	return &partsReader{ctx: ctx, b: b, id: id, parts: parts}
+}
+
+// DeleteParts deletes every part of the upload.
+func (b *Blobstore) DeleteParts(ctx context.Context, id string) error {
+	return b.DeleteObject(ctx, partsPrefix(id))
+}
+
+// partsReader opens each part only once the previous one has been read,
+// so that only one is open at a time.
+type partsReader struct {
+	ctx   context.Context
+	b     *Blobstore
+	id    string
+	parts []*Part
+	r     *blob.Reader
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.r == nil {
+			if len(r.parts) == 0 {
+				return 0, io.EOF
+			}
+
+			var err error
+			if r.r, err = r.b.NewReader(r.ctx, partKey(r.id, r.parts[0].Number), &blob.ReaderOptions{}); err != nil {
+				return 0, err
+			}
+			r.parts = r.parts[1:]
+		}
+
+		n, err := r.r.Read(p)
+		if errors.Is(err, io.EOF) {
+			_ = r.r.Close()
+			r.r = nil
+			if n > 0 {
+				return n, nil
+			}
+
+			continue
+		}
+
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() error {
+	if r.r != nil {
+		return r.r.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package bucket
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	s3v2 "github.com/aws/aws-sdk-go-v2/service/s3"
+	awsv1 "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+// ErrSigningUnsupported is returned when the bucket's driver cannot sign
+// URLs, in which case content must be transferred through the API instead.
+var ErrSigningUnsupported = errors.New("bucket does not support signed URLs")
+
+func (b *Blobstore) signURL(ctx context.Context, key, method string, expiry time.Duration, beforeSign func(func(any) bool) error) (string, error) {
+	u, err := b.SignedURL(ctx, key, &blob.SignedURLOptions{
+		Method:     method,
+		Expiry:     expiry,
+		BeforeSign: beforeSign,
+	})
+	if gcerrors.Code(err) == gcerrors.Unimplemented || errors.Is(err, ErrSigningUnsupported) {
+		return "", ErrSigningUnsupported
+	}
+
+	return u, err
+}
+
+// SignPartURL returns a URL that the numbered part of the upload, which must
+// be of the given size, can be PUT to directly for expiry. The size is signed
+// into the URL, so that it cannot be used to get around the upload quota.
+// Buckets that cannot sign it are treated as if they could not sign URLs.
+func (b *Blobstore) SignPartURL(ctx context.Context, id string, number int, size int64, expiry time.Duration) (string, error) {
+	return b.signURL(ctx, partKey(id, number), http.MethodPut, expiry, func(as func(any) bool) error {
+		var (
+			in   *s3.PutObjectInput
+			inV2 *s3v2.PutObjectInput
+		)
+		switch {
+		case as(&in):
+			in.ContentLength = awsv1.Int64(size)
+		case as(&inV2):
+			inV2.ContentLength = size
+		default:
+			return ErrSigningUnsupported
+		}
+
+		return nil
+	})
+}
+
+// SignObjectFileURL returns a URL that the named file
+// of the object can be GET from directly for expiry.
+func (b *Blobstore) SignObjectFileURL(ctx context.Context, id, name string, expiry time.Duration) (string, error) {
+	return b.signURL(ctx, path.Join(id, name), http.MethodGet, expiry, nil)
+}
+
+// ListObjectFiles returns the names of the object's files
+// without reading them, in the order that GetObject would.
+func (b *Blobstore) ListObjectFiles(ctx context.Context, id string) ([]string, error) {
+	var (
+		li    = b.List(&blob.ListOptions{Prefix: id + "/"})
+		names = []string{}
+	)
+	for {
+		lo, err := li.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if !lo.IsDir {
+			names = append(names, strings.TrimPrefix(lo.Key, id+"/"))
+		}
+	}
+
+	return names, nil
+}
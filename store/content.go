@@ -0,0 +1,282 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/logsquaredn/rototiller/encoding/format"
+	"github.com/logsquaredn/rototiller/store/blob/bucket"
+	"github.com/logsquaredn/rototiller/volume"
+	"gocloud.dev/blob"
+)
+
+// Content is validated content that a storage is created from, either held
+// in memory or already written to the blobstore as it was validated.
+type Content interface {
+	// id is the ID of the object that the content is written to,
+	// which becomes the ID of its content if it is not a duplicate.
+	id() string
+	// put writes the content as the object with the given ID.
+	put(ctx context.Context, blobstore *bucket.Blobstore, id string) error
+	// discard deletes whatever of the content was written
+	// to the blobstore, e.g. once it is found to be a duplicate.
+	discard(ctx context.Context, blobstore *bucket.Blobstore) error
+}
+
+// VolumeContent returns the Content of the volume, which is
+// written to the blobstore only once it is known to be needed.
+func VolumeContent(vol volume.Volume) Content {
+	return &volumeContent{objectID: uuid.NewString(), vol: vol}
+}
+
+type volumeContent struct {
+	objectID string
+	vol      volume.Volume
+}
+
+func (c *volumeContent) id() string {
+	return c.objectID
+}
+
+func (c *volumeContent) put(ctx context.Context, blobstore *bucket.Blobstore, id string) error {
+	return blobstore.PutObject(ctx, id, c.vol)
+}
+
+func (c *volumeContent) discard(context.Context, *bucket.Blobstore) error {
+	return nil
+}
+
+// stagedContent is Content that was written to the blobstore as it was read.
+type stagedContent struct {
+	objectID string
+}
+
+func (c *stagedContent) id() string {
+	return c.objectID
+}
+
+func (c *stagedContent) put(ctx context.Context, blobstore *bucket.Blobstore, id string) error {
+	if id == c.objectID {
+		return nil
+	}
+
+	li := blobstore.List(&blob.ListOptions{Prefix: c.objectID + "/"})
+	for {
+		lo, err := li.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if !lo.IsDir {
+			if err = blobstore.Copy(ctx, path.Join(id, path.Base(lo.Key)), lo.Key, &blob.CopyOptions{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return c.discard(ctx, blobstore)
+}
+
+func (c *stagedContent) discard(ctx context.Context, blobstore *bucket.Blobstore) error {
+	return blobstore.DeleteObject(ctx, c.objectID)
+}
+
+// Ingest validates the content of the given Format that is read from r,
+// which is named name plus the extension of its Format once stored. It is
+// first sniffed from no more than format.SniffLen bytes, so that mislabeled
+// content is rejected before the rest of it is read. Content of Formats that
+// tasks can read, which may be large, is then written to the blobstore as
+// it is validated rather than being held in memory, and is deleted if it
+// is invalid. If it is, a *format.ValidationError is returned.
+func Ingest(ctx context.Context, blobstore *bucket.Blobstore, f *format.Format, name string, r io.Reader) (*format.Info, Content, error) {
+	br, err := sniff(f, r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !f.Native() {
+		b, err := io.ReadAll(br)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		info, err := format.Validate(f, b)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		vol, err := info.Volume(name, b)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return info, VolumeContent(vol), nil
+	}
+
+	content := &stagedContent{objectID: uuid.NewString()}
+
+	info, err := stage(ctx, blobstore, content.objectID, f, name+f.Ext, br)
+	if err != nil {
+		_ = content.discard(ctx, blobstore)
+		return nil, nil, err
+	}
+
+	return info, content, nil
+}
+
+// IngestParts validates the content of the given Format that is the given
+// parts of the upload, assembled in order, just as Ingest does. Where the
+// bucket can, they are assembled within it and only read back to validate
+// them, rather than being written again.
+func IngestParts(ctx context.Context, blobstore *bucket.Blobstore, f *format.Format, uploadID string, parts []*bucket.Part, name string) (*format.Info, Content, error) {
+	content := &stagedContent{objectID: uuid.NewString()}
+
+	switch err := blobstore.ComposeParts(ctx, uploadID, parts, content.objectID, name+f.Ext); {
+	case errors.Is(err, bucket.ErrComposeUnsupported):
+		r := blobstore.GetParts(ctx, uploadID, parts)
+		defer r.Close()
+
+		return Ingest(ctx, blobstore, f, name, r)
+	case err != nil:
+		_ = content.discard(ctx, blobstore)
+		return nil, nil, err
+	}
+
+	info, c, err := ingestObject(ctx, blobstore, f, content, name+f.Ext)
+	if err != nil || c != content {
+		_ = content.discard(ctx, blobstore)
+	}
+
+	return info, c, err
+}
+
+// Discard deletes whatever of the content was written to the
+// blobstore, e.g. once it is found not to be what was expected.
+func Discard(ctx context.Context, blobstore *bucket.Blobstore, c Content) error {
+	return c.discard(ctx, blobstore)
+}
+
+// sniff checks that what is read from r looks like the
+// given Format, returning a reader of all of it.
+func sniff(f *format.Format, r io.Reader) (*bufio.Reader, error) {
+	br := bufio.NewReaderSize(r, format.SniffLen)
+	head, err := br.Peek(format.SniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if err = format.ValidateHead(f, head); err != nil {
+		return nil, err
+	}
+
+	return br, nil
+}
+
+// ingestObject validates the content of the given Format that is already the
+// named file of the staged content by reading it back. Formats that tasks
+// cannot read are ingested from it anew, just as Ingest would ingest them.
+func ingestObject(ctx context.Context, blobstore *bucket.Blobstore, f *format.Format, content *stagedContent, name string) (*format.Info, Content, error) {
+	r, err := blobstore.NewReader(ctx, path.Join(content.objectID, name), &blob.ReaderOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+
+	if !f.Native() {
+		return Ingest(ctx, blobstore, f, strings.TrimSuffix(name, f.Ext), r)
+	}
+
+	h := sha256.New()
+	br, err := sniff(f, io.TeeReader(r, h))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var info *format.Info
+	switch f {
+	case format.GeoJSON:
+		if info, err = format.ValidateGeoJSON(br); err == nil {
+			_, err = io.Copy(io.Discard, br)
+		}
+	case format.Shapefile:
+		// a zip's directory is at its end, so it is read to
+		// hash it but validated by reading only what it needs
+		if _, err = io.Copy(io.Discard, br); err == nil {
+			info, err = format.ValidateShapefile(blobstore.NewFileReaderAt(ctx, content.objectID, name, r.Size()), r.Size())
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info.Size = r.Size()
+	info.Checksum = hex.EncodeToString(h.Sum(nil))
+
+	return info, content, nil
+}
+
+// stage writes what is read from r as the named file of the object with
+// the given ID, validating it as content of the given Format as it does.
+func stage(ctx context.Context, blobstore *bucket.Blobstore, id string, f *format.Format, name string, r io.Reader) (*format.Info, error) {
+	var (
+		h    = sha256.New()
+		info *format.Info
+		size int64
+		err  error
+	)
+	switch f {
+	case format.GeoJSON:
+		var (
+			pr, pw    = io.Pipe()
+			validated = make(chan struct{})
+			valErr    error
+		)
+		go func() {
+			defer close(validated)
+
+			if info, valErr = format.ValidateGeoJSON(pr); valErr != nil {
+				// stop the write, as the content is invalid
+				_ = pr.CloseWithError(valErr)
+				return
+			}
+
+			_, _ = io.Copy(io.Discard, pr)
+		}()
+
+		size, err = blobstore.PutFile(ctx, id, name, io.TeeReader(r, io.MultiWriter(h, pw)))
+		_ = pw.CloseWithError(err)
+		<-validated
+
+		// an invalid write is more telling than the truncated content
+		// that it leaves the validator with, unless the invalid
+		// content is itself why the write was stopped
+		if err == nil {
+			err = valErr
+		}
+	case format.Shapefile:
+		// a zip's directory is at its end, so it
+		// is only validated once it is all written
+		if size, err = blobstore.PutFile(ctx, id, name, io.TeeReader(r, h)); err != nil {
+			return nil, err
+		}
+
+		info, err = format.ValidateShapefile(blobstore.NewFileReaderAt(ctx, id, name, size), size)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info.Size = size
+	info.Checksum = hex.EncodeToString(h.Sum(nil))
+
+	return info, nil
+}
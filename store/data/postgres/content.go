@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"database/sql"
+	_ "embed"
+	"errors"
+	"time"
+
+	"github.com/logsquaredn/rototiller/pb"
+)
+
+var (
+	//go:embed sql/execs/acquire_content.sql
+	acquireContentSQL string
+
+	//go:embed sql/execs/release_content.sql
+	releaseContentSQL string
+
+	//go:embed sql/execs/delete_content.sql
+	deleteContentSQL string
+
+	//go:embed sql/execs/ready_content.sql
+	readyContentSQL string
+
+	//go:embed sql/execs/abandon_content.sql
+	abandonContentSQL string
+)
+
+// staleContentAfter is how long content may be pending before it is taken to
+// have been abandoned mid-write, e.g. by a crash, so that it can be taken over.
+const staleContentAfter = 15 * time.Minute
+
+// ErrContentPending is returned when the content is still being written by
+// another caller, in which case it cannot yet be linked to and is not acquired.
+var ErrContentPending = errors.New("content is still being written")
+
+// AcquireContent adds a reference to the namespace's ready content of the
+// given format with the given SHA-256 checksum, returning its ID and whether
+// it is new, in which case its ID is the given one, it is pending and the
+// caller is responsible for writing its blob and then calling ReadyContent
+// or, if that fails, AbandonContent. If the content is pending, having yet
+// to be written by another caller, ErrContentPending is returned.
+func (d *Datastore) AcquireContent(id, namespace, format, checksum string) (string, bool, error) {
+	var status string
+	if err := d.stmt.acquireContent.QueryRow(
+		id, namespace, format, checksum, staleContentAfter.Seconds(),
+	).Scan(&id, &status); errors.Is(err, sql.ErrNoRows) {
+		return "", false, ErrContentPending
+	} else if err != nil {
+		return "", false, err
+	}
+
+	return id, status == pb.ContentStatusPending.String(), nil
+}
+
+// ReadyContent marks the pending content as written, so that it can be
+// acquired by others. If the caller took so long to write it that it was
+// taken over, ErrContentPending is returned, as it is no longer the caller's.
+func (d *Datastore) ReadyContent(id string) error {
+	if err := d.stmt.readyContent.QueryRow(id).Scan(&id); errors.Is(err, sql.ErrNoRows) {
+		return ErrContentPending
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AbandonContent deletes the pending content, e.g. once writing its blob
+// failed. Since pending content cannot be acquired by others, its blob
+// is the caller's alone to delete. Content that is ready is kept.
+func (d *Datastore) AbandonContent(id string) error {
+	if err := d.stmt.abandonContent.QueryRow(id).Scan(&id); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseContent removes a reference to the content, returning whether that
+// was the last one, in which case its record is gone and the caller is
+// responsible for deleting its blob. Content that was acquired again in
+// the meantime is kept.
+func (d *Datastore) ReleaseContent(id string) (bool, error) {
+	var refCount int
+	if err := d.stmt.releaseContent.QueryRow(id).Scan(&refCount); err != nil {
+		return false, err
+	}
+
+	if refCount > 0 {
+		return false, nil
+	}
+
+	if err := d.stmt.deleteContent.QueryRow(id).Scan(&id); errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
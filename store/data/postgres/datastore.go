@@ -28,6 +28,8 @@ type Datastore struct {
 		getTasksByJobID         *sql.Stmt
 		getTaskByType           *sql.Stmt
 		getTasksByTypes         *sql.Stmt
+		getTasks                *sql.Stmt
+		upsertTask              *sql.Stmt
 		getStorage              *sql.Stmt
 		createStorage           *sql.Stmt
 		deleteStorage           *sql.Stmt
@@ -39,6 +41,48 @@ type Datastore struct {
 		getInputStorageByJobID  *sql.Stmt
 		createStep              *sql.Stmt
 		getStepsByJobID         *sql.Stmt
+		createUpload            *sql.Stmt
+		updateUpload            *sql.Stmt
+		deleteUpload            *sql.Stmt
+		getUpload               *sql.Stmt
+		getUploadsBefore        *sql.Stmt
+		createImport            *sql.Stmt
+		updateImport            *sql.Stmt
+		deleteImport            *sql.Stmt
+		getImport               *sql.Stmt
+		getImportsByNamespace   *sql.Stmt
+		getImportsBefore        *sql.Stmt
+		acquireContent          *sql.Stmt
+		releaseContent          *sql.Stmt
+		deleteContent           *sql.Stmt
+		createStorageRevision   *sql.Stmt
+		getStorageRevision      *sql.Stmt
+		getStorageRevisions     *sql.Stmt
+		upsertQuota             *sql.Stmt
+		deleteQuota             *sql.Stmt
+		getQuotaByNamespace     *sql.Stmt
+		getUsageByNamespace     *sql.Stmt
+		upsertWorker            *sql.Stmt
+		deleteWorker            *sql.Stmt
+		deleteWorkersBefore     *sql.Stmt
+		getWorkers              *sql.Stmt
+		createSchedule          *sql.Stmt
+		updateScheduleStatus    *sql.Stmt
+		claimSchedule           *sql.Stmt
+		deleteSchedule          *sql.Stmt
+		createScheduleRun       *sql.Stmt
+		getSchedule             *sql.Stmt
+		getSchedulesByNamespace *sql.Stmt
+		getDueSchedules         *sql.Stmt
+		getScheduleRuns         *sql.Stmt
+		claimJob                *sql.Stmt
+		readyContent            *sql.Stmt
+		abandonContent          *sql.Stmt
+		lockNamespace           *sql.Stmt
+		touchStorage            *sql.Stmt
+		getPendingByNamespace   *sql.Stmt
+		upsertDefaultQuota      *sql.Stmt
+		deleteDefaultQuota      *sql.Stmt
 	}
 }
 
@@ -53,6 +97,8 @@ func New(ctx context.Context, addr string) (*Datastore, error) {
 			getTasksByJobID         *sql.Stmt
 			getTaskByType           *sql.Stmt
 			getTasksByTypes         *sql.Stmt
+			getTasks                *sql.Stmt
+			upsertTask              *sql.Stmt
 			getStorage              *sql.Stmt
 			createStorage           *sql.Stmt
 			deleteStorage           *sql.Stmt
@@ -64,6 +110,48 @@ func New(ctx context.Context, addr string) (*Datastore, error) {
 			getInputStorageByJobID  *sql.Stmt
 			createStep              *sql.Stmt
 			getStepsByJobID         *sql.Stmt
+			createUpload            *sql.Stmt
+			updateUpload            *sql.Stmt
+			deleteUpload            *sql.Stmt
+			getUpload               *sql.Stmt
+			getUploadsBefore        *sql.Stmt
+			createImport            *sql.Stmt
+			updateImport            *sql.Stmt
+			deleteImport            *sql.Stmt
+			getImport               *sql.Stmt
+			getImportsByNamespace   *sql.Stmt
+			getImportsBefore        *sql.Stmt
+			acquireContent          *sql.Stmt
+			releaseContent          *sql.Stmt
+			deleteContent           *sql.Stmt
+			createStorageRevision   *sql.Stmt
+			getStorageRevision      *sql.Stmt
+			getStorageRevisions     *sql.Stmt
+			upsertQuota             *sql.Stmt
+			deleteQuota             *sql.Stmt
+			getQuotaByNamespace     *sql.Stmt
+			getUsageByNamespace     *sql.Stmt
+			upsertWorker            *sql.Stmt
+			deleteWorker            *sql.Stmt
+			deleteWorkersBefore     *sql.Stmt
+			getWorkers              *sql.Stmt
+			createSchedule          *sql.Stmt
+			updateScheduleStatus    *sql.Stmt
+			claimSchedule           *sql.Stmt
+			deleteSchedule          *sql.Stmt
+			createScheduleRun       *sql.Stmt
+			getSchedule             *sql.Stmt
+			getSchedulesByNamespace *sql.Stmt
+			getDueSchedules         *sql.Stmt
+			getScheduleRuns         *sql.Stmt
+			claimJob                *sql.Stmt
+			readyContent            *sql.Stmt
+			abandonContent          *sql.Stmt
+			lockNamespace           *sql.Stmt
+			touchStorage            *sql.Stmt
+			getPendingByNamespace   *sql.Stmt
+			upsertDefaultQuota      *sql.Stmt
+			deleteDefaultQuota      *sql.Stmt
 		}{},
 	}
 
@@ -134,6 +222,14 @@ func New(ctx context.Context, addr string) (*Datastore, error) {
 		return nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 
+	if d.stmt.getTasks, err = d.DB.Prepare(getTasksSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+
+	if d.stmt.upsertTask, err = d.DB.Prepare(upsertTaskSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+
 	if d.stmt.createStorage, err = d.DB.Prepare(createStorageSQL); err != nil {
 		return nil, fmt.Errorf("failed to prepare statement; %w", err)
 	}
@@ -178,5 +274,173 @@ func New(ctx context.Context, addr string) (*Datastore, error) {
 		return nil, fmt.Errorf("failed to prepare statement; %w", err)
 	}
 
+	if d.stmt.createUpload, err = d.DB.Prepare(createUploadSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.updateUpload, err = d.DB.Prepare(updateUploadSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.deleteUpload, err = d.DB.Prepare(deleteUploadSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getUpload, err = d.DB.Prepare(getUploadByIDSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getUploadsBefore, err = d.DB.Prepare(getUploadsBeforeSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.createImport, err = d.DB.Prepare(createImportSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.updateImport, err = d.DB.Prepare(updateImportSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.deleteImport, err = d.DB.Prepare(deleteImportSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getImport, err = d.DB.Prepare(getImportByIDSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getImportsByNamespace, err = d.DB.Prepare(getImportsByNamespaceSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getImportsBefore, err = d.DB.Prepare(getImportsBeforeSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.acquireContent, err = d.DB.Prepare(acquireContentSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.releaseContent, err = d.DB.Prepare(releaseContentSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.deleteContent, err = d.DB.Prepare(deleteContentSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.createStorageRevision, err = d.DB.Prepare(createStorageRevisionSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getStorageRevision, err = d.DB.Prepare(getStorageRevisionSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getStorageRevisions, err = d.DB.Prepare(getStorageRevisionsSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.upsertQuota, err = d.DB.Prepare(upsertQuotaSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.deleteQuota, err = d.DB.Prepare(deleteQuotaSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getQuotaByNamespace, err = d.DB.Prepare(getQuotaByNamespaceSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getUsageByNamespace, err = d.DB.Prepare(getUsageByNamespaceSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.upsertWorker, err = d.DB.Prepare(upsertWorkerSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.deleteWorker, err = d.DB.Prepare(deleteWorkerSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.deleteWorkersBefore, err = d.DB.Prepare(deleteWorkersBeforeSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getWorkers, err = d.DB.Prepare(getWorkersSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.createSchedule, err = d.DB.Prepare(createScheduleSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.updateScheduleStatus, err = d.DB.Prepare(updateScheduleStatusSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.claimSchedule, err = d.DB.Prepare(claimScheduleSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.deleteSchedule, err = d.DB.Prepare(deleteScheduleSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.createScheduleRun, err = d.DB.Prepare(createScheduleRunSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getSchedule, err = d.DB.Prepare(getScheduleByIDSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getSchedulesByNamespace, err = d.DB.Prepare(getSchedulesByNamespaceSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getDueSchedules, err = d.DB.Prepare(getDueSchedulesSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getScheduleRuns, err = d.DB.Prepare(getScheduleRunsSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.claimJob, err = d.DB.Prepare(claimJobSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.readyContent, err = d.DB.Prepare(readyContentSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.abandonContent, err = d.DB.Prepare(abandonContentSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.lockNamespace, err = d.DB.Prepare(lockNamespaceSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.touchStorage, err = d.DB.Prepare(touchStorageSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.getPendingByNamespace, err = d.DB.Prepare(getPendingByNamespaceSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.upsertDefaultQuota, err = d.DB.Prepare(upsertDefaultQuotaSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
+	if d.stmt.deleteDefaultQuota, err = d.DB.Prepare(deleteDefaultQuotaSQL); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement; %w", err)
+	}
+
 	return d, nil
 }
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/logsquaredn/rototiller/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	//go:embed sql/execs/create_import.sql
+	createImportSQL string
+
+	//go:embed sql/execs/update_import.sql
+	updateImportSQL string
+
+	//go:embed sql/execs/delete_import.sql
+	deleteImportSQL string
+
+	//go:embed sql/queries/get_import_by_id.sql
+	getImportByIDSQL string
+
+	//go:embed sql/queries/get_imports_by_namespace.sql
+	getImportsByNamespaceSQL string
+
+	//go:embed sql/queries/get_imports_before.sql
+	getImportsBeforeSQL string
+)
+
+func scanImport(sc scanner) (*pb.Import, error) {
+	var (
+		i                          = &pb.Import{}
+		name, importErr, storageID sql.NullString
+		headers                    []byte
+		createTime, endTime        sql.NullTime
+	)
+
+	if err := sc.Scan(
+		&i.Id, &i.Namespace, &name,
+		&i.Url, &headers, &i.Status,
+		&importErr, &storageID, &createTime,
+		&endTime,
+	); err != nil {
+		return nil, err
+	}
+
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &i.Headers); err != nil {
+			return nil, err
+		}
+	}
+
+	i.Name = name.String
+	i.Error = importErr.String
+	i.StorageId = storageID.String
+	i.CreateTime = timestamppb.New(createTime.Time)
+	if endTime.Valid {
+		i.EndTime = timestamppb.New(endTime.Time)
+	}
+
+	return i, nil
+}
+
+func scanImports(rows *sql.Rows) ([]*pb.Import, error) {
+	defer rows.Close()
+
+	imports := []*pb.Import{}
+	for rows.Next() {
+		i, err := scanImport(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		imports = append(imports, i)
+	}
+
+	return imports, rows.Err()
+}
+
+func (d *Datastore) CreateImport(i *pb.Import) (*pb.Import, error) {
+	var headers []byte
+	if len(i.Headers) > 0 {
+		var err error
+		if headers, err = json.Marshal(i.Headers); err != nil {
+			return nil, err
+		}
+	}
+
+	return scanImport(d.stmt.createImport.QueryRow(
+		uuid.NewString(), i.Namespace, i.Name,
+		i.Url, headers,
+	))
+}
+
+func (d *Datastore) UpdateImport(i *pb.Import) (*pb.Import, error) {
+	var endTime sql.NullTime
+	if i.EndTime != nil {
+		endTime = sql.NullTime{Time: i.EndTime.AsTime(), Valid: true}
+	}
+
+	return scanImport(d.stmt.updateImport.QueryRow(
+		i.Id, i.Status, i.Error,
+		i.StorageId, endTime,
+	))
+}
+
+func (d *Datastore) GetImport(id string) (*pb.Import, error) {
+	return scanImport(d.stmt.getImport.QueryRow(id))
+}
+
+func (d *Datastore) GetImports(namespace string, offset, limit int) ([]*pb.Import, error) {
+	rows, err := d.stmt.getImportsByNamespace.Query(namespace, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanImports(rows)
+}
+
+func (d *Datastore) DeleteImport(id string) error {
+	_, err := d.stmt.deleteImport.Exec(id)
+	return err
+}
+
+func (d *Datastore) GetImportsBefore(duration time.Duration) ([]*pb.Import, error) {
+	rows, err := d.stmt.getImportsBefore.Query(time.Now().Add(-duration))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanImports(rows)
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"database/sql"
 	_ "embed"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,6 +21,9 @@ var (
 	//go:embed sql/execs/update_job.sql
 	updateJobSQL string
 
+	//go:embed sql/execs/claim_job.sql
+	claimJobSQL string
+
 	//go:embed sql/queries/get_jobs_before.sql
 	getJobsBeforeSQL string
 
@@ -30,23 +34,38 @@ var (
 	getJobsByNamespaceSQL string
 )
 
+// CreateJob creates the job and its steps, unless its namespace may not
+// create another job of its priority, in which case a *pb.Error is returned.
 func (d *Datastore) CreateJob(j *pb.Job) (*pb.Job, error) {
 	var (
 		id                 = uuid.New().String()
 		jobErr             sql.NullString
 		startTime, endTime sql.NullTime
+		inputRevision      sql.NullInt32
 		outputID           sql.NullString
 	)
 
-	if err := d.stmt.createJob.QueryRow(
-		id, j.Namespace,
-		j.InputId,
-	).Scan(
-		&j.Id, &j.Namespace,
-		&j.InputId, &outputID,
-		&j.Status, &jobErr,
-		&startTime, &endTime,
-	); err != nil {
+	if err := d.inNamespaceTx(j.Namespace, func(tx *sql.Tx) error {
+		if err := d.checkJobQuota(tx, j.Namespace, j.Priority); err != nil {
+			return err
+		}
+
+		if err := tx.Stmt(d.stmt.createJob).QueryRow(
+			id, j.Namespace,
+			j.InputId, j.InputRevision,
+			j.Priority,
+		).Scan(
+			&j.Id, &j.Namespace,
+			&j.InputId, &outputID,
+			&j.Status, &jobErr,
+			&startTime, &endTime,
+			&inputRevision, &j.Priority,
+		); err != nil {
+			return err
+		}
+
+		return d.createSteps(tx, j.Id, j.Steps)
+	}); err != nil {
 		return j, err
 	}
 
@@ -54,12 +73,7 @@ func (d *Datastore) CreateJob(j *pb.Job) (*pb.Job, error) {
 	j.StartTime = timestamppb.New(startTime.Time)
 	j.EndTime = timestamppb.New(endTime.Time)
 	j.OutputId = outputID.String
-
-	var err error
-	j.Steps, err = d.createSteps(j.Id, j.Steps)
-	if err != nil {
-		return j, err
-	}
+	j.InputRevision = inputRevision.Int32
 
 	return j, nil
 }
@@ -68,6 +82,7 @@ func (d *Datastore) UpdateJob(j *pb.Job) (*pb.Job, error) {
 	var (
 		jobErr             sql.NullString
 		startTime, endTime sql.NullTime
+		inputRevision      sql.NullInt32
 		outputID           sql.NullString
 	)
 
@@ -81,6 +96,7 @@ func (d *Datastore) UpdateJob(j *pb.Job) (*pb.Job, error) {
 			&j.InputId, &outputID,
 			&j.Status, &jobErr,
 			&startTime, &endTime,
+			&inputRevision, &j.Priority,
 		); err != nil {
 			return j, err
 		}
@@ -94,6 +110,7 @@ func (d *Datastore) UpdateJob(j *pb.Job) (*pb.Job, error) {
 			&j.InputId, &outputID,
 			&j.Status, &jobErr,
 			&startTime, &endTime,
+			&inputRevision, &j.Priority,
 		); err != nil {
 			return j, err
 		}
@@ -103,17 +120,57 @@ func (d *Datastore) UpdateJob(j *pb.Job) (*pb.Job, error) {
 	j.StartTime = timestamppb.New(startTime.Time)
 	j.EndTime = timestamppb.New(endTime.Time)
 	j.OutputId = outputID.String
+	j.InputRevision = inputRevision.Int32
 
 	// TODO any reason why Steps would need updated?
 
 	return j, nil
 }
 
+// ClaimJob marks the job as in progress, reporting whether it was claimed.
+// A job that is already in progress or complete is not claimed, so that
+// one that is delivered more than once is only run once.
+func (d *Datastore) ClaimJob(id string) (*pb.Job, bool, error) {
+	var (
+		j                  = &pb.Job{}
+		jobErr, outputID   sql.NullString
+		startTime, endTime sql.NullTime
+		inputRevision      sql.NullInt32
+		err                error
+	)
+
+	if err = d.stmt.claimJob.QueryRow(id).Scan(
+		&j.Id, &j.Namespace,
+		&j.InputId, &outputID,
+		&j.Status, &jobErr,
+		&startTime, &endTime,
+		&inputRevision, &j.Priority,
+	); errors.Is(err, sql.ErrNoRows) {
+		return j, false, nil
+	} else if err != nil {
+		return j, false, err
+	}
+
+	j.Error = jobErr.String
+	j.StartTime = timestamppb.New(startTime.Time)
+	j.EndTime = timestamppb.New(endTime.Time)
+	j.OutputId = outputID.String
+	j.InputRevision = inputRevision.Int32
+
+	j.Steps, err = d.getSteps(j.Id)
+	if err != nil {
+		return j, true, err
+	}
+
+	return j, true, nil
+}
+
 func (d *Datastore) GetJob(id string) (*pb.Job, error) {
 	var (
 		j                  = &pb.Job{}
 		jobErr, outputID   sql.NullString
 		startTime, endTime sql.NullTime
+		inputRevision      sql.NullInt32
 		err                error
 	)
 
@@ -122,6 +179,7 @@ func (d *Datastore) GetJob(id string) (*pb.Job, error) {
 		&j.InputId, &outputID,
 		&j.Status, &jobErr,
 		&startTime, &endTime,
+		&inputRevision, &j.Priority,
 	); err != nil {
 		return j, err
 	}
@@ -130,6 +188,7 @@ func (d *Datastore) GetJob(id string) (*pb.Job, error) {
 	j.StartTime = timestamppb.New(startTime.Time)
 	j.EndTime = timestamppb.New(endTime.Time)
 	j.OutputId = outputID.String
+	j.InputRevision = inputRevision.Int32
 
 	j.Steps, err = d.getSteps(j.Id)
 	if err != nil {
@@ -154,6 +213,7 @@ func (d *Datastore) GetJobsBefore(duration time.Duration) ([]*pb.Job, error) {
 			j                  = &pb.Job{}
 			jobErr             sql.NullString
 			startTime, endTime sql.NullTime
+			inputRevision      sql.NullInt32
 			outputID           sql.NullString
 		)
 
@@ -162,6 +222,7 @@ func (d *Datastore) GetJobsBefore(duration time.Duration) ([]*pb.Job, error) {
 			&j.InputId, &outputID,
 			&j.Status, &jobErr,
 			&startTime, &endTime,
+			&inputRevision, &j.Priority,
 		)
 		if err != nil {
 			return nil, err
@@ -171,6 +232,7 @@ func (d *Datastore) GetJobsBefore(duration time.Duration) ([]*pb.Job, error) {
 		j.StartTime = timestamppb.New(startTime.Time)
 		j.EndTime = timestamppb.New(endTime.Time)
 		j.OutputId = outputID.String
+		j.InputRevision = inputRevision.Int32
 
 		j.Steps, err = d.getSteps(j.Id)
 		if err != nil {
@@ -201,6 +263,7 @@ func (d *Datastore) GetJobs(id string, offset, limit int) ([]*pb.Job, error) {
 			j                  = &pb.Job{}
 			jobErr, outputID   sql.NullString
 			startTime, endTime sql.NullTime
+			inputRevision      sql.NullInt32
 		)
 
 		err = rows.Scan(
@@ -208,6 +271,7 @@ func (d *Datastore) GetJobs(id string, offset, limit int) ([]*pb.Job, error) {
 			&j.InputId, &outputID,
 			&j.Status, &jobErr,
 			&startTime, &endTime,
+			&inputRevision, &j.Priority,
 		)
 		if err != nil {
 			return nil, err
@@ -217,6 +281,7 @@ func (d *Datastore) GetJobs(id string, offset, limit int) ([]*pb.Job, error) {
 		j.StartTime = timestamppb.New(startTime.Time)
 		j.EndTime = timestamppb.New(endTime.Time)
 		j.OutputId = outputID.String
+		j.InputRevision = inputRevision.Int32
 
 		j.Steps, err = d.getSteps(j.Id)
 		if err != nil {
@@ -0,0 +1,266 @@
+package postgres
+
+import (
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"net/http"
+
+	"github.com/logsquaredn/rototiller/pb"
+)
+
+var (
+	//go:embed sql/execs/upsert_quota.sql
+	upsertQuotaSQL string
+
+	//go:embed sql/execs/delete_quota.sql
+	deleteQuotaSQL string
+
+	//go:embed sql/execs/upsert_default_quota.sql
+	upsertDefaultQuotaSQL string
+
+	//go:embed sql/execs/delete_default_quota.sql
+	deleteDefaultQuotaSQL string
+
+	//go:embed sql/queries/get_quota_by_namespace.sql
+	getQuotaByNamespaceSQL string
+
+	//go:embed sql/queries/get_pending_by_namespace.sql
+	getPendingByNamespaceSQL string
+
+	//go:embed sql/queries/get_usage_by_namespace.sql
+	getUsageByNamespaceSQL string
+
+	//go:embed sql/execs/lock_namespace.sql
+	lockNamespaceSQL string
+)
+
+// GetQuota gets the namespace's quota, falling back to
+// the default quota for any limit that it does not set.
+func (d *Datastore) GetQuota(namespace string) (*pb.Quota, error) {
+	return d.getQuota(nil, namespace)
+}
+
+func (d *Datastore) getQuota(tx *sql.Tx, namespace string) (*pb.Quota, error) {
+	q := &pb.Quota{}
+	if err := txStmt(tx, d.stmt.getQuotaByNamespace).QueryRow(namespace).Scan(
+		&q.Namespace,
+		&q.StorageBytes, &q.UploadBytes,
+		&q.ConcurrentJobs, &q.DailyJobs,
+		&q.MaxPriority,
+	); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// UpsertQuota sets the limits of the quota that are set,
+// leaving any others that the namespace had as they were.
+func (d *Datastore) UpsertQuota(q *pb.Quota) (*pb.Quota, error) {
+	if _, err := d.stmt.upsertQuota.Exec(
+		q.GetNamespace(),
+		// unset limits are nil, i.e. NULL
+		q.StorageBytes, q.UploadBytes,
+		q.ConcurrentJobs, q.DailyJobs,
+		q.MaxPriority,
+	); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// UpsertDefaultQuota sets the limits of the default quota that are set,
+// leaving any others as they were. The default quota is kept alongside
+// those of namespaces, so that every API and worker enforces the same one.
+func (d *Datastore) UpsertDefaultQuota(q *pb.Quota) (*pb.Quota, error) {
+	if _, err := d.stmt.upsertDefaultQuota.Exec(
+		// unset limits are nil, i.e. NULL
+		q.StorageBytes, q.UploadBytes,
+		q.ConcurrentJobs, q.DailyJobs,
+		q.MaxPriority,
+	); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// DeleteDefaultQuota unsets each of the default quota's limits,
+// so that namespaces that do not set them have no limit.
+func (d *Datastore) DeleteDefaultQuota() error {
+	_, err := d.stmt.deleteDefaultQuota.Exec()
+	return err
+}
+
+// DeleteQuota unsets each of the namespace's limits,
+// so that it falls back to the defaults.
+func (d *Datastore) DeleteQuota(namespace string) error {
+	_, err := d.stmt.deleteQuota.Exec(namespace)
+	return err
+}
+
+// GetUsage gets how much the namespace has stored, counting content
+// that it has deduplicated once, how many of its jobs have yet to finish
+// and how many jobs it has created in the last day.
+func (d *Datastore) GetUsage(namespace string) (*pb.Usage, error) {
+	return d.getUsage(nil, namespace)
+}
+
+func (d *Datastore) getUsage(tx *sql.Tx, namespace string) (*pb.Usage, error) {
+	u := &pb.Usage{}
+	if err := txStmt(tx, d.stmt.getUsageByNamespace).QueryRow(namespace).Scan(
+		&u.StorageBytes, &u.ConcurrentJobs, &u.DailyJobs,
+	); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// GetPending gets how many of the namespace's
+// jobs and imports have yet to finish.
+func (d *Datastore) GetPending(namespace string) (int, error) {
+	var pending int
+	if err := d.stmt.getPendingByNamespace.QueryRow(namespace).Scan(&pending); err != nil {
+		return 0, err
+	}
+
+	return pending, nil
+}
+
+// UploadLimit is the most that a namespace may upload at
+// once and the error that exceeding it fails with.
+type UploadLimit struct {
+	// Bytes is the limit, or 0 if there is none.
+	Bytes int64
+	Err   error
+}
+
+// GetUploadLimit gets the most that the namespace may upload at once,
+// or what it may yet store if that is less. If it may store no more,
+// a *pb.Error is returned.
+func (d *Datastore) GetUploadLimit(namespace string) (*UploadLimit, error) {
+	quota, err := d.getQuota(nil, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := &UploadLimit{
+		Bytes: quota.GetUploadBytes(),
+		Err:   pb.NewErr(fmt.Errorf("upload exceeds %d bytes, the most that may be uploaded at once", quota.GetUploadBytes()), http.StatusRequestEntityTooLarge),
+	}
+	if storageBytes := quota.GetStorageBytes(); storageBytes > 0 {
+		usage, err := d.getUsage(nil, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining := storageBytes - usage.GetStorageBytes()
+		if remaining <= 0 {
+			return nil, pb.NewErr(fmt.Errorf("namespace already stores %d bytes, the most that it may", storageBytes), http.StatusRequestEntityTooLarge)
+		}
+
+		if limit.Bytes <= 0 || remaining < limit.Bytes {
+			limit.Bytes = remaining
+			limit.Err = pb.NewErr(fmt.Errorf("upload exceeds %d bytes, the most that namespace may yet store", remaining), http.StatusRequestEntityTooLarge)
+		}
+	}
+
+	return limit, nil
+}
+
+// CheckJobQuota checks that the namespace may create another job of the
+// given priority. CreateJob checks the same as it creates the job, so
+// this is only to fail before doing anything else needed to create it.
+func (d *Datastore) CheckJobQuota(namespace string, priority int32) error {
+	return d.checkJobQuota(nil, namespace, priority)
+}
+
+// checkJobQuota checks that the namespace may create another job of the
+// given priority. If it may not, a *pb.Error is returned.
+func (d *Datastore) checkJobQuota(tx *sql.Tx, namespace string, priority int32) error {
+	quota, err := d.getQuota(tx, namespace)
+	if err != nil {
+		return err
+	}
+
+	usage, err := d.getUsage(tx, namespace)
+	if err != nil {
+		return err
+	}
+
+	if limit := quota.GetConcurrentJobs(); limit > 0 && usage.GetConcurrentJobs() >= limit {
+		return pb.NewErr(fmt.Errorf("namespace already has %d jobs that have yet to finish, the most that it may have", limit), http.StatusTooManyRequests)
+	}
+
+	if limit := quota.GetDailyJobs(); limit > 0 && usage.GetDailyJobs() >= limit {
+		return pb.NewErr(fmt.Errorf("namespace already created %d jobs in the last day, the most that it may", limit), http.StatusTooManyRequests)
+	}
+
+	if limit := quota.GetMaxPriority(); priority > limit {
+		return pb.NewErr(fmt.Errorf("namespace may not give jobs a priority higher than %d", limit), http.StatusForbidden)
+	}
+
+	return nil
+}
+
+// checkStorageQuota checks that the storage that was just created within tx
+// did not bring the namespace past what it may store, given how much it
+// stored before. Storages whose content the namespace already stored add
+// nothing, so they may be created even once it stores as much as it may.
+// If it did, a *pb.Error is returned.
+func (d *Datastore) checkStorageQuota(tx *sql.Tx, namespace string, before *pb.Usage) error {
+	quota, err := d.getQuota(tx, namespace)
+	if err != nil {
+		return err
+	}
+
+	limit := quota.GetStorageBytes()
+	if limit <= 0 {
+		return nil
+	}
+
+	after, err := d.getUsage(tx, namespace)
+	if err != nil {
+		return err
+	}
+
+	if after.GetStorageBytes() > limit && after.GetStorageBytes() > before.GetStorageBytes() {
+		return pb.NewErr(fmt.Errorf("storage would bring namespace to %d bytes, more than the %d that it may store", after.GetStorageBytes(), limit), http.StatusRequestEntityTooLarge)
+	}
+
+	return nil
+}
+
+// inNamespaceTx runs f within a transaction that holds the namespace's lock,
+// so that what f checks about the namespace, e.g. its usage, cannot be
+// changed by any other such transaction before what f writes is committed.
+func (d *Datastore) inNamespaceTx(namespace string, f func(*sql.Tx) error) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Stmt(d.stmt.lockNamespace).Exec(namespace); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err = f(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// txStmt returns the statement for use within tx, or as-is if tx is nil.
+func txStmt(tx *sql.Tx, stmt *sql.Stmt) *sql.Stmt {
+	if tx == nil {
+		return stmt
+	}
+
+	return tx.Stmt(stmt)
+}
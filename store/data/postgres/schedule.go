@@ -0,0 +1,209 @@
+package postgres
+
+import (
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/logsquaredn/rototiller/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	//go:embed sql/execs/create_schedule.sql
+	createScheduleSQL string
+
+	//go:embed sql/execs/update_schedule_status.sql
+	updateScheduleStatusSQL string
+
+	//go:embed sql/execs/claim_schedule.sql
+	claimScheduleSQL string
+
+	//go:embed sql/execs/delete_schedule.sql
+	deleteScheduleSQL string
+
+	//go:embed sql/execs/create_schedule_run.sql
+	createScheduleRunSQL string
+
+	//go:embed sql/queries/get_schedule_by_id.sql
+	getScheduleByIDSQL string
+
+	//go:embed sql/queries/get_schedules_by_namespace.sql
+	getSchedulesByNamespaceSQL string
+
+	//go:embed sql/queries/get_due_schedules.sql
+	getDueSchedulesSQL string
+
+	//go:embed sql/queries/get_schedule_runs.sql
+	getScheduleRunsSQL string
+)
+
+func scanSchedule(sc scanner) (*pb.Schedule, error) {
+	var (
+		s                                    = &pb.Schedule{}
+		name                                 sql.NullString
+		args                                 []byte
+		nextRunTime, lastRunTime, createTime sql.NullTime
+	)
+
+	if err := sc.Scan(
+		&s.Id, &s.Namespace, &name,
+		&s.Cron, &s.TaskType, &args,
+		&s.Input, &s.Status, &s.MissedRunPolicy,
+		&nextRunTime, &lastRunTime, &createTime,
+	); err != nil {
+		return nil, err
+	}
+
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &s.Args); err != nil {
+			return nil, err
+		}
+	}
+
+	s.Name = name.String
+	s.NextRunTime = timestamppb.New(nextRunTime.Time)
+	if lastRunTime.Valid {
+		s.LastRunTime = timestamppb.New(lastRunTime.Time)
+	}
+	s.CreateTime = timestamppb.New(createTime.Time)
+
+	return s, nil
+}
+
+func scanSchedules(rows *sql.Rows) ([]*pb.Schedule, error) {
+	defer rows.Close()
+
+	schedules := []*pb.Schedule{}
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, s)
+	}
+
+	return schedules, rows.Err()
+}
+
+func (d *Datastore) CreateSchedule(s *pb.Schedule) (*pb.Schedule, error) {
+	var args []byte
+	if len(s.Args) > 0 {
+		var err error
+		if args, err = json.Marshal(s.Args); err != nil {
+			return nil, err
+		}
+	}
+
+	return scanSchedule(d.stmt.createSchedule.QueryRow(
+		uuid.NewString(), s.Namespace, s.Name,
+		s.Cron, s.TaskType, args,
+		s.Input, s.MissedRunPolicy, s.NextRunTime.AsTime(),
+	))
+}
+
+// UpdateScheduleStatus pauses or resumes the schedule,
+// which next runs at the given time if it is active.
+func (d *Datastore) UpdateScheduleStatus(id string, status pb.ScheduleStatus, nextRunTime time.Time) (*pb.Schedule, error) {
+	return scanSchedule(d.stmt.updateScheduleStatus.QueryRow(id, status.String(), nextRunTime))
+}
+
+// ClaimSchedule moves the active schedule's next run from the given time to
+// the given next one, recording that it ran at lastRunTime, returning false
+// if another caller claimed it first or it was paused in the meantime.
+func (d *Datastore) ClaimSchedule(s *pb.Schedule, nextRunTime, lastRunTime time.Time) (*pb.Schedule, bool, error) {
+	claimed, err := scanSchedule(d.stmt.claimSchedule.QueryRow(
+		s.GetId(), s.GetNextRunTime().AsTime(),
+		nextRunTime, lastRunTime,
+	))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return s, false, nil
+	case err != nil:
+		return s, false, err
+	}
+
+	return claimed, true, nil
+}
+
+func (d *Datastore) DeleteSchedule(id string) error {
+	_, err := d.stmt.deleteSchedule.Exec(id)
+	return err
+}
+
+func (d *Datastore) GetSchedule(id string) (*pb.Schedule, error) {
+	return scanSchedule(d.stmt.getSchedule.QueryRow(id))
+}
+
+func (d *Datastore) GetSchedules(namespace string, offset, limit int) ([]*pb.Schedule, error) {
+	rows, err := d.stmt.getSchedulesByNamespace.Query(namespace, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSchedules(rows)
+}
+
+// GetDueSchedules gets the active schedules that were to run by the given time.
+func (d *Datastore) GetDueSchedules(before time.Time) ([]*pb.Schedule, error) {
+	rows, err := d.stmt.getDueSchedules.Query(before)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSchedules(rows)
+}
+
+func scanScheduleRun(sc scanner) (*pb.ScheduleRun, error) {
+	var (
+		r                         = &pb.ScheduleRun{}
+		jobID, runErr             sql.NullString
+		scheduledTime, createTime sql.NullTime
+	)
+
+	if err := sc.Scan(
+		&r.ScheduleId, &scheduledTime, &jobID,
+		&runErr, &createTime,
+	); err != nil {
+		return nil, err
+	}
+
+	r.ScheduledTime = timestamppb.New(scheduledTime.Time)
+	r.JobId = jobID.String
+	r.Error = runErr.String
+	r.CreateTime = timestamppb.New(createTime.Time)
+
+	return r, nil
+}
+
+func (d *Datastore) CreateScheduleRun(r *pb.ScheduleRun) (*pb.ScheduleRun, error) {
+	return scanScheduleRun(d.stmt.createScheduleRun.QueryRow(
+		r.GetScheduleId(), r.GetScheduledTime().AsTime(),
+		r.GetJobId(), r.GetError(),
+	))
+}
+
+// GetScheduleRuns gets the schedule's runs, latest first.
+func (d *Datastore) GetScheduleRuns(id string, offset, limit int) ([]*pb.ScheduleRun, error) {
+	rows, err := d.stmt.getScheduleRuns.Query(id, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []*pb.ScheduleRun{}
+	for rows.Next() {
+		r, err := scanScheduleRun(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, r)
+	}
+
+	return runs, rows.Err()
+}
@@ -0,0 +1 @@
+DELETE FROM content WHERE content_id = $1 AND content_status = 'pending' RETURNING content_id;
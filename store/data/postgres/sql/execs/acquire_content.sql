@@ -0,0 +1,17 @@
+INSERT INTO content (
+    content_id,
+    namespace,
+    content_format,
+    checksum
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+) ON CONFLICT (namespace, content_format, checksum) DO UPDATE SET
+    -- content that is still pending past $5 seconds was abandoned mid-write, so it is taken over
+    content_id = CASE WHEN content.content_status = 'ready' THEN content.content_id ELSE EXCLUDED.content_id END,
+    ref_count = CASE WHEN content.content_status = 'ready' THEN content.ref_count + 1 ELSE 1 END,
+    update_time = CASE WHEN content.content_status = 'ready' THEN content.update_time ELSE NOW() END
+WHERE content.content_status = 'ready' OR content.update_time < NOW() - make_interval(secs => $5)
+RETURNING content_id, content_status;
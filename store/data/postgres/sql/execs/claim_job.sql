@@ -0,0 +1 @@
+UPDATE job SET job_status = 'inprogress' WHERE job_id = $1 AND job_status NOT IN ('inprogress', 'complete') RETURNING job_id, namespace, input_id, output_id, job_status, job_error, start_time, end_time, input_revision, priority;
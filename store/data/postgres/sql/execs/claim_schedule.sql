@@ -0,0 +1,7 @@
+UPDATE schedule SET (
+    next_run_time,
+    last_run_time
+) = (
+    $3,
+    $4
+) WHERE schedule_id = $1 AND next_run_time = $2 AND schedule_status = 'active' RETURNING schedule_id, namespace, schedule_name, cron, task_type, args, input, schedule_status, missed_run_policy, next_run_time, last_run_time, create_time;
@@ -0,0 +1,13 @@
+INSERT INTO import (
+    import_id,
+    namespace,
+    import_name,
+    import_url,
+    import_headers
+) VALUES (
+    $1,
+    $2,
+    NULLIF($3, ''),
+    $4,
+    $5
+) RETURNING import_id, namespace, import_name, import_url, import_headers, import_status, import_error, storage_id, create_time, end_time;
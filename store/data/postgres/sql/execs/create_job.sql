@@ -1,9 +1,13 @@
 INSERT INTO job (
     job_id,
     namespace,
-    input_id
+    input_id,
+    input_revision,
+    priority
 ) VALUES (
     $1,
     $2,
-    $3
-) RETURNING job_id, namespace, input_id, output_id, job_status, job_error, start_time, end_time;
+    $3,
+    NULLIF($4, 0),
+    $5
+) RETURNING job_id, namespace, input_id, output_id, job_status, job_error, start_time, end_time, input_revision, priority;
@@ -0,0 +1,21 @@
+INSERT INTO schedule (
+    schedule_id,
+    namespace,
+    schedule_name,
+    cron,
+    task_type,
+    args,
+    input,
+    missed_run_policy,
+    next_run_time
+) VALUES (
+    $1,
+    $2,
+    NULLIF($3, ''),
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+) RETURNING schedule_id, namespace, schedule_name, cron, task_type, args, input, schedule_status, missed_run_policy, next_run_time, last_run_time, create_time;
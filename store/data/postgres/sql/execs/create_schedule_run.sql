@@ -0,0 +1,11 @@
+INSERT INTO schedule_run (
+    schedule_id,
+    scheduled_time,
+    job_id,
+    run_error
+) VALUES (
+    $1,
+    $2,
+    NULLIF($3, ''),
+    NULLIF($4, '')
+RETURNING schedule_id, scheduled_time, job_id, run_error, create_time;
@@ -2,10 +2,12 @@ INSERT INTO step (
     step_id,
     job_id,
     task_type,
-    job_args
+    job_args,
+    overlay_id
 ) VALUES (
     $1,
     $2,
     $3,
-    $4
-) RETURNING step_id, job_id, task_type, job_args;
+    $4,
+    NULLIF($5, '')
+) RETURNING step_id, job_id, task_type, job_args, COALESCE(overlay_id, '');
@@ -1,11 +1,54 @@
-INSERT INTO storage (
-    storage_id,
-    storage_status,
-    namespace,
-    storage_name
-) VALUES (
-    $1,
-    $2,
-    $3,
-    $4
-) RETURNING storage_id, storage_status, namespace, storage_name, last_used, create_time;
+WITH s AS (
+    INSERT INTO storage (
+        storage_id,
+        storage_status,
+        namespace,
+        storage_name,
+        storage_format,
+        layers,
+        feature_count,
+        storage_size,
+        checksum,
+        crs,
+        min_x,
+        min_y,
+        max_x,
+        max_y,
+        geometry_type,
+        storage_columns,
+        content_id
+    ) VALUES (
+        $1,
+        $2,
+        $3,
+        $4,
+        NULLIF($5, ''),
+        $6,
+        $7,
+        $8,
+        NULLIF($9, ''),
+        NULLIF($10, ''),
+        $11,
+        $12,
+        $13,
+        $14,
+        NULLIF($15, ''),
+        $16,
+        NULLIF($17, '')
+) RETURNING storage_id, storage_status, namespace, storage_name, storage_format, layers, feature_count, storage_size, checksum, crs, min_x, min_y, max_x, max_y, geometry_type, storage_columns, content_id, revision, last_used, create_time
+), r AS (
+    INSERT INTO storage_revision (
+        storage_id, revision, storage_status, content_id,
+        storage_format, layers, feature_count,
+        storage_size, checksum, crs,
+        min_x, min_y, max_x, max_y,
+        geometry_type, storage_columns, create_time
+    ) SELECT
+        storage_id, revision, storage_status, content_id,
+        storage_format, layers, feature_count,
+        storage_size, checksum, crs,
+        min_x, min_y, max_x, max_y,
+        geometry_type, storage_columns, create_time
+    FROM s
+)
+SELECT storage_id, storage_status, namespace, storage_name, storage_format, layers, feature_count, storage_size, checksum, crs, min_x, min_y, max_x, max_y, geometry_type, storage_columns, content_id, revision, last_used, create_time FROM s;
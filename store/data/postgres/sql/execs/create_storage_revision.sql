@@ -0,0 +1,54 @@
+WITH r AS (
+    INSERT INTO storage_revision (
+        storage_id, revision, content_id,
+        storage_format, layers, feature_count,
+        storage_size, checksum, crs,
+        min_x, min_y, max_x, max_y,
+        geometry_type, storage_columns
+    ) SELECT
+        storage_id, revision + 1, NULLIF($14, ''),
+        NULLIF($2, ''), $3, $4,
+        $5, NULLIF($6, ''), NULLIF($7, ''),
+        $8, $9, $10, $11,
+        NULLIF($12, ''), $13
+    FROM storage
+    WHERE storage_id = $1
+    RETURNING *
+)
+UPDATE storage SET (
+    storage_status,
+    revision,
+    content_id,
+    storage_format,
+    layers,
+    feature_count,
+    storage_size,
+    checksum,
+    crs,
+    min_x,
+    min_y,
+    max_x,
+    max_y,
+    geometry_type,
+    storage_columns,
+    last_used
+) = (
+    -- the storage's status is that of its latest revision
+    r.storage_status,
+    r.revision,
+    r.content_id,
+    r.storage_format,
+    r.layers,
+    r.feature_count,
+    r.storage_size,
+    r.checksum,
+    r.crs,
+    r.min_x,
+    r.min_y,
+    r.max_x,
+    r.max_y,
+    r.geometry_type,
+    r.storage_columns,
+    NOW()
+) FROM r WHERE storage.storage_id = r.storage_id
+RETURNING storage.storage_id, storage.storage_status, storage.namespace, storage.storage_name, storage.storage_format, storage.layers, storage.feature_count, storage.storage_size, storage.checksum, storage.crs, storage.min_x, storage.min_y, storage.max_x, storage.max_y, storage.geometry_type, storage.storage_columns, storage.content_id, storage.revision, storage.last_used, storage.create_time;
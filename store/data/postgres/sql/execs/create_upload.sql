@@ -0,0 +1,15 @@
+INSERT INTO upload (
+    upload_id,
+    namespace,
+    upload_name,
+    content_type,
+    upload_size,
+    checksum
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    NULLIF($5, 0),
+    NULLIF($6, '')
+) RETURNING upload_id, namespace, upload_name, content_type, upload_status, upload_size, checksum, storage_id, create_time;
@@ -0,0 +1 @@
+DELETE FROM content WHERE content_id = $1 AND ref_count <= 0 RETURNING content_id;
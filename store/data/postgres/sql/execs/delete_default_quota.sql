@@ -0,0 +1 @@
+DELETE FROM default_quota;
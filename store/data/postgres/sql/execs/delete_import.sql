@@ -0,0 +1 @@
+DELETE FROM import WHERE import_id = $1;
@@ -0,0 +1,2 @@
+DELETE FROM quota
+WHERE namespace = $1;
@@ -0,0 +1,2 @@
+DELETE FROM schedule
+WHERE schedule_id = $1;
@@ -0,0 +1 @@
+DELETE FROM upload WHERE upload_id = $1;
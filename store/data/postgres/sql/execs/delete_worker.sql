@@ -0,0 +1,2 @@
+DELETE FROM worker
+WHERE worker_id = $1;
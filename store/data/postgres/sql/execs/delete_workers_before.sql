@@ -0,0 +1,2 @@
+DELETE FROM worker
+WHERE heartbeat_time < $1;
@@ -0,0 +1 @@
+UPDATE content SET content_status = 'ready', update_time = NOW() WHERE content_id = $1 AND content_status = 'pending' RETURNING content_id;
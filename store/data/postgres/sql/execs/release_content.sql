@@ -0,0 +1 @@
+UPDATE content SET ref_count = ref_count - 1 WHERE content_id = $1 RETURNING ref_count;
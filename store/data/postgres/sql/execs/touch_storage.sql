@@ -0,0 +1 @@
+UPDATE storage SET last_used = $2 WHERE storage_id = $1;
@@ -0,0 +1,11 @@
+UPDATE import SET (
+    import_status,
+    import_error,
+    storage_id,
+    end_time
+) = (
+    $2,
+    NULLIF($3, ''),
+    NULLIF($4, ''),
+    $5
+) WHERE import_id = $1 RETURNING import_id, namespace, import_name, import_url, import_headers, import_status, import_error, storage_id, create_time, end_time;
@@ -10,4 +10,4 @@ UPDATE job SET (
     $4,
     $5,
     $6
-) WHERE job_id = $1 RETURNING job_id, namespace, input_id, output_id, job_status, job_error, start_time, end_time;
+) WHERE job_id = $1 RETURNING job_id, namespace, input_id, output_id, job_status, job_error, start_time, end_time, input_revision, priority;
@@ -1 +1,15 @@
-UPDATE storage SET storage_status = $2, last_used = $3 WHERE storage_id = $1 RETURNING storage_id, storage_status, namespace, storage_name, last_used, create_time;
+WITH r AS (
+    UPDATE storage_revision SET storage_status = $2
+    WHERE storage_id = $1 AND revision = $4
+    RETURNING *
+), s AS (
+    -- the storage's status is that of its latest revision
+    UPDATE storage SET
+        storage_status = CASE WHEN storage.revision = $4 THEN $2 ELSE storage.storage_status END,
+        last_used = $3
+    WHERE storage_id = $1
+    RETURNING *
+)
+SELECT s.storage_id, r.storage_status, s.namespace, s.storage_name, r.storage_format, r.layers, r.feature_count, r.storage_size, r.checksum, r.crs, r.min_x, r.min_y, r.max_x, r.max_y, r.geometry_type, r.storage_columns, r.content_id, r.revision, s.last_used, r.create_time
+FROM r
+INNER JOIN s ON s.storage_id = r.storage_id;
@@ -0,0 +1 @@
+UPDATE upload SET upload_status = $2, storage_id = NULLIF($3, '') WHERE upload_id = $1 RETURNING upload_id, namespace, upload_name, content_type, upload_status, upload_size, checksum, storage_id, create_time;
@@ -0,0 +1,8 @@
+INSERT INTO default_quota (storage_bytes, upload_bytes, concurrent_jobs, daily_jobs, max_priority)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (only_row) DO UPDATE
+SET storage_bytes = COALESCE(EXCLUDED.storage_bytes, default_quota.storage_bytes),
+    upload_bytes = COALESCE(EXCLUDED.upload_bytes, default_quota.upload_bytes),
+    concurrent_jobs = COALESCE(EXCLUDED.concurrent_jobs, default_quota.concurrent_jobs),
+    daily_jobs = COALESCE(EXCLUDED.daily_jobs, default_quota.daily_jobs),
+    max_priority = COALESCE(EXCLUDED.max_priority, default_quota.max_priority);
@@ -0,0 +1,8 @@
+INSERT INTO quota (namespace, storage_bytes, upload_bytes, concurrent_jobs, daily_jobs, max_priority)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (namespace) DO UPDATE
+SET storage_bytes = COALESCE(EXCLUDED.storage_bytes, quota.storage_bytes),
+    upload_bytes = COALESCE(EXCLUDED.upload_bytes, quota.upload_bytes),
+    concurrent_jobs = COALESCE(EXCLUDED.concurrent_jobs, quota.concurrent_jobs),
+    daily_jobs = COALESCE(EXCLUDED.daily_jobs, quota.daily_jobs),
+    max_priority = COALESCE(EXCLUDED.max_priority, quota.max_priority);
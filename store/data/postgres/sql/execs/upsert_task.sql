@@ -0,0 +1,35 @@
+INSERT INTO task (
+    task_type,
+    task_kind,
+    task_params,
+    task_description,
+    task_executable,
+    task_inputs,
+    task_outputs,
+    task_schema
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+) ON CONFLICT (task_type) DO UPDATE SET (
+    task_kind,
+    task_params,
+    task_description,
+    task_executable,
+    task_inputs,
+    task_outputs,
+    task_schema
+) = (
+    EXCLUDED.task_kind,
+    EXCLUDED.task_params,
+    EXCLUDED.task_description,
+    EXCLUDED.task_executable,
+    EXCLUDED.task_inputs,
+    EXCLUDED.task_outputs,
+    EXCLUDED.task_schema
+) RETURNING task_type, task_kind, task_params, task_description, task_executable, task_inputs, task_outputs, task_schema;
@@ -0,0 +1,10 @@
+INSERT INTO worker (worker_id, hostname, worker_version, task_types, capacity, job_ids)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (worker_id) DO UPDATE
+SET hostname = EXCLUDED.hostname,
+    worker_version = EXCLUDED.worker_version,
+    task_types = EXCLUDED.task_types,
+    capacity = EXCLUDED.capacity,
+    job_ids = EXCLUDED.job_ids,
+    heartbeat_time = NOW()
+RETURNING worker_id, hostname, worker_version, task_types, capacity, job_ids, start_time, heartbeat_time;
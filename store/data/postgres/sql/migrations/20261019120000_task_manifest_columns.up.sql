@@ -0,0 +1,25 @@
+BEGIN;
+
+ALTER TABLE task ADD COLUMN IF NOT EXISTS task_description TEXT;
+ALTER TABLE task ADD COLUMN IF NOT EXISTS task_executable VARCHAR (256);
+ALTER TABLE task ADD COLUMN IF NOT EXISTS task_inputs TEXT[];
+ALTER TABLE task ADD COLUMN IF NOT EXISTS task_outputs TEXT[];
+
+-- task types are looked up in lowercase, so 'polygonVectorLookup' could never be found
+INSERT INTO task (
+    task_type,
+    task_kind,
+    task_params,
+    task_executable
+) VALUES (
+    'polygonvectorlookup',
+    'lookup',
+    ARRAY['attributes', 'polygon'],
+    'polygonVectorLookup'
+) ON CONFLICT DO NOTHING;
+
+UPDATE step SET task_type = 'polygonvectorlookup' WHERE task_type = 'polygonVectorLookup';
+
+DELETE FROM task WHERE task_type = 'polygonVectorLookup';
+
+COMMIT;
@@ -0,0 +1 @@
+ALTER TABLE step ADD COLUMN IF NOT EXISTS overlay_id VARCHAR (64) REFERENCES storage(storage_id);
@@ -0,0 +1,3 @@
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS storage_format VARCHAR (32);
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS layers TEXT[];
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS feature_count BIGINT;
@@ -0,0 +1,9 @@
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS storage_size BIGINT;
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS checksum VARCHAR (64);
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS crs VARCHAR (64);
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS min_x DOUBLE PRECISION;
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS min_y DOUBLE PRECISION;
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS max_x DOUBLE PRECISION;
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS max_y DOUBLE PRECISION;
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS geometry_type VARCHAR (32);
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS storage_columns JSONB;
@@ -0,0 +1,13 @@
+CREATE TYPE upload_status AS ENUM ('pending', 'complete');
+
+CREATE TABLE IF NOT EXISTS upload (
+    upload_id VARCHAR (64) PRIMARY KEY,
+    namespace VARCHAR (64) NOT NULL,
+    upload_name VARCHAR (64),
+    content_type VARCHAR (64) NOT NULL,
+    upload_status UPLOAD_STATUS NOT NULL DEFAULT 'pending',
+    upload_size BIGINT,
+    checksum VARCHAR (64),
+    storage_id VARCHAR (64),
+    create_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
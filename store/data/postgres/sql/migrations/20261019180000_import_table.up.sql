@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS import (
+    import_id VARCHAR (64) PRIMARY KEY,
+    namespace VARCHAR (64) NOT NULL,
+    import_name VARCHAR (64),
+    import_url TEXT NOT NULL,
+    import_headers JSONB,
+    import_status JOB_STATUS NOT NULL DEFAULT 'waiting',
+    import_error TEXT,
+    storage_id VARCHAR (64),
+    create_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    end_time TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS content (
+    content_id VARCHAR (64) PRIMARY KEY,
+    namespace VARCHAR (64) NOT NULL,
+    content_format VARCHAR (32) NOT NULL,
+    checksum VARCHAR (64) NOT NULL,
+    ref_count INTEGER NOT NULL DEFAULT 1,
+    create_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (namespace, content_format, checksum)
+);
+
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS content_id VARCHAR (64);
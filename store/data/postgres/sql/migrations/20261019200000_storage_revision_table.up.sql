@@ -0,0 +1,38 @@
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS revision INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS storage_revision (
+    storage_id VARCHAR (64) NOT NULL REFERENCES storage(storage_id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    content_id VARCHAR (64),
+    storage_format VARCHAR (32),
+    layers TEXT[],
+    feature_count BIGINT,
+    storage_size BIGINT,
+    checksum VARCHAR (64),
+    crs VARCHAR (64),
+    min_x DOUBLE PRECISION,
+    min_y DOUBLE PRECISION,
+    max_x DOUBLE PRECISION,
+    max_y DOUBLE PRECISION,
+    geometry_type VARCHAR (32),
+    storage_columns JSONB,
+    create_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (storage_id, revision)
+);
+
+INSERT INTO storage_revision (
+    storage_id, revision, content_id,
+    storage_format, layers, feature_count,
+    storage_size, checksum, crs,
+    min_x, min_y, max_x, max_y,
+    geometry_type, storage_columns, create_time
+) SELECT
+    storage_id, revision, content_id,
+    storage_format, layers, feature_count,
+    storage_size, checksum, crs,
+    min_x, min_y, max_x, max_y,
+    geometry_type, storage_columns, create_time
+FROM storage
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE job ADD COLUMN IF NOT EXISTS input_revision INTEGER;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS quota (
+    namespace VARCHAR (64) PRIMARY KEY,
+    storage_bytes BIGINT,
+    upload_bytes BIGINT,
+    concurrent_jobs INTEGER,
+    daily_jobs INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS job_namespace_start_time_idx ON job (namespace, start_time);
@@ -0,0 +1,3 @@
+ALTER TABLE job ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE quota ADD COLUMN IF NOT EXISTS max_priority INTEGER;
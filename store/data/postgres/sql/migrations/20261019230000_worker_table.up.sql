@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS worker (
+    worker_id VARCHAR (64) PRIMARY KEY,
+    hostname VARCHAR (256) NOT NULL,
+    worker_version VARCHAR (64) NOT NULL,
+    task_types TEXT[],
+    capacity INTEGER NOT NULL DEFAULT 0,
+    job_ids TEXT[],
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    heartbeat_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,29 @@
+CREATE TYPE schedule_status AS ENUM ('active', 'paused');
+
+CREATE TYPE missed_run_policy AS ENUM ('skip', 'catchup');
+
+CREATE TABLE IF NOT EXISTS schedule (
+    schedule_id VARCHAR (64) PRIMARY KEY,
+    namespace VARCHAR (64) NOT NULL,
+    schedule_name VARCHAR (64),
+    cron VARCHAR (128) NOT NULL,
+    task_type VARCHAR (32) NOT NULL,
+    args JSONB,
+    input VARCHAR (128) NOT NULL,
+    schedule_status SCHEDULE_STATUS NOT NULL DEFAULT 'active',
+    missed_run_policy MISSED_RUN_POLICY NOT NULL DEFAULT 'skip',
+    next_run_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_run_time TIMESTAMP WITH TIME ZONE,
+    create_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS schedule_next_run_time_idx ON schedule (next_run_time) WHERE schedule_status = 'active';
+
+CREATE TABLE IF NOT EXISTS schedule_run (
+    schedule_id VARCHAR (64) NOT NULL REFERENCES schedule(schedule_id) ON DELETE CASCADE,
+    scheduled_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    job_id VARCHAR (64),
+    run_error VARCHAR (512),
+    create_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (schedule_id, scheduled_time)
+);
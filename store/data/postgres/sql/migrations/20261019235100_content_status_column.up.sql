@@ -0,0 +1,6 @@
+CREATE TYPE content_status AS ENUM ('pending', 'ready');
+
+-- content from before now has all been written, so only new content starts out pending
+ALTER TABLE content ADD COLUMN IF NOT EXISTS content_status CONTENT_STATUS NOT NULL DEFAULT 'ready';
+ALTER TABLE content ALTER COLUMN content_status SET DEFAULT 'pending';
+ALTER TABLE content ADD COLUMN IF NOT EXISTS update_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
@@ -0,0 +1,2 @@
+ALTER TABLE step DROP CONSTRAINT IF EXISTS step_overlay_id_fkey;
+ALTER TABLE step ADD CONSTRAINT step_overlay_id_fkey FOREIGN KEY (overlay_id) REFERENCES storage(storage_id) ON DELETE SET NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE storage_revision ADD COLUMN IF NOT EXISTS storage_status STORAGE_STATUS NOT NULL DEFAULT 'unknown';
+
+-- only the latest revision's status was recorded, on the storage
+UPDATE storage_revision r SET storage_status = s.storage_status
+FROM storage s
+WHERE r.storage_id = s.storage_id AND r.revision = s.revision;
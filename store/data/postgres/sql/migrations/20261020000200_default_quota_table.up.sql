@@ -0,0 +1,10 @@
+-- the limits of each namespace that its own quota does not set,
+-- which there is at most one row of
+CREATE TABLE IF NOT EXISTS default_quota (
+    only_row BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (only_row),
+    storage_bytes BIGINT,
+    upload_bytes BIGINT,
+    concurrent_jobs INTEGER,
+    daily_jobs INTEGER,
+    max_priority INTEGER
+);
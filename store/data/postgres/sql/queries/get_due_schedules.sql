@@ -0,0 +1,4 @@
+SELECT schedule_id, namespace, schedule_name, cron, task_type, args, input, schedule_status, missed_run_policy, next_run_time, last_run_time, create_time
+FROM schedule
+WHERE schedule_status = 'active' AND next_run_time <= $1
+ORDER BY next_run_time;
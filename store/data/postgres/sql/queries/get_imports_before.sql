@@ -0,0 +1,3 @@
+SELECT import_id, namespace, import_name, import_url, import_headers, import_status, import_error, storage_id, create_time, end_time
+FROM import
+WHERE create_time < $1;
@@ -0,0 +1,4 @@
+SELECT import_id, namespace, import_name, import_url, import_headers, import_status, import_error, storage_id, create_time, end_time
+FROM import
+WHERE namespace = $1
+ORDER BY create_time OFFSET $2 LIMIT $3;
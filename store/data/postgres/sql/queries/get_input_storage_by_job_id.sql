@@ -1 +1,5 @@
-SELECT s.storage_id, s.storage_status, s.namespace, s.storage_name, s.last_used, s.create_time FROM storage s INNER JOIN job j ON s.storage_id = j.input_id WHERE j.job_id = $1;
+SELECT s.storage_id, r.storage_status, s.namespace, s.storage_name, r.storage_format, r.layers, r.feature_count, r.storage_size, r.checksum, r.crs, r.min_x, r.min_y, r.max_x, r.max_y, r.geometry_type, r.storage_columns, r.content_id, r.revision, s.last_used, r.create_time
+FROM job j
+INNER JOIN storage s ON s.storage_id = j.input_id
+INNER JOIN storage_revision r ON r.storage_id = s.storage_id AND r.revision = COALESCE(j.input_revision, s.revision)
+WHERE j.job_id = $1;
@@ -1,3 +1,3 @@
-SELECT job_id, namespace, input_id, output_id, job_status, job_error, start_time, end_time
+SELECT job_id, namespace, input_id, output_id, job_status, job_error, start_time, end_time, input_revision, priority
 FROM job
 WHERE end_time < $1;
@@ -1 +1 @@
-SELECT job_id, namespace, input_id, output_id, job_status, job_error, start_time, end_time FROM job WHERE namespace = $1 ORDER BY start_time OFFSET $2 LIMIT $3;
\ No newline at end of file
+SELECT job_id, namespace, input_id, output_id, job_status, job_error, start_time, end_time, input_revision, priority FROM job WHERE namespace = $1 ORDER BY start_time OFFSET $2 LIMIT $3;
\ No newline at end of file
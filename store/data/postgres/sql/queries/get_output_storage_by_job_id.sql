@@ -1 +1 @@
-SELECT s.storage_id, s.storage_status, s.namespace, s.storage_name, s.last_used, s.create_time FROM storage s INNER JOIN job j ON s.storage_id = j.output_id WHERE j.job_id = $1;
+SELECT s.storage_id, s.storage_status, s.namespace, s.storage_name, s.storage_format, s.layers, s.feature_count, s.storage_size, s.checksum, s.crs, s.min_x, s.min_y, s.max_x, s.max_y, s.geometry_type, s.storage_columns, s.content_id, s.revision, s.last_used, s.create_time FROM storage s INNER JOIN job j ON s.storage_id = j.output_id WHERE j.job_id = $1;
@@ -0,0 +1,9 @@
+SELECT (
+    SELECT COUNT(*)
+    FROM job
+    WHERE namespace = $1 AND job_status IN ('waiting', 'inprogress')
+) + (
+    SELECT COUNT(*)
+    FROM import
+    WHERE namespace = $1 AND import_status IN ('waiting', 'inprogress')
+);
@@ -0,0 +1,7 @@
+SELECT n.namespace,
+    COALESCE(q.storage_bytes, d.storage_bytes, 0), COALESCE(q.upload_bytes, d.upload_bytes, 0),
+    COALESCE(q.concurrent_jobs, d.concurrent_jobs, 0), COALESCE(q.daily_jobs, d.daily_jobs, 0),
+    COALESCE(q.max_priority, d.max_priority, 0)
+FROM (SELECT $1::VARCHAR AS namespace) n
+LEFT JOIN quota q ON q.namespace = n.namespace
+LEFT JOIN default_quota d ON TRUE;
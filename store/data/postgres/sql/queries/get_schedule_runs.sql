@@ -0,0 +1,4 @@
+SELECT schedule_id, scheduled_time, job_id, run_error, create_time
+FROM schedule_run
+WHERE schedule_id = $1
+ORDER BY scheduled_time DESC OFFSET $2 LIMIT $3;
@@ -1,3 +1,3 @@
-select step_id, job_id, task_type, job_args
+select step_id, job_id, task_type, job_args, coalesce(overlay_id, '')
 from step
 where job_id = $1;
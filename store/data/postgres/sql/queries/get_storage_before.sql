@@ -1,3 +1,3 @@
-SELECT storage_id, storage_status, namespace, storage_name, last_used, create_time 
+SELECT storage_id, storage_status, namespace, storage_name, storage_format, layers, feature_count, storage_size, checksum, crs, min_x, min_y, max_x, max_y, geometry_type, storage_columns, content_id, revision, last_used, create_time 
 FROM storage
 WHERE last_used < $1;
@@ -1 +1,11 @@
-SELECT storage_id, storage_status, namespace, storage_name, last_used, create_time FROM storage WHERE namespace = $1 ORDER BY create_time OFFSET $2 LIMIT $3;
+SELECT storage_id, storage_status, namespace, storage_name, storage_format, layers, feature_count, storage_size, checksum, crs, min_x, min_y, max_x, max_y, geometry_type, storage_columns, content_id, revision, last_used, create_time
+FROM storage
+WHERE namespace = $1
+AND ($4::TEXT = '' OR storage_format = $4)
+AND ($5::TEXT = '' OR geometry_type = $5)
+AND ($6::TEXT = '' OR crs = $6)
+AND ($7::TEXT = '' OR storage_columns @> jsonb_build_array(jsonb_build_object('name', $7::TEXT)))
+AND ($8::BIGINT IS NULL OR feature_count >= $8)
+AND ($9::BIGINT IS NULL OR feature_count <= $9)
+AND ($10::DOUBLE PRECISION IS NULL OR (min_x <= $12 AND max_x >= $10 AND min_y <= $13 AND max_y >= $11))
+ORDER BY create_time OFFSET $2 LIMIT $3;
@@ -0,0 +1,5 @@
+SELECT s.storage_id, r.storage_status, s.namespace, s.storage_name, r.storage_format, r.layers, r.feature_count, r.storage_size, r.checksum, r.crs, r.min_x, r.min_y, r.max_x, r.max_y, r.geometry_type, r.storage_columns, r.content_id, r.revision, s.last_used, r.create_time
+FROM storage_revision r
+INNER JOIN storage s ON s.storage_id = r.storage_id
+WHERE r.storage_id = $1
+ORDER BY r.revision OFFSET $2 LIMIT $3;
@@ -0,0 +1 @@
+SELECT task_type, task_kind, task_params, task_description, task_executable, task_inputs, task_outputs, task_schema FROM task ORDER BY task_type;
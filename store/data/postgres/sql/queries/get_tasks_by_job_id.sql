@@ -1,3 +1,3 @@
-SELECT t.task_type, task_kind, task_params 
+SELECT t.task_type, task_kind, task_params, task_description, task_executable, task_inputs, task_outputs, task_schema
 FROM task t INNER JOIN step s ON t.task_type = s.task_type 
 WHERE s.job_id = $1;
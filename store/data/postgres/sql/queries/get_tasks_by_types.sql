@@ -1 +1 @@
-SELECT DISTINCT task_type, task_kind, task_params FROM task where task_type = ANY($1);
+SELECT DISTINCT task_type, task_kind, task_params, task_description, task_executable, task_inputs, task_outputs, task_schema FROM task where task_type = ANY($1);
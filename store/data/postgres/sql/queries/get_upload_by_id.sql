@@ -0,0 +1 @@
+SELECT upload_id, namespace, upload_name, content_type, upload_status, upload_size, checksum, storage_id, create_time FROM upload WHERE upload_id = $1;
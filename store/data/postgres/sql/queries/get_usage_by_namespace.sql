@@ -0,0 +1,17 @@
+SELECT (
+    SELECT COALESCE(SUM(c.storage_size), 0)
+    FROM (
+        SELECT DISTINCT ON (COALESCE(r.content_id, r.storage_id || '@' || r.revision)) r.storage_size
+        FROM storage_revision r
+        JOIN storage s ON s.storage_id = r.storage_id
+        WHERE s.namespace = $1
+    ) c
+), (
+    SELECT COUNT(*)
+    FROM job
+    WHERE namespace = $1 AND job_status IN ('waiting', 'inprogress')
+), (
+    SELECT COUNT(*)
+    FROM job
+    WHERE namespace = $1 AND start_time > NOW() - INTERVAL '1 day'
+);
@@ -0,0 +1,4 @@
+SELECT worker_id, hostname, worker_version, task_types, capacity, job_ids, start_time, heartbeat_time
+FROM worker
+ORDER BY start_time
+OFFSET $1 LIMIT $2;
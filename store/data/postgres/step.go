@@ -1,13 +1,12 @@
 package postgres
 
 import (
-	"context"
+	"database/sql"
 	_ "embed"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"github.com/logsquaredn/rototiller/pb"
-	"golang.org/x/sync/errgroup"
 )
 
 var (
@@ -18,31 +17,26 @@ var (
 	getStepsByJobIDSQL string
 )
 
-func (d *Datastore) createSteps(jobID string, steps []*pb.Step) ([]*pb.Step, error) {
-	eg, _ := errgroup.WithContext(context.TODO())
-	for i, step := range steps {
-		i := i
-		step := step
-		eg.Go(func() error {
-			id := uuid.New().String()
-			return d.stmt.createStep.QueryRow(
-				id, jobID,
-				step.TaskType,
-				pq.Array(step.Args),
-			).Scan(
-				&step.Id, &step.JobId,
-				&step.TaskType, pq.Array(&step.Args),
-			)
-		})
-
-		steps[i] = step
-	}
-
-	if err := eg.Wait(); err != nil {
-		return nil, err
+// createSteps creates the job's steps within tx, one at a time, as
+// a transaction's statements cannot be run at the same time.
+func (d *Datastore) createSteps(tx *sql.Tx, jobID string, steps []*pb.Step) error {
+	createStep := tx.Stmt(d.stmt.createStep)
+	for _, step := range steps {
+		if err := createStep.QueryRow(
+			uuid.New().String(), jobID,
+			step.TaskType,
+			pq.Array(step.Args),
+			step.OverlayId,
+		).Scan(
+			&step.Id, &step.JobId,
+			&step.TaskType, pq.Array(&step.Args),
+			&step.OverlayId,
+		); err != nil {
+			return err
+		}
 	}
 
-	return steps, nil
+	return nil
 }
 
 func (d *Datastore) getSteps(jobID string) ([]*pb.Step, error) {
@@ -57,6 +51,7 @@ func (d *Datastore) getSteps(jobID string) ([]*pb.Step, error) {
 		err = rows.Scan(
 			&s.Id, &s.JobId,
 			&s.TaskType, pq.Array(&s.Args),
+			&s.OverlayId,
 		)
 		if err != nil {
 			return nil, err
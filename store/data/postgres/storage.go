@@ -3,9 +3,11 @@ package postgres
 import (
 	"database/sql"
 	_ "embed"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/logsquaredn/rototiller/pb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -23,6 +25,9 @@ var (
 	//go:embed sql/execs/update_storage.sql
 	updateStorageSQL string
 
+	//go:embed sql/execs/touch_storage.sql
+	touchStorageSQL string
+
 	//go:embed sql/queries/get_storage_by_namespace.sql
 	getStorgageByNamespaceSQL string
 
@@ -34,172 +39,268 @@ var (
 
 	//go:embed sql/queries/get_input_storage_by_job_id.sql
 	getInputStorageByJobIDSQL string
-)
 
-func (d *Datastore) UpdateStorage(s *pb.Storage) (*pb.Storage, error) {
-	var lastUsed, createTime sql.NullTime
-	if err := d.stmt.updateStorage.QueryRow(
-		s.Id, s.Status, time.Now(),
-	).Scan(
-		&s.Id, &s.Status, &s.Namespace,
-		&s.Name, &lastUsed, &createTime,
-	); err != nil {
-		return nil, err
-	}
+	//go:embed sql/execs/create_storage_revision.sql
+	createStorageRevisionSQL string
 
-	s.LastUsed = timestamppb.New(lastUsed.Time)
-	s.CreateTime = timestamppb.New(createTime.Time)
+	//go:embed sql/queries/get_storage_revision.sql
+	getStorageRevisionSQL string
 
-	return s, nil
+	//go:embed sql/queries/get_storage_revisions.sql
+	getStorageRevisionsSQL string
+)
+
+// StorageFilter narrows down the storages returned by GetOwnerStorage.
+// Zero values match every storage.
+type StorageFilter struct {
+	Format       string
+	GeometryType string
+	CRS          string
+	// Column is the name of a column that the storage must have.
+	Column      string
+	MinFeatures *int64
+	MaxFeatures *int64
+	// BBox is min x, min y, max x and max y,
+	// which the storage's bbox must intersect.
+	BBox []float64
 }
 
-func (d *Datastore) CreateStorage(s *pb.Storage) (*pb.Storage, error) {
+func scanStorage(sc scanner) (*pb.Storage, error) {
 	var (
-		id                   = uuid.NewString()
-		lastUsed, createTime sql.NullTime
-		err                  error
+		s                                   = &pb.Storage{}
+		format, checksum, crs, geometryType sql.NullString
+		contentID                           sql.NullString
+		featureCount, size                  sql.NullInt64
+		minX, minY, maxX, maxY              sql.NullFloat64
+		columns                             []byte
+		lastUsed, createTime                sql.NullTime
 	)
 
-	if s.Status == "" {
-		s.Status = pb.StorageStatusUnknown.String()
-	}
-
-	if err = d.stmt.createStorage.QueryRow(
-		id, s.Status, s.Namespace, s.Name,
-	).Scan(
+	if err := sc.Scan(
 		&s.Id, &s.Status, &s.Namespace,
-		&s.Name, &lastUsed, &createTime,
+		&s.Name, &format, pq.Array(&s.Layers), &featureCount,
+		&size, &checksum, &crs,
+		&minX, &minY, &maxX, &maxY,
+		&geometryType, &columns, &contentID,
+		&s.Revision, &lastUsed, &createTime,
 	); err != nil {
 		return nil, err
 	}
 
+	if len(columns) > 0 {
+		if err := json.Unmarshal(columns, &s.Columns); err != nil {
+			return nil, err
+		}
+	}
+
+	if minX.Valid && minY.Valid && maxX.Valid && maxY.Valid {
+		s.Bbox = []float64{minX.Float64, minY.Float64, maxX.Float64, maxY.Float64}
+	}
+
+	s.Format = format.String
+	s.FeatureCount = featureCount.Int64
+	s.Size = size.Int64
+	s.Checksum = checksum.String
+	s.Crs = crs.String
+	s.GeometryType = geometryType.String
+	s.ContentId = contentID.String
 	s.LastUsed = timestamppb.New(lastUsed.Time)
 	s.CreateTime = timestamppb.New(createTime.Time)
 
 	return s, nil
 }
 
-func (d *Datastore) GetStorage(id string) (*pb.Storage, error) {
-	var (
-		s                    = &pb.Storage{}
-		lastUsed, createTime sql.NullTime
-	)
+func scanStorages(rows *sql.Rows) ([]*pb.Storage, error) {
+	defer rows.Close()
 
-	if err := d.stmt.getStorage.QueryRow(id).Scan(
-		&s.Id, &s.Status, &s.Namespace,
-		&s.Name, &lastUsed, &createTime,
-	); err != nil {
-		return nil, err
+	storages := []*pb.Storage{}
+	for rows.Next() {
+		s, err := scanStorage(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		storages = append(storages, s)
 	}
 
-	s.LastUsed = timestamppb.New(lastUsed.Time)
-	s.CreateTime = timestamppb.New(createTime.Time)
+	return storages, rows.Err()
+}
 
-	return s, nil
+// UpdateStorage records the status of the storage's revision, since the status
+// was learned from that revision's content, and that the storage was just used.
+// The storage itself only takes the status if it is still its latest revision.
+// If the revision does not exist, sql.ErrNoRows is returned.
+func (d *Datastore) UpdateStorage(s *pb.Storage) (*pb.Storage, error) {
+	return scanStorage(d.stmt.updateStorage.QueryRow(
+		s.Id, s.Status, time.Now(), s.Revision,
+	))
 }
 
-func (d *Datastore) DeleteStorage(id string) error {
-	_, err := d.stmt.deleteStorage.Exec(id)
+// TouchStorage records that the storage was just used, e.g. by a job that
+// refers to it in its params, so that it is not deleted for going unused.
+func (d *Datastore) TouchStorage(id string) error {
+	_, err := d.stmt.touchStorage.Exec(id, time.Now())
 	return err
 }
 
-func (d *Datastore) GetOwnerStorage(id string, offset, limit int) ([]*pb.Storage, error) {
-	rows, err := d.stmt.getStorageByNamespace.Query(id, offset, limit)
+// CreateStorage creates the storage, unless it would bring its
+// namespace past what it may store, in which case a *pb.Error is returned.
+func (d *Datastore) CreateStorage(s *pb.Storage) (*pb.Storage, error) {
+	if s.Status == "" {
+		s.Status = pb.StorageStatusUnknown.String()
+	}
+
+	content, err := contentParams(s)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	storage := []*pb.Storage{}
+	var storage *pb.Storage
+	if err = d.inNamespaceTx(s.Namespace, func(tx *sql.Tx) error {
+		before, err := d.getUsage(tx, s.Namespace)
+		if err != nil {
+			return err
+		}
 
-	for rows.Next() {
-		var (
-			s                    = &pb.Storage{}
-			lastUsed, createTime sql.NullTime
-		)
-
-		if err = rows.Scan(
-			&s.Id, &s.Status, &s.Namespace,
-			&s.Name, &lastUsed, &createTime,
-		); err != nil {
-			return nil, err
+		if storage, err = scanStorage(tx.Stmt(d.stmt.createStorage).QueryRow(
+			append([]any{uuid.NewString(), s.Status, s.Namespace, s.Name}, content...)...,
+		)); err != nil {
+			return err
 		}
 
-		s.LastUsed = timestamppb.New(lastUsed.Time)
-		s.CreateTime = timestamppb.New(createTime.Time)
+		return d.checkStorageQuota(tx, s.Namespace, before)
+	}); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
 
-		storage = append(storage, s)
+// CreateStorageRevision records the storage's content as its next revision,
+// which becomes the one that the storage, and so new jobs, refer to, unless
+// it would bring the storage's namespace past what it may store, in
+// which case a *pb.Error is returned.
+func (d *Datastore) CreateStorageRevision(s *pb.Storage) (*pb.Storage, error) {
+	content, err := contentParams(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var storage *pb.Storage
+	if err = d.inNamespaceTx(s.Namespace, func(tx *sql.Tx) error {
+		before, err := d.getUsage(tx, s.Namespace)
+		if err != nil {
+			return err
+		}
+
+		if storage, err = scanStorage(tx.Stmt(d.stmt.createStorageRevision).QueryRow(
+			append([]any{s.Id}, content...)...,
+		)); err != nil {
+			return err
+		}
+
+		return d.checkStorageQuota(tx, s.Namespace, before)
+	}); err != nil {
+		return nil, err
 	}
 
 	return storage, nil
 }
 
-func (d *Datastore) GetJobInputStorage(id string) (*pb.Storage, error) {
+// contentParams returns the params that describe the storage's
+// content, in the order that storage and storage_revision share.
+func contentParams(s *pb.Storage) ([]any, error) {
 	var (
-		s                    = &pb.Storage{}
-		lastUsed, createTime sql.NullTime
+		// storages whose content could not be described, e.g.
+		// the outputs of lookups, have no known feature count
+		featureCount = sql.NullInt64{Int64: s.FeatureCount, Valid: s.Format != ""}
+		size         = sql.NullInt64{Int64: s.Size, Valid: s.Checksum != ""}
+		bbox         = make([]sql.NullFloat64, 4)
+		columns      []byte
+		err          error
 	)
 
-	if err := d.stmt.getInputStorageByJobID.QueryRow(id).Scan(
-		&s.Id, &s.Status, &s.Namespace,
-		&s.Name, &lastUsed, &createTime,
-	); err != nil {
-		return nil, err
+	if len(s.Bbox) == 4 {
+		for i, f := range s.Bbox {
+			bbox[i] = sql.NullFloat64{Float64: f, Valid: true}
+		}
 	}
 
-	s.LastUsed = timestamppb.New(lastUsed.Time)
-	s.CreateTime = timestamppb.New(createTime.Time)
+	if s.Columns != nil {
+		if columns, err = json.Marshal(s.Columns); err != nil {
+			return nil, err
+		}
+	}
 
-	return s, nil
+	return []any{
+		s.Format, pq.Array(s.Layers), featureCount,
+		size, s.Checksum, s.Crs,
+		bbox[0], bbox[1], bbox[2], bbox[3],
+		s.GeometryType, columns, s.ContentId,
+	}, nil
 }
 
-func (d *Datastore) GetJobOutputStorage(id string) (*pb.Storage, error) {
-	var (
-		s                    = &pb.Storage{}
-		lastUsed, createTime sql.NullTime
-	)
+func (d *Datastore) GetStorageRevision(id string, revision int32) (*pb.Storage, error) {
+	return scanStorage(d.stmt.getStorageRevision.QueryRow(id, revision))
+}
 
-	if err := d.stmt.getOutputStorageByJobID.QueryRow(id).Scan(
-		&s.Id, &s.Status, &s.Namespace,
-		&s.Name, &lastUsed, &createTime,
-	); err != nil {
+func (d *Datastore) GetStorageRevisions(id string, offset, limit int) ([]*pb.Storage, error) {
+	rows, err := d.stmt.getStorageRevisions.Query(id, offset, limit)
+	if err != nil {
 		return nil, err
 	}
 
-	s.LastUsed = timestamppb.New(lastUsed.Time)
-	s.CreateTime = timestamppb.New(createTime.Time)
+	return scanStorages(rows)
+}
 
-	return s, nil
+func (d *Datastore) GetStorage(id string) (*pb.Storage, error) {
+	return scanStorage(d.stmt.getStorage.QueryRow(id))
 }
 
-func (d *Datastore) GetStorageBefore(duration time.Duration) ([]*pb.Storage, error) {
-	beforeTimestamp := time.Now().Add(-duration)
-	rows, err := d.stmt.getStorageBefore.Query(beforeTimestamp)
+func (d *Datastore) DeleteStorage(id string) error {
+	_, err := d.stmt.deleteStorage.Exec(id)
+	return err
+}
+
+func (d *Datastore) GetOwnerStorage(id string, offset, limit int, filter *StorageFilter) ([]*pb.Storage, error) {
+	if filter == nil {
+		filter = &StorageFilter{}
+	}
+
+	bbox := make([]sql.NullFloat64, 4)
+	if len(filter.BBox) == 4 {
+		for i, f := range filter.BBox {
+			bbox[i] = sql.NullFloat64{Float64: f, Valid: true}
+		}
+	}
+
+	rows, err := d.stmt.getStorageByNamespace.Query(
+		id, offset, limit,
+		filter.Format, filter.GeometryType, filter.CRS, filter.Column,
+		filter.MinFeatures, filter.MaxFeatures,
+		bbox[0], bbox[1], bbox[2], bbox[3],
+	)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var storages []*pb.Storage
+	return scanStorages(rows)
+}
 
-	for rows.Next() {
-		var (
-			s                    = &pb.Storage{}
-			lastUsed, createTime sql.NullTime
-		)
-
-		if err = rows.Scan(
-			&s.Id, &s.Status, &s.Namespace,
-			&s.Name, &lastUsed, &createTime,
-		); err != nil {
-			return nil, err
-		}
+func (d *Datastore) GetJobInputStorage(id string) (*pb.Storage, error) {
+	return scanStorage(d.stmt.getInputStorageByJobID.QueryRow(id))
+}
 
-		s.LastUsed = timestamppb.New(lastUsed.Time)
-		s.CreateTime = timestamppb.New(createTime.Time)
+func (d *Datastore) GetJobOutputStorage(id string) (*pb.Storage, error) {
+	return scanStorage(d.stmt.getOutputStorageByJobID.QueryRow(id))
+}
 
-		storages = append(storages, s)
+func (d *Datastore) GetStorageBefore(duration time.Duration) ([]*pb.Storage, error) {
+	beforeTimestamp := time.Now().Add(-duration)
+	rows, err := d.stmt.getStorageBefore.Query(beforeTimestamp)
+	if err != nil {
+		return nil, err
 	}
 
-	return storages, nil
+	return scanStorages(rows)
 }
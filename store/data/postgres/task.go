@@ -1,10 +1,12 @@
 package postgres
 
 import (
+	"database/sql"
 	_ "embed"
+	"encoding/json"
 
 	"github.com/lib/pq"
-	"github.com/logsquaredn/rototiller"
+	"github.com/logsquaredn/rototiller/pb"
 )
 
 var (
@@ -13,44 +15,80 @@ var (
 
 	//go:embed sql/queries/get_tasks_by_types.sql
 	getTasksByTypesSQL string
+
+	//go:embed sql/queries/get_tasks.sql
+	getTasksSQL string
+
+	//go:embed sql/execs/upsert_task.sql
+	upsertTaskSQL string
 )
 
-func (d *Datastore) GetTasksByJobID(id string) ([]*rototiller.Task, error) {
-	rows, err := d.stmt.getTasksByJobID.Query(id)
-	if err != nil {
+type scanner interface {
+	Scan(...any) error
+}
+
+func scanTask(s scanner) (*pb.Task, error) {
+	var (
+		t                       = &pb.Task{}
+		description, executable sql.NullString
+		schema                  []byte
+	)
+
+	if err := s.Scan(
+		&t.Type, &t.Kind, pq.Array(&t.Params),
+		&description, &executable,
+		pq.Array(&t.Inputs), pq.Array(&t.Outputs),
+		&schema,
+	); err != nil {
 		return nil, err
 	}
+
+	t.Description = description.String
+	t.Executable = executable.String
+
+	if len(schema) > 0 {
+		if err := json.Unmarshal(schema, &t.Schema); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+func scanTasks(rows *sql.Rows) ([]*pb.Task, error) {
 	defer rows.Close()
 
-	tasks := []*rototiller.Task{}
+	tasks := []*pb.Task{}
 
 	for rows.Next() {
-		t := &rototiller.Task{}
-
-		if err = rows.Scan(&t.Type, &t.Kind, pq.Array(&t.Params)); err != nil {
+		t, err := scanTask(rows)
+		if err != nil {
 			return nil, err
 		}
 
 		tasks = append(tasks, t)
 	}
 
-	return tasks, nil
+	return tasks, rows.Err()
 }
 
-//go:embed sql/queries/get_task_by_type.sql
-var getTaskByTypeSQL string
-
-func (d *Datastore) GetTask(tt rototiller.TaskType) (*rototiller.Task, error) {
-	t := &rototiller.Task{}
-
-	if err := d.stmt.getTaskByType.QueryRow(tt.String()).Scan(&t.Type, &t.Kind, pq.Array(&t.Params)); err != nil {
+func (d *Datastore) GetTasksByJobID(id string) ([]*pb.Task, error) {
+	rows, err := d.stmt.getTasksByJobID.Query(id)
+	if err != nil {
 		return nil, err
 	}
 
-	return t, nil
+	return scanTasks(rows)
 }
 
-func (d *Datastore) GetTasks(taskTypes ...rototiller.TaskType) ([]*rototiller.Task, error) {
+//go:embed sql/queries/get_task_by_type.sql
+var getTaskByTypeSQL string
+
+func (d *Datastore) GetTask(tt pb.TaskType) (*pb.Task, error) {
+	return scanTask(d.stmt.getTaskByType.QueryRow(tt.String()))
+}
+
+func (d *Datastore) GetTasks(taskTypes ...pb.TaskType) ([]*pb.Task, error) {
 	rawTaskTypes := make([]string, len(taskTypes))
 	for i, tt := range taskTypes {
 		rawTaskTypes[i] = tt.String()
@@ -60,19 +98,29 @@ func (d *Datastore) GetTasks(taskTypes ...rototiller.TaskType) ([]*rototiller.Ta
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var tasks []*rototiller.Task
+	return scanTasks(rows)
+}
 
-	for rows.Next() {
-		t := &rototiller.Task{}
+func (d *Datastore) ListTasks() ([]*pb.Task, error) {
+	rows, err := d.stmt.getTasks.Query()
+	if err != nil {
+		return nil, err
+	}
 
-		if err = rows.Scan(&t.Type, &t.Kind, pq.Array(&t.Params)); err != nil {
-			return nil, err
-		}
+	return scanTasks(rows)
+}
 
-		tasks = append(tasks, t)
+func (d *Datastore) UpsertTask(t *pb.Task) (*pb.Task, error) {
+	schema, err := json.Marshal(t.Schema)
+	if err != nil {
+		return nil, err
 	}
 
-	return tasks, rows.Err()
+	return scanTask(d.stmt.upsertTask.QueryRow(
+		t.Type, t.Kind, pq.Array(t.Params),
+		t.Description, t.Executable,
+		pq.Array(t.Inputs), pq.Array(t.Outputs),
+		schema,
+	))
 }
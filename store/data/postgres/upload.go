@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"database/sql"
+	_ "embed"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/logsquaredn/rototiller/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	//go:embed sql/execs/create_upload.sql
+	createUploadSQL string
+
+	//go:embed sql/execs/update_upload.sql
+	updateUploadSQL string
+
+	//go:embed sql/execs/delete_upload.sql
+	deleteUploadSQL string
+
+	//go:embed sql/queries/get_upload_by_id.sql
+	getUploadByIDSQL string
+
+	//go:embed sql/queries/get_uploads_before.sql
+	getUploadsBeforeSQL string
+)
+
+func scanUpload(sc scanner) (*pb.Upload, error) {
+	var (
+		u                         = &pb.Upload{}
+		name, checksum, storageID sql.NullString
+		size                      sql.NullInt64
+		createTime                sql.NullTime
+	)
+
+	if err := sc.Scan(
+		&u.Id, &u.Namespace, &name,
+		&u.ContentType, &u.Status, &size,
+		&checksum, &storageID, &createTime,
+	); err != nil {
+		return nil, err
+	}
+
+	u.Name = name.String
+	u.Size = size.Int64
+	u.Checksum = checksum.String
+	u.StorageId = storageID.String
+	u.CreateTime = timestamppb.New(createTime.Time)
+
+	return u, nil
+}
+
+func (d *Datastore) CreateUpload(u *pb.Upload) (*pb.Upload, error) {
+	return scanUpload(d.stmt.createUpload.QueryRow(
+		uuid.NewString(), u.Namespace, u.Name,
+		u.ContentType, u.Size, u.Checksum,
+	))
+}
+
+func (d *Datastore) UpdateUpload(u *pb.Upload) (*pb.Upload, error) {
+	return scanUpload(d.stmt.updateUpload.QueryRow(
+		u.Id, u.Status, u.StorageId,
+	))
+}
+
+func (d *Datastore) GetUpload(id string) (*pb.Upload, error) {
+	return scanUpload(d.stmt.getUpload.QueryRow(id))
+}
+
+func (d *Datastore) DeleteUpload(id string) error {
+	_, err := d.stmt.deleteUpload.Exec(id)
+	return err
+}
+
+func (d *Datastore) GetUploadsBefore(duration time.Duration) ([]*pb.Upload, error) {
+	rows, err := d.stmt.getUploadsBefore.Query(time.Now().Add(-duration))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	uploads := []*pb.Upload{}
+	for rows.Next() {
+		u, err := scanUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		uploads = append(uploads, u)
+	}
+
+	return uploads, rows.Err()
+}
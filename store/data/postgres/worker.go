@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"database/sql"
+	_ "embed"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/logsquaredn/rototiller/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	//go:embed sql/execs/upsert_worker.sql
+	upsertWorkerSQL string
+
+	//go:embed sql/execs/delete_worker.sql
+	deleteWorkerSQL string
+
+	//go:embed sql/execs/delete_workers_before.sql
+	deleteWorkersBeforeSQL string
+
+	//go:embed sql/queries/get_workers.sql
+	getWorkersSQL string
+)
+
+func scanWorker(sc scanner) (*pb.Worker, error) {
+	var (
+		w                        = &pb.Worker{}
+		startTime, heartbeatTime sql.NullTime
+	)
+
+	if err := sc.Scan(
+		&w.Id, &w.Hostname, &w.Version,
+		pq.Array(&w.TaskTypes), &w.Capacity, pq.Array(&w.JobIds),
+		&startTime, &heartbeatTime,
+	); err != nil {
+		return nil, err
+	}
+
+	w.StartTime = timestamppb.New(startTime.Time)
+	w.HeartbeatTime = timestamppb.New(heartbeatTime.Time)
+
+	return w, nil
+}
+
+// UpsertWorker registers the worker or, if it is already
+// registered, updates it and records that it is still alive.
+func (d *Datastore) UpsertWorker(w *pb.Worker) (*pb.Worker, error) {
+	return scanWorker(d.stmt.upsertWorker.QueryRow(
+		w.GetId(), w.GetHostname(), w.GetVersion(),
+		pq.Array(w.GetTaskTypes()), w.GetCapacity(), pq.Array(w.GetJobIds()),
+	))
+}
+
+func (d *Datastore) DeleteWorker(id string) error {
+	_, err := d.stmt.deleteWorker.Exec(id)
+	return err
+}
+
+// DeleteWorkersBefore deletes the workers that
+// have not heartbeated within the duration.
+func (d *Datastore) DeleteWorkersBefore(duration time.Duration) error {
+	_, err := d.stmt.deleteWorkersBefore.Exec(time.Now().Add(-duration))
+	return err
+}
+
+func (d *Datastore) GetWorkers(offset, limit int) ([]*pb.Worker, error) {
+	rows, err := d.stmt.getWorkers.Query(offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workers := []*pb.Worker{}
+	for rows.Next() {
+		w, err := scanWorker(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		workers = append(workers, w)
+	}
+
+	return workers, rows.Err()
+}
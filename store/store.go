@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/store/blob/bucket"
+	"github.com/logsquaredn/rototiller/store/data/postgres"
+)
+
+const (
+	// revisionsPageSize is how many of a storage's revisions are got at a time.
+	revisionsPageSize = 100
+	// contentPollInterval is how often content that another
+	// caller is still writing is checked for being ready.
+	contentPollInterval = 250 * time.Millisecond
+	// contentWaitTimeout is how long content that another caller
+	// is still writing is waited for before giving up on it.
+	contentWaitTimeout = 30 * time.Second
+)
+
+// CreateStorage creates the storage and writes its content. Storages with
+// a format and checksum, i.e. ingested datasets, are deduplicated within
+// their namespace: identical content is written to the blobstore once
+// and linked to by each of the storages that it was ingested as.
+func CreateStorage(ctx context.Context, datastore *postgres.Datastore, blobstore *bucket.Blobstore, s *pb.Storage, c Content) (*pb.Storage, error) {
+	if s.GetFormat() == "" || s.GetChecksum() == "" {
+		storage, err := datastore.CreateStorage(s)
+		if err != nil {
+			_ = c.discard(ctx, blobstore)
+			return nil, err
+		}
+
+		return storage, c.put(ctx, blobstore, storage.GetId())
+	}
+
+	if err := putContent(ctx, datastore, blobstore, s, c); err != nil {
+		return nil, err
+	}
+
+	storage, err := datastore.CreateStorage(s)
+	if err != nil {
+		_ = releaseContent(ctx, datastore, blobstore, s.GetContentId())
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// CreateStorageRevision writes the content as the next revision of the
+// storage with the given ID, deduplicated just as CreateStorage does.
+// Earlier revisions are kept as they were, so jobs can still use them.
+func CreateStorageRevision(ctx context.Context, datastore *postgres.Datastore, blobstore *bucket.Blobstore, s *pb.Storage, c Content) (*pb.Storage, error) {
+	if err := putContent(ctx, datastore, blobstore, s, c); err != nil {
+		return nil, err
+	}
+
+	storage, err := datastore.CreateStorageRevision(s)
+	if err != nil {
+		_ = releaseContent(ctx, datastore, blobstore, s.GetContentId())
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// DeleteStorage deletes the storage and the volume of each of its
+// revisions, unless its content is still linked to by other storages.
+func DeleteStorage(ctx context.Context, datastore *postgres.Datastore, blobstore *bucket.Blobstore, s *pb.Storage) error {
+	revisions := []*pb.Storage{}
+	for offset := 0; ; offset += revisionsPageSize {
+		page, err := datastore.GetStorageRevisions(s.GetId(), offset, revisionsPageSize)
+		if err != nil {
+			return err
+		}
+
+		revisions = append(revisions, page...)
+		if len(page) < revisionsPageSize {
+			break
+		}
+	}
+
+	// revisions are deleted along with the storage
+	if err := datastore.DeleteStorage(s.GetId()); err != nil {
+		return err
+	}
+
+	for _, revision := range revisions {
+		if revision.GetContentId() == "" {
+			if err := blobstore.DeleteObject(ctx, revision.GetId()); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := releaseContent(ctx, datastore, blobstore, revision.GetContentId()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// putContent links the storage to the namespace's content with the same
+// format and checksum as it, writing the content if there is none yet
+// or else discarding it. Content that another caller is still writing
+// is waited for, as it cannot be linked to until it has been written.
+func putContent(ctx context.Context, datastore *postgres.Datastore, blobstore *bucket.Blobstore, s *pb.Storage, c Content) error {
+	contentID, isNew, err := acquireContent(ctx, datastore, s, c)
+	if err != nil {
+		_ = c.discard(ctx, blobstore)
+		return err
+	}
+
+	if !isNew {
+		if err = c.discard(ctx, blobstore); err != nil {
+			_ = releaseContent(ctx, datastore, blobstore, contentID)
+			return err
+		}
+
+		s.ContentId = contentID
+
+		return nil
+	}
+
+	if err = c.put(ctx, blobstore, contentID); err == nil {
+		err = datastore.ReadyContent(contentID)
+	}
+	if err != nil {
+		// pending content cannot be linked to, so its blob is ours alone
+		_ = datastore.AbandonContent(contentID)
+		_ = blobstore.DeleteObject(ctx, contentID)
+		return err
+	}
+
+	s.ContentId = contentID
+
+	return nil
+}
+
+// acquireContent acquires the content that the storage describes, polling
+// for it to be ready for as long as another caller is still writing it.
+func acquireContent(ctx context.Context, datastore *postgres.Datastore, s *pb.Storage, c Content) (string, bool, error) {
+	var (
+		timeout = time.NewTimer(contentWaitTimeout)
+		ticker  = time.NewTicker(contentPollInterval)
+	)
+	defer timeout.Stop()
+	defer ticker.Stop()
+
+	for {
+		contentID, isNew, err := datastore.AcquireContent(c.id(), s.GetNamespace(), s.GetFormat(), s.GetChecksum())
+		if !errors.Is(err, postgres.ErrContentPending) {
+			return contentID, isNew, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", false, ctx.Err()
+		case <-timeout.C:
+			return "", false, pb.NewErr(fmt.Errorf("identical content is still being stored, try again"), http.StatusConflict)
+		case <-ticker.C:
+		}
+	}
+}
+
+func releaseContent(ctx context.Context, datastore *postgres.Datastore, blobstore *bucket.Blobstore, id string) error {
+	last, err := datastore.ReleaseContent(id)
+	if err != nil || !last {
+		return err
+	}
+
+	return blobstore.DeleteObject(ctx, id)
+}
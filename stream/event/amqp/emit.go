@@ -5,16 +5,31 @@ import (
 	"encoding/json"
 
 	"github.com/logsquaredn/rototiller"
+	"github.com/logsquaredn/rototiller/pb"
 	"github.com/rabbitmq/amqp091-go"
 )
 
-func (a *EventStreamProducer) Emit(ctx context.Context, event *rototiller.Event) error {
+// Emit publishes the event with its type as the routing key,
+// qualified by the given words so that consumers may bind to
+// a subset of the events of that type, e.g. by task type.
+func (a *EventStreamProducer) Emit(ctx context.Context, event *rototiller.Event, words ...string) error {
+	return a.EmitWithPriority(ctx, event, 0, words...)
+}
+
+// EmitWithPriority is Emit, but the event is delivered ahead of
+// those still queued with a lower priority, up to MaxPriority.
+func (a *EventStreamProducer) EmitWithPriority(ctx context.Context, event *rototiller.Event, priority uint8, words ...string) error {
 	body, err := json.Marshal(event)
 	if err != nil {
 		return err
 	}
 
-	return a.Channel.PublishWithContext(ctx, ExchangeName, event.GetType(), false, false, amqp091.Publishing{
-		Body: body,
+	if priority > MaxPriority {
+		priority = MaxPriority
+	}
+
+	return a.Channel.PublishWithContext(ctx, ExchangeName, pb.EventType(event.GetType()).Key(words...).String(), false, false, amqp091.Publishing{
+		Body:     body,
+		Priority: priority,
 	})
 }
@@ -7,6 +7,14 @@ import (
 	"github.com/logsquaredn/rototiller"
 )
 
+// Prefetch limits the events that are delivered to the consumer but not
+// yet acked to count, or removes the limit if count is 0, so that those
+// beyond it are left on the queue for other consumers. It must be called
+// before Listen.
+func (a *EventStreamConsumer) Prefetch(count int) error {
+	return a.Channel.Qos(count, 0, false)
+}
+
 func (a *EventStreamConsumer) Listen(ctx context.Context) (<-chan *rototiller.Event, <-chan error) {
 	var (
 		eventC = make(chan *rototiller.Event)
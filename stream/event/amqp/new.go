@@ -70,8 +70,13 @@ func (e *EventStream) NewProducer(ctx context.Context) (*EventStreamProducer, er
 	return &EventStreamProducer{e}, nil
 }
 
+// NewConsumer declares the queue named by id, bound to the given event types,
+// to consume from. Queues deliver events by their priority, so a queue that
+// was declared before they did must be deleted for it to be declared again.
 func (e *EventStream) NewConsumer(ctx context.Context, id string, events ...pb.EventType) (*EventStreamConsumer, error) {
-	queue, err := e.Channel.QueueDeclare(NewQueueName(id), true, false, false, false, nil)
+	queue, err := e.Channel.QueueDeclare(NewQueueName(id), true, false, false, false, amqp.Table{
+		"x-max-priority": MaxPriority,
+	})
 	if err != nil {
 		return nil, err
 	}
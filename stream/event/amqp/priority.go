@@ -0,0 +1,25 @@
+package amqp
+
+import "math/bits"
+
+// MaxPriority is the highest priority that an event may be emitted with.
+// Queues deliver the events that they hold with the highest priority first,
+// and those of the same priority in the order that they were emitted.
+const MaxPriority uint8 = 9
+
+// FairPriority is the priority to emit an event of a namespace with, given how
+// many of its jobs and imports, the event's own included, have yet to finish.
+// Each doubling of them lowers it by one, so that the events of a namespace
+// that creates many jobs at once are queued behind those of the namespaces
+// that have few, rather than holding them up until its own are delivered.
+func FairPriority(pending int) uint8 {
+	if pending <= 1 {
+		return MaxPriority
+	}
+
+	if ahead := uint8(bits.Len(uint(pending - 1))); ahead < MaxPriority {
+		return MaxPriority - ahead
+	}
+
+	return 0
+}
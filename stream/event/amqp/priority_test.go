@@ -0,0 +1,30 @@
+package amqp_test
+
+import (
+	"testing"
+
+	"github.com/logsquaredn/rototiller/stream/event/amqp"
+)
+
+func TestFairPriority(t *testing.T) {
+	tests := []struct {
+		pending int
+		want    uint8
+	}{
+		{pending: 0, want: amqp.MaxPriority},
+		{pending: 1, want: amqp.MaxPriority},
+		{pending: 2, want: amqp.MaxPriority - 1},
+		{pending: 3, want: amqp.MaxPriority - 2},
+		{pending: 5, want: amqp.MaxPriority - 3},
+		{pending: 8, want: amqp.MaxPriority - 3},
+		{pending: 9, want: amqp.MaxPriority - 4},
+		{pending: 257, want: 0},
+		{pending: 1 << 20, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := amqp.FairPriority(tt.pending); got != tt.want {
+			t.Errorf("FairPriority(%d) = %d, want %d", tt.pending, got, tt.want)
+		}
+	}
+}
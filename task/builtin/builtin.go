@@ -0,0 +1,26 @@
+// Package builtin registers every in-process Task that ships with rototiller.
+// It is imported for its side effects by the worker.
+package builtin
+
+import (
+	// register the calculate Task
+	_ "github.com/logsquaredn/rototiller/task/calculate"
+	// register the clip Task
+	_ "github.com/logsquaredn/rototiller/task/clip"
+	// register the derive Task
+	_ "github.com/logsquaredn/rototiller/task/derive"
+	// register the difference Task
+	_ "github.com/logsquaredn/rototiller/task/difference"
+	// register the dissolve Task
+	_ "github.com/logsquaredn/rototiller/task/dissolve"
+	// register the intersect Task
+	_ "github.com/logsquaredn/rototiller/task/intersect"
+	// register the simplify Task
+	_ "github.com/logsquaredn/rototiller/task/simplify"
+	// register the spatialjoin Task
+	_ "github.com/logsquaredn/rototiller/task/spatialjoin"
+	// register the union Task
+	_ "github.com/logsquaredn/rototiller/task/union"
+	// register the where Task
+	_ "github.com/logsquaredn/rototiller/task/where"
+)
@@ -0,0 +1,168 @@
+// Package calculate implements the calculate Task, which adds, transforms,
+// renames, drops and selects the columns of features.
+package calculate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/frantjc/go-js"
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/expr"
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb/geojson"
+	"mellium.im/sysexit"
+)
+
+const (
+	// Name is the name of the calculate Task.
+	Name = "calculate"
+
+	ParamExpressions = "expressions"
+	ParamRename      = "rename"
+	ParamDrop        = "drop"
+	ParamSelect      = "select"
+)
+
+func init() {
+	task.Register(Name, task.TaskFunc(Run))
+}
+
+// Run changes the columns of each feature of the input. In order, it assigns
+// the value of each of the expressions param's expressions to its column,
+// where later expressions can refer to the columns assigned by earlier ones,
+// renames the columns of the rename param, drops the columns of the drop
+// param and, if the select param is given, drops every column not in it.
+func Run(_ context.Context, input volume.Volume, output volume.Directory, args task.Args) error {
+	if !js.Some([]string{ParamExpressions, ParamRename, ParamDrop, ParamSelect}, func(param string, _ int, _ []string) bool {
+		return args.Has(param)
+	}) {
+		return task.Errorf(sysexit.ErrConfig, "at least one of params '%s', '%s', '%s' and '%s' must be given", ParamExpressions, ParamRename, ParamDrop, ParamSelect)
+	}
+
+	var assignments []*expr.Assignment
+	if args.Has(ParamExpressions) {
+		var err error
+		if assignments, err = expr.ParseAssignments(args.String(ParamExpressions)); err != nil {
+			return task.NewErr(sysexit.ErrConfig, err)
+		}
+	}
+
+	renames, err := ParseRenames(args.String(ParamRename))
+	if err != nil {
+		return task.NewErr(sysexit.ErrConfig, err)
+	}
+
+	var (
+		drop   = split(args.String(ParamDrop))
+		keep   = split(args.String(ParamSelect))
+		rename = map[string]string{}
+	)
+	for _, r := range renames {
+		rename[r[0]] = r[1]
+	}
+
+	fc, err := features.Read(input)
+	if err != nil {
+		return err
+	}
+
+	// a column that no feature has is almost certainly a typo,
+	// so fail rather than silently do nothing with it
+	columns := features.Columns(fc)
+	for _, a := range assignments {
+		if err := checkColumns(columns, a.Expr.Columns()...); err != nil {
+			return err
+		}
+
+		if !js.Includes(columns, a.Column) {
+			columns = append(columns, a.Column)
+		}
+	}
+
+	if err := checkColumns(columns, js.Map(renames, func(r [2]string, _ int, _ [][2]string) string {
+		return r[0]
+	})...); err != nil {
+		return err
+	}
+
+	columns = js.Map(columns, func(c string, _ int, _ []string) string {
+		if to, ok := rename[c]; ok {
+			return to
+		}
+
+		return c
+	})
+
+	if err := checkColumns(columns, append(drop, keep...)...); err != nil {
+		return err
+	}
+
+	for i, f := range fc.Features {
+		env := expr.NewFeatureEnv(f)
+		for _, a := range assignments {
+			v, err := a.Expr.Eval(env)
+			if err != nil {
+				return task.DataErrorf("feature %d: %s: %w", i, a, err)
+			}
+
+			f.Properties[a.Column] = v
+		}
+
+		props := geojson.Properties{}
+		for k, v := range f.Properties {
+			if to, ok := rename[k]; ok {
+				k = to
+			}
+
+			if js.Includes(drop, k) || (len(keep) > 0 && !js.Includes(keep, k)) {
+				continue
+			}
+
+			props[k] = v
+		}
+		f.Properties = props
+	}
+
+	return features.Write(output, fc)
+}
+
+func checkColumns(columns []string, cs ...string) error {
+	for _, c := range cs {
+		if !js.Includes(columns, c) {
+			return task.Errorf(sysexit.ErrConfig, "unknown column '%s', expected one of '%s'", c, strings.Join(columns, "', '"))
+		}
+	}
+
+	return nil
+}
+
+// ParseRenames parses a comma separated list of renames
+// of the form from:to, e.g. NAME:name,POP2020:population.
+func ParseRenames(s string) ([][2]string, error) {
+	renames := [][2]string{}
+	for _, part := range split(s) {
+		from, to, ok := strings.Cut(part, ":")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("rename '%s' must be of the form from:to", part)
+		}
+
+		renames = append(renames, [2]string{from, to})
+	}
+
+	return renames, nil
+}
+
+// split splits a comma separated list, trimming each value.
+func split(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return []string{}
+	}
+
+	return js.Map(strings.Split(s, ","), func(v string, _ int, _ []string) string {
+		return strings.TrimSpace(v)
+	})
+}
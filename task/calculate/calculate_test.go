@@ -0,0 +1,89 @@
+package calculate_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/calculate"
+	"github.com/logsquaredn/rototiller/task/tasktest"
+	"github.com/logsquaredn/rototiller/volume"
+	"mellium.im/sysexit"
+)
+
+// golden is where the input and expected outputs of the calculate Task live.
+var golden = filepath.Join("..", "..", "testdata", "geojson", "calculate")
+
+var params = []string{calculate.ParamExpressions, calculate.ParamRename, calculate.ParamDrop, calculate.ParamSelect}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		golden string
+	}{
+		{
+			name:   "expressions",
+			values: []string{"density = pop / area; label = name || ' (' || code || ')'; dense = density > 50", "", "", ""},
+			golden: "expressions.json",
+		},
+		{
+			name:   "rename and drop",
+			values: []string{"", "pop:population, name:city", "code,area", ""},
+			golden: "rename-drop.json",
+		},
+		{
+			name:   "select after rename",
+			values: []string{"density = pop / area", "pop:population", "", "name,population,density"},
+			golden: "select.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := volume.Directory(t.TempDir())
+			if err := calculate.Run(
+				context.Background(),
+				tasktest.Volume(t, filepath.Join(golden, "input.json")),
+				output,
+				task.NewArgs(params, tt.values),
+			); err != nil {
+				t.Fatalf("Run() = %v", err)
+			}
+
+			tasktest.Golden(t, filepath.Join(golden, tt.golden), output)
+		})
+	}
+}
+
+func TestRunInvalidArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+	}{
+		{name: "no params", values: []string{"", "", "", ""}},
+		{name: "invalid expression", values: []string{"density = pop /", "", "", ""}},
+		{name: "unknown column in expression", values: []string{"density = people / area", "", "", ""}},
+		{name: "invalid rename", values: []string{"", "pop", "", ""}},
+		{name: "unknown column to rename", values: []string{"", "people:population", "", ""}},
+		{name: "drop of a renamed column", values: []string{"", "pop:population", "pop", ""}},
+		{name: "unknown column to select", values: []string{"", "", "", "name,people"}},
+		// the fault of the expression rather than of the input
+		{name: "expression that does not suit the data", values: []string{"total = name + pop", "", "", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := calculate.Run(
+				context.Background(),
+				tasktest.Volume(t, filepath.Join(golden, "input.json")),
+				volume.Directory(t.TempDir()),
+				task.NewArgs(params, tt.values),
+			)
+			if code := task.ExitCode(err); code != sysexit.ErrConfig {
+				t.Errorf("Run() = %v, exit code %d, want %d", err, code, sysexit.ErrConfig)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Package clip implements the clip Task, which cuts features
+// to a bounding box, a polygon or the polygons of another storage.
+package clip
+
+import (
+	"context"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/paulmach/orb/geojson"
+	"github.com/peterstace/simplefeatures/geom"
+	"mellium.im/sysexit"
+)
+
+const (
+	// Name is the name of the clip Task.
+	Name = "clip"
+
+	ParamBBox    = "bbox"
+	ParamPolygon = "polygon"
+	ParamMask    = "mask"
+)
+
+func init() {
+	task.Register(Name, task.TaskFunc(Run))
+}
+
+// Run cuts the geometry of each feature of the input to the extent given
+// by exactly one of the bbox, polygon and mask params, keeping its properties.
+// Features that fall outside of the extent are dropped.
+func Run(_ context.Context, input volume.Volume, output volume.Directory, args task.Args) error {
+	given := 0
+	for _, param := range []string{ParamBBox, ParamPolygon, ParamMask} {
+		if args.Has(param) {
+			given++
+		}
+	}
+
+	if given != 1 {
+		return task.Errorf(sysexit.ErrConfig, "exactly one of params '%s', '%s' and '%s' must be given", ParamBBox, ParamPolygon, ParamMask)
+	}
+
+	fc, err := features.Read(input)
+	if err != nil {
+		return err
+	}
+
+	mask, err := readMask(args)
+	if err != nil {
+		return err
+	}
+
+	out := geojson.NewFeatureCollection()
+	for i, f := range fc.Features {
+		if f.Geometry == nil {
+			continue
+		}
+
+		g, err := Geometry(f.Geometry, mask)
+		if err != nil {
+			return task.Errorf(sysexit.ErrData, "feature %d: %w", i, err)
+		}
+
+		if g != nil {
+			f.Geometry = g
+			out.Append(f)
+		}
+	}
+
+	return features.Write(output, out)
+}
+
+// readMask reads the polygon to clip to from the bbox or polygon
+// param or as the union of the polygons of the mask storage.
+func readMask(args task.Args) (geom.Geometry, error) {
+	if args.Has(ParamBBox) {
+		bound, err := task.ParseBBox(args.String(ParamBBox))
+		if err != nil {
+			return geom.Geometry{}, task.NewErr(sysexit.ErrConfig, err)
+		}
+
+		return geom.NewEnvelope(
+			geom.XY{X: bound.Min.X(), Y: bound.Min.Y()},
+			geom.XY{X: bound.Max.X(), Y: bound.Max.Y()},
+		).AsGeometry(), nil
+	}
+
+	if args.Has(ParamPolygon) {
+		g, err := wkt.Unmarshal(args.String(ParamPolygon))
+		if err != nil {
+			return geom.Geometry{}, task.Errorf(sysexit.ErrConfig, "param '%s' is not WKT: %w", ParamPolygon, err)
+		}
+
+		mask, err := features.ToGeom(g)
+		if err != nil {
+			return geom.Geometry{}, task.NewErr(sysexit.ErrConfig, err)
+		}
+
+		return mask, nil
+	}
+
+	fc, err := features.ReadParam(args, ParamMask)
+	if err != nil {
+		return geom.Geometry{}, err
+	}
+
+	polygons := []geom.Geometry{}
+	for _, f := range fc.Features {
+		if p := features.Homogenize(f.Geometry, 2); p != nil {
+			g, err := features.ToGeom(p)
+			if err != nil {
+				return geom.Geometry{}, task.Errorf(sysexit.ErrConfig, "read mask storage '%s': %w", args.String(ParamMask), err)
+			}
+
+			polygons = append(polygons, g)
+		}
+	}
+
+	if len(polygons) == 0 {
+		return geom.Geometry{}, task.Errorf(sysexit.ErrConfig, "mask storage '%s' has no polygons", args.String(ParamMask))
+	}
+
+	mask, err := geom.UnionMany(polygons)
+	if err != nil {
+		return geom.Geometry{}, task.Errorf(sysexit.ErrConfig, "union polygons of mask storage '%s': %w", args.String(ParamMask), err)
+	}
+
+	return mask, nil
+}
+
+// Geometry returns the part of g that is inside of mask, with the same
+// dimension as g, or nil if there is none.
+func Geometry(g orb.Geometry, mask geom.Geometry) (orb.Geometry, error) {
+	sg, err := features.ToGeom(g)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sg.Envelope().Intersects(mask.Envelope()) {
+		return nil, nil
+	}
+
+	clipped, err := geom.Intersection(sg, mask)
+	if err != nil {
+		return nil, err
+	}
+
+	og, err := features.FromGeom(clipped)
+	if err != nil {
+		return nil, err
+	}
+
+	return features.Homogenize(og, g.Dimensions()), nil
+}
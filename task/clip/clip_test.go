@@ -0,0 +1,87 @@
+package clip_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/clip"
+	"github.com/logsquaredn/rototiller/task/tasktest"
+	"github.com/logsquaredn/rototiller/volume"
+	"mellium.im/sysexit"
+)
+
+// golden is where the input, mask and expected outputs of the clip task live.
+var golden = filepath.Join("..", "..", "testdata", "geojson", "clip")
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		param  string
+		value  string
+		golden string
+	}{
+		{
+			name:   "bbox",
+			param:  clip.ParamBBox,
+			value:  "0.5,0.5,1.5,1.5",
+			golden: "bbox.json",
+		},
+		{
+			name:   "polygon",
+			param:  clip.ParamPolygon,
+			value:  "POLYGON((0 0,4 0,0 4,0 0))",
+			golden: "polygon.json",
+		},
+		{
+			name:   "mask",
+			param:  clip.ParamMask,
+			value:  "mask-id",
+			golden: "mask-output.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := task.NewArgs([]string{tt.param}, []string{tt.value})
+			if tt.param == clip.ParamMask {
+				args = args.WithVolume(clip.ParamMask, tasktest.Volume(t, filepath.Join(golden, "mask.json")))
+			}
+
+			output := volume.Directory(t.TempDir())
+			if err := clip.Run(context.Background(), tasktest.Volume(t, filepath.Join(golden, "input.json")), output, args); err != nil {
+				t.Fatalf("Run() = %v", err)
+			}
+
+			tasktest.Golden(t, filepath.Join(golden, tt.golden), output)
+		})
+	}
+}
+
+func TestRunInvalidArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []string
+		values []string
+	}{
+		{name: "no extent"},
+		{name: "more than one extent", params: []string{clip.ParamBBox, clip.ParamPolygon}, values: []string{"0,0,1,1", "POLYGON((0 0,1 0,1 1,0 0))"}},
+		{name: "invalid bbox", params: []string{clip.ParamBBox}, values: []string{"0,0,1"}},
+		{name: "invalid polygon", params: []string{clip.ParamPolygon}, values: []string{"not wkt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := clip.Run(
+				context.Background(),
+				tasktest.Volume(t, filepath.Join(golden, "input.json")),
+				volume.Directory(t.TempDir()),
+				task.NewArgs(tt.params, tt.values),
+			)
+			if code := task.ExitCode(err); code != sysexit.ErrConfig {
+				t.Errorf("Run() = %v, exit code %d, want %d", err, code, sysexit.ErrConfig)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Package derive implements the derive Task, which replaces the geometry
+// of features with one derived from it, e.g. its centroid.
+package derive
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb"
+	"github.com/peterstace/simplefeatures/geom"
+	"mellium.im/sysexit"
+)
+
+const (
+	// Name is the name of the derive Task.
+	Name = "derive"
+
+	ParamMode = "mode"
+)
+
+// Mode is the kind of geometry to derive.
+type Mode string
+
+const (
+	// ModeCentroid derives the center of mass of a geometry,
+	// which may lie outside of it, e.g. for a crescent.
+	ModeCentroid Mode = "centroid"
+	// ModeConvexHull derives the smallest convex polygon that contains
+	// a geometry, which is a line or point if the geometry is degenerate.
+	ModeConvexHull Mode = "convex-hull"
+	// ModeEnvelope derives the bounding box of a geometry, which is
+	// a line or point if the geometry is degenerate.
+	ModeEnvelope Mode = "envelope"
+	// ModePointOnSurface derives a point that is guaranteed
+	// to lie on a geometry, e.g. to label it with.
+	ModePointOnSurface Mode = "point-on-surface"
+)
+
+// Modes are all of the supported Modes.
+var Modes = []Mode{ModeCentroid, ModeConvexHull, ModeEnvelope, ModePointOnSurface}
+
+func (m Mode) String() string {
+	return string(m)
+}
+
+// ParseMode returns the Mode with the given name.
+func ParseMode(s string) (Mode, error) {
+	for _, m := range Modes {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown mode '%s'", s)
+}
+
+func init() {
+	task.Register(Name, task.TaskFunc(Run))
+}
+
+// Run replaces the geometry of each feature of the input with the
+// geometry that the mode param derives from it, keeping its properties.
+// Features without a geometry are kept as-is.
+func Run(_ context.Context, input volume.Volume, output volume.Directory, args task.Args) error {
+	mode, err := ParseMode(args.String(ParamMode))
+	if err != nil {
+		return task.NewErr(sysexit.ErrConfig, err)
+	}
+
+	fc, err := features.Read(input)
+	if err != nil {
+		return err
+	}
+
+	for i, f := range fc.Features {
+		if f.Geometry == nil {
+			continue
+		}
+
+		if f.Geometry, err = Geometry(f.Geometry, mode); err != nil {
+			return task.DataErrorf("feature %d: %w", i, err)
+		}
+	}
+
+	return features.Write(output, fc)
+}
+
+// Geometry derives a geometry from g according to mode.
+// It returns nil if g is empty.
+func Geometry(g orb.Geometry, mode Mode) (orb.Geometry, error) {
+	sg, err := features.ToGeom(g)
+	if err != nil {
+		return nil, err
+	}
+
+	if sg.IsEmpty() {
+		return nil, nil
+	}
+
+	var derived geom.Geometry
+	switch mode {
+	case ModeCentroid:
+		derived = sg.Centroid().AsGeometry()
+	case ModeConvexHull:
+		derived = sg.ConvexHull()
+	case ModeEnvelope:
+		derived = sg.Envelope().AsGeometry()
+	case ModePointOnSurface:
+		derived = sg.PointOnSurface().AsGeometry()
+	default:
+		return nil, fmt.Errorf("unknown mode '%s'", mode)
+	}
+
+	return features.FromGeom(derived)
+}
@@ -0,0 +1,46 @@
+package derive_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/derive"
+	"github.com/logsquaredn/rototiller/task/tasktest"
+	"github.com/logsquaredn/rototiller/volume"
+	"mellium.im/sysexit"
+)
+
+// golden is where the input and expected outputs of the derive Task live.
+var golden = filepath.Join("..", "..", "testdata", "geojson", "derive")
+
+func TestRun(t *testing.T) {
+	for _, mode := range derive.Modes {
+		t.Run(mode.String(), func(t *testing.T) {
+			output := volume.Directory(t.TempDir())
+			if err := derive.Run(
+				context.Background(),
+				tasktest.Volume(t, filepath.Join(golden, "input.json")),
+				output,
+				task.NewArgs([]string{derive.ParamMode}, []string{mode.String()}),
+			); err != nil {
+				t.Fatalf("Run() = %v", err)
+			}
+
+			tasktest.Golden(t, filepath.Join(golden, mode.String()+".json"), output)
+		})
+	}
+}
+
+func TestRunInvalidMode(t *testing.T) {
+	err := derive.Run(
+		context.Background(),
+		tasktest.Volume(t, filepath.Join(golden, "input.json")),
+		volume.Directory(t.TempDir()),
+		task.NewArgs([]string{derive.ParamMode}, []string{"boundary"}),
+	)
+	if code := task.ExitCode(err); code != sysexit.ErrConfig {
+		t.Errorf("Run() = %v, exit code %d, want %d", err, code, sysexit.ErrConfig)
+	}
+}
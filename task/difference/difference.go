@@ -0,0 +1,66 @@
+// Package difference implements the difference Task, which erases
+// the features of another storage from the features of the input.
+package difference
+
+import (
+	"context"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/logsquaredn/rototiller/task/overlay"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb/geojson"
+	"mellium.im/sysexit"
+)
+
+// Name is the name of the difference Task.
+const Name = "difference"
+
+func init() {
+	task.Register(Name, task.TaskFunc(Run))
+}
+
+// Run removes the parts of the geometry of each feature of the input that
+// are in any feature of the overlay, keeping its properties. Features that
+// are entirely covered by the overlay are dropped.
+func Run(_ context.Context, input volume.Volume, output volume.Directory, args task.Args) error {
+	fc, err := features.Read(input)
+	if err != nil {
+		return err
+	}
+
+	ov, err := overlay.Read(args)
+	if err != nil {
+		return err
+	}
+
+	out := geojson.NewFeatureCollection()
+	for i, f := range fc.Features {
+		if f.Geometry == nil {
+			continue
+		}
+
+		g, err := features.ToGeom(f.Geometry)
+		if err != nil {
+			return task.Errorf(sysexit.ErrData, "feature %d: %w", i, err)
+		}
+
+		dim := f.Geometry.Dimensions()
+		erase, err := ov.Union(ov.Intersecting(g), dim)
+		if err != nil {
+			return task.Errorf(sysexit.ErrData, "feature %d: %w", i, err)
+		}
+
+		difference, err := overlay.Difference(g, erase, dim)
+		if err != nil {
+			return task.Errorf(sysexit.ErrData, "feature %d: %w", i, err)
+		}
+
+		if difference != nil {
+			f.Geometry = difference
+			out.Append(f)
+		}
+	}
+
+	return features.Write(output, out)
+}
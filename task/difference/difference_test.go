@@ -0,0 +1,30 @@
+package difference_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/difference"
+	"github.com/logsquaredn/rototiller/task/overlay"
+	"github.com/logsquaredn/rototiller/task/tasktest"
+	"github.com/logsquaredn/rototiller/volume"
+)
+
+// golden is where the input, overlay and expected outputs of the overlay Tasks live.
+var golden = filepath.Join("..", "..", "testdata", "geojson", "overlay")
+
+func TestRun(t *testing.T) {
+	args := task.NewArgs(
+		[]string{task.ParamOverlay, overlay.ParamPrefix},
+		[]string{"overlay-id", "ov_"},
+	).WithVolume(task.ParamOverlay, tasktest.Volume(t, filepath.Join(golden, "overlay.json")))
+
+	output := volume.Directory(t.TempDir())
+	if err := difference.Run(context.Background(), tasktest.Volume(t, filepath.Join(golden, "input.json")), output, args); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+
+	tasktest.Golden(t, filepath.Join(golden, "difference.json"), output)
+}
@@ -0,0 +1,177 @@
+package dissolve
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Function is an aggregate function over the values of a column.
+type Function string
+
+const (
+	// FunctionSum adds up the numbers in the column.
+	FunctionSum Function = "sum"
+	// FunctionCount counts the values in the column that are not null,
+	// or the features themselves if the column is *.
+	FunctionCount Function = "count"
+	// FunctionMin finds the least number or string in the column.
+	FunctionMin Function = "min"
+	// FunctionMax finds the greatest number or string in the column.
+	FunctionMax Function = "max"
+	// FunctionFirst finds the first value in the column that is not null.
+	FunctionFirst Function = "first"
+)
+
+// Functions are all of the supported Functions.
+var Functions = []Function{FunctionSum, FunctionCount, FunctionMin, FunctionMax, FunctionFirst}
+
+func (f Function) String() string {
+	return string(f)
+}
+
+// All is the column that count takes to count features.
+const All = "*"
+
+// Aggregate is a Function applied to a column, e.g. sum(population).
+type Aggregate struct {
+	Function Function
+	Column   string
+}
+
+func (a *Aggregate) String() string {
+	return fmt.Sprintf("%s(%s)", a.Function, a.Column)
+}
+
+// Property returns the name of the property that the result of a is
+// written to, e.g. sum_population, or count for count(*).
+func (a *Aggregate) Property() string {
+	if a.Column == All {
+		return a.Function.String()
+	}
+
+	return a.Function.String() + "_" + a.Column
+}
+
+// ParseAggregates parses a comma separated list of Aggregates,
+// e.g. sum(population),count(*),first(name).
+func ParseAggregates(s string) ([]*Aggregate, error) {
+	aggregates := []*Aggregate{}
+	if strings.TrimSpace(s) == "" {
+		return aggregates, nil
+	}
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		open := strings.Index(part, "(")
+		if open < 0 || !strings.HasSuffix(part, ")") {
+			return nil, fmt.Errorf("aggregate '%s' must be of the form function(column)", part)
+		}
+
+		a := &Aggregate{
+			Function: Function(strings.ToLower(strings.TrimSpace(part[:open]))),
+			Column:   strings.TrimSpace(part[open+1 : len(part)-1]),
+		}
+
+		known := false
+		for _, f := range Functions {
+			known = known || f == a.Function
+		}
+
+		switch {
+		case !known:
+			return nil, fmt.Errorf("unknown aggregate function '%s'", a.Function)
+		case a.Column == "":
+			return nil, fmt.Errorf("aggregate '%s' has no column", part)
+		case a.Column == All && a.Function != FunctionCount:
+			return nil, fmt.Errorf("only %s can aggregate column '%s'", FunctionCount, All)
+		}
+
+		aggregates = append(aggregates, a)
+	}
+
+	return aggregates, nil
+}
+
+// Apply computes a over values, the values of its column in each feature
+// of a group. The result is nil if every value is, except for count.
+func (a *Aggregate) Apply(values []any) (any, error) {
+	var result any
+	for _, v := range values {
+		if v == nil && a.Column != All {
+			continue
+		}
+
+		switch a.Function {
+		case FunctionCount:
+			n, _ := result.(float64)
+			result = n + 1
+		case FunctionFirst:
+			return v, nil
+		case FunctionSum:
+			n, ok := v.(float64)
+			if !ok {
+				return nil, fmt.Errorf("%s: column '%s' has non-numeric value %v", a, a.Column, v)
+			}
+
+			sum, _ := result.(float64)
+			result = sum + n
+		case FunctionMin, FunctionMax:
+			if result == nil {
+				if !orderable(v) {
+					return nil, fmt.Errorf("%s: column '%s' has value %v, which is neither a number nor a string", a, a.Column, v)
+				}
+
+				result = v
+				continue
+			}
+
+			c, err := compare(v, result)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", a, err)
+			}
+
+			if (a.Function == FunctionMin && c < 0) || (a.Function == FunctionMax && c > 0) {
+				result = v
+			}
+		}
+	}
+
+	if result == nil && a.Function == FunctionCount {
+		return float64(0), nil
+	}
+
+	return result, nil
+}
+
+func orderable(v any) bool {
+	switch v.(type) {
+	case float64, string:
+		return true
+	}
+
+	return false
+}
+
+// compare returns -1, 0 or 1 as a is less than, equal to or
+// greater than b, which must both be numbers or both strings.
+func compare(a, b any) (int, error) {
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			switch {
+			case a < b:
+				return -1, nil
+			case a > b:
+				return 1, nil
+			}
+
+			return 0, nil
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), nil
+		}
+	}
+
+	return 0, fmt.Errorf("cannot compare %v with %v", a, b)
+}
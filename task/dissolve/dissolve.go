@@ -0,0 +1,143 @@
+// Package dissolve implements the dissolve Task, which merges the features
+// that share a value in a column, aggregating their other columns.
+package dissolve
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/frantjc/go-js"
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb/geojson"
+	"github.com/peterstace/simplefeatures/geom"
+	"mellium.im/sysexit"
+)
+
+const (
+	// Name is the name of the dissolve Task.
+	Name = "dissolve"
+
+	ParamDissolveColumn = "dissolve-column"
+	ParamAggregates     = "aggregates"
+)
+
+func init() {
+	task.Register(Name, task.TaskFunc(Run))
+}
+
+// group is the features that share a value in the dissolve column.
+type group struct {
+	value    any
+	features []*geojson.Feature
+}
+
+// Run outputs a feature for each distinct value of the dissolve-column param,
+// null included, whose geometry is the union of the geometries of the features
+// with that value and whose properties are that value and the results of the
+// aggregates param over them. Features are output in the order that their
+// values first appear.
+func Run(_ context.Context, input volume.Volume, output volume.Directory, args task.Args) error {
+	column := args.String(ParamDissolveColumn)
+
+	aggregates, err := ParseAggregates(args.String(ParamAggregates))
+	if err != nil {
+		return task.NewErr(sysexit.ErrConfig, err)
+	}
+
+	fc, err := features.Read(input)
+	if err != nil {
+		return err
+	}
+
+	columns := features.Columns(fc)
+	for _, c := range append([]string{column}, js.Map(aggregates, func(a *Aggregate, _ int, _ []*Aggregate) string {
+		return a.Column
+	})...) {
+		if c != All && !js.Includes(columns, c) {
+			return task.Errorf(sysexit.ErrConfig, "unknown column '%s', expected one of '%s'", c, strings.Join(columns, "', '"))
+		}
+	}
+
+	var (
+		groups = []*group{}
+		byKey  = map[string]*group{}
+	)
+	for _, f := range fc.Features {
+		value := f.Properties[column]
+
+		// key by JSON so that e.g. the number 1 and the string "1" differ
+		key, err := json.Marshal(value)
+		if err != nil {
+			return task.DataErrorf("%w", err)
+		}
+
+		g, ok := byKey[string(key)]
+		if !ok {
+			g = &group{value: value}
+			byKey[string(key)] = g
+			groups = append(groups, g)
+		}
+		g.features = append(g.features, f)
+	}
+
+	out := geojson.NewFeatureCollection()
+	for _, g := range groups {
+		f, err := dissolve(g, column, aggregates)
+		if err != nil {
+			return err
+		}
+
+		out.Append(f)
+	}
+
+	return features.Write(output, out)
+}
+
+func dissolve(g *group, column string, aggregates []*Aggregate) (*geojson.Feature, error) {
+	geoms := []geom.Geometry{}
+	for _, f := range g.features {
+		if f.Geometry == nil {
+			continue
+		}
+
+		sg, err := features.ToGeom(f.Geometry)
+		if err != nil {
+			return nil, task.Errorf(sysexit.ErrData, "%s %v: %w", column, g.value, err)
+		}
+
+		geoms = append(geoms, sg)
+	}
+
+	f := &geojson.Feature{Type: "Feature", Properties: geojson.Properties{column: g.value}}
+	if len(geoms) > 0 {
+		union, err := geom.UnionMany(geoms)
+		if err != nil {
+			return nil, task.Errorf(sysexit.ErrData, "%s %v: union geometries: %w", column, g.value, err)
+		}
+
+		if f.Geometry, err = features.FromGeom(union); err != nil {
+			return nil, task.Errorf(sysexit.ErrData, "%s %v: %w", column, g.value, err)
+		}
+	}
+
+	for _, a := range aggregates {
+		values := make([]any, len(g.features))
+		for i, gf := range g.features {
+			values[i] = gf.Properties[a.Column]
+		}
+
+		v, err := a.Apply(values)
+		if err != nil {
+			// the aggregate does not suit the values of its column,
+			// which is the fault of the params rather than of the input
+			return nil, task.Errorf(sysexit.ErrConfig, "%s %v: %w", column, g.value, err)
+		}
+
+		f.Properties[a.Property()] = v
+	}
+
+	return f, nil
+}
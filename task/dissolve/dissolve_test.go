@@ -0,0 +1,78 @@
+package dissolve_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/dissolve"
+	"github.com/logsquaredn/rototiller/task/tasktest"
+	"github.com/logsquaredn/rototiller/volume"
+	"mellium.im/sysexit"
+)
+
+// golden is where the input and expected outputs of the dissolve Task live.
+var golden = filepath.Join("..", "..", "testdata", "geojson", "dissolve")
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		aggregates string
+		golden     string
+	}{
+		{
+			name:   "no aggregates",
+			golden: "state.json",
+		},
+		{
+			name:       "aggregates",
+			aggregates: "sum(pop), count(*), count(name), min(name), max(pop), first(name)",
+			golden:     "aggregates.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := task.NewArgs(
+				[]string{dissolve.ParamDissolveColumn, dissolve.ParamAggregates},
+				[]string{"state", tt.aggregates},
+			)
+
+			output := volume.Directory(t.TempDir())
+			if err := dissolve.Run(context.Background(), tasktest.Volume(t, filepath.Join(golden, "input.json")), output, args); err != nil {
+				t.Fatalf("Run() = %v", err)
+			}
+
+			tasktest.Golden(t, filepath.Join(golden, tt.golden), output)
+		})
+	}
+}
+
+func TestRunInvalidArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		column     string
+		aggregates string
+	}{
+		{name: "unknown dissolve column", column: "county"},
+		{name: "unknown aggregate column", column: "state", aggregates: "sum(area)"},
+		{name: "unknown aggregate function", column: "state", aggregates: "avg(pop)"},
+		{name: "malformed aggregate", column: "state", aggregates: "sum pop"},
+		{name: "aggregate that does not suit its column", column: "state", aggregates: "sum(name)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dissolve.Run(
+				context.Background(),
+				tasktest.Volume(t, filepath.Join(golden, "input.json")),
+				volume.Directory(t.TempDir()),
+				task.NewArgs([]string{dissolve.ParamDissolveColumn, dissolve.ParamAggregates}, []string{tt.column, tt.aggregates}),
+			)
+			if code := task.ExitCode(err); code != sysexit.ErrConfig {
+				t.Errorf("Run() = %v, exit code %d, want %d", err, code, sysexit.ErrConfig)
+			}
+		})
+	}
+}
@@ -0,0 +1,552 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Env is what an Expr is evaluated against.
+type Env interface {
+	// Column returns the value of the named column,
+	// or nil if it is null or missing.
+	Column(name string) any
+	// Variable returns the value of the named $variable.
+	Variable(name string) (any, error)
+}
+
+// EvalError is returned when an Expr cannot be evaluated
+// because of the values that it was given.
+type EvalError struct {
+	Expr string
+	Msg  string
+}
+
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("evaluate %s: %s", e.Expr, e.Msg)
+}
+
+func evalErrorf(n node, format string, a ...any) error {
+	return &EvalError{Expr: n.String(), Msg: fmt.Sprintf(format, a...)}
+}
+
+type node interface {
+	eval(Env) (any, error)
+	String() string
+}
+
+// walk calls fn for n and each of its descendants.
+func walk(n node, fn func(node)) {
+	fn(n)
+	switch n := n.(type) {
+	case *unary:
+		walk(n.x, fn)
+	case *binary:
+		walk(n.left, fn)
+		walk(n.right, fn)
+	case *in:
+		walk(n.x, fn)
+		for _, x := range n.list {
+			walk(x, fn)
+		}
+	case *isNull:
+		walk(n.x, fn)
+	case *like:
+		walk(n.x, fn)
+		walk(n.pattern, fn)
+	case *call:
+		for _, x := range n.args {
+			walk(x, fn)
+		}
+	}
+}
+
+type literal struct {
+	v any
+}
+
+func (n *literal) eval(Env) (any, error) {
+	return n.v, nil
+}
+
+func (n *literal) String() string {
+	switch v := n.v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case bool:
+		return strings.ToUpper(strconv.FormatBool(v))
+	}
+
+	return toString(n.v)
+}
+
+type column struct {
+	name string
+}
+
+func (n *column) eval(env Env) (any, error) {
+	return env.Column(n.name), nil
+}
+
+func (n *column) String() string {
+	return n.name
+}
+
+type variable struct {
+	name string
+}
+
+func (n *variable) eval(env Env) (any, error) {
+	return env.Variable(n.name)
+}
+
+func (n *variable) String() string {
+	return n.name
+}
+
+type unary struct {
+	op string
+	x  node
+}
+
+func (n *unary) eval(env Env) (any, error) {
+	x, err := n.x.eval(env)
+	if err != nil || x == nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "NOT":
+		b, ok := x.(bool)
+		if !ok {
+			return nil, evalErrorf(n, "NOT expects a boolean, got %s", describe(x))
+		}
+
+		return !b, nil
+	case "-":
+		f, ok := toNumber(x)
+		if !ok {
+			return nil, evalErrorf(n, "cannot negate %s", describe(x))
+		}
+
+		return -f, nil
+	}
+
+	return nil, evalErrorf(n, "unknown operator '%s'", n.op)
+}
+
+func (n *unary) String() string {
+	if n.op == "NOT" {
+		return "NOT " + n.x.String()
+	}
+
+	return n.op + n.x.String()
+}
+
+type binary struct {
+	op          string
+	left, right node
+}
+
+func (n *binary) eval(env Env) (any, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "AND", "OR":
+		// three-valued logic like SQL: NULL means unknown
+		l, err := n.logical(left)
+		if err != nil {
+			return nil, err
+		}
+
+		if l != nil && *l == (n.op == "OR") {
+			return *l, nil
+		}
+
+		right, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := n.logical(right)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case r != nil && *r == (n.op == "OR"):
+			return *r, nil
+		case l == nil || r == nil:
+			return nil, nil
+		}
+
+		return *r, nil
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil || left == nil || right == nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "=", "!=":
+		eq, err := equal(left, right)
+		if errors.Is(err, errUnknown) {
+			return nil, nil
+		} else if err != nil {
+			return nil, evalErrorf(n, "%s", err)
+		}
+
+		return eq == (n.op == "="), nil
+	case "<", "<=", ">", ">=":
+		c, err := compare(left, right)
+		if errors.Is(err, errUnknown) {
+			return nil, nil
+		} else if err != nil {
+			return nil, evalErrorf(n, "%s", err)
+		}
+
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+
+		return c >= 0, nil
+	case "||":
+		return toString(left) + toString(right), nil
+	}
+
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+	if !lok || !rok {
+		return nil, evalErrorf(n, "'%s' expects numbers, got %s and %s", n.op, describe(left), describe(right))
+	}
+
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, nil
+		}
+
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, nil
+		}
+
+		return math.Mod(l, r), nil
+	}
+
+	return nil, evalErrorf(n, "unknown operator '%s'", n.op)
+}
+
+func (n *binary) logical(v any) (*bool, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return nil, evalErrorf(n, "%s expects booleans, got %s", n.op, describe(v))
+	}
+
+	return &b, nil
+}
+
+func (n *binary) String() string {
+	return "(" + n.left.String() + " " + n.op + " " + n.right.String() + ")"
+}
+
+type in struct {
+	x    node
+	list []node
+	not  bool
+}
+
+func (n *in) eval(env Env) (any, error) {
+	x, err := n.x.eval(env)
+	if err != nil || x == nil {
+		return nil, err
+	}
+
+	sawNull := false
+	for _, item := range n.list {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		if v == nil {
+			sawNull = true
+			continue
+		}
+
+		eq, err := equal(x, v)
+		if errors.Is(err, errUnknown) {
+			sawNull = true
+			continue
+		} else if err != nil {
+			return nil, evalErrorf(n, "%s", err)
+		}
+
+		if eq {
+			return !n.not, nil
+		}
+	}
+
+	if sawNull {
+		return nil, nil
+	}
+
+	return n.not, nil
+}
+
+func (n *in) String() string {
+	list := make([]string, len(n.list))
+	for i, x := range n.list {
+		list[i] = x.String()
+	}
+
+	op := " IN "
+	if n.not {
+		op = " NOT IN "
+	}
+
+	return n.x.String() + op + "(" + strings.Join(list, ", ") + ")"
+}
+
+type isNull struct {
+	x   node
+	not bool
+}
+
+func (n *isNull) eval(env Env) (any, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	return (x == nil) != n.not, nil
+}
+
+func (n *isNull) String() string {
+	if n.not {
+		return n.x.String() + " IS NOT NULL"
+	}
+
+	return n.x.String() + " IS NULL"
+}
+
+type like struct {
+	x, pattern  node
+	not         bool
+	insensitive bool
+	re          *regexp.Regexp
+}
+
+func (n *like) eval(env Env) (any, error) {
+	x, err := n.x.eval(env)
+	if err != nil || x == nil {
+		return nil, err
+	}
+
+	re := n.re
+	if re == nil {
+		pattern, err := n.pattern.eval(env)
+		if err != nil || pattern == nil {
+			return nil, err
+		}
+
+		if re, err = likeRegexp(toString(pattern), n.insensitive); err != nil {
+			return nil, evalErrorf(n, "%s", err)
+		}
+	}
+
+	return re.MatchString(toString(x)) != n.not, nil
+}
+
+func (n *like) String() string {
+	op := " LIKE "
+	if n.insensitive {
+		op = " ILIKE "
+	}
+
+	if n.not {
+		op = " NOT" + op
+	}
+
+	return n.x.String() + op + n.pattern.String()
+}
+
+type call struct {
+	name string
+	fn   func([]any) (any, error)
+	args []node
+}
+
+func (n *call) eval(env Env) (any, error) {
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		args[i] = v
+	}
+
+	v, err := n.fn(args)
+	if err != nil {
+		return nil, evalErrorf(n, "%s", err)
+	}
+
+	return v, nil
+}
+
+func (n *call) String() string {
+	args := make([]string, len(n.args))
+	for i, arg := range n.args {
+		args[i] = arg.String()
+	}
+
+	return n.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// Bool reports whether v is the boolean true. NULL, like
+// in a SQL WHERE clause, is not.
+func Bool(v any) (bool, error) {
+	switch v := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	}
+
+	return false, fmt.Errorf("expected a boolean, got %s", describe(v))
+}
+
+// toNumber converts v to a number, parsing strings if need be.
+func toNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+func toString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	return fmt.Sprint(v)
+}
+
+// equal compares two non-nil values. Numbers equal strings that parse as them.
+func equal(a, b any) (bool, error) {
+	switch a := a.(type) {
+	case bool:
+		if b, ok := b.(bool); ok {
+			return a == b, nil
+		}
+
+		return false, fmt.Errorf("cannot compare %s and %s", describe(a), describe(b))
+	case string:
+		if b, ok := b.(string); ok {
+			return a == b, nil
+		}
+	}
+
+	if _, ok := b.(bool); ok {
+		return false, fmt.Errorf("cannot compare %s and %s", describe(a), describe(b))
+	}
+
+	c, err := compare(a, b)
+	return c == 0, err
+}
+
+// errUnknown is returned when one of two values is a number and the other a
+// string that is not one, e.g. population > 100 where population is 'abc'.
+// Like SQL's NULL, neither can be said to equal or order before the other,
+// whereas ordering them as strings would quietly give nonsense.
+var errUnknown = errors.New("unknown")
+
+// compare orders two non-nil values numerically if they both are, or can be
+// parsed as, numbers and lexically if they are both strings that cannot. If
+// only one is a number, it returns errUnknown.
+func compare(a, b any) (int, error) {
+	if _, ok := a.(bool); ok {
+		return 0, fmt.Errorf("cannot order %s", describe(a))
+	}
+
+	if _, ok := b.(bool); ok {
+		return 0, fmt.Errorf("cannot order %s", describe(b))
+	}
+
+	l, lok := toNumber(a)
+	r, rok := toNumber(b)
+	switch {
+	case lok && rok:
+		switch {
+		case l < r:
+			return -1, nil
+		case l > r:
+			return 1, nil
+		}
+
+		return 0, nil
+	case isNumber(a) || isNumber(b):
+		return 0, errUnknown
+	}
+
+	return strings.Compare(toString(a), toString(b)), nil
+}
+
+func isNumber(v any) bool {
+	_, ok := v.(float64)
+	return ok
+}
+
+func describe(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return fmt.Sprintf("string '%s'", v)
+	case float64:
+		return "number " + toString(v)
+	case bool:
+		return "boolean " + toString(v)
+	}
+
+	return fmt.Sprintf("%T", v)
+}
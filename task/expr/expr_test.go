@@ -0,0 +1,174 @@
+package expr_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/task/expr"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+func feature() *geojson.Feature {
+	f := geojson.NewFeature(orb.Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}})
+	f.Properties = geojson.Properties{
+		"name":       "Austin",
+		"state":      "TX",
+		"population": 961855.0,
+		"capital":    true,
+		"zip":        "78701",
+		"code":       "abc",
+		"nickname":   nil,
+	}
+
+	return f
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{expr: "population > 10000", want: true},
+		{expr: "population >= 961855 AND population <= 961855", want: true},
+		{expr: "population < 10000 OR state = 'TX'", want: true},
+		{expr: "NOT capital", want: false},
+		{expr: "state != 'OK'", want: true},
+		{expr: "state <> 'TX'", want: false},
+		{expr: "state == 'TX'", want: true},
+		{expr: "state IN ('TX', 'OK')", want: true},
+		{expr: "state NOT IN ('TX', 'OK')", want: false},
+		{expr: "nickname IS NULL", want: true},
+		{expr: "name IS NOT NULL", want: true},
+		{expr: "missing IS NULL", want: true},
+		{expr: "name LIKE 'Aus%'", want: true},
+		{expr: "name LIKE 'aus%'", want: false},
+		{expr: "name ILIKE 'aus%'", want: true},
+		{expr: "name NOT LIKE '_ustin'", want: false},
+		{expr: "zip = 78701", want: true},
+		{expr: "$geometry_type = 'Polygon'", want: true},
+		{expr: "$geometry_type IN ('Point', 'MultiPoint')", want: false},
+		{expr: "population / 1000 > 961", want: true},
+		{expr: "name || ', ' || state", want: "Austin, TX"},
+		// NULL is unknown, like in SQL
+		{expr: "nickname = 'ATX'", want: nil},
+		{expr: "nickname = 'ATX' AND capital", want: nil},
+		{expr: "nickname = 'ATX' OR capital", want: true},
+		{expr: "nickname = 'ATX' AND NOT capital", want: false},
+		{expr: "population / 0", want: nil},
+		// a number and a string that is not one are unknown to each other
+		// rather than ordered as strings
+		{expr: "code > 100", want: nil},
+		{expr: "code <= 100", want: nil},
+		{expr: "code = 100", want: nil},
+		{expr: "code != 100", want: nil},
+		{expr: "code IN (100)", want: nil},
+		{expr: "code IN (100, 'abc')", want: true},
+		{expr: "code NOT IN (100, 200)", want: nil},
+		{expr: "code > 100 OR capital", want: true},
+		{expr: "code > '100'", want: true},
+		{expr: "zip > 100", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := expr.Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() = %v", err)
+			}
+
+			got, err := e.Eval(expr.NewFeatureEnv(feature()))
+			if err != nil {
+				t.Fatalf("Eval() = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Eval() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []string{
+		"name + 1",
+		"capital > 'TX'",
+	}
+
+	for _, s := range tests {
+		t.Run(s, func(t *testing.T) {
+			e, err := expr.Parse(s)
+			if err != nil {
+				t.Fatalf("Parse() = %v", err)
+			}
+
+			var evalErr *expr.EvalError
+			if _, err = e.Eval(expr.NewFeatureEnv(feature())); !errors.As(err, &evalErr) {
+				t.Errorf("Eval() = %v, want *expr.EvalError", err)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"population >",
+		"state IN ('TX'",
+		"state NOT = 'TX'",
+		"name IS 'Austin'",
+		"(population > 1",
+		"population > 1 population",
+		"$nope = 1",
+	}
+
+	for _, s := range tests {
+		t.Run(s, func(t *testing.T) {
+			if _, err := expr.Parse(s); err == nil {
+				t.Errorf("Parse() = nil, want error")
+			}
+		})
+	}
+}
+
+func TestColumns(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{expr: "population > 1 AND state IN ('TX', name)", want: []string{"name", "population", "state"}},
+		{expr: "$area > 1 AND state = state", want: []string{"state"}},
+		{expr: "1 = 1", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got := expr.MustParse(tt.expr).Columns()
+			if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("Columns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBool(t *testing.T) {
+	tests := []struct {
+		v       any
+		want    bool
+		wantErr bool
+	}{
+		{v: true, want: true},
+		{v: false, want: false},
+		{v: nil, want: false},
+		{v: "true", wantErr: true},
+		{v: 1.0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := expr.Bool(tt.v)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Bool(%#v) = %v, %v, want %v, error %v", tt.v, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
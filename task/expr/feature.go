@@ -0,0 +1,106 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/paulmach/orb/geojson"
+)
+
+// variables describes each $variable that a FeatureEnv provides.
+var variables = map[string]string{
+	"$geometry_type": "the GeoJSON type of the feature's geometry, e.g. 'Polygon', or NULL if it has none",
+	"$id":            "the feature's id, or NULL if it has none",
+	"$area":          "the area of the feature's polygons in square meters, or NULL if it has no geometry",
+	"$length":        "the length of the feature's lines in meters, or NULL if it has no geometry",
+	"$perimeter":     "the length of the rings of the feature's polygons in meters, or NULL if it has no geometry",
+	"$x":             "the longitude of the feature's point, or of the centroid of its geometry, or NULL if it has none",
+	"$y":             "the latitude of the feature's point, or of the centroid of its geometry, or NULL if it has none",
+}
+
+// Variables returns the sorted names of the $variables that expressions can refer to.
+func Variables() []string {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// FeatureEnv evaluates expressions against a GeoJSON feature.
+// Columns are the feature's properties.
+type FeatureEnv struct {
+	Feature *geojson.Feature
+}
+
+// NewFeatureEnv returns an Env for the given feature.
+func NewFeatureEnv(f *geojson.Feature) *FeatureEnv {
+	return &FeatureEnv{Feature: f}
+}
+
+var _ Env = &FeatureEnv{}
+
+func (e *FeatureEnv) Column(name string) any {
+	return normalize(e.Feature.Properties[name])
+}
+
+func (e *FeatureEnv) Variable(name string) (any, error) {
+	switch name {
+	case "$geometry_type":
+		if e.Feature.Geometry == nil {
+			return nil, nil
+		}
+
+		return e.Feature.Geometry.GeoJSONType(), nil
+	case "$id":
+		return normalize(e.Feature.ID), nil
+	case "$area", "$length", "$perimeter", "$x", "$y":
+		if e.Feature.Geometry == nil {
+			return nil, nil
+		}
+
+		switch name {
+		case "$area":
+			return geometryArea(e.Feature.Geometry), nil
+		case "$length":
+			return geometryLength(e.Feature.Geometry), nil
+		case "$perimeter":
+			return geometryPerimeter(e.Feature.Geometry), nil
+		case "$x":
+			return geometryCentroid(e.Feature.Geometry).X(), nil
+		}
+
+		return geometryCentroid(e.Feature.Geometry).Y(), nil
+	}
+
+	return nil, fmt.Errorf("unknown variable '%s'", name)
+}
+
+// normalize converts a decoded JSON value into one of the types
+// that expressions operate on. Arrays and objects become their JSON.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case nil, string, float64, bool:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+
+		return v.String()
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
@@ -0,0 +1,106 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+type function struct {
+	// maxArgs of -1 means any number of args
+	minArgs, maxArgs int
+	fn               func([]any) (any, error)
+}
+
+var functions = map[string]function{
+	"lower":    {1, 1, stringFunc(strings.ToLower)},
+	"upper":    {1, 1, stringFunc(strings.ToUpper)},
+	"trim":     {1, 1, stringFunc(strings.TrimSpace)},
+	"length":   {1, 1, length},
+	"abs":      {1, 1, numberFunc(math.Abs)},
+	"floor":    {1, 1, numberFunc(math.Floor)},
+	"ceil":     {1, 1, numberFunc(math.Ceil)},
+	"round":    {1, 2, round},
+	"coalesce": {1, -1, coalesce},
+}
+
+// Functions returns the sorted names of the functions that expressions can call.
+func Functions() []string {
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// nullable returns NULL if any of args is NULL and the result of fn otherwise.
+func nullable(args []any, fn func() any) any {
+	for _, arg := range args {
+		if arg == nil {
+			return nil
+		}
+	}
+
+	return fn()
+}
+
+func stringFunc(fn func(string) string) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		return nullable(args, func() any { return fn(toString(args[0])) }), nil
+	}
+}
+
+func length(args []any) (any, error) {
+	return nullable(args, func() any { return float64(len([]rune(toString(args[0])))) }), nil
+}
+
+func numberFunc(fn func(float64) float64) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+
+		f, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("expected a number, got %s", describe(args[0]))
+		}
+
+		return fn(f), nil
+	}
+}
+
+func round(args []any) (any, error) {
+	for _, arg := range args {
+		if arg == nil {
+			return nil, nil
+		}
+	}
+
+	f, ok := toNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("expected a number, got %s", describe(args[0]))
+	}
+
+	places := 0.0
+	if len(args) > 1 {
+		if places, ok = toNumber(args[1]); !ok {
+			return nil, fmt.Errorf("expected a number of decimal places, got %s", describe(args[1]))
+		}
+	}
+
+	pow := math.Pow(10, math.Trunc(places))
+	return math.Round(f*pow) / pow, nil
+}
+
+func coalesce(args []any) (any, error) {
+	for _, arg := range args {
+		if arg != nil {
+			return arg, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,70 @@
+package expr
+
+import (
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"github.com/paulmach/orb/planar"
+)
+
+// geometryArea returns the geodesic area of the polygons of g, whose
+// coordinates are longitudes and latitudes, in square meters.
+func geometryArea(g orb.Geometry) float64 {
+	switch g := g.(type) {
+	case orb.Ring, orb.Polygon, orb.MultiPolygon, orb.Bound:
+		return geo.Area(g)
+	case orb.Collection:
+		a := 0.0
+		for _, m := range g {
+			a += geometryArea(m)
+		}
+
+		return a
+	}
+
+	return 0
+}
+
+// geometryLength returns the geodesic length of the lines of g in meters.
+// The rings of polygons are not lines, see perimeter.
+func geometryLength(g orb.Geometry) float64 {
+	switch g := g.(type) {
+	case orb.LineString, orb.MultiLineString:
+		return geo.Length(g)
+	case orb.Collection:
+		l := 0.0
+		for _, m := range g {
+			l += geometryLength(m)
+		}
+
+		return l
+	}
+
+	return 0
+}
+
+// geometryPerimeter returns the geodesic length of the rings of the polygons of g in meters.
+func geometryPerimeter(g orb.Geometry) float64 {
+	switch g := g.(type) {
+	case orb.Ring, orb.Polygon, orb.MultiPolygon, orb.Bound:
+		return geo.Length(g)
+	case orb.Collection:
+		p := 0.0
+		for _, m := range g {
+			p += geometryPerimeter(m)
+		}
+
+		return p
+	}
+
+	return 0
+}
+
+// geometryCentroid returns g if it is a point and its planar centroid otherwise.
+func geometryCentroid(g orb.Geometry) orb.Point {
+	if p, ok := g.(orb.Point); ok {
+		return p
+	}
+
+	c, _ := planar.CentroidArea(g)
+	return c
+}
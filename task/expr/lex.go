@@ -0,0 +1,157 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenVariable
+	tokenKeyword
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenSemicolon
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+var keywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true,
+	"IN": true, "IS": true, "NULL": true, "LIKE": true, "ILIKE": true,
+	"TRUE": true, "FALSE": true,
+}
+
+// lex splits s into tokens. Keywords are uppercased, identifiers
+// keep their case and strings have their quotes removed.
+func lex(s string) ([]token, error) {
+	var (
+		tokens = []token{}
+		runes  = []rune(s)
+	)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case r == ';':
+			tokens = append(tokens, token{tokenSemicolon, ";", i})
+			i++
+		case r == '\'' || r == '"':
+			// 'string literal' or "quoted identifier", where
+			// the quote is escaped by doubling it
+			var (
+				start = i
+				b     strings.Builder
+			)
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, &SyntaxError{Pos: start, Msg: "unterminated quote"}
+				}
+
+				if runes[i] == r {
+					if i+1 < len(runes) && runes[i+1] == r {
+						b.WriteRune(r)
+						i++
+						continue
+					}
+					i++
+					break
+				}
+
+				b.WriteRune(runes[i])
+			}
+
+			kind := tokenString
+			if r == '"' {
+				kind = tokenIdent
+			}
+			tokens = append(tokens, token{kind, b.String(), start})
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+
+				if j < len(runes) && unicode.IsDigit(runes[j]) {
+					for i = j; i < len(runes) && unicode.IsDigit(runes[i]); i++ {
+					}
+				}
+			}
+			tokens = append(tokens, token{tokenNumber, string(runes[start:i]), start})
+		case r == '$' || r == '_' || unicode.IsLetter(r):
+			start := i
+			for i++; i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])); i++ {
+			}
+
+			text := string(runes[start:i])
+			switch {
+			case r == '$':
+				if len(text) == 1 {
+					return nil, &SyntaxError{Pos: start, Msg: "expected variable name after '$'"}
+				}
+				tokens = append(tokens, token{tokenVariable, strings.ToLower(text), start})
+			case keywords[strings.ToUpper(text)]:
+				tokens = append(tokens, token{tokenKeyword, strings.ToUpper(text), start})
+			default:
+				tokens = append(tokens, token{tokenIdent, text, start})
+			}
+		default:
+			start := i
+			op := string(r)
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "<=", ">=", "<>", "!=", "==", "||":
+					op = two
+				}
+			}
+
+			switch op {
+			case "=", "==", "!=", "<>", "<", "<=", ">", ">=", "+", "-", "*", "/", "%", "||":
+			default:
+				return nil, &SyntaxError{Pos: start, Msg: fmt.Sprintf("unexpected character '%s'", op)}
+			}
+
+			i += len([]rune(op))
+			tokens = append(tokens, token{tokenOperator, op, start})
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", len(runes)}), nil
+}
@@ -0,0 +1,542 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SyntaxError is returned when an expression cannot be parsed.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos+1, e.Msg)
+}
+
+// Expr is a parsed expression that can be evaluated against an Env.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses s into an Expr. Along with the syntax, it checks that
+// every $variable and function that s refers to exists, so that the only
+// errors left for evaluation are those that depend on the data.
+func Parse(s string) (*Expr, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t)}
+	}
+
+	return &Expr{src: s, root: root}, nil
+}
+
+// MustParse is like Parse but panics if s cannot be parsed.
+func MustParse(s string) *Expr {
+	e, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return e
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the Expr against env. The result is
+// nil, a float64, a string or a bool.
+func (e *Expr) Eval(env Env) (any, error) {
+	return e.root.eval(env)
+}
+
+// Columns returns the sorted, distinct names of the columns that the Expr refers to.
+func (e *Expr) Columns() []string {
+	set := map[string]bool{}
+	walk(e.root, func(n node) {
+		if c, ok := n.(*column); ok {
+			set[c.name] = true
+		}
+	})
+
+	columns := make([]string, 0, len(set))
+	for c := range set {
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+
+	return columns
+}
+
+// Assignment is a column and the Expr whose value to assign to it.
+type Assignment struct {
+	Column string
+	Expr   *Expr
+}
+
+func (a *Assignment) String() string {
+	return fmt.Sprintf("%s = %s", a.Column, a.Expr)
+}
+
+// ParseAssignments parses s as a list of assignments separated by
+// semicolons, each of the form column = expr, e.g.
+// area_km2 = $area / 1e6; label = name || ' (' || id || ')'.
+// The column may be a "quoted identifier".
+func ParseAssignments(s string) ([]*Assignment, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		runes       = []rune(s)
+		assignments = []*Assignment{}
+	)
+	for start := 0; start < len(tokens)-1; {
+		end := start
+		for tokens[end].kind != tokenSemicolon && tokens[end].kind != tokenEOF {
+			end++
+		}
+
+		if end == start {
+			// allow empty assignments, e.g. a trailing semicolon
+			start++
+			continue
+		}
+
+		if tokens[start].kind != tokenIdent {
+			return nil, &SyntaxError{Pos: tokens[start].pos, Msg: fmt.Sprintf("expected column to assign to, got %s", tokens[start])}
+		}
+
+		if t := tokens[start+1]; t.kind != tokenOperator || t.text != "=" {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("expected '=', got %s", t)}
+		}
+
+		// parse the tokens up to the semicolon
+		// as if they were the whole expression
+		exprTokens := append(append([]token{}, tokens[start+2:end]...), token{tokenEOF, "", tokens[end].pos})
+		p := &parser{tokens: exprTokens}
+		root, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if t := p.peek(); t.kind != tokenEOF {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t)}
+		}
+
+		assignments = append(assignments, &Assignment{
+			Column: tokens[start].text,
+			Expr: &Expr{
+				src:  strings.TrimSpace(string(runes[tokens[start+1].pos+1 : tokens[end].pos])),
+				root: root,
+			},
+		})
+		start = end + 1
+	}
+
+	if len(assignments) == 0 {
+		return nil, &SyntaxError{Pos: 0, Msg: "expected at least one assignment"}
+	}
+
+	return assignments, nil
+}
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+
+	return t
+}
+
+// accept consumes the next token if it is of the given kind and,
+// if any texts are given, has one of them as its text.
+func (p *parser) accept(kind tokenKind, texts ...string) (token, bool) {
+	t := p.peek()
+	if t.kind != kind {
+		return t, false
+	}
+
+	if len(texts) > 0 {
+		found := false
+		for _, text := range texts {
+			if t.text == text {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return t, false
+		}
+	}
+
+	return p.next(), true
+}
+
+func (p *parser) expect(kind tokenKind, text, want string) (token, error) {
+	if t, ok := p.accept(kind, text); ok {
+		return t, nil
+	}
+
+	t := p.peek()
+	return t, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("expected %s, got %s", want, t)}
+}
+
+// the grammar, from lowest to highest precedence:
+//
+//	or        = and { "OR" and }
+//	and       = not { "AND" not }
+//	not       = "NOT" not | predicate
+//	predicate = concat [ compare concat
+//	                   | [ "NOT" ] "IN" "(" expr { "," expr } ")"
+//	                   | "IS" [ "NOT" ] "NULL"
+//	                   | [ "NOT" ] ( "LIKE" | "ILIKE" ) concat ]
+//	concat    = additive { "||" additive }
+//	additive  = term { ( "+" | "-" ) term }
+//	term      = unary { ( "*" | "/" | "%" ) unary }
+//	unary     = "-" unary | primary
+//	primary   = number | string | "TRUE" | "FALSE" | "NULL" | column
+//	          | $variable | function "(" [ expr { "," expr } ] ")" | "(" expr ")"
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept(tokenKeyword, "OR"); !ok {
+			return left, nil
+		}
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binary{op: "OR", left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept(tokenKeyword, "AND"); !ok {
+			return left, nil
+		}
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binary{op: "AND", left: left, right: right}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	if _, ok := p.accept(tokenKeyword, "NOT"); ok {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return &unary{op: "NOT", x: x}, nil
+	}
+
+	return p.parsePredicate()
+}
+
+func (p *parser) parsePredicate() (node, error) {
+	left, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+
+	if t, ok := p.accept(tokenOperator, "=", "==", "!=", "<>", "<", "<=", ">", ">="); ok {
+		right, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+
+		op := t.text
+		switch op {
+		case "==":
+			op = "="
+		case "<>":
+			op = "!="
+		}
+
+		return &binary{op: op, left: left, right: right}, nil
+	}
+
+	if _, ok := p.accept(tokenKeyword, "IS"); ok {
+		_, not := p.accept(tokenKeyword, "NOT")
+		if _, err := p.expect(tokenKeyword, "NULL", "'NULL'"); err != nil {
+			return nil, err
+		}
+
+		return &isNull{x: left, not: not}, nil
+	}
+
+	_, not := p.accept(tokenKeyword, "NOT")
+
+	if _, ok := p.accept(tokenKeyword, "IN"); ok {
+		if _, err := p.expect(tokenLParen, "(", "'('"); err != nil {
+			return nil, err
+		}
+
+		list, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+
+		return &in{x: left, list: list, not: not}, nil
+	}
+
+	if t, ok := p.accept(tokenKeyword, "LIKE", "ILIKE"); ok {
+		pattern, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+
+		l := &like{x: left, pattern: pattern, not: not, insensitive: t.text == "ILIKE"}
+		if lit, ok := pattern.(*literal); ok {
+			// compile constant patterns once up front
+			if l.re, err = likeRegexp(toString(lit.v), l.insensitive); err != nil {
+				return nil, &SyntaxError{Pos: t.pos, Msg: err.Error()}
+			}
+		}
+
+		return l, nil
+	}
+
+	if not {
+		t := p.peek()
+		return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("expected 'IN' or 'LIKE' after 'NOT', got %s", t)}
+	}
+
+	return left, nil
+}
+
+// parseList parses a comma separated list of expressions
+// up to and including the closing parenthesis.
+func (p *parser) parseList() ([]node, error) {
+	list := []node{}
+	if _, ok := p.accept(tokenRParen); ok {
+		return list, nil
+	}
+
+	for {
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, x)
+
+		if _, ok := p.accept(tokenComma); ok {
+			continue
+		}
+
+		if _, err := p.expect(tokenRParen, ")", "',' or ')'"); err != nil {
+			return nil, err
+		}
+
+		return list, nil
+	}
+}
+
+func (p *parser) parseConcat() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept(tokenOperator, "||"); !ok {
+			return left, nil
+		}
+
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binary{op: "||", left: left, right: right}
+	}
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t, ok := p.accept(tokenOperator, "+", "-")
+		if !ok {
+			return left, nil
+		}
+
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binary{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseTerm() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t, ok := p.accept(tokenOperator, "*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binary{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if _, ok := p.accept(tokenOperator, "-"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &unary{op: "-", x: x}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("invalid number '%s'", t.text)}
+		}
+
+		return &literal{v: f}, nil
+	case tokenString:
+		return &literal{v: t.text}, nil
+	case tokenKeyword:
+		switch t.text {
+		case "TRUE":
+			return &literal{v: true}, nil
+		case "FALSE":
+			return &literal{v: false}, nil
+		case "NULL":
+			return &literal{v: nil}, nil
+		}
+	case tokenVariable:
+		if _, ok := variables[t.text]; !ok {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unknown variable '%s', expected one of '%s'", t.text, strings.Join(Variables(), "', '"))}
+		}
+
+		return &variable{name: t.text}, nil
+	case tokenIdent:
+		if _, ok := p.accept(tokenLParen); !ok {
+			return &column{name: t.text}, nil
+		}
+
+		name := strings.ToLower(t.text)
+		fn, ok := functions[name]
+		if !ok {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unknown function '%s'", t.text)}
+		}
+
+		args, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("wrong number of arguments to '%s'", name)}
+		}
+
+		return &call{name: name, fn: fn.fn, args: args}, nil
+	case tokenLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = p.expect(tokenRParen, ")", "')'"); err != nil {
+			return nil, err
+		}
+
+		return x, nil
+	}
+
+	return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t)}
+}
+
+// likeRegexp converts a LIKE pattern, where '%' matches any number
+// of characters and '_' matches exactly one, into a regular expression.
+func likeRegexp(pattern string, insensitive bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if insensitive {
+		b.WriteString("(?i)")
+	}
+	b.WriteString("^")
+
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString("(?s:.*)")
+		case '_':
+			b.WriteString("(?s:.)")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,156 @@
+// Package features reads and writes the features that in-process Tasks
+// operate on, as GeoJSON, in the same files that executable tasks do.
+package features
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/logsquaredn/rototiller/encoding/format"
+	"github.com/logsquaredn/rototiller/encoding/shapefile"
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb/geojson"
+	"mellium.im/sysexit"
+)
+
+const (
+	// OutputJSON is the name of the GeoJSON file that Tasks write their output to.
+	OutputJSON = "output.json"
+	// OutputZip is the name of the zipped shapefile that Tasks write their output to.
+	OutputZip = "output.zip"
+)
+
+// Read decodes the first GeoJSON file or zipped shapefile in vol.
+// A single Feature is returned as a FeatureCollection containing only it.
+func Read(vol volume.Volume) (*geojson.FeatureCollection, error) {
+	var (
+		b   []byte
+		ext string
+	)
+	if err := vol.Walk(func(_ string, f volume.File, err error) error {
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		switch ext = strings.ToLower(filepath.Ext(f.GetName())); ext {
+		case ".json", ".geojson", ".zip":
+		default:
+			return nil
+		}
+
+		if b, err = io.ReadAll(f); err != nil {
+			return err
+		}
+
+		// only the first one is read, so end the Walk
+		return io.EOF
+	}); err != nil && err != io.EOF {
+		return nil, task.NewErr(sysexit.ErrNoInput, err)
+	}
+
+	switch {
+	case b == nil:
+		return nil, task.Errorf(sysexit.ErrNoInput, "input file must be a .zip or .json")
+	case ext == ".zip":
+		fc, err := shapefile.Unmarshal(b)
+		switch {
+		case errors.Is(err, shapefile.ErrNoShapefile):
+			return nil, task.NewErr(sysexit.ErrNoInput, err)
+		case err != nil:
+			return nil, task.NewErr(sysexit.ErrData, err)
+		}
+
+		return fc, nil
+	}
+
+	return Unmarshal(b)
+}
+
+// ReadParam decodes the features of the storage that the named storage param
+// refers to, like Read. That storage is not the job's input, so whatever is
+// wrong with it is wrong with the job's params rather than with its input,
+// and every error exits sysexit.ErrConfig, naming the storage.
+func ReadParam(args task.Args, name string) (*geojson.FeatureCollection, error) {
+	vol, ok := args.Volume(name)
+	if !ok {
+		return nil, task.Errorf(sysexit.ErrConfig, "content of storage '%s' given by param '%s' not found", args.String(name), name)
+	}
+
+	fc, err := Read(vol)
+	if err != nil {
+		return nil, task.Errorf(sysexit.ErrConfig, "read storage '%s' given by param '%s': %w", args.String(name), name, err)
+	}
+
+	return fc, nil
+}
+
+// Unmarshal decodes a GeoJSON FeatureCollection or Feature.
+func Unmarshal(b []byte) (*geojson.FeatureCollection, error) {
+	fc, err := format.UnmarshalGeoJSON(b)
+	if err != nil {
+		return nil, task.Errorf(sysexit.ErrData, "input is not a GeoJSON FeatureCollection or Feature")
+	}
+
+	return fc, nil
+}
+
+// Write encodes fc to both OutputJSON and OutputZip in dir,
+// like the executable tasks do.
+func Write(dir volume.Directory, fc *geojson.FeatureCollection) error {
+	b, err := fc.MarshalJSON()
+	if err != nil {
+		return task.NewErr(sysexit.ErrCantCreat, err)
+	}
+
+	if err = writeFile(dir, OutputJSON, b); err != nil {
+		return err
+	}
+
+	if b, err = shapefile.Marshal(fc, strings.TrimSuffix(OutputZip, filepath.Ext(OutputZip))); err != nil {
+		return task.Errorf(sysexit.ErrCantCreat, "failed to produce shapefile output: %w", err)
+	}
+
+	return writeFile(dir, OutputZip, b)
+}
+
+func writeFile(dir volume.Directory, name string, b []byte) error {
+	f, err := dir.Create(name)
+	if err != nil {
+		return task.NewErr(sysexit.ErrCantCreat, err)
+	}
+	defer f.Close()
+
+	if _, err = f.Write(b); err != nil {
+		return task.NewErr(sysexit.ErrCantCreat, err)
+	}
+
+	if err = f.Close(); err != nil {
+		return task.NewErr(sysexit.ErrCantCreat, fmt.Errorf("close %s: %w", name, err))
+	}
+
+	return nil
+}
+
+// Columns returns the sorted, distinct property names across every feature in fc.
+func Columns(fc *geojson.FeatureCollection) []string {
+	set := map[string]bool{}
+	for _, f := range fc.Features {
+		for k := range f.Properties {
+			set[k] = true
+		}
+	}
+
+	columns := make([]string, 0, len(set))
+	for c := range set {
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+
+	return columns
+}
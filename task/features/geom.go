@@ -0,0 +1,87 @@
+package features
+
+import (
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkb"
+	"github.com/peterstace/simplefeatures/geom"
+)
+
+// ToGeom converts g for use with the algorithms that orb lacks, e.g. overlays.
+// A nil g becomes an empty GeometryCollection. g is not validated, so the
+// result of algorithms that require valid geometries may be incorrect.
+func ToGeom(g orb.Geometry) (geom.Geometry, error) {
+	if g == nil {
+		return geom.Geometry{}, nil
+	}
+
+	b, err := wkb.Marshal(g)
+	if err != nil {
+		return geom.Geometry{}, err
+	}
+
+	return geom.UnmarshalWKB(b, geom.NoValidate{})
+}
+
+// FromGeom converts g back from ToGeom. An empty g becomes nil.
+func FromGeom(g geom.Geometry) (orb.Geometry, error) {
+	if g.IsEmpty() {
+		return nil, nil
+	}
+
+	return wkb.Unmarshal(g.AsBinary())
+}
+
+// Homogenize returns the parts of g that have the given dimension as a single
+// geometry, e.g. the polygons of a Collection as a MultiPolygon, or nil if it
+// has none. Overlays return such mixed Collections where their inputs touch.
+func Homogenize(g orb.Geometry, dim int) orb.Geometry {
+	var (
+		mp  orb.MultiPoint
+		mls orb.MultiLineString
+		mpg orb.MultiPolygon
+	)
+
+	var add func(orb.Geometry)
+	add = func(g orb.Geometry) {
+		switch g := g.(type) {
+		case orb.Point:
+			mp = append(mp, g)
+		case orb.MultiPoint:
+			mp = append(mp, g...)
+		case orb.LineString:
+			mls = append(mls, g)
+		case orb.MultiLineString:
+			mls = append(mls, g...)
+		case orb.Ring:
+			mpg = append(mpg, orb.Polygon{g})
+		case orb.Bound:
+			mpg = append(mpg, g.ToPolygon())
+		case orb.Polygon:
+			mpg = append(mpg, g)
+		case orb.MultiPolygon:
+			mpg = append(mpg, g...)
+		case orb.Collection:
+			for _, m := range g {
+				add(m)
+			}
+		}
+	}
+	add(g)
+
+	switch {
+	case dim == 0 && len(mp) == 1:
+		return mp[0]
+	case dim == 0 && len(mp) > 1:
+		return mp
+	case dim == 1 && len(mls) == 1:
+		return mls[0]
+	case dim == 1 && len(mls) > 1:
+		return mls
+	case dim == 2 && len(mpg) == 1:
+		return mpg[0]
+	case dim == 2 && len(mpg) > 1:
+		return mpg
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+// Package intersect implements the intersect Task, which cuts features
+// into their overlaps with the features of another storage.
+package intersect
+
+import (
+	"context"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/logsquaredn/rototiller/task/overlay"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb/geojson"
+	"mellium.im/sysexit"
+)
+
+// Name is the name of the intersect Task.
+const Name = "intersect"
+
+func init() {
+	task.Register(Name, task.TaskFunc(Run))
+}
+
+// Run outputs a feature for each pair of input and overlay features that
+// intersect, whose geometry is their intersection with the dimension of the
+// input feature and whose properties are those of both, the overlay feature's
+// prefixed by the prefix param. Features that intersect nothing are dropped.
+func Run(_ context.Context, input volume.Volume, output volume.Directory, args task.Args) error {
+	fc, err := features.Read(input)
+	if err != nil {
+		return err
+	}
+
+	in, err := overlay.NewLayer(fc)
+	if err != nil {
+		return task.NewErr(sysexit.ErrData, err)
+	}
+
+	ov, err := overlay.Read(args)
+	if err != nil {
+		return err
+	}
+
+	prefix := args.String(overlay.ParamPrefix)
+
+	out := geojson.NewFeatureCollection()
+	for i, f := range in.Features {
+		g := in.Geometry(i)
+		for _, j := range ov.Intersecting(g) {
+			intersection, err := overlay.Intersection(g, ov.Geometry(j), f.Geometry.Dimensions())
+			if err != nil {
+				return task.Errorf(sysexit.ErrData, "feature %d: %w", i, err)
+			}
+
+			if intersection != nil {
+				out.Append(&geojson.Feature{
+					Type:       f.Type,
+					Geometry:   intersection,
+					Properties: overlay.Properties(f, ov.Features[j], prefix),
+				})
+			}
+		}
+	}
+
+	return features.Write(output, out)
+}
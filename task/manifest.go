@@ -0,0 +1,198 @@
+package task
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/logsquaredn/rototiller/pb"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDir is the directory that operators
+// can drop additional task manifests into.
+const DefaultDir = "/etc/rototiller/tasks"
+
+// Manifest describes a task: how to invoke it,
+// what it takes and what it produces.
+type Manifest struct {
+	Name        string   `yaml:"name"`
+	Kind        string   `yaml:"kind"`
+	Description string   `yaml:"description,omitempty"`
+	Executable  string   `yaml:"executable,omitempty"`
+	Params      []*Param `yaml:"params,omitempty"`
+	Inputs      []string `yaml:"inputs,omitempty"`
+	Outputs     []string `yaml:"outputs,omitempty"`
+}
+
+var (
+	//go:embed manifests/*.yaml
+	builtin embed.FS
+
+	nameRegexp = regexp.MustCompile("^[a-z][a-z0-9-]{0,31}$")
+)
+
+// Validate checks that the Manifest is well-formed
+// and fills in its defaults.
+func (m *Manifest) Validate() error {
+	if !nameRegexp.MatchString(m.Name) {
+		return fmt.Errorf("invalid task name '%s'", m.Name)
+	}
+
+	kind, err := pb.ParseTaskKind(m.Kind)
+	if err != nil {
+		return fmt.Errorf("task '%s': %w", m.Name, err)
+	}
+	m.Kind = kind.String()
+
+	if m.Executable == "" {
+		m.Executable = m.Name
+	}
+
+	if len(m.Inputs) == 0 {
+		return fmt.Errorf("task '%s' accepts no inputs", m.Name)
+	}
+
+	var (
+		seen   = map[string]bool{}
+		oneOfs = map[string]int{}
+	)
+	for _, p := range m.Params {
+		if err = p.Validate(); err != nil {
+			return fmt.Errorf("task '%s': %w", m.Name, err)
+		}
+
+		if seen[p.Name] {
+			return fmt.Errorf("task '%s' has duplicate param '%s'", m.Name, p.Name)
+		}
+		seen[p.Name] = true
+
+		if p.OneOf != "" {
+			oneOfs[p.OneOf]++
+		}
+	}
+
+	for oneOf, n := range oneOfs {
+		if n < 2 {
+			return fmt.Errorf("task '%s' has only one param that is one of '%s'", m.Name, oneOf)
+		}
+	}
+
+	return nil
+}
+
+// Task converts the Manifest into a *pb.Task
+// suitable for the datastore.
+func (m *Manifest) Task() *pb.Task {
+	var (
+		params = make([]string, len(m.Params))
+		schema = make([]*pb.Param, len(m.Params))
+	)
+	for i, p := range m.Params {
+		params[i] = p.Name
+		schema[i] = p.Proto()
+	}
+
+	return &pb.Task{
+		Type:        m.Name,
+		Kind:        m.Kind,
+		Description: m.Description,
+		Executable:  m.Executable,
+		Params:      params,
+		Inputs:      m.Inputs,
+		Outputs:     m.Outputs,
+		Schema:      schema,
+	}
+}
+
+// ReadManifest reads a Manifest from the YAML file at path in fsys.
+func ReadManifest(fsys fs.FS, path string) (*Manifest, error) {
+	b, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err = yaml.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	if err = m.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// ReadManifests reads every *.yaml and *.yml Manifest in the root of fsys.
+func ReadManifests(fsys fs.FS) ([]*Manifest, error) {
+	manifests := []*Manifest{}
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		paths, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range paths {
+			m, err := ReadManifest(fsys, path)
+			if err != nil {
+				return nil, err
+			}
+
+			manifests = append(manifests, m)
+		}
+	}
+
+	return manifests, nil
+}
+
+// LoadManifests returns the builtin Manifests overridden by, and
+// extended with, the Manifests found in dirs. Directories that
+// do not exist are skipped.
+func LoadManifests(dirs ...string) ([]*Manifest, error) {
+	sub, err := fs.Sub(builtin, "manifests")
+	if err != nil {
+		return nil, err
+	}
+
+	manifests, err := ReadManifests(sub)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]*Manifest{}
+	for _, m := range manifests {
+		byName[m.Name] = m
+	}
+
+	for _, dir := range dirs {
+		if _, err = os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		manifests, err = ReadManifests(os.DirFS(filepath.Clean(dir)))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range manifests {
+			byName[m.Name] = m
+		}
+	}
+
+	manifests = make([]*Manifest, 0, len(byName))
+	for _, m := range byName {
+		manifests = append(manifests, m)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].Name < manifests[j].Name
+	})
+
+	return manifests, nil
+}
@@ -0,0 +1,174 @@
+// Package overlay holds what the Tasks that combine the input with the
+// features of a second storage, given by the overlay param, have in common.
+package overlay
+
+import (
+	"fmt"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/peterstace/simplefeatures/geom"
+	"mellium.im/sysexit"
+)
+
+// ParamPrefix is the name of the param that overlay Tasks
+// prepend to the names of the properties of overlay features.
+const ParamPrefix = "prefix"
+
+// Layer is a FeatureCollection whose geometries have been
+// converted for use in overlays, skipping those that are nil.
+type Layer struct {
+	Features []*geojson.Feature
+
+	geoms []geom.Geometry
+}
+
+// NewLayer converts the geometries of fc for use in overlays.
+func NewLayer(fc *geojson.FeatureCollection) (*Layer, error) {
+	l := &Layer{}
+	for i, f := range fc.Features {
+		if f.Geometry == nil {
+			continue
+		}
+
+		g, err := features.ToGeom(f.Geometry)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+
+		l.Features = append(l.Features, f)
+		l.geoms = append(l.geoms, g)
+	}
+
+	return l, nil
+}
+
+// Read reads the Layer from the storage given by the overlay param. Like
+// features.ReadParam, every error exits sysexit.ErrConfig, naming the storage.
+func Read(args task.Args) (*Layer, error) {
+	fc, err := features.ReadParam(args, task.ParamOverlay)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := NewLayer(fc)
+	if err != nil {
+		return nil, task.Errorf(sysexit.ErrConfig, "read overlay storage '%s': %w", args.String(task.ParamOverlay), err)
+	}
+
+	return l, nil
+}
+
+// Len returns the number of features in the Layer.
+func (l *Layer) Len() int {
+	return len(l.Features)
+}
+
+// Geometry returns the converted geometry of the ith feature.
+func (l *Layer) Geometry(i int) geom.Geometry {
+	return l.geoms[i]
+}
+
+// Intersecting returns the indices of the features that intersect g, in order.
+func (l *Layer) Intersecting(g geom.Geometry) []int {
+	var (
+		env = g.Envelope()
+		is  = []int{}
+	)
+	for i, o := range l.geoms {
+		if o.Envelope().Intersects(env) && geom.Intersects(o, g) {
+			is = append(is, i)
+		}
+	}
+
+	return is
+}
+
+// Union returns the union of the geometries of the features with the
+// given indices, or of all of them if is is nil. Only their parts with a
+// dimension of at least dim are included, since lesser ones cannot take
+// anything away from a geometry of that dimension.
+func (l *Layer) Union(is []int, dim int) (geom.Geometry, error) {
+	if is == nil {
+		is = make([]int, len(l.geoms))
+		for i := range is {
+			is[i] = i
+		}
+	}
+
+	gs := []geom.Geometry{}
+	for _, i := range is {
+		for d := dim; d <= 2; d++ {
+			if part := features.Homogenize(l.Features[i].Geometry, d); part != nil {
+				g, err := features.ToGeom(part)
+				if err != nil {
+					return geom.Geometry{}, err
+				}
+
+				gs = append(gs, g)
+			}
+		}
+	}
+
+	if len(gs) == 0 {
+		return geom.Geometry{}, nil
+	}
+
+	return geom.UnionMany(gs)
+}
+
+// Intersection returns the part of a that is in b,
+// with the same dimension as a, or nil if there is none.
+func Intersection(a, b geom.Geometry, dim int) (orb.Geometry, error) {
+	g, err := geom.Intersection(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromGeom(g, dim)
+}
+
+// Difference returns the part of a that is not in b,
+// with the same dimension as a, or nil if there is none.
+func Difference(a, b geom.Geometry, dim int) (orb.Geometry, error) {
+	if b.IsEmpty() || !a.Envelope().Intersects(b.Envelope()) {
+		return fromGeom(a, dim)
+	}
+
+	g, err := geom.Difference(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromGeom(g, dim)
+}
+
+func fromGeom(g geom.Geometry, dim int) (orb.Geometry, error) {
+	og, err := features.FromGeom(g)
+	if err != nil {
+		return nil, err
+	}
+
+	return features.Homogenize(og, dim), nil
+}
+
+// Properties returns the properties of f along with those of o,
+// whose names are prefixed with prefix. Either may be nil.
+func Properties(f, o *geojson.Feature, prefix string) geojson.Properties {
+	props := geojson.Properties{}
+	if f != nil {
+		for k, v := range f.Properties {
+			props[k] = v
+		}
+	}
+
+	if o != nil {
+		for k, v := range o.Properties {
+			props[prefix+k] = v
+		}
+	}
+
+	return props
+}
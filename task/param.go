@@ -0,0 +1,369 @@
+package task
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/frantjc/go-js"
+	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/task/expr"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkt"
+)
+
+// ParamType is the type of a task parameter's value.
+type ParamType string
+
+const (
+	ParamTypeString  ParamType = "string"
+	ParamTypeInteger ParamType = "integer"
+	ParamTypeNumber  ParamType = "number"
+	ParamTypeBoolean ParamType = "boolean"
+)
+
+func (t ParamType) String() string {
+	return string(t)
+}
+
+// ParamFormat further restricts the value of a string or integer parameter.
+type ParamFormat string
+
+const (
+	// ParamFormatEPSG is a positive integer EPSG code, e.g. 4326.
+	ParamFormatEPSG ParamFormat = "epsg"
+	// ParamFormatWKTPolygon is a Polygon or MultiPolygon in WKT format.
+	ParamFormatWKTPolygon ParamFormat = "wkt-polygon"
+	// ParamFormatCSV is a comma separated list of non-empty values.
+	ParamFormatCSV ParamFormat = "csv"
+	// ParamFormatExpression is an expression as understood by package expr,
+	// e.g. population > 10000 AND state IN ('TX', 'OK').
+	ParamFormatExpression ParamFormat = "expression"
+	// ParamFormatAssignments is a list of assignments of expressions to columns
+	// separated by semicolons, e.g. area_km2 = $area / 1e6; label = name || '!'.
+	ParamFormatAssignments ParamFormat = "assignments"
+	// ParamFormatBBox is a bounding box of the form minx,miny,maxx,maxy.
+	ParamFormatBBox ParamFormat = "bbox"
+	// ParamFormatStorage is the ID of a storage that the requester owns. The
+	// worker downloads its content for the Task, see Args.Volume.
+	ParamFormatStorage ParamFormat = "storage"
+)
+
+// ParamOverlay is the name of the storage param that two-input Tasks take
+// their secondary input from. Jobs record it as the overlay ID of their step.
+const ParamOverlay = "overlay"
+
+func (f ParamFormat) String() string {
+	return string(f)
+}
+
+// Param describes a parameter that a task takes
+// and the values that are valid for it.
+type Param struct {
+	Name        string      `yaml:"name"`
+	Type        ParamType   `yaml:"type"`
+	Required    bool        `yaml:"required,omitempty"`
+	Default     string      `yaml:"default,omitempty"`
+	Min         *float64    `yaml:"min,omitempty"`
+	Max         *float64    `yaml:"max,omitempty"`
+	Enum        []string    `yaml:"enum,omitempty"`
+	Pattern     string      `yaml:"pattern,omitempty"`
+	Format      ParamFormat `yaml:"format,omitempty"`
+	Description string      `yaml:"description,omitempty"`
+	// OneOf names a group of params of which
+	// exactly one must be given, e.g. alternative
+	// ways of giving the same value
+	OneOf string `yaml:"oneOf,omitempty"`
+}
+
+// Validate checks that the Param is well-formed
+// and fills in its defaults.
+func (p *Param) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("param has no name")
+	}
+
+	switch p.Type {
+	case "":
+		p.Type = ParamTypeString
+	case ParamTypeString, ParamTypeInteger, ParamTypeNumber, ParamTypeBoolean:
+	default:
+		return fmt.Errorf("param '%s' has unknown type '%s'", p.Name, p.Type)
+	}
+
+	switch p.Format {
+	case "", ParamFormatEPSG, ParamFormatWKTPolygon, ParamFormatCSV, ParamFormatExpression, ParamFormatAssignments, ParamFormatBBox, ParamFormatStorage:
+	default:
+		return fmt.Errorf("param '%s' has unknown format '%s'", p.Name, p.Format)
+	}
+
+	if p.Pattern != "" {
+		if _, err := regexp.Compile(p.Pattern); err != nil {
+			return fmt.Errorf("param '%s' has invalid pattern: %w", p.Name, err)
+		}
+	}
+
+	if (p.Min != nil || p.Max != nil) && p.Type != ParamTypeInteger && p.Type != ParamTypeNumber {
+		return fmt.Errorf("param '%s' has min or max, but only integer and number params can", p.Name)
+	}
+
+	if p.Min != nil && p.Max != nil && *p.Min > *p.Max {
+		return fmt.Errorf("param '%s' has min greater than max", p.Name)
+	}
+
+	if p.OneOf != "" && (p.Required || p.Default != "") {
+		return fmt.Errorf("param '%s' is one of '%s', so it cannot be required or have a default", p.Name, p.OneOf)
+	}
+
+	if p.Default != "" {
+		if err := ValidateParam(p.Proto(), p.Default); err != nil {
+			return fmt.Errorf("param '%s' has invalid default: %w", p.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Proto converts the Param into a *pb.Param.
+func (p *Param) Proto() *pb.Param {
+	return &pb.Param{
+		Name:        p.Name,
+		Type:        p.Type.String(),
+		Required:    p.Required,
+		Default:     p.Default,
+		Min:         p.Min,
+		Max:         p.Max,
+		Enum:        p.Enum,
+		Pattern:     p.Pattern,
+		Format:      p.Format.String(),
+		Description: p.Description,
+		OneOf:       p.OneOf,
+	}
+}
+
+// ValidateParam checks the given value against the schema of p.
+func ValidateParam(p *pb.Param, value string) error {
+	var (
+		number float64
+		// numeric is whether the value is a number,
+		// which only then has a min and max
+		numeric bool
+	)
+
+	switch ParamType(p.GetType()) {
+	case ParamTypeInteger:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		number, numeric = float64(i), true
+	case ParamTypeNumber:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		number, numeric = f, true
+	case ParamTypeBoolean:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("must be a boolean")
+		}
+	}
+
+	if numeric && p.Min != nil && number < p.GetMin() {
+		return fmt.Errorf("must be at least %v", p.GetMin())
+	}
+
+	if numeric && p.Max != nil && number > p.GetMax() {
+		return fmt.Errorf("must be at most %v", p.GetMax())
+	}
+
+	if len(p.GetEnum()) > 0 && !js.Includes(p.GetEnum(), value) {
+		return fmt.Errorf("must be one of '%s'", strings.Join(p.GetEnum(), "', '"))
+	}
+
+	if p.GetPattern() != "" {
+		if matched, err := regexp.MatchString("^(?:"+p.GetPattern()+")$", value); err != nil || !matched {
+			return fmt.Errorf("must match pattern '%s'", p.GetPattern())
+		}
+	}
+
+	switch ParamFormat(p.GetFormat()) {
+	case ParamFormatEPSG:
+		if code, err := strconv.Atoi(value); err != nil || code <= 0 {
+			return fmt.Errorf("must be an EPSG code")
+		}
+	case ParamFormatWKTPolygon:
+		geom, err := wkt.Unmarshal(value)
+		if err != nil {
+			return fmt.Errorf("must be a polygon in WKT format: %w", err)
+		}
+
+		switch geom.(type) {
+		case orb.Polygon, orb.MultiPolygon:
+		default:
+			return fmt.Errorf("must be a polygon in WKT format, got %s", geom.GeoJSONType())
+		}
+	case ParamFormatCSV:
+		if js.Some(strings.Split(value, ","), func(s string, _ int, _ []string) bool {
+			return strings.TrimSpace(s) == ""
+		}) {
+			return fmt.Errorf("must be a comma separated list of non-empty values")
+		}
+	case ParamFormatExpression:
+		if _, err := expr.Parse(value); err != nil {
+			return fmt.Errorf("must be a valid expression: %w", err)
+		}
+	case ParamFormatAssignments:
+		if _, err := expr.ParseAssignments(value); err != nil {
+			return fmt.Errorf("must be a list of assignments of the form column = expression separated by semicolons: %w", err)
+		}
+	case ParamFormatBBox:
+		if _, err := ParseBBox(value); err != nil {
+			return err
+		}
+	case ParamFormatStorage:
+		// ownership of the storage is checked by the API,
+		// which has the requester's namespace
+		if strings.TrimSpace(value) != value {
+			return fmt.Errorf("must be a storage ID")
+		}
+	}
+
+	return nil
+}
+
+// ParseBBox parses a bounding box of the form minx,miny,maxx,maxy.
+func ParseBBox(value string) (orb.Bound, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return orb.Bound{}, fmt.Errorf("must be a bounding box of the form minx,miny,maxx,maxy")
+	}
+
+	coords := make([]float64, len(parts))
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return orb.Bound{}, fmt.Errorf("must be a bounding box of the form minx,miny,maxx,maxy")
+		}
+		coords[i] = f
+	}
+
+	if coords[0] >= coords[2] || coords[1] >= coords[3] {
+		return orb.Bound{}, fmt.Errorf("must be a bounding box whose min is less than its max")
+	}
+
+	return orb.Bound{Min: orb.Point{coords[0], coords[1]}, Max: orb.Point{coords[2], coords[3]}}, nil
+}
+
+// FieldError describes why the value given
+// for a param is invalid.
+type FieldError struct {
+	Param string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("param '%s' %s", e.Param, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError lists every param that
+// is invalid for a single job.
+type ValidationError struct {
+	Task   string
+	Fields []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid params for task '%s': %s", e.Task, strings.Join(e.Details(), "; "))
+}
+
+// Details returns a message for each invalid param.
+func (e *ValidationError) Details() []string {
+	return js.Map(e.Fields, func(f *FieldError, _ int, _ []*FieldError) string {
+		return f.Error()
+	})
+}
+
+// SchemaOf returns the schema of the Task's param with the given name.
+func SchemaOf(t *pb.Task, name string) *pb.Param {
+	for _, s := range t.GetSchema() {
+		if s.GetName() == name {
+			return s
+		}
+	}
+
+	// tasks from before the schema was introduced
+	// only know their params by name; require them
+	return &pb.Param{Name: name, Type: ParamTypeString.String(), Required: true}
+}
+
+// BuildArgs validates the value returned by get for each of the Task's
+// params against its schema and returns the args to run the Task with
+// in the order of its params. Params that are missing take their default.
+// Exactly one param of each group of params with the same OneOf must be given.
+// If any param is invalid, a *ValidationError listing all of them is returned.
+func BuildArgs(t *pb.Task, get func(string) string) ([]string, error) {
+	var (
+		args   = make([]string, len(t.GetParams()))
+		fields = []*FieldError{}
+		// groups are the names of the params of each OneOf,
+		// in the order that the OneOfs first appear
+		groups  = map[string][]string{}
+		oneOfs  = []string{}
+		givenIn = map[string][]string{}
+	)
+
+	for i, name := range t.GetParams() {
+		p := SchemaOf(t, name)
+		value := get(name)
+		if oneOf := p.GetOneOf(); oneOf != "" {
+			if _, ok := groups[oneOf]; !ok {
+				oneOfs = append(oneOfs, oneOf)
+			}
+			groups[oneOf] = append(groups[oneOf], name)
+			if value != "" {
+				givenIn[oneOf] = append(givenIn[oneOf], name)
+			}
+		}
+
+		switch {
+		case value == "" && p.GetDefault() != "":
+			value = p.GetDefault()
+		case value == "" && p.GetRequired():
+			fields = append(fields, &FieldError{Param: name, Err: fmt.Errorf("is required")})
+			continue
+		case value == "":
+			continue
+		}
+
+		if err := ValidateParam(p, value); err != nil {
+			fields = append(fields, &FieldError{Param: name, Err: err})
+			continue
+		}
+
+		args[i] = value
+	}
+
+	for _, oneOf := range oneOfs {
+		given := givenIn[oneOf]
+		switch {
+		case len(given) == 0:
+			fields = append(fields, &FieldError{Param: groups[oneOf][0], Err: fmt.Errorf("or one of '%s' is required", strings.Join(groups[oneOf][1:], "', '"))})
+		case len(given) > 1:
+			for _, name := range given[1:] {
+				fields = append(fields, &FieldError{Param: name, Err: fmt.Errorf("cannot be given with '%s'", given[0])})
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return nil, &ValidationError{Task: t.GetType(), Fields: fields}
+	}
+
+	return args, nil
+}
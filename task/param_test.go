@@ -0,0 +1,229 @@
+package task_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/paulmach/orb"
+)
+
+func TestParseBBox(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    orb.Bound
+		wantErr bool
+	}{
+		{value: "-97.8,30.2,-97.6,30.4", want: orb.Bound{Min: orb.Point{-97.8, 30.2}, Max: orb.Point{-97.6, 30.4}}},
+		{value: " 0, 0 ,1,1", want: orb.Bound{Min: orb.Point{0, 0}, Max: orb.Point{1, 1}}},
+		{value: "0,0,1", wantErr: true},
+		{value: "0,0,1,1,1", wantErr: true},
+		{value: "0,0,one,1", wantErr: true},
+		{value: "1,0,0,1", wantErr: true},
+		{value: "0,1,1,1", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := task.ParseBBox(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBBox() = %v, want error %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseBBox() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateParam(t *testing.T) {
+	var (
+		zero = 0.0
+		ten  = 10.0
+	)
+
+	tests := []struct {
+		name    string
+		param   *pb.Param
+		value   string
+		wantErr bool
+	}{
+		{name: "integer", param: &pb.Param{Type: "integer"}, value: "4"},
+		{name: "not an integer", param: &pb.Param{Type: "integer"}, value: "4.5", wantErr: true},
+		{name: "number", param: &pb.Param{Type: "number"}, value: "4.5"},
+		{name: "not a number", param: &pb.Param{Type: "number"}, value: "four", wantErr: true},
+		{name: "boolean", param: &pb.Param{Type: "boolean"}, value: "true"},
+		{name: "not a boolean", param: &pb.Param{Type: "boolean"}, value: "yes", wantErr: true},
+		{name: "within min and max", param: &pb.Param{Type: "number", Min: &zero, Max: &ten}, value: "10"},
+		{name: "below min", param: &pb.Param{Type: "number", Min: &zero}, value: "-1", wantErr: true},
+		{name: "above max", param: &pb.Param{Type: "integer", Max: &ten}, value: "11", wantErr: true},
+		// min and max only apply to numbers, whatever else a schema says
+		{name: "string with min", param: &pb.Param{Type: "string", Min: &ten}, value: "a"},
+		{name: "storage with min", param: &pb.Param{Type: "string", Format: "storage", Min: &ten}, value: "id"},
+		{name: "boolean with max", param: &pb.Param{Type: "boolean", Max: &zero}, value: "true"},
+		{name: "enum", param: &pb.Param{Type: "string", Enum: []string{"a", "b"}}, value: "b"},
+		{name: "not in enum", param: &pb.Param{Type: "string", Enum: []string{"a", "b"}}, value: "c", wantErr: true},
+		{name: "pattern", param: &pb.Param{Type: "string", Pattern: "[a-z]+"}, value: "abc"},
+		{name: "pattern is anchored", param: &pb.Param{Type: "string", Pattern: "[a-z]+"}, value: "abc1", wantErr: true},
+		{name: "epsg", param: &pb.Param{Type: "integer", Format: "epsg"}, value: "4326"},
+		{name: "not epsg", param: &pb.Param{Type: "integer", Format: "epsg"}, value: "-4326", wantErr: true},
+		{name: "wkt polygon", param: &pb.Param{Format: "wkt-polygon"}, value: "POLYGON((0 0,1 0,1 1,0 0))"},
+		{name: "wkt multipolygon", param: &pb.Param{Format: "wkt-polygon"}, value: "MULTIPOLYGON(((0 0,1 0,1 1,0 0)))"},
+		{name: "wkt point", param: &pb.Param{Format: "wkt-polygon"}, value: "POINT(0 0)", wantErr: true},
+		{name: "csv", param: &pb.Param{Format: "csv"}, value: "a,b"},
+		{name: "csv with empty value", param: &pb.Param{Format: "csv"}, value: "a,,b", wantErr: true},
+		{name: "expression", param: &pb.Param{Format: "expression"}, value: "a > 1 AND b IS NULL"},
+		{name: "invalid expression", param: &pb.Param{Format: "expression"}, value: "a >", wantErr: true},
+		{name: "assignments", param: &pb.Param{Format: "assignments"}, value: "a = 1; b = a || 'x'"},
+		{name: "invalid assignments", param: &pb.Param{Format: "assignments"}, value: "a 1", wantErr: true},
+		{name: "bbox", param: &pb.Param{Format: "bbox"}, value: "0,0,1,1"},
+		{name: "invalid bbox", param: &pb.Param{Format: "bbox"}, value: "0,0,1", wantErr: true},
+		{name: "storage", param: &pb.Param{Format: "storage"}, value: "2e1c6b3a-5f4e-4d7a-9b8c-1a2b3c4d5e6f"},
+		{name: "storage with spaces", param: &pb.Param{Format: "storage"}, value: " id ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := task.ValidateParam(tt.param, tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateParam() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildArgs(t *testing.T) {
+	one := 1.0
+	clip := &pb.Task{
+		Type:   "clip",
+		Params: []string{"bbox", "polygon", "overlay", "buffer", "mode"},
+		Schema: []*pb.Param{
+			{Name: "bbox", Type: "string", Format: "bbox", OneOf: "mask"},
+			{Name: "polygon", Type: "string", Format: "wkt-polygon", OneOf: "mask"},
+			{Name: "overlay", Type: "string", Format: "storage", OneOf: "mask"},
+			{Name: "buffer", Type: "number", Min: &one},
+			{Name: "mode", Type: "string", Required: true, Enum: []string{"inside", "outside"}, Default: "inside"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		values     map[string]string
+		want       []string
+		wantFields []string
+	}{
+		{
+			name:   "one of a group with defaults",
+			values: map[string]string{"bbox": "0,0,1,1"},
+			want:   []string{"0,0,1,1", "", "", "", "inside"},
+		},
+		{
+			name:   "another of a group",
+			values: map[string]string{"overlay": "id", "buffer": "2", "mode": "outside"},
+			want:   []string{"", "", "id", "2", "outside"},
+		},
+		{
+			name:       "none of a group",
+			values:     map[string]string{},
+			wantFields: []string{"param 'bbox' or one of 'polygon', 'overlay' is required"},
+		},
+		{
+			name:   "more than one of a group",
+			values: map[string]string{"bbox": "0,0,1,1", "polygon": "POLYGON((0 0,1 0,1 1,0 0))", "overlay": "id"},
+			wantFields: []string{
+				"param 'polygon' cannot be given with 'bbox'",
+				"param 'overlay' cannot be given with 'bbox'",
+			},
+		},
+		{
+			name:   "every invalid param",
+			values: map[string]string{"bbox": "0,0", "buffer": "0", "mode": "around"},
+			wantFields: []string{
+				"param 'bbox' must be a bounding box of the form minx,miny,maxx,maxy",
+				"param 'buffer' must be at least 1",
+				"param 'mode' must be one of 'inside', 'outside'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := task.BuildArgs(clip, func(name string) string {
+				return tt.values[name]
+			})
+
+			var valErr *task.ValidationError
+			switch {
+			case tt.wantFields == nil && err != nil:
+				t.Fatalf("BuildArgs() = %v", err)
+			case tt.wantFields == nil:
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("BuildArgs() = %q, want %q", got, tt.want)
+				}
+			case !errors.As(err, &valErr):
+				t.Fatalf("BuildArgs() = %v, want *task.ValidationError", err)
+			case !reflect.DeepEqual(valErr.Details(), tt.wantFields):
+				t.Errorf("BuildArgs() = %q, want %q", valErr.Details(), tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestBuildArgsWithoutSchema(t *testing.T) {
+	legacy := &pb.Task{Type: "legacy", Params: []string{"a"}}
+
+	if _, err := task.BuildArgs(legacy, func(string) string { return "" }); err == nil {
+		t.Error("BuildArgs() = nil, want params without a schema to be required")
+	}
+
+	got, err := task.BuildArgs(legacy, func(string) string { return "x" })
+	if err != nil || !reflect.DeepEqual(got, []string{"x"}) {
+		t.Errorf("BuildArgs() = %q, %v, want [x]", got, err)
+	}
+}
+
+func TestParamValidate(t *testing.T) {
+	var (
+		zero = 0.0
+		one  = 1.0
+	)
+
+	tests := []struct {
+		name    string
+		param   *task.Param
+		wantErr bool
+	}{
+		{name: "defaults to string", param: &task.Param{Name: "a"}},
+		{name: "no name", param: &task.Param{}, wantErr: true},
+		{name: "unknown type", param: &task.Param{Name: "a", Type: "date"}, wantErr: true},
+		{name: "unknown format", param: &task.Param{Name: "a", Format: "email"}, wantErr: true},
+		{name: "invalid pattern", param: &task.Param{Name: "a", Pattern: "("}, wantErr: true},
+		{name: "number with min and max", param: &task.Param{Name: "a", Type: "number", Min: &zero, Max: &one}},
+		{name: "integer with min", param: &task.Param{Name: "a", Type: "integer", Min: &one}},
+		{name: "min greater than max", param: &task.Param{Name: "a", Type: "number", Min: &one, Max: &zero}, wantErr: true},
+		{name: "string with min", param: &task.Param{Name: "a", Type: "string", Min: &one}, wantErr: true},
+		{name: "boolean with max", param: &task.Param{Name: "a", Type: "boolean", Max: &one}, wantErr: true},
+		{name: "untyped with min", param: &task.Param{Name: "a", Min: &one}, wantErr: true},
+		{name: "required one of", param: &task.Param{Name: "a", OneOf: "b", Required: true}, wantErr: true},
+		{name: "one of with default", param: &task.Param{Name: "a", OneOf: "b", Default: "c"}, wantErr: true},
+		{name: "valid default", param: &task.Param{Name: "a", Type: "integer", Min: &one, Default: "2"}},
+		{name: "invalid default", param: &task.Param{Name: "a", Type: "integer", Min: &one, Default: "0"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.param.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuiltinManifests(t *testing.T) {
+	if _, err := task.LoadManifests(); err != nil {
+		t.Fatalf("LoadManifests() = %v", err)
+	}
+}
@@ -0,0 +1,368 @@
+package simplify
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+	"github.com/paulmach/orb/simplify"
+)
+
+// Algorithm is a line simplification algorithm.
+type Algorithm string
+
+const (
+	// AlgorithmDouglasPeucker removes points that are within
+	// tolerance of the line between the points that are kept.
+	AlgorithmDouglasPeucker Algorithm = "douglas-peucker"
+	// AlgorithmVisvalingam removes points whose triangle with their
+	// neighbors has an area less than tolerance squared.
+	AlgorithmVisvalingam Algorithm = "visvalingam"
+)
+
+// Algorithms are all of the supported Algorithms.
+var Algorithms = []Algorithm{AlgorithmDouglasPeucker, AlgorithmVisvalingam}
+
+func (a Algorithm) String() string {
+	return string(a)
+}
+
+// ParseAlgorithm returns the Algorithm with the given name,
+// defaulting to AlgorithmDouglasPeucker.
+func ParseAlgorithm(s string) (Algorithm, error) {
+	if s == "" {
+		return AlgorithmDouglasPeucker, nil
+	}
+
+	for _, a := range Algorithms {
+		if a.String() == s {
+			return a, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown algorithm '%s'", s)
+}
+
+// attempts is how many times a topology-preserving simplification halves its
+// tolerance to avoid changing the topology of a geometry before giving up.
+const attempts = 8
+
+type simplifier interface {
+	LineString(orb.LineString) orb.LineString
+	Ring(orb.Ring) orb.Ring
+}
+
+func newSimplifier(algorithm Algorithm, tolerance float64, minPoints int) simplifier {
+	if algorithm == AlgorithmVisvalingam {
+		return simplify.Visvalingam(tolerance*tolerance, minPoints)
+	}
+
+	return simplify.DouglasPeucker(tolerance)
+}
+
+// Geometry simplifies g, leaving it unmodified. Points are never simplified.
+//
+// Without preserveTopology, rings that collapse are dropped along with their
+// polygons if they are shells, and nil is returned if nothing is left.
+// With it, the tolerance is reduced for geometries that would otherwise lose
+// parts, have rings or lines cross that did not before, or have holes escape
+// their shells, and geometries that cannot be simplified safely are kept as-is.
+func Geometry(g orb.Geometry, algorithm Algorithm, tolerance float64, preserveTopology bool) orb.Geometry {
+	if !preserveTopology {
+		return simplifyGeometry(g, newSimplifier(algorithm, tolerance, 2), newSimplifier(algorithm, tolerance, 0))
+	}
+
+	crossings := countCrossings(g)
+	for i := 0; i < attempts; i, tolerance = i+1, tolerance/2 {
+		s := simplifyGeometry(g, newSimplifier(algorithm, tolerance, 2), newSimplifier(algorithm, tolerance, 4))
+		if preserved(g, s) && countCrossings(s) <= crossings {
+			return s
+		}
+	}
+
+	return g
+}
+
+// simplifyGeometry simplifies a clone of g, simplifying lines with ls and rings with rs.
+func simplifyGeometry(g orb.Geometry, ls, rs simplifier) orb.Geometry {
+	switch g := g.(type) {
+	case orb.LineString:
+		return ls.LineString(g.Clone())
+	case orb.MultiLineString:
+		mls := make(orb.MultiLineString, len(g))
+		for i, l := range g {
+			mls[i] = ls.LineString(l.Clone())
+		}
+
+		return mls
+	case orb.Ring:
+		if p := simplifyPolygon(orb.Polygon{g}, rs); p != nil {
+			return p[0]
+		}
+	case orb.Polygon:
+		if p := simplifyPolygon(g, rs); p != nil {
+			return p
+		}
+	case orb.MultiPolygon:
+		mp := orb.MultiPolygon{}
+		for _, p := range g {
+			if p = simplifyPolygon(p, rs); p != nil {
+				mp = append(mp, p)
+			}
+		}
+
+		if len(mp) > 0 {
+			return mp
+		}
+	case orb.Collection:
+		c := orb.Collection{}
+		for _, m := range g {
+			if m = simplifyGeometry(m, ls, rs); m != nil {
+				c = append(c, m)
+			}
+		}
+
+		if len(c) > 0 {
+			return c
+		}
+	default:
+		return g
+	}
+
+	return nil
+}
+
+// simplifyPolygon simplifies each ring of p, dropping those that collapse.
+// It returns nil if the shell collapses.
+func simplifyPolygon(p orb.Polygon, rs simplifier) orb.Polygon {
+	out := orb.Polygon{}
+	for i, r := range p {
+		if r = rs.Ring(r.Clone()); len(r) < 4 {
+			if i == 0 {
+				return nil
+			}
+
+			continue
+		}
+
+		out = append(out, r)
+	}
+
+	return out
+}
+
+// preserved reports whether s has every part of g
+// and every hole in s is still in its shell.
+func preserved(g, s orb.Geometry) bool {
+	switch g := g.(type) {
+	case orb.MultiLineString:
+		s, ok := s.(orb.MultiLineString)
+		return ok && len(s) == len(g)
+	case orb.Ring:
+		s, ok := s.(orb.Ring)
+		return ok && len(s) >= 4
+	case orb.Polygon:
+		s, ok := s.(orb.Polygon)
+		return ok && polygonPreserved(g, s)
+	case orb.MultiPolygon:
+		s, ok := s.(orb.MultiPolygon)
+		if !ok || len(s) != len(g) {
+			return false
+		}
+
+		for i := range g {
+			if !polygonPreserved(g[i], s[i]) {
+				return false
+			}
+		}
+	case orb.Collection:
+		s, ok := s.(orb.Collection)
+		if !ok || len(s) != len(g) {
+			return false
+		}
+
+		for i := range g {
+			if !preserved(g[i], s[i]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func polygonPreserved(g, s orb.Polygon) bool {
+	if len(s) != len(g) {
+		return false
+	}
+
+	for _, h := range s[1:] {
+		// the first point of a ring is always kept, so if it is still in
+		// the shell and no rings cross, the whole hole is still in the shell
+		if !planar.RingContains(s[0], h[0]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type segment struct {
+	a, b orb.Point
+	// part identifies the line or ring that the segment belongs to,
+	// i its index in it and n how many segments it has
+	part, i, n int
+	closed     bool
+}
+
+func (s *segment) minX() float64 {
+	if s.a[0] < s.b[0] {
+		return s.a[0]
+	}
+
+	return s.b[0]
+}
+
+func (s *segment) maxX() float64 {
+	if s.a[0] > s.b[0] {
+		return s.a[0]
+	}
+
+	return s.b[0]
+}
+
+// adjacent reports whether s and t share a point by way of their part.
+func (s *segment) adjacent(t *segment) bool {
+	if s.part != t.part {
+		return false
+	}
+
+	d := s.i - t.i
+	return d == 1 || d == -1 || (s.closed && (d == s.n-1 || d == 1-s.n))
+}
+
+// countCrossings counts the pairs of segments in the lines and rings of g
+// that intersect, other than those that are next to each other.
+func countCrossings(g orb.Geometry) int {
+	var (
+		segments = []*segment{}
+		part     = 0
+	)
+	addLine := func(ps []orb.Point) {
+		n := len(ps) - 1
+		for i := 0; i < n; i++ {
+			segments = append(segments, &segment{a: ps[i], b: ps[i+1], part: part, i: i, n: n, closed: ps[0] == ps[n]})
+		}
+		part++
+	}
+
+	var add func(orb.Geometry)
+	add = func(g orb.Geometry) {
+		switch g := g.(type) {
+		case orb.LineString:
+			addLine(g)
+		case orb.MultiLineString:
+			for _, l := range g {
+				addLine(l)
+			}
+		case orb.Ring:
+			addLine(g)
+		case orb.Polygon:
+			for _, r := range g {
+				addLine(r)
+			}
+		case orb.MultiPolygon:
+			for _, p := range g {
+				for _, r := range p {
+					addLine(r)
+				}
+			}
+		case orb.Collection:
+			for _, m := range g {
+				add(m)
+			}
+		}
+	}
+	add(g)
+
+	// sweep from left to right, only comparing
+	// segments whose extents overlap in x
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].minX() < segments[j].minX()
+	})
+
+	crossings := 0
+	for i, s := range segments {
+		maxX := s.maxX()
+		for _, t := range segments[i+1:] {
+			if t.minX() > maxX {
+				break
+			}
+
+			if !s.adjacent(t) && intersects(s.a, s.b, t.a, t.b) {
+				crossings++
+			}
+		}
+	}
+
+	return crossings
+}
+
+// intersects reports whether segments ab and cd have any point in common.
+func intersects(a, b, c, d orb.Point) bool {
+	var (
+		o1 = orientation(a, b, c)
+		o2 = orientation(a, b, d)
+		o3 = orientation(c, d, a)
+		o4 = orientation(c, d, b)
+	)
+
+	switch {
+	case o1 != o2 && o3 != o4:
+		return true
+	case o1 == 0 && onSegment(a, c, b),
+		o2 == 0 && onSegment(a, d, b),
+		o3 == 0 && onSegment(c, a, d),
+		o4 == 0 && onSegment(c, b, d):
+		return true
+	}
+
+	return false
+}
+
+// orientation returns 0 if p, q and r are collinear,
+// 1 if they turn clockwise and -1 if counterclockwise.
+func orientation(p, q, r orb.Point) int {
+	v := (q[1]-p[1])*(r[0]-q[0]) - (q[0]-p[0])*(r[1]-q[1])
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+
+	return 0
+}
+
+// onSegment reports whether q, which is collinear with p and r, lies on pr.
+func onSegment(p, q, r orb.Point) bool {
+	return q[0] <= maxf(p[0], r[0]) && q[0] >= minf(p[0], r[0]) &&
+		q[1] <= maxf(p[1], r[1]) && q[1] >= minf(p[1], r[1])
+}
+
+func minf(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func maxf(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,61 @@
+// Package simplify implements the simplify Task, which reduces the number
+// of points in the lines and polygons of features, e.g. to make them
+// lighter to ship to web maps.
+package simplify
+
+import (
+	"context"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/logsquaredn/rototiller/volume"
+	"mellium.im/sysexit"
+)
+
+const (
+	// Name is the name of the simplify Task.
+	Name = "simplify"
+
+	ParamTolerance        = "tolerance"
+	ParamAlgorithm        = "algorithm"
+	ParamPreserveTopology = "preserve-topology"
+)
+
+func init() {
+	task.Register(Name, task.TaskFunc(Run))
+}
+
+// Run simplifies the geometry of each feature of the input.
+func Run(_ context.Context, input volume.Volume, output volume.Directory, args task.Args) error {
+	tolerance, err := args.Float(ParamTolerance)
+	if err != nil {
+		return err
+	}
+
+	if tolerance < 0 {
+		return task.Errorf(sysexit.ErrConfig, "param '%s' must not be negative", ParamTolerance)
+	}
+
+	algorithm, err := ParseAlgorithm(args.String(ParamAlgorithm))
+	if err != nil {
+		return task.NewErr(sysexit.ErrConfig, err)
+	}
+
+	preserveTopology, err := args.Bool(ParamPreserveTopology)
+	if err != nil {
+		return err
+	}
+
+	fc, err := features.Read(input)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fc.Features {
+		if f.Geometry != nil {
+			f.Geometry = Geometry(f.Geometry, algorithm, tolerance, preserveTopology)
+		}
+	}
+
+	return features.Write(output, fc)
+}
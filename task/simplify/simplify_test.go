@@ -0,0 +1,163 @@
+package simplify_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/encoding/shapefile"
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/logsquaredn/rototiller/task/simplify"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// golden is where the input and expected outputs of the simplify task live.
+var golden = filepath.Join("..", "..", "testdata", "geojson", "simplify")
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name             string
+		tolerance        string
+		algorithm        string
+		preserveTopology string
+		golden           string
+	}{
+		{
+			name:      "douglas-peucker",
+			tolerance: "1",
+			algorithm: "douglas-peucker",
+			golden:    "douglas-peucker.json",
+		},
+		{
+			name:      "visvalingam",
+			tolerance: "1",
+			algorithm: "visvalingam",
+			golden:    "visvalingam.json",
+		},
+		{
+			name:             "preserve-topology",
+			tolerance:        "2",
+			algorithm:        "douglas-peucker",
+			preserveTopology: "true",
+			golden:           "preserve-topology.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := volume.Directory(t.TempDir())
+			args := task.NewArgs(
+				[]string{simplify.ParamTolerance, simplify.ParamAlgorithm, simplify.ParamPreserveTopology},
+				[]string{tt.tolerance, tt.algorithm, tt.preserveTopology},
+			)
+
+			if err := simplify.Run(context.Background(), input(t), output, args); err != nil {
+				t.Fatalf("Run() = %v", err)
+			}
+
+			want := read(t, filepath.Join(golden, tt.golden))
+			assertEqual(t, want, read(t, filepath.Join(string(output), features.OutputJSON)))
+
+			b, err := os.ReadFile(filepath.Join(string(output), features.OutputZip))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// a shapefile holds one family of geometry, so
+			// features are split across a layer for each
+			layers, err := shapefile.Layers(b)
+			if err != nil {
+				t.Fatalf("shapefile.Layers() = %v", err)
+			}
+
+			n := 0
+			for _, layer := range layers {
+				fc, err := shapefile.UnmarshalLayer(b, layer)
+				if err != nil {
+					t.Fatalf("shapefile.UnmarshalLayer(%s) = %v", layer, err)
+				}
+
+				n += len(fc.Features)
+			}
+
+			if n != len(want.Features) {
+				t.Fatalf("shapefile output has %d features, want %d", n, len(want.Features))
+			}
+		})
+	}
+}
+
+func TestRunInvalidArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		tolerance string
+		algorithm string
+	}{
+		{name: "negative tolerance", tolerance: "-1"},
+		{name: "unknown algorithm", tolerance: "1", algorithm: "ramer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := task.NewArgs(
+				[]string{simplify.ParamTolerance, simplify.ParamAlgorithm},
+				[]string{tt.tolerance, tt.algorithm},
+			)
+
+			if err := simplify.Run(context.Background(), input(t), volume.Directory(t.TempDir()), args); err == nil {
+				t.Fatal("Run() = nil, want error")
+			}
+		})
+	}
+}
+
+func input(t *testing.T) volume.Volume {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join(golden, "input.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return volume.New(volume.NewFile("input.json", f, int(fi.Size())))
+}
+
+func read(t *testing.T, name string) *geojson.FeatureCollection {
+	t.Helper()
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fc, err := geojson.UnmarshalFeatureCollection(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fc
+}
+
+func assertEqual(t *testing.T, want, got *geojson.FeatureCollection) {
+	t.Helper()
+
+	if len(got.Features) != len(want.Features) {
+		t.Fatalf("got %d features, want %d", len(got.Features), len(want.Features))
+	}
+
+	for i, f := range got.Features {
+		if !orb.Equal(f.Geometry, want.Features[i].Geometry) {
+			t.Errorf("feature %d: got geometry %v, want %v", i, f.Geometry, want.Features[i].Geometry)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Package spatialjoin implements the spatialjoin Task, which attaches the
+// properties of the features of another storage to the features of the
+// input that they intersect.
+package spatialjoin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/logsquaredn/rototiller/task/overlay"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb/geojson"
+	"mellium.im/sysexit"
+)
+
+const (
+	// Name is the name of the spatialjoin Task.
+	Name = "spatialjoin"
+
+	ParamMode          = "mode"
+	ParamKeepUnmatched = "keep-unmatched"
+)
+
+// Mode is how a feature is joined to more than one overlay feature.
+type Mode string
+
+const (
+	// ModeOneToOne joins each feature to the first
+	// overlay feature that it intersects.
+	ModeOneToOne Mode = "one-to-one"
+	// ModeOneToMany outputs a copy of each feature for
+	// each overlay feature that it intersects.
+	ModeOneToMany Mode = "one-to-many"
+)
+
+// Modes are all of the supported Modes.
+var Modes = []Mode{ModeOneToOne, ModeOneToMany}
+
+func (m Mode) String() string {
+	return string(m)
+}
+
+// ParseMode returns the Mode with the given name,
+// defaulting to ModeOneToOne.
+func ParseMode(s string) (Mode, error) {
+	if s == "" {
+		return ModeOneToOne, nil
+	}
+
+	for _, m := range Modes {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown mode '%s'", s)
+}
+
+func init() {
+	task.Register(Name, task.TaskFunc(Run))
+}
+
+// Run adds the properties of the overlay features that intersect each
+// feature of the input to it, prefixed by the prefix param, according to
+// the mode param. Geometries are left as-is. Features that intersect
+// nothing are kept unless the keep-unmatched param is false.
+func Run(_ context.Context, input volume.Volume, output volume.Directory, args task.Args) error {
+	mode, err := ParseMode(args.String(ParamMode))
+	if err != nil {
+		return task.NewErr(sysexit.ErrConfig, err)
+	}
+
+	keepUnmatched, err := args.Bool(ParamKeepUnmatched)
+	if err != nil {
+		return err
+	}
+
+	fc, err := features.Read(input)
+	if err != nil {
+		return err
+	}
+
+	ov, err := overlay.Read(args)
+	if err != nil {
+		return err
+	}
+
+	var (
+		prefix = args.String(overlay.ParamPrefix)
+		out    = geojson.NewFeatureCollection()
+	)
+	for i, f := range fc.Features {
+		g, err := features.ToGeom(f.Geometry)
+		if err != nil {
+			return task.Errorf(sysexit.ErrData, "feature %d: %w", i, err)
+		}
+
+		js := []int{}
+		if f.Geometry != nil {
+			js = ov.Intersecting(g)
+		}
+
+		if len(js) == 0 {
+			if keepUnmatched {
+				out.Append(f)
+			}
+
+			continue
+		}
+
+		if mode == ModeOneToOne {
+			js = js[:1]
+		}
+
+		for _, j := range js {
+			out.Append(&geojson.Feature{
+				ID:         f.ID,
+				Type:       f.Type,
+				BBox:       f.BBox,
+				Geometry:   f.Geometry,
+				Properties: overlay.Properties(f, ov.Features[j], prefix),
+			})
+		}
+	}
+
+	return features.Write(output, out)
+}
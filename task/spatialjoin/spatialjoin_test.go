@@ -0,0 +1,73 @@
+package spatialjoin_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/overlay"
+	"github.com/logsquaredn/rototiller/task/spatialjoin"
+	"github.com/logsquaredn/rototiller/task/tasktest"
+	"github.com/logsquaredn/rototiller/volume"
+	"mellium.im/sysexit"
+)
+
+// golden is where the input, overlay and expected outputs of the overlay Tasks live.
+var golden = filepath.Join("..", "..", "testdata", "geojson", "overlay")
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          string
+		keepUnmatched string
+		golden        string
+	}{
+		{
+			name:          "one to one",
+			mode:          spatialjoin.ModeOneToOne.String(),
+			keepUnmatched: "true",
+			golden:        "spatialjoin-one-to-one.json",
+		},
+		{
+			name:          "one to many",
+			mode:          spatialjoin.ModeOneToMany.String(),
+			keepUnmatched: "true",
+			golden:        "spatialjoin-one-to-many.json",
+		},
+		{
+			name:          "drop unmatched",
+			mode:          spatialjoin.ModeOneToMany.String(),
+			keepUnmatched: "false",
+			golden:        "spatialjoin-drop-unmatched.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := task.NewArgs(
+				[]string{task.ParamOverlay, overlay.ParamPrefix, spatialjoin.ParamMode, spatialjoin.ParamKeepUnmatched},
+				[]string{"overlay-id", "ov_", tt.mode, tt.keepUnmatched},
+			).WithVolume(task.ParamOverlay, tasktest.Volume(t, filepath.Join(golden, "overlay.json")))
+
+			output := volume.Directory(t.TempDir())
+			if err := spatialjoin.Run(context.Background(), tasktest.Volume(t, filepath.Join(golden, "input.json")), output, args); err != nil {
+				t.Fatalf("Run() = %v", err)
+			}
+
+			tasktest.Golden(t, filepath.Join(golden, tt.golden), output)
+		})
+	}
+}
+
+func TestRunInvalidMode(t *testing.T) {
+	args := task.NewArgs(
+		[]string{task.ParamOverlay, spatialjoin.ParamMode, spatialjoin.ParamKeepUnmatched},
+		[]string{"overlay-id", "many-to-many", "true"},
+	).WithVolume(task.ParamOverlay, tasktest.Volume(t, filepath.Join(golden, "overlay.json")))
+
+	err := spatialjoin.Run(context.Background(), tasktest.Volume(t, filepath.Join(golden, "input.json")), volume.Directory(t.TempDir()), args)
+	if code := task.ExitCode(err); code != sysexit.ErrConfig {
+		t.Errorf("Run() = %v, exit code %d, want %d", err, code, sysexit.ErrConfig)
+	}
+}
@@ -0,0 +1,221 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/logsquaredn/rototiller/task/expr"
+	"github.com/logsquaredn/rototiller/volume"
+	"mellium.im/sysexit"
+)
+
+// Task is a task that runs in-process in the worker instead of
+// as a separate executable. It reads its input from the input volume,
+// which holds a single file, and writes its output to the output volume.
+//
+// To be run in the same way as an executable, a Task's errors should
+// wrap a sysexit.Code, e.g. via NewErr. Errors that do not are treated
+// as if the executable exited with an unknown code.
+type Task interface {
+	Run(ctx context.Context, input volume.Volume, output volume.Directory, args Args) error
+}
+
+// TaskFunc adapts an ordinary function to a Task.
+type TaskFunc func(context.Context, volume.Volume, volume.Directory, Args) error
+
+func (f TaskFunc) Run(ctx context.Context, input volume.Volume, output volume.Directory, args Args) error {
+	return f(ctx, input, output, args)
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Task{}
+)
+
+// Register makes a Task available to the worker by the given name,
+// which must match the name of its manifest. It panics if called twice
+// with the same name or with a nil Task. It is meant to be called from
+// the init function of the package that implements the Task.
+func Register(name string, t Task) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if t == nil {
+		panic("task: Register task is nil")
+	}
+
+	if _, ok := registry[name]; ok {
+		panic("task: Register called twice for task " + name)
+	}
+
+	registry[name] = t
+}
+
+// Lookup returns the Task registered by the given name, if any.
+func Lookup(name string) (Task, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	t, ok := registry[name]
+	return t, ok
+}
+
+// Registered returns the sorted names of the registered Tasks.
+func Registered() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Error is an error from a Task along with the exit code
+// that an equivalent executable would have exited with.
+type Error struct {
+	Code sysexit.Code
+	Err  error
+}
+
+// NewErr wraps err with code.
+func NewErr(code sysexit.Code, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{Code: code, Err: err}
+}
+
+// Errorf formats an error and wraps it with code.
+func Errorf(code sysexit.Code, format string, a ...any) error {
+	return NewErr(code, fmt.Errorf(format, a...))
+}
+
+// DataErrorf formats an error that a Task ran into over the features of its
+// input and wraps it with sysexit.ErrData, which marks the input unusable,
+// unless it wraps an *expr.EvalError. That is the fault of an expression in
+// the job's params rather than of the input, e.g. one that adds a number to
+// a string, so it is wrapped with sysexit.ErrConfig instead.
+func DataErrorf(format string, a ...any) error {
+	err := fmt.Errorf(format, a...)
+
+	evalErr := &expr.EvalError{}
+	if errors.As(err, &evalErr) {
+		return NewErr(sysexit.ErrConfig, err)
+	}
+
+	return NewErr(sysexit.ErrData, err)
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the sysexit.Code that err carries. A nil error
+// is sysexit.Ok and any other error is sysexit.ErrSoftware.
+func ExitCode(err error) sysexit.Code {
+	if err == nil {
+		return sysexit.Ok
+	}
+
+	e := &Error{}
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	return sysexit.ErrSoftware
+}
+
+// Args are the values of a Task's params for a single job. They have
+// already been validated against the Task's schema, so the typed getters
+// only fail if a Task asks for a param as a type other than its own.
+type Args struct {
+	values  map[string]string
+	volumes map[string]volume.Volume
+}
+
+// NewArgs pairs the names of a Task's params with a job's args.
+func NewArgs(params, args []string) Args {
+	a := Args{values: map[string]string{}, volumes: map[string]volume.Volume{}}
+	for i, param := range params {
+		if i < len(args) {
+			a.values[param] = args[i]
+		}
+	}
+
+	return a
+}
+
+// WithVolume makes the content of the storage that the param
+// refers to available to the Task as the given Volume.
+func (a Args) WithVolume(name string, v volume.Volume) Args {
+	if a.volumes == nil {
+		a.volumes = map[string]volume.Volume{}
+	}
+	a.volumes[name] = v
+
+	return a
+}
+
+// Volume returns the content of the storage that the param
+// refers to, if it has format storage and a value was given.
+func (a Args) Volume(name string) (volume.Volume, bool) {
+	v, ok := a.volumes[name]
+	return v, ok
+}
+
+// Has reports whether a value was given for the param.
+func (a Args) Has(name string) bool {
+	return a.values[name] != ""
+}
+
+// String returns the value of the param.
+func (a Args) String(name string) string {
+	return a.values[name]
+}
+
+// Int returns the value of the param as an integer.
+func (a Args) Int(name string) (int64, error) {
+	i, err := strconv.ParseInt(a.values[name], 10, 64)
+	if err != nil {
+		return 0, Errorf(sysexit.ErrConfig, "param '%s' is not an integer", name)
+	}
+
+	return i, nil
+}
+
+// Float returns the value of the param as a number.
+func (a Args) Float(name string) (float64, error) {
+	f, err := strconv.ParseFloat(a.values[name], 64)
+	if err != nil {
+		return 0, Errorf(sysexit.ErrConfig, "param '%s' is not a number", name)
+	}
+
+	return f, nil
+}
+
+// Bool returns the value of the param as a boolean.
+func (a Args) Bool(name string) (bool, error) {
+	if a.values[name] == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(a.values[name])
+	if err != nil {
+		return false, Errorf(sysexit.ErrConfig, "param '%s' is not a boolean", name)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,36 @@
+package task_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/expr"
+	"mellium.im/sysexit"
+)
+
+func TestDataErrorf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want sysexit.Code
+	}{
+		{name: "data", err: errors.New("invalid geometry"), want: sysexit.ErrData},
+		{name: "expression", err: &expr.EvalError{Expr: "(name + 1)", Msg: "'+' expects numbers"}, want: sysexit.ErrConfig},
+		{name: "wrapped expression", err: fmt.Errorf("assign: %w", &expr.EvalError{}), want: sysexit.ErrConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := task.DataErrorf("feature %d: %w", 0, tt.err)
+			if got := task.ExitCode(err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("DataErrorf() = %v, want it to wrap %v", err, tt.err)
+			}
+		})
+	}
+}
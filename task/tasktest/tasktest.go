@@ -0,0 +1,113 @@
+// Package tasktest implements utilities for testing in-process Tasks
+// against golden files of the features that they are expected to output.
+package tasktest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// update makes Golden write what Tasks output to the golden files
+// instead of comparing them, e.g. `go test ./task/clip -update`.
+// The changes to them must be checked by hand before they are committed.
+var update = flag.Bool("update", false, "update golden files")
+
+// Volume returns a Volume that holds only a copy of the named file,
+// as a Task's input or as the content of a storage that a param refers to.
+func Volume(t testing.TB, name string) volume.Volume {
+	t.Helper()
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err = os.WriteFile(filepath.Join(dir, filepath.Base(name)), b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return volume.Directory(dir)
+}
+
+// Read reads the features of the named GeoJSON file.
+func Read(t testing.TB, name string) *geojson.FeatureCollection {
+	t.Helper()
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fc, err := geojson.UnmarshalFeatureCollection(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fc
+}
+
+// Output reads the features that a Task wrote to output.
+func Output(t testing.TB, output volume.Directory) *geojson.FeatureCollection {
+	t.Helper()
+
+	return Read(t, filepath.Join(string(output), features.OutputJSON))
+}
+
+// Golden compares the features that a Task wrote to output
+// to those of the named golden file, see AssertEqual.
+func Golden(t testing.TB, name string, output volume.Directory) {
+	t.Helper()
+
+	got := Output(t, output)
+	if *update {
+		b, err := json.Marshal(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf := new(bytes.Buffer)
+		if err = json.Indent(buf, b, "", "    "); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = os.WriteFile(name, append(buf.Bytes(), '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	AssertEqual(t, Read(t, name), got)
+}
+
+// AssertEqual fails t unless got has the same features as want, in the same
+// order, each with the same geometry, vertex for vertex, and properties.
+func AssertEqual(t testing.TB, want, got *geojson.FeatureCollection) {
+	t.Helper()
+
+	if len(got.Features) != len(want.Features) {
+		t.Fatalf("got %d features, want %d", len(got.Features), len(want.Features))
+	}
+
+	for i, f := range got.Features {
+		w := want.Features[i]
+		if (f.Geometry == nil) != (w.Geometry == nil) || (f.Geometry != nil && !orb.Equal(f.Geometry, w.Geometry)) {
+			t.Errorf("feature %d: got geometry %v, want %v", i, f.Geometry, w.Geometry)
+		}
+
+		if !reflect.DeepEqual(f.Properties, w.Properties) {
+			t.Errorf("feature %d: got properties %v, want %v", i, f.Properties, w.Properties)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Package union implements the union Task, which combines the features
+// of the input with the features of another storage, splitting them
+// where they overlap.
+package union
+
+import (
+	"context"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/logsquaredn/rototiller/task/overlay"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb/geojson"
+	"mellium.im/sysexit"
+)
+
+// Name is the name of the union Task.
+const Name = "union"
+
+func init() {
+	task.Register(Name, task.TaskFunc(Run))
+}
+
+// Run outputs the intersection of each pair of input and overlay features
+// that intersect, with the properties of both, followed by the parts of
+// each input feature and then of each overlay feature that are not in the
+// other storage, with only their own properties. The properties of overlay
+// features are prefixed by the prefix param. Input features without a
+// geometry are kept as-is.
+//
+// Features are expected not to overlap others of the same storage, since
+// the parts where they do are output once for each of them.
+func Run(_ context.Context, input volume.Volume, output volume.Directory, args task.Args) error {
+	fc, err := features.Read(input)
+	if err != nil {
+		return err
+	}
+
+	in, err := overlay.NewLayer(fc)
+	if err != nil {
+		return task.NewErr(sysexit.ErrData, err)
+	}
+
+	ov, err := overlay.Read(args)
+	if err != nil {
+		return err
+	}
+
+	var (
+		prefix    = args.String(overlay.ParamPrefix)
+		out       = geojson.NewFeatureCollection()
+		remainder = []*geojson.Feature{}
+	)
+	for _, f := range fc.Features {
+		if f.Geometry == nil {
+			remainder = append(remainder, f)
+		}
+	}
+
+	for i, f := range in.Features {
+		var (
+			g   = in.Geometry(i)
+			dim = f.Geometry.Dimensions()
+			js  = ov.Intersecting(g)
+		)
+		for _, j := range js {
+			intersection, err := overlay.Intersection(g, ov.Geometry(j), dim)
+			if err != nil {
+				return task.Errorf(sysexit.ErrData, "feature %d: %w", i, err)
+			}
+
+			if intersection != nil {
+				out.Append(&geojson.Feature{
+					Type:       f.Type,
+					Geometry:   intersection,
+					Properties: overlay.Properties(f, ov.Features[j], prefix),
+				})
+			}
+		}
+
+		erase, err := ov.Union(js, dim)
+		if err != nil {
+			return task.Errorf(sysexit.ErrData, "feature %d: %w", i, err)
+		}
+
+		difference, err := overlay.Difference(g, erase, dim)
+		if err != nil {
+			return task.Errorf(sysexit.ErrData, "feature %d: %w", i, err)
+		}
+
+		if difference != nil {
+			remainder = append(remainder, &geojson.Feature{
+				Type:       f.Type,
+				Geometry:   difference,
+				Properties: overlay.Properties(f, nil, prefix),
+			})
+		}
+	}
+
+	for j, o := range ov.Features {
+		var (
+			g   = ov.Geometry(j)
+			dim = o.Geometry.Dimensions()
+		)
+		erase, err := in.Union(in.Intersecting(g), dim)
+		if err != nil {
+			return task.Errorf(sysexit.ErrData, "overlay feature %d: %w", j, err)
+		}
+
+		difference, err := overlay.Difference(g, erase, dim)
+		if err != nil {
+			return task.Errorf(sysexit.ErrData, "overlay feature %d: %w", j, err)
+		}
+
+		if difference != nil {
+			remainder = append(remainder, &geojson.Feature{
+				Type:       o.Type,
+				Geometry:   difference,
+				Properties: overlay.Properties(nil, o, prefix),
+			})
+		}
+	}
+
+	for _, f := range remainder {
+		out.Append(f)
+	}
+
+	return features.Write(output, out)
+}
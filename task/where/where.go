@@ -0,0 +1,69 @@
+// Package where implements the where Task, which keeps only the
+// features whose properties match an expression.
+package where
+
+import (
+	"context"
+	"strings"
+
+	"github.com/frantjc/go-js"
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/expr"
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb/geojson"
+	"mellium.im/sysexit"
+)
+
+const (
+	// Name is the name of the where Task.
+	Name = "where"
+	// ParamWhere is the name of the param that holds the expression.
+	ParamWhere = "where"
+)
+
+func init() {
+	task.Register(Name, task.TaskFunc(Run))
+}
+
+// Run writes the features of the input for which the where
+// param's expression evaluates to TRUE to the output.
+func Run(_ context.Context, input volume.Volume, output volume.Directory, args task.Args) error {
+	e, err := expr.Parse(args.String(ParamWhere))
+	if err != nil {
+		return task.NewErr(sysexit.ErrConfig, err)
+	}
+
+	fc, err := features.Read(input)
+	if err != nil {
+		return err
+	}
+
+	// a column that no feature has is almost certainly a typo,
+	// so fail rather than silently treat it as NULL everywhere
+	columns := features.Columns(fc)
+	for _, c := range e.Columns() {
+		if !js.Includes(columns, c) {
+			return task.Errorf(sysexit.ErrConfig, "unknown column '%s', expected one of '%s'", c, strings.Join(columns, "', '"))
+		}
+	}
+
+	out := geojson.NewFeatureCollection()
+	for i, f := range fc.Features {
+		v, err := e.Eval(expr.NewFeatureEnv(f))
+		if err != nil {
+			return task.DataErrorf("feature %d: %w", i, err)
+		}
+
+		keep, err := expr.Bool(v)
+		if err != nil {
+			return task.Errorf(sysexit.ErrConfig, "expression '%s' is not a condition: %w", e, err)
+		}
+
+		if keep {
+			out.Append(f)
+		}
+	}
+
+	return features.Write(output, out)
+}
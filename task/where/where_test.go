@@ -0,0 +1,78 @@
+package where_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/features"
+	"github.com/logsquaredn/rototiller/task/where"
+	"github.com/logsquaredn/rototiller/volume"
+	"github.com/paulmach/orb/geojson"
+	"mellium.im/sysexit"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		where    string
+		want     int
+		wantCode sysexit.Code
+	}{
+		{where: "prop0 = 'value0'", want: 1},
+		{where: "prop0 != 'value0'", want: 0},
+		{where: "prop0 LIKE 'value%' AND $geometry_type = 'Polygon'", want: 1},
+		// a number and a string that is not one are unknown to each other
+		{where: "prop0 > 1", want: 0},
+		// mistakes in the expression are the params' fault, not the input's
+		{where: "prop0 + 1 > 0", wantCode: sysexit.ErrConfig},
+		{where: "prop9 = 1", wantCode: sysexit.ErrConfig},
+		{where: "prop0", wantCode: sysexit.ErrConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.where, func(t *testing.T) {
+			output := volume.Directory(t.TempDir())
+			err := where.Run(
+				context.Background(),
+				input(t),
+				output,
+				task.NewArgs([]string{where.ParamWhere}, []string{tt.where}),
+			)
+			if code := task.ExitCode(err); code != tt.wantCode {
+				t.Fatalf("Run() = %v, exit code %d, want %d", err, code, tt.wantCode)
+			}
+
+			if tt.wantCode != sysexit.Ok {
+				return
+			}
+
+			b, err := os.ReadFile(filepath.Join(string(output), features.OutputJSON))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fc, err := geojson.UnmarshalFeatureCollection(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(fc.Features) != tt.want {
+				t.Errorf("got %d features, want %d", len(fc.Features), tt.want)
+			}
+		})
+	}
+}
+
+func input(t *testing.T) volume.Volume {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("..", "..", "testdata", "geojson", "featurecollection.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return volume.New(volume.NewFile("input.json", bytes.NewReader(b), len(b)))
+}
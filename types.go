@@ -10,16 +10,42 @@ type Event = pb.Event
 
 type Error = pb.Error
 
+type Import = pb.RestImport
+
 type Job = pb.RestJob
 
 type Step = pb.RestStep
 
+type MissedRunPolicy = pb.MissedRunPolicy
+
+type Part = pb.Part
+
+type Quota = pb.RestQuota
+
+type Schedule = pb.RestSchedule
+
+type ScheduleRun = pb.RestScheduleRun
+
+type ScheduleStatus = pb.ScheduleStatus
+
 type Storage = pb.RestStorage
 
 type StorageStatus = pb.StorageStatus
 
-type Task = pb.Task
+type Task = pb.RestTask
 
 type TaskKind = pb.TaskKind
 
 type TaskType = pb.TaskType
+
+type Upload = pb.RestUpload
+
+type UploadStatus = pb.UploadStatus
+
+type Usage = pb.RestUsage
+
+type Worker = pb.RestWorker
+
+type WorkerStatus = pb.WorkerStatus
+
+type SignedURL = pb.RestSignedURL
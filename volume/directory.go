@@ -52,6 +52,17 @@ func (v Directory) Walk(fn WalkVolFunc) error {
 	})
 }
 
+// Create creates the named file in the Directory,
+// along with any parent directories that it needs.
+func (v Directory) Create(name string) (*os.File, error) {
+	path := filepath.Join(string(v), filepath.Clean("/"+name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	return os.Create(path)
+}
+
 func (v Directory) Download(path string) error {
 	return fmt.Errorf("github.com/logsquaredn/rototiller/volume.*Directory.Download not implemented")
 }
@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/logsquaredn/rototiller"
+	"github.com/logsquaredn/rototiller/encoding/format"
+	"github.com/logsquaredn/rototiller/fetch"
+	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/store"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// importPrefix is the name of imported files, without their extension,
+// the same as that of uploaded ones, as both become inputs to jobs.
+const importPrefix = "input"
+
+func WithFetcher(fetcher *fetch.Fetcher) Opt {
+	return func(w *Worker) {
+		w.Fetcher = fetcher
+	}
+}
+
+// DoImport fetches the import's URL and stores it just as the API stores an
+// uploaded dataset, deduplicated alike, recording whatever went wrong on the import if not.
+func (w *Worker) DoImport(ctx context.Context, id string) error {
+	logr := rototiller.LoggerFrom(ctx)
+
+	imp, err := w.Datastore.GetImport(id)
+	if err != nil {
+		return err
+	}
+
+	switch imp.Status {
+	case rototiller.JobStatusComplete.String(), rototiller.JobStatusInProgress.String():
+		return nil
+	}
+
+	imp.Status = rototiller.JobStatusInProgress.String()
+	if imp, err = w.Datastore.UpdateImport(imp); err != nil {
+		return err
+	}
+
+	defer func() {
+		imp.EndTime = timestamppb.New(time.Now())
+		if err != nil {
+			imp.Error = err.Error()
+			imp.Status = rototiller.JobStatusError.String()
+		} else {
+			imp.Status = rototiller.JobStatusComplete.String()
+		}
+
+		if _, err := w.Datastore.UpdateImport(imp); err != nil {
+			logr.Error(err, "updating import", "id", imp.GetId())
+		}
+	}()
+
+	res, err := w.Fetcher.Fetch(ctx, imp.GetUrl(), imp.GetHeaders())
+	if err != nil {
+		return err
+	}
+
+	// imports are held to the namespace's upload quota just as uploads are
+	limit, err := w.Datastore.GetUploadLimit(imp.GetNamespace())
+	if err != nil {
+		return err
+	}
+
+	if limit.Bytes > 0 && int64(len(res.Body)) > limit.Bytes {
+		err = limit.Err
+		return err
+	}
+
+	f := importFormat(res)
+	if f == nil {
+		err = fmt.Errorf("could not tell the format of the content")
+		return err
+	}
+
+	info, content, err := store.Ingest(ctx, w.Blobstore, f, importPrefix, bytes.NewReader(res.Body))
+	if err != nil {
+		return err
+	}
+
+	storage, err := store.CreateStorage(ctx, w.Datastore, w.Blobstore, info.Describe(&pb.Storage{
+		Namespace: imp.GetNamespace(),
+		Name:      imp.GetName(),
+	}), content)
+	if err != nil {
+		return err
+	}
+	imp.StorageId = storage.GetId()
+
+	return nil
+}
+
+// importFormat finds the format of the fetched content from the Content-Type
+// that it was served with or, as servers often serve everything as e.g.
+// application/octet-stream, its filename or, failing that, the content itself.
+func importFormat(res *fetch.Response) *format.Format {
+	if f, err := format.FromContentType(res.ContentType); err == nil {
+		return f
+	}
+
+	if f := format.FromFilename(res.Filename); f != nil {
+		return f
+	}
+
+	return format.Sniff(res.Body)
+}
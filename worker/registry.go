@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/logsquaredn/rototiller"
+	"github.com/logsquaredn/rototiller/pb"
+)
+
+// JobIDs returns the IDs of the jobs that the worker is running.
+func (w *Worker) JobIDs() []string {
+	jobIDs := []string{}
+	w.jobIDs.Range(func(key, _ any) bool {
+		jobIDs = append(jobIDs, key.(string))
+		return true
+	})
+	sort.Strings(jobIDs)
+
+	return jobIDs
+}
+
+// Register records the worker in the datastore, then heartbeats every interval
+// with the IDs of the jobs that it is running until ctx is done, when it
+// deregisters. Workers that miss heartbeats are considered dead.
+func (w *Worker) Register(ctx context.Context, registration *pb.Worker, interval time.Duration) (*pb.Worker, error) {
+	if registration.Id == "" {
+		registration.Id = uuid.NewString()
+	}
+
+	registration, err := w.Datastore.UpsertWorker(registration)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		var (
+			logr   = rototiller.LoggerFrom(ctx)
+			ticker = time.NewTicker(interval)
+		)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				registration.JobIds = w.JobIDs()
+				if _, err := w.Datastore.UpsertWorker(registration); err != nil {
+					logr.Error(err, "failed to heartbeat", "id", registration.GetId())
+				}
+			case <-ctx.Done():
+				if err := w.Datastore.DeleteWorker(registration.GetId()); err != nil {
+					logr.Error(err, "failed to deregister", "id", registration.GetId())
+				}
+				return
+			}
+		}
+	}()
+
+	return registration, nil
+}
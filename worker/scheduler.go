@@ -0,0 +1,221 @@
+package worker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/logsquaredn/rototiller/pb"
+)
+
+// Scheduler queues events per namespace and dispatches them fairly,
+// so that a namespace that creates many jobs at once cannot hold up
+// every other namespace's jobs until its own are done. It is only fair
+// among the events that it holds, so it relies on them being delivered
+// fairly too, see amqp.FairPriority.
+//
+// The next event dispatched is the one with the highest priority, with ties
+// going to the namespace that was dispatched to the least recently, then to
+// the event that was queued first, among those whose namespace and task
+// type are not already at their limits.
+type Scheduler struct {
+	// Limit is the most events that may be
+	// dispatched at once, or 0 for no limit
+	Limit int
+	// NamespaceLimit is the most events of one namespace
+	// that may be dispatched at once, or 0 for no limit
+	NamespaceLimit int
+	// TaskLimits are the most events of each task type that may be
+	// dispatched at once. Task types without one are only limited by Limit
+	TaskLimits map[string]int
+	// Capacity is the most events that may be held at once, queued or
+	// dispatched but not yet done, or 0 for no limit. Push blocks while
+	// it is reached, so that the rest are left for other workers to take
+	Capacity int
+
+	mu     sync.Mutex
+	queues map[queueKey][]*queued
+	// dispatched counts the events of each namespace
+	// and of each task type that are yet to be done
+	dispatched, dispatchedTasks map[string]int
+	total                       int
+	// held counts the events that are queued or yet to be done
+	held int
+	// served is when each namespace was last
+	// dispatched to, in dispatches
+	served map[string]uint64
+	// seq orders every push and dispatch
+	seq  uint64
+	wake chan struct{}
+	// room is signaled when an event is done
+	// and so there may be room to push another
+	room chan struct{}
+}
+
+// queueKey separates each namespace's events by task type, so that
+// those of a task type that is at its limit do not hold up the rest.
+type queueKey struct {
+	namespace, taskType string
+}
+
+type queued struct {
+	event    *pb.Event
+	priority int32
+	seq      uint64
+}
+
+func NewScheduler(limit, namespaceLimit int, taskLimits map[string]int, capacity int) *Scheduler {
+	return &Scheduler{
+		Limit:           limit,
+		NamespaceLimit:  namespaceLimit,
+		TaskLimits:      taskLimits,
+		Capacity:        capacity,
+		queues:          map[queueKey][]*queued{},
+		dispatched:      map[string]int{},
+		dispatchedTasks: map[string]int{},
+		served:          map[string]uint64{},
+		wake:            make(chan struct{}, 1),
+		room:            make(chan struct{}, 1),
+	}
+}
+
+// Push queues the event behind those of its namespace and task type with
+// the same or a higher priority, first blocking until there is room for it.
+func (s *Scheduler) Push(ctx context.Context, event *pb.Event) error {
+	var (
+		metadata = pb.JobEventMetadata(event.GetMetadata())
+		key      = queueKey{metadata.GetNamespace(), metadata.GetTaskType()}
+	)
+
+	for {
+		s.mu.Lock()
+		if s.Capacity <= 0 || s.held < s.Capacity {
+			break
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.room:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	s.held++
+	s.seq++
+	var (
+		q     = &queued{event, metadata.GetPriority(), s.seq}
+		queue = s.queues[key]
+		i     = len(queue)
+	)
+	for i > 0 && queue[i-1].priority < q.priority {
+		i--
+	}
+	queue = append(queue, nil)
+	copy(queue[i+1:], queue[i:])
+	queue[i] = q
+	s.queues[key] = queue
+	s.mu.Unlock()
+
+	s.signal(s.wake)
+
+	return nil
+}
+
+// Next blocks until an event may be dispatched, returning it and a function
+// to call once it has been handled to make room for the next one.
+func (s *Scheduler) Next(ctx context.Context) (*pb.Event, func(), error) {
+	for {
+		if event, key, ok := s.next(); ok {
+			return event, func() {
+				s.done(key)
+			}, nil
+		}
+
+		select {
+		case <-s.wake:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+func (s *Scheduler) next() (*pb.Event, queueKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Limit > 0 && s.total >= s.Limit {
+		return nil, queueKey{}, false
+	}
+
+	var (
+		next  queueKey
+		head  *queued
+		found bool
+	)
+	for key, queue := range s.queues {
+		if s.NamespaceLimit > 0 && s.dispatched[key.namespace] >= s.NamespaceLimit {
+			continue
+		}
+
+		if limit := s.TaskLimits[key.taskType]; limit > 0 && s.dispatchedTasks[key.taskType] >= limit {
+			continue
+		}
+
+		if !found || s.before(key.namespace, queue[0], next.namespace, head) {
+			next, head, found = key, queue[0], true
+		}
+	}
+
+	if !found {
+		return nil, queueKey{}, false
+	}
+
+	if queue := s.queues[next]; len(queue) == 1 {
+		delete(s.queues, next)
+	} else {
+		s.queues[next] = queue[1:]
+	}
+
+	s.seq++
+	s.served[next.namespace] = s.seq
+	s.dispatched[next.namespace]++
+	s.dispatchedTasks[next.taskType]++
+	s.total++
+
+	return head.event, next, true
+}
+
+// before reports whether a, of namespace, should be dispatched before b, of otherNamespace.
+func (s *Scheduler) before(namespace string, a *queued, otherNamespace string, b *queued) bool {
+	switch {
+	case a.priority != b.priority:
+		return a.priority > b.priority
+	case s.served[namespace] != s.served[otherNamespace]:
+		return s.served[namespace] < s.served[otherNamespace]
+	default:
+		return a.seq < b.seq
+	}
+}
+
+func (s *Scheduler) done(key queueKey) {
+	s.mu.Lock()
+	if s.dispatched[key.namespace]--; s.dispatched[key.namespace] <= 0 {
+		delete(s.dispatched, key.namespace)
+	}
+	if s.dispatchedTasks[key.taskType]--; s.dispatchedTasks[key.taskType] <= 0 {
+		delete(s.dispatchedTasks, key.taskType)
+	}
+	s.total--
+	s.held--
+	s.mu.Unlock()
+
+	s.signal(s.wake)
+	s.signal(s.room)
+}
+
+func (s *Scheduler) signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
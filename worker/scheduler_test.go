@@ -0,0 +1,296 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/stream/event/amqp"
+	"github.com/logsquaredn/rototiller/worker"
+)
+
+// blocked is how long to wait to be fairly
+// sure that a Scheduler call is blocking.
+const blocked = 50 * time.Millisecond
+
+func event(id, namespace, taskType string, priority int) *pb.Event {
+	return &pb.Event{
+		Metadata: map[string]string{
+			"id":        id,
+			"namespace": namespace,
+			"task_type": taskType,
+			"priority":  strconv.Itoa(priority),
+		},
+	}
+}
+
+func TestSchedulerOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []*pb.Event
+		want   []string
+	}{
+		{
+			name: "namespaces take turns",
+			events: []*pb.Event{
+				event("a1", "a", "clip", 0),
+				event("a2", "a", "clip", 0),
+				event("a3", "a", "clip", 0),
+				event("b1", "b", "clip", 0),
+				event("c1", "c", "clip", 0),
+			},
+			want: []string{"a1", "b1", "c1", "a2", "a3"},
+		},
+		{
+			name: "task types of a namespace are queued in order",
+			events: []*pb.Event{
+				event("a1", "a", "clip", 0),
+				event("a2", "a", "where", 0),
+				event("a3", "a", "clip", 0),
+			},
+			want: []string{"a1", "a2", "a3"},
+		},
+		{
+			name: "higher priority first",
+			events: []*pb.Event{
+				event("a1", "a", "clip", 0),
+				event("b1", "b", "clip", 0),
+				event("a2", "a", "clip", 5),
+				event("b2", "b", "clip", 1),
+			},
+			want: []string{"a2", "b2", "a1", "b1"},
+		},
+		{
+			name: "imports have no task type",
+			events: []*pb.Event{
+				event("a1", "a", "", 0),
+				event("b1", "b", "clip", 0),
+			},
+			want: []string{"a1", "b1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := worker.NewScheduler(0, 0, nil, 0)
+			for _, e := range tt.events {
+				if err := s.Push(ctx, e); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, want := range tt.want {
+				e, done, err := s.Next(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				done()
+
+				if got := pb.JobEventMetadata(e.GetMetadata()).GetId(); got != want {
+					t.Fatalf("Next() = %s, want %s", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		scheduler *worker.Scheduler
+		events    []*pb.Event
+		// dispatched are the events that are dispatched
+		// before the limits are reached, while none are done
+		dispatched []string
+	}{
+		{
+			name:       "limit",
+			scheduler:  worker.NewScheduler(2, 0, nil, 0),
+			events:     []*pb.Event{event("a1", "a", "clip", 0), event("b1", "b", "clip", 0), event("c1", "c", "clip", 0)},
+			dispatched: []string{"a1", "b1"},
+		},
+		{
+			name:       "namespace limit",
+			scheduler:  worker.NewScheduler(0, 1, nil, 0),
+			events:     []*pb.Event{event("a1", "a", "clip", 0), event("a2", "a", "where", 0), event("b1", "b", "clip", 0)},
+			dispatched: []string{"a1", "b1"},
+		},
+		{
+			name:       "task limit",
+			scheduler:  worker.NewScheduler(0, 0, map[string]int{"clip": 1}, 0),
+			events:     []*pb.Event{event("a1", "a", "clip", 0), event("b1", "b", "clip", 0), event("b2", "b", "where", 0)},
+			dispatched: []string{"a1", "b2"},
+		},
+		{
+			name:       "task limit does not hold up other task types",
+			scheduler:  worker.NewScheduler(0, 0, map[string]int{"clip": 1}, 0),
+			events:     []*pb.Event{event("a1", "a", "clip", 9), event("a2", "a", "clip", 9), event("a3", "a", "where", 0)},
+			dispatched: []string{"a1", "a3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, e := range tt.events {
+				if err := tt.scheduler.Push(ctx, e); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			dones := []func(){}
+			for _, want := range tt.dispatched {
+				e, done, err := tt.scheduler.Next(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				dones = append(dones, done)
+
+				if got := pb.JobEventMetadata(e.GetMetadata()).GetId(); got != want {
+					t.Fatalf("Next() = %s, want %s", got, want)
+				}
+			}
+
+			timeout, cancel := context.WithTimeout(ctx, blocked)
+			defer cancel()
+
+			if e, _, err := tt.scheduler.Next(timeout); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Next() = %v, %v, want it to block", e, err)
+			}
+
+			// once the dispatched events are done, the rest can be
+			remaining := len(tt.events) - len(tt.dispatched)
+			for _, done := range dones {
+				done()
+			}
+
+			for i := 0; i < remaining; i++ {
+				timeout, cancel := context.WithTimeout(ctx, time.Second)
+				_, done, err := tt.scheduler.Next(timeout)
+				cancel()
+				if err != nil {
+					t.Fatalf("Next() = %v", err)
+				}
+				done()
+			}
+		})
+	}
+}
+
+func TestSchedulerCapacity(t *testing.T) {
+	var (
+		ctx = context.Background()
+		s   = worker.NewScheduler(0, 0, nil, 2)
+	)
+	for _, e := range []*pb.Event{event("a1", "a", "clip", 0), event("a2", "a", "clip", 0)} {
+		if err := s.Push(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, blocked)
+	defer cancel()
+
+	if err := s.Push(timeout, event("a3", "a", "clip", 0)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Push() = %v, want it to block while at capacity", err)
+	}
+
+	// dispatched events still count until they are done
+	_, done, err := s.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushed := make(chan error, 1)
+	go func() {
+		pushed <- s.Push(ctx, event("a3", "a", "clip", 0))
+	}()
+
+	select {
+	case err := <-pushed:
+		t.Fatalf("Push() = %v, want it to block until an event is done", err)
+	case <-time.After(blocked):
+	}
+
+	done()
+
+	select {
+	case err := <-pushed:
+		if err != nil {
+			t.Fatalf("Push() = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Push() is still blocked after an event is done")
+	}
+}
+
+// TestSchedulerFlood emits one of a namespace's events after a flood of
+// another's, queued as the API emits them, with amqp.FairPriority, and
+// delivered as the queue does, to show that the worker dispatches it before
+// the flood is through rather than only once it is within the prefetch window.
+func TestSchedulerFlood(t *testing.T) {
+	const (
+		flood    = 1000
+		prefetch = 4
+	)
+
+	type delivery struct {
+		event    *pb.Event
+		priority uint8
+	}
+
+	var (
+		ctx     = context.Background()
+		queue   = []*delivery{}
+		pending = map[string]int{}
+		emit    = func(id, namespace string) {
+			pending[namespace]++
+			queue = append(queue, &delivery{event(id, namespace, "clip", 0), amqp.FairPriority(pending[namespace])})
+		}
+	)
+	for i := 0; i < flood; i++ {
+		emit("a"+strconv.Itoa(i), "a")
+	}
+	emit("b0", "b")
+
+	// the queue delivers the highest priority first,
+	// and those of the same priority in the order emitted
+	sort.SliceStable(queue, func(i, j int) bool {
+		return queue[i].priority > queue[j].priority
+	})
+
+	s := worker.NewScheduler(1, 0, nil, prefetch)
+	for _, d := range queue[:prefetch] {
+		if err := s.Push(ctx, d.event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, d := range queue[prefetch:] {
+		e, done, err := s.Next(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		done()
+
+		if pb.JobEventMetadata(e.GetMetadata()).GetId() == "b0" {
+			if i >= prefetch {
+				t.Errorf("b0 was dispatched after %d of a's events, want fewer than %d", i, prefetch)
+			}
+
+			return
+		}
+
+		// acking the event makes room for the queue to deliver the next
+		if err := s.Push(ctx, d.event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Fatal("b0 was not dispatched before the flood was through")
+}
@@ -3,18 +3,29 @@ package worker
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/frantjc/go-js"
 	"github.com/logsquaredn/rototiller"
+	"github.com/logsquaredn/rototiller/encoding/format"
+	"github.com/logsquaredn/rototiller/fetch"
 	"github.com/logsquaredn/rototiller/pb"
+	"github.com/logsquaredn/rototiller/sandbox"
 	"github.com/logsquaredn/rototiller/store/blob/bucket"
 	"github.com/logsquaredn/rototiller/store/data/postgres"
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/features"
 	"github.com/logsquaredn/rototiller/volume"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"mellium.im/sysexit"
@@ -26,6 +37,22 @@ type Worker struct {
 	*postgres.Datastore
 	*bucket.Blobstore
 	WorkingDir string
+	// Sandbox, if set, is used to run each task
+	// isolated from the host
+	Sandbox *sandbox.Sandbox
+	// Fetcher fetches the URLs of imports
+	Fetcher *fetch.Fetcher
+
+	// jobIDs are the IDs of the jobs being run
+	jobIDs sync.Map
+}
+
+type Opt func(*Worker)
+
+func WithSandbox(sandbox *sandbox.Sandbox) Opt {
+	return func(w *Worker) {
+		w.Sandbox = sandbox
+	}
 }
 
 const (
@@ -33,26 +60,52 @@ const (
 	EnvVarOutputDir = "ROTOTILLER_OUTPUT_DIR"
 )
 
-func New(ctx context.Context, workingDir string, datastore *postgres.Datastore, blobstore *bucket.Blobstore) (*Worker, error) {
-	return &Worker{
+const (
+	// sandboxInputDir is where the input volume
+	// is mounted read-only inside of a sandbox
+	sandboxInputDir = "/input"
+	// sandboxOutputDir is where the output volume
+	// is mounted read-write inside of a sandbox
+	sandboxOutputDir = "/output"
+	// sandboxParamsDir is where the volume of each param that
+	// refers to a storage is mounted read-only inside of a sandbox
+	sandboxParamsDir = "/params"
+)
+
+// RunTaskCommand is the subcommand of the current executable that
+// runs a Go Task, which the worker re-executes itself as inside of a
+// sandbox to run one there rather than in-process. It must be registered
+// by the caller, e.g. `rototiller run-task`.
+var RunTaskCommand = "run-task"
+
+func New(ctx context.Context, workingDir string, datastore *postgres.Datastore, blobstore *bucket.Blobstore, opts ...Opt) (*Worker, error) {
+	w := &Worker{
 		Datastore:  datastore,
 		Blobstore:  blobstore,
 		WorkingDir: workingDir,
-	}, nil
+		Fetcher:    fetch.New(),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w, nil
 }
 
 func (w *Worker) DoJob(ctx context.Context, id string) error {
 	logr := rototiller.LoggerFrom(ctx)
 
-	j, err := w.Datastore.GetJob(id)
-	if err != nil {
+	// the job is claimed rather than checked, so that if it is
+	// delivered more than once, e.g. after a worker was restarted,
+	// only one delivery runs it
+	j, claimed, err := w.Datastore.ClaimJob(id)
+	if !claimed {
 		return err
 	}
 
-	switch j.Status {
-	case rototiller.JobStatusComplete.String(), rototiller.JobStatusInProgress.String():
-		return nil
-	}
+	w.jobIDs.Store(id, struct{}{})
+	defer w.jobIDs.Delete(id)
 
 	stderr := new(bytes.Buffer)
 	defer func() {
@@ -60,7 +113,7 @@ func (w *Worker) DoJob(ctx context.Context, id string) error {
 		jobErr := stderr.Bytes()
 		switch {
 		case len(jobErr) > 0:
-			j.Error = strings.ReplaceAll(string(jobErr)[0:512], "\n", " ")
+			j.Error = strings.ReplaceAll(string(jobErr[:js.Ternary(len(jobErr) > 512, 512, len(jobErr))]), "\n", " ")
 			j.Status = rototiller.JobStatusError.String()
 		case err != nil:
 			j.Error = err.Error()
@@ -77,7 +130,11 @@ func (w *Worker) DoJob(ctx context.Context, id string) error {
 		}
 	}()
 
-	inputStorage, err := w.Datastore.GetStorage(j.GetInputId())
+	if err != nil {
+		return err
+	}
+
+	inputStorage, err := w.Datastore.GetJobInputStorage(j.GetId())
 	if err != nil {
 		return err
 	}
@@ -88,15 +145,14 @@ func (w *Worker) DoJob(ctx context.Context, id string) error {
 	}
 
 	defer func() {
-		_, _ = w.Datastore.UpdateStorage(inputStorage)
+		// inputStorage is the revision that the job is pinned to, so what
+		// the job learned about its status is recorded for that revision,
+		// which only becomes the storage's status if it is still its latest
+		if _, err := w.Datastore.UpdateStorage(inputStorage); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			logr.Error(err, "updating storage", "id", inputStorage.GetId())
+		}
 	}()
 
-	j.Status = rototiller.JobStatusInProgress.String()
-	j, err = w.Datastore.UpdateJob(j)
-	if err != nil {
-		return err
-	}
-
 	tasks, err := w.Datastore.GetTasksByJobID(id)
 	if err != nil {
 		return err
@@ -108,7 +164,7 @@ func (w *Worker) DoJob(ctx context.Context, id string) error {
 	}
 	defer os.RemoveAll(w.jobDir(id))
 
-	input, err := w.Blobstore.GetObject(ctx, j.GetInputId())
+	input, err := w.Blobstore.GetObject(ctx, inputStorage.GetObjectId())
 	if err != nil {
 		return err
 	}
@@ -128,6 +184,13 @@ func (w *Worker) DoJob(ctx context.Context, id string) error {
 			if e != nil {
 				return e
 			}
+
+			// uploads in formats that tasks cannot read are
+			// stored alongside their normalization to GeoJSON
+			if ff := format.FromFilename(f.GetName()); ff == nil || !ff.Native() {
+				return nil
+			}
+
 			filename = f.GetName()
 			// we only expect 1 input, so use the first one we find and end the Walk
 			return fmt.Errorf("found")
@@ -139,56 +202,34 @@ func (w *Worker) DoJob(ctx context.Context, id string) error {
 	}
 
 	// TDDO refactor to expect more than one task per job
-	task := exec.Command(tasks[0].Type) //nolint:gosec // t.Type is guaranteed to refer to a Task binary
-	// start with current env minus configuration that might contain secrets
-	// e.g. ROTOTILLER_POSTGRES_PASSWORD
-	task.Env = js.Filter(os.Environ(), func(e string, _ int, _ []string) bool {
-		return !(strings.HasPrefix(e, "ROTOTILLER_") || strings.HasPrefix(e, "AWS_") || strings.Contains(e, "PASSWORD") || strings.Contains(e, "USERNAME") || strings.Contains(e, "SECRET"))
-	})
-	// add input file path and output dir path
-	task.Env = append(task.Env,
-		EnvVarInputFile+"="+filepath.Join(w.inputVolumePath(j.GetId()), filename),
-		EnvVarOutputDir+"="+w.outputVolumePath(j.GetId()),
-	)
-	// add arbitrary args defined by the task entry in the datastore
-	// e.g. task.type = 'reproject'
-	//		=> task.params = ['target-projection'],
-	//      => ROTOTILLER_TARGET_PROJECTION=${?target-projection}
-	// TDDO refactor to expect more than one task per job
-	task.Env = append(task.Env, js.Map(j.Steps[0].Args, func(a string, i int, _ []string) string {
-		return "ROTOTILLER_" + strings.ToUpper(HyphenToUnderscoreReplacer.Replace(tasks[0].Params[i])) + "=" + a
-	})...)
-	task.Stdin = os.Stdin
-	task.Stdout = os.Stdout
-	task.Stderr = stderr
+	var exitCode int
+	if t, ok := task.Lookup(tasks[0].GetType()); ok {
+		var args task.Args
+		if args, err = w.taskArgs(ctx, j, tasks[0]); err != nil {
+			return err
+		}
 
-	if err := task.Run(); err != nil {
+		if exitCode, err = w.runTask(ctx, t, tasks[0], j, args, stderr); err != nil {
+			return err
+		}
+	} else if exitCode, err = w.execTask(j, tasks[0], filename, stderr); err != nil {
 		return err
 	}
 
-	switch task.ProcessState.ExitCode() {
-	case int(sysexit.Ok):
-		inputStorage.Status = rototiller.StorageStatusTransformable.String()
-	case int(sysexit.ErrData), int(sysexit.ErrNoInput):
-		inputStorage.Status = rototiller.StorageStatusUnusable.String()
-		err = fmt.Errorf("unusable input")
-	case int(sysexit.ErrCantCreat):
-		err = fmt.Errorf("can't create output file")
-	case int(sysexit.ErrConfig):
-		err = fmt.Errorf("configuration error")
-	default:
-		inputStorage.Status = rototiller.StorageStatusUnknown.String()
-		err = fmt.Errorf("unknown error")
-	}
-	if err != nil {
+	if inputStorage.Status, err = inputStatus(exitCode, inputStorage.Status); err != nil {
 		return err
 	}
 
 	// TDDO refactor to expect more than one task per job
-	ost, err := w.Datastore.CreateStorage(&pb.Storage{
+	ost, err := w.describeOutput(&pb.Storage{
 		Namespace: j.GetNamespace(),
 		Status:    js.Ternary(tasks[0].GetKind() == rototiller.TaskKindLookup.String(), rototiller.StorageStatusFinal.String(), rototiller.StorageStatusTransformable.String()),
-	})
+	}, j.GetId())
+	if err != nil {
+		return err
+	}
+
+	ost, err = w.Datastore.CreateStorage(ost)
 	if err != nil {
 		return err
 	}
@@ -197,6 +238,242 @@ func (w *Worker) DoJob(ctx context.Context, id string) error {
 	return w.Blobstore.PutObject(ctx, j.GetOutputId(), outvol)
 }
 
+// inputStatus returns what the exit code of a job's task says about the status
+// of its input storage, which has the given status, along with the error that
+// the job failed with, if any. Only sysexit.ErrData and sysexit.ErrNoInput are
+// about the input itself. Tasks exit sysexit.ErrConfig for what is wrong with
+// any other storage that the job's params refer to, which leaves it as it was.
+func inputStatus(exitCode int, status string) (string, error) {
+	switch exitCode {
+	case int(sysexit.Ok):
+		return rototiller.StorageStatusTransformable.String(), nil
+	case int(sysexit.ErrData), int(sysexit.ErrNoInput):
+		return rototiller.StorageStatusUnusable.String(), fmt.Errorf("unusable input")
+	case int(sysexit.ErrCantCreat):
+		return status, fmt.Errorf("can't create output file")
+	case int(sysexit.ErrConfig):
+		return status, fmt.Errorf("configuration error")
+	}
+
+	return rototiller.StorageStatusUnknown.String(), fmt.Errorf("unknown error")
+}
+
+// describeOutput sets the metadata of the storage that will hold the job's
+// output from its GeoJSON or, failing that, its zip. Output that is not
+// features, e.g. that of a lookup, only has its size and checksum set.
+func (w *Worker) describeOutput(s *pb.Storage, id string) (*pb.Storage, error) {
+	for _, output := range []struct {
+		name   string
+		format *format.Format
+	}{
+		{features.OutputJSON, format.GeoJSON},
+		{features.OutputZip, format.Shapefile},
+	} {
+		b, err := os.ReadFile(filepath.Join(w.outputVolumePath(id), output.name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		info, err := format.Validate(output.format, b)
+		if err != nil {
+			checksum := sha256.Sum256(b)
+			s.Size = int64(len(b))
+			s.Checksum = hex.EncodeToString(checksum[:])
+
+			return s, nil
+		}
+
+		return info.Describe(s), nil
+	}
+
+	return s, nil
+}
+
+// execTask runs the Task's executable over the named file in the job's input volume,
+// returning its exit code.
+func (w *Worker) execTask(j *pb.Job, t *pb.Task, filename string, stderr io.Writer) (int, error) {
+	var (
+		inputFile = filepath.Join(w.inputVolumePath(j.GetId()), filename)
+		outputDir = w.outputVolumePath(j.GetId())
+	)
+	if w.Sandbox != nil {
+		// the task sees the volumes at different paths from inside of the sandbox
+		inputFile = filepath.Join(sandboxInputDir, filename)
+		outputDir = sandboxOutputDir
+	}
+
+	// add input file path and output dir path
+	env := []string{
+		EnvVarInputFile + "=" + inputFile,
+		EnvVarOutputDir + "=" + outputDir,
+	}
+	// add arbitrary args defined by the task entry in the datastore
+	// e.g. task.type = 'reproject'
+	//		=> task.params = ['target-projection'],
+	//      => ROTOTILLER_TARGET_PROJECTION=${?target-projection}
+	env = append(env, js.Map(j.Steps[0].GetArgs(), func(a string, i int, _ []string) string {
+		return "ROTOTILLER_" + strings.ToUpper(HyphenToUnderscoreReplacer.Replace(t.GetParams()[i])) + "=" + a
+	})...)
+
+	executable := js.Ternary(t.GetExecutable() != "", t.GetExecutable(), t.GetType())
+
+	var (
+		cmd *exec.Cmd
+		err error
+	)
+	if w.Sandbox != nil {
+		cmd, err = w.Sandbox.Command(w.sandboxRootPath(j.GetId()), []*sandbox.Mount{
+			{Source: w.inputVolumePath(j.GetId()), Target: sandboxInputDir, ReadOnly: true},
+			{Source: w.outputVolumePath(j.GetId()), Target: sandboxOutputDir},
+		}, env, executable)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		cmd = exec.Command(executable) //nolint:gosec // executable comes from a Task manifest
+		// start with current env minus configuration that might contain secrets
+		// e.g. ROTOTILLER_POSTGRES_PASSWORD
+		cmd.Env = append(js.Filter(os.Environ(), func(e string, _ int, _ []string) bool {
+			return !(strings.HasPrefix(e, "ROTOTILLER_") || strings.HasPrefix(e, "AWS_") || strings.Contains(e, "PASSWORD") || strings.Contains(e, "USERNAME") || strings.Contains(e, "SECRET"))
+		}), env...)
+	}
+	cmd.Stdin = os.Stdin
+
+	return runCommand(cmd, stderr)
+}
+
+// runCommand runs the task's command, writing its stderr
+// to stderr, and returns its exit code.
+func runCommand(cmd *exec.Cmd, stderr io.Writer) (int, error) {
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		// a non-zero exit code is mapped to a status by the caller
+		if exitErr := (&exec.ExitError{}); !errors.As(err, &exitErr) {
+			return 0, err
+		}
+	}
+
+	return cmd.ProcessState.ExitCode(), nil
+}
+
+// runTask runs the Go Task over the job's input volume, in-process or, if
+// the Worker has a Sandbox, by re-executing itself as RunTaskCommand inside
+// of it, returning its exit code.
+func (w *Worker) runTask(ctx context.Context, t task.Task, pt *pb.Task, j *pb.Job, args task.Args, stderr io.Writer) (int, error) {
+	if w.Sandbox == nil {
+		return RunTask(
+			ctx, t,
+			volume.Directory(w.inputVolumePath(j.GetId())),
+			volume.Directory(w.outputVolumePath(j.GetId())),
+			args, stderr,
+		), nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		mounts = []*sandbox.Mount{
+			{Source: w.inputVolumePath(j.GetId()), Target: sandboxInputDir, ReadOnly: true},
+			{Source: w.outputVolumePath(j.GetId()), Target: sandboxOutputDir},
+		}
+		cmdArgs = []string{RunTaskCommand, pt.GetType(), "--input", sandboxInputDir, "--output", sandboxOutputDir}
+	)
+	for _, name := range pt.GetParams() {
+		if args.Has(name) {
+			cmdArgs = append(cmdArgs, "--arg", name+"="+args.String(name))
+		}
+
+		// the task sees the volumes of params at different
+		// paths from inside of the sandbox too
+		if _, ok := args.Volume(name); ok {
+			target := filepath.Join(sandboxParamsDir, name)
+			mounts = append(mounts, &sandbox.Mount{Source: w.paramVolumePath(j.GetId(), name), Target: target, ReadOnly: true})
+			cmdArgs = append(cmdArgs, "--volume", name+"="+target)
+		}
+	}
+
+	cmd, err := w.Sandbox.Command(w.sandboxRootPath(j.GetId()), mounts, nil, executable, cmdArgs...)
+	if err != nil {
+		return 0, err
+	}
+
+	return runCommand(cmd, stderr)
+}
+
+// RunTask runs the Go Task over the input volume, writing its error, if any,
+// to stderr like an executable would and returning the exit code that it
+// corresponds to.
+func RunTask(ctx context.Context, t task.Task, input volume.Volume, output volume.Directory, args task.Args, stderr io.Writer) (exitCode int) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintln(stderr, "panic:", r)
+			exitCode = int(sysexit.ErrSoftware)
+		}
+	}()
+
+	err := t.Run(ctx, input, output, args)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+	}
+
+	return int(task.ExitCode(err))
+}
+
+// taskArgs pairs the job's args with the Task's params, downloading the
+// content of each storage that a param with format storage refers to
+// and recording that it was used.
+func (w *Worker) taskArgs(ctx context.Context, j *pb.Job, t *pb.Task) (task.Args, error) {
+	var (
+		values = j.Steps[0].GetArgs()
+		args   = task.NewArgs(t.GetParams(), values)
+	)
+	for i, name := range t.GetParams() {
+		if i >= len(values) || values[i] == "" || task.SchemaOf(t, name).GetFormat() != task.ParamFormatStorage.String() {
+			continue
+		}
+
+		storage, err := w.Datastore.GetStorage(values[i])
+		if err != nil {
+			return args, err
+		}
+
+		// the storage is used by the job as much as its input is,
+		// so it must not be deleted for going unused either
+		if err = w.Datastore.TouchStorage(storage.GetId()); err != nil {
+			return args, err
+		}
+
+		vol, err := w.Blobstore.GetObject(ctx, storage.GetObjectId())
+		if err != nil {
+			return args, err
+		}
+
+		path := w.paramVolumePath(j.GetId(), name)
+		if _, err = volume.NewDir(path); err != nil {
+			return args, err
+		}
+
+		if err = vol.Download(path); err != nil {
+			return args, err
+		}
+
+		args = args.WithVolume(name, volume.Directory(path))
+	}
+
+	return args, nil
+}
+
+func (w *Worker) paramVolumePath(id, param string) string {
+	return filepath.Join(w.jobDir(id), "params", param)
+}
+
 func (w *Worker) inputVolumePath(id string) string {
 	return filepath.Join(w.jobDir(id), "input")
 }
@@ -205,6 +482,10 @@ func (w *Worker) outputVolumePath(id string) string {
 	return filepath.Join(w.jobDir(id), "output")
 }
 
+func (w *Worker) sandboxRootPath(id string) string {
+	return filepath.Join(w.jobDir(id), "root")
+}
+
 func (w *Worker) inputVolume(id string) (volume.Volume, error) {
 	return volume.NewDir(w.inputVolumePath(id))
 }
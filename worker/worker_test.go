@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/logsquaredn/rototiller"
+	"github.com/logsquaredn/rototiller/task"
+	"github.com/logsquaredn/rototiller/task/clip"
+	"github.com/logsquaredn/rototiller/volume"
+	"mellium.im/sysexit"
+)
+
+func TestInputStatus(t *testing.T) {
+	var (
+		transformable = rototiller.StorageStatusTransformable.String()
+		unusable      = rototiller.StorageStatusUnusable.String()
+		unknown       = rototiller.StorageStatusUnknown.String()
+	)
+
+	tests := []struct {
+		name     string
+		exitCode sysexit.Code
+		want     string
+		wantErr  bool
+	}{
+		{name: "ok", exitCode: sysexit.Ok, want: transformable},
+		{name: "bad data", exitCode: sysexit.ErrData, want: unusable, wantErr: true},
+		{name: "no input", exitCode: sysexit.ErrNoInput, want: unusable, wantErr: true},
+		{name: "bad config", exitCode: sysexit.ErrConfig, want: transformable, wantErr: true},
+		{name: "can't create output", exitCode: sysexit.ErrCantCreat, want: transformable, wantErr: true},
+		{name: "anything else", exitCode: sysexit.ErrSoftware, want: unknown, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := inputStatus(int(tt.exitCode), transformable)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("inputStatus() = %s, %v, want %s, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestBadMaskLeavesInputStatus runs clip with masks that cannot be used, none
+// of which is the input's fault, so the input's status must not change.
+func TestBadMaskLeavesInputStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		// mask is the content of the mask storage, or nil if it was not found
+		mask []byte
+	}{
+		{name: "not found"},
+		{name: "not GeoJSON", mask: []byte("not json")},
+		{name: "no polygons", mask: []byte(`{"type":"Feature","geometry":{"type":"Point","coordinates":[0,0]},"properties":{}}`)},
+	}
+
+	input, err := os.ReadFile(filepath.Join("..", "testdata", "geojson", "featurecollection.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			write(t, filepath.Join(dir, "input", "input.json"), input)
+
+			args := task.NewArgs([]string{clip.ParamMask}, []string{"mask-id"})
+			if tt.mask != nil {
+				write(t, filepath.Join(dir, "mask", "mask.json"), tt.mask)
+				args = args.WithVolume(clip.ParamMask, volume.Directory(filepath.Join(dir, "mask")))
+			}
+
+			err := clip.Run(context.Background(), volume.Directory(filepath.Join(dir, "input")), volume.Directory(t.TempDir()), args)
+			if code := task.ExitCode(err); code != sysexit.ErrConfig {
+				t.Fatalf("clip.Run() = %v, exit code %d, want %d", err, code, sysexit.ErrConfig)
+			}
+
+			status := rototiller.StorageStatusTransformable.String()
+			if got, _ := inputStatus(int(task.ExitCode(err)), status); got != status {
+				t.Errorf("input status = %s, want %s", got, status)
+			}
+		})
+	}
+}
+
+func write(t *testing.T, name string, b []byte) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(name, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}